@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"github.com/rasul07/alif-task/internal/config"
 	"github.com/rasul07/alif-task/internal/handlers"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/notify"
+	"github.com/rasul07/alif-task/internal/outbox"
 	"github.com/rasul07/alif-task/internal/service"
 	"github.com/rasul07/alif-task/internal/storage"
+	"github.com/rasul07/alif-task/internal/topup"
 )
 
 func main() {
@@ -21,9 +27,115 @@ func main() {
 	}
 	defer db.Close()
 
-	walletService := service.NewWalletService(db)
+	sink := notify.NewConsoleSink()
+	if cfg.NotificationSinkPath != "" {
+		sink, err = notify.NewFileSink(cfg.NotificationSinkPath)
+		if err != nil {
+			log.Fatalf("Failed to open notification sink: %v", err)
+		}
+	}
+	notificationService := service.NewNotificationService(db, map[string]notify.Sender{
+		notify.ChannelSMS:   sink,
+		notify.ChannelEmail: sink,
+		notify.ChannelPush:  sink,
+	})
+
+	webhookService := service.NewWebhookService(db)
+	budgetService := service.NewBudgetService(db, notificationService)
+	streamService := service.NewStreamService(db)
+	nonceService := service.NewNonceService(db)
+	walletService := service.NewWalletService(db, notificationService)
+	parentalService := service.NewParentalControlService(db, walletService, notificationService)
+	paymentRequestService := service.NewPaymentRequestService(db, walletService)
+	qrService := service.NewQRService(db, walletService, cfg.Key(config.KeyQR))
+	merchantService := service.NewMerchantService(db, walletService)
+	splitService := service.NewSplitService(db, walletService, notificationService)
+	payoutService := service.NewPayoutService(db, walletService)
+	reconciliationService := service.NewReconciliationService(db)
+	snapshotService := service.NewSnapshotService(db)
+	transactionService := service.NewTransactionService(db)
+	searchService := service.NewSearchService(db)
+	graphqlService := service.NewGraphQLService(db)
+	receiptService := service.NewReceiptService(db, cfg.Key(config.KeyReceipt))
+	topUpService := service.NewTopUpService(db,
+		topup.NewSimulator(cfg.Key(config.KeyTopUpSimulator), 2*time.Second, cfg.TopUpSimulatorCallbackURL))
+	escrowService := service.NewEscrowService(db, walletService)
+	voucherService := service.NewVoucherService(db, walletService, cfg.Key(config.KeyVoucher))
+	loyaltyService := service.NewLoyaltyService(db, walletService, cfg.LoyaltyPointValue)
+
+	// Sink names are kept with the events they published, they must not change
+	sinks := outbox.Fanout{
+		"webhooks":      webhookService,
+		"notifications": notificationService,
+		"loyalty":       service.NewLoyaltyEarner(db, cfg.LoyaltyPointsTTL),
+		"budgets":       budgetService,
+	}
+	if cfg.StreamSource == models.StreamSourceBus {
+		sinks["stream"] = streamService
+	}
+	if cfg.OutboxSinkURL != "" {
+		sink, err := outbox.NewSink(cfg.OutboxSinkURL, cfg.OutboxWebhookSecret)
+		if err != nil {
+			log.Fatalf("Failed to create outbox sink: %v", err)
+		}
+		sinks["external"] = sink
+	}
+	outboxRelay := service.NewOutboxRelay(db, sinks)
+
+	api := handlers.NewAPI(handlers.Services{
+		Wallet:         walletService,
+		Parental:       parentalService,
+		PaymentRequest: paymentRequestService,
+		QR:             qrService,
+		Merchant:       merchantService,
+		Webhook:        webhookService,
+		Split:          splitService,
+		Payout:         payoutService,
+		Reconciliation: reconciliationService,
+		Snapshot:       snapshotService,
+		Transaction:    transactionService,
+		Search:         searchService,
+		Receipt:        receiptService,
+		TopUp:          topUpService,
+		Escrow:         escrowService,
+		Voucher:        voucherService,
+		Loyalty:        loyaltyService,
+		Budget:         budgetService,
+		Notification:   notificationService,
+		Stream:         streamService,
+		GraphQL:        graphqlService,
+		Outbox:         outboxRelay,
+		Nonce:          nonceService,
+	}, cfg.AdminToken, cfg.V1Sunset)
 
-	api := handlers.NewAPI(walletService)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go webhookService.Run(ctx)
+	go notificationService.Run(ctx)
+	go payoutService.Run(ctx)
+	go reconciliationService.Run(ctx, cfg.ReconcileInterval, cfg.ReconcileOpenCases)
+	go snapshotService.Run(ctx)
+	go topUpService.Run(ctx)
+	go escrowService.Run(ctx)
+	go loyaltyService.Run(ctx)
+	if cfg.StreamSource == models.StreamSourceNotify {
+		go func() {
+			if err := streamService.Listen(ctx, cfg.DatabaseURL); err != nil {
+				log.Printf("Wallet event stream stopped: %v", err)
+			}
+		}()
+	}
+	go outboxRelay.Run(ctx)
+	go nonceService.Run(ctx)
+
+	if cfg.GRPCPort != "" {
+		go func() {
+			log.Printf("gRPC server starting on port %s", cfg.GRPCPort)
+			if err := api.RunGRPC(":" + cfg.GRPCPort); err != nil {
+				log.Fatalf("Failed to run gRPC server: %v", err)
+			}
+		}()
+	}
 
 	log.Printf("Server starting on port %s", cfg.ServerPort)
 	if err := api.Run(":" + cfg.ServerPort); err != nil {
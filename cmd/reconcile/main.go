@@ -0,0 +1,49 @@
+// Command reconcile compares every wallet balance with the sum of its transactions
+// once and prints the discrepancies. It exits with status 1 when any are found.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/rasul07/alif-task/internal/config"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/service"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+func main() {
+	openCases := flag.Bool("open-cases", false, "open a correction case for every mismatched wallet")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	db, err := storage.NewPostgresDB(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	run, discrepancies, err := service.NewReconciliationService(db).Reconcile(*openCases)
+	if err != nil {
+		log.Fatalf("Failed to reconcile balances: %v", err)
+	}
+
+	fmt.Printf("run %s: %d wallets checked, %d discrepancies, total difference %s, %d cases opened\n",
+		run.ID, run.WalletsChecked, run.Discrepancies, models.FormatAmount(run.TotalDifference), run.CasesOpened)
+	for _, d := range discrepancies {
+		fmt.Printf("wallet %s: stored %s, ledger %s, difference %s, %d transactions, case %s\n",
+			d.WalletID, models.FormatAmount(d.StoredBalance), models.FormatAmount(d.LedgerBalance),
+			models.FormatAmount(d.Difference), d.TransactionCount, d.CaseID)
+	}
+
+	if len(discrepancies) > 0 {
+		db.Close()
+		os.Exit(1)
+	}
+}
@@ -1,15 +1,66 @@
 package config
 
 import (
+	"crypto/sha256"
+	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/rasul07/alif-task/internal/models"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Purposes of the keys derived from SecretKey, see Config.Key
+const (
+	KeyQR             = "qr payload"
+	KeyReceipt        = "receipt"
+	KeyVoucher        = "voucher code"
+	KeyTopUpSimulator = "topup simulator"
 )
 
 type Config struct {
 	DatabaseURL string
 	ServerPort  string
 	SecretKey string
+	AdminToken  string
+
+	// ReconcileInterval is how often balances are reconciled with the ledger
+	ReconcileInterval  time.Duration
+	ReconcileOpenCases bool
+
+	// TopUpSimulatorCallbackURL is where the top-up simulator posts its callbacks,
+	// without it top-ups are settled by polling
+	TopUpSimulatorCallbackURL string
+
+	// LoyaltyPointsTTL is how long earned points can be redeemed, LoyaltyPointValue
+	// is what a point is worth in minor units
+	LoyaltyPointsTTL  time.Duration
+	LoyaltyPointValue int64
+
+	// NotificationSinkPath is the file notifications are written to instead of
+	// being sent, the console when empty
+	NotificationSinkPath string
+
+	// OutboxSinkURL is where domain events are published: file:///path,
+	// http(s)://webhook or nats://host:port/subject. When it is empty events only
+	// feed the user webhooks. OutboxWebhookSecret signs webhook requests.
+	OutboxSinkURL       string
+	OutboxWebhookSecret string
+
+	// StreamSource feeds the wallet event stream: "bus" from the events the outbox
+	// relay of this process publishes or "notify" from PostgreSQL LISTEN/NOTIFY,
+	// which also sees the events published by other instances
+	StreamSource string
+
+	// GRPCPort is the port of the gRPC API, which is off when it is empty
+	GRPCPort string
+
+	// V1Sunset is when /v1 will be removed, announced in the Sunset header of its
+	// responses when set
+	V1Sunset *time.Time
 }
 
 func Load() (*Config, error) {
@@ -18,9 +69,87 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	reconcileInterval := 24 * time.Hour
+	if value := os.Getenv("RECONCILE_INTERVAL"); value != "" {
+		reconcileInterval, err = time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reconcileOpenCases, _ := strconv.ParseBool(os.Getenv("RECONCILE_OPEN_CASES"))
+
+	loyaltyPointsTTL := 365 * 24 * time.Hour
+	if value := os.Getenv("LOYALTY_POINTS_TTL"); value != "" {
+		loyaltyPointsTTL, err = time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	loyaltyPointValue := int64(1)
+	if value := os.Getenv("LOYALTY_POINT_VALUE"); value != "" {
+		loyaltyPointValue, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	streamSource := models.StreamSourceBus
+	if value := os.Getenv("STREAM_SOURCE"); value != "" {
+		if value != models.StreamSourceBus && value != models.StreamSourceNotify {
+			return nil, fmt.Errorf("invalid STREAM_SOURCE %q", value)
+		}
+		streamSource = value
+	}
+
+	secretKey := os.Getenv("SECRET_KEY")
+	if secretKey == "" {
+		return nil, fmt.Errorf("SECRET_KEY is required")
+	}
+
+	var v1Sunset *time.Time
+	if value := os.Getenv("V1_SUNSET"); value != "" {
+		sunset, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid V1_SUNSET %q: %v", value, err)
+		}
+		v1Sunset = &sunset
+	}
+
 	return &Config{
 		DatabaseURL: os.Getenv("DATABASE_URL"),
 		ServerPort:  os.Getenv("SERVER_PORT"),
-		SecretKey: os.Getenv("SECRET_KEY"),
+		SecretKey: secretKey,
+		AdminToken:  os.Getenv("ADMIN_TOKEN"),
+
+		ReconcileInterval:  reconcileInterval,
+		ReconcileOpenCases: reconcileOpenCases,
+
+		TopUpSimulatorCallbackURL: os.Getenv("TOPUP_SIMULATOR_CALLBACK_URL"),
+
+		LoyaltyPointsTTL:  loyaltyPointsTTL,
+		LoyaltyPointValue: loyaltyPointValue,
+
+		NotificationSinkPath: os.Getenv("NOTIFICATION_SINK_PATH"),
+
+		OutboxSinkURL:       os.Getenv("OUTBOX_SINK_URL"),
+		OutboxWebhookSecret: os.Getenv("OUTBOX_WEBHOOK_SECRET"),
+
+		StreamSource: streamSource,
+
+		GRPCPort: os.Getenv("GRPC_PORT"),
+
+		V1Sunset: v1Sunset,
 	}, nil
+}
+
+// Key derives the signing key of one purpose from SecretKey, so a signature of
+// one feature can't be forged with the key of another
+func (c *Config) Key(purpose string) string {
+	key := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(c.SecretKey), nil, []byte(purpose)), key); err != nil {
+		panic(err)
+	}
+	return string(key)
 }
\ No newline at end of file
@@ -0,0 +1,82 @@
+// Package graphql is the executable schema of the GraphQL API, generated by
+// gqlgen from schema.graphqls. The resolvers live in the GraphQL service.
+package graphql
+
+//go:generate go run github.com/99designs/gqlgen generate --config gqlgen.yml
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Request is a GraphQL request as posted by clients
+type Request struct {
+	Query         string         `json:"query" binding:"required"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Response carries the data of an executed query and the errors of the fields
+// that failed. Data is left out when the query was rejected before execution.
+type Response = graphql.Response
+
+// Limits rejects queries longer than MaxQueryLength bytes or nesting selections
+// deeper than MaxDepth before they are executed, zero means no limit. The cost
+// of a query is limited by the complexity extension of gqlgen.
+type Limits struct {
+	MaxQueryLength int
+	MaxDepth       int
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationParameterMutator
+	graphql.OperationContextMutator
+} = Limits{}
+
+func (Limits) ExtensionName() string {
+	return "Limits"
+}
+
+func (Limits) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (l Limits) MutateOperationParameters(ctx context.Context, request *graphql.RawParams) *gqlerror.Error {
+	if l.MaxQueryLength > 0 && len(request.Query) > l.MaxQueryLength {
+		return gqlerror.Errorf("query length exceeds the limit of %d bytes", l.MaxQueryLength)
+	}
+	return nil
+}
+
+// MutateOperationContext runs after validation, so fragments are known and
+// don't form cycles
+func (l Limits) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if l.MaxDepth > 0 && depth(rc.Operation.SelectionSet) > l.MaxDepth {
+		return gqlerror.Errorf("query depth exceeds the limit of %d", l.MaxDepth)
+	}
+	return nil
+}
+
+// depth is how deep fields nest in the selection set, fragments don't add a level
+func depth(selections ast.SelectionSet) int {
+	deepest := 0
+	for _, selection := range selections {
+		var d int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			d = 1 + depth(selection.SelectionSet)
+		case *ast.InlineFragment:
+			d = depth(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			if selection.Definition != nil {
+				d = depth(selection.Definition.SelectionSet)
+			}
+		}
+		deepest = max(deepest, d)
+	}
+	return deepest
+}
@@ -0,0 +1,30 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package graphql
+
+import (
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor,omitempty"`
+}
+
+type Query struct {
+}
+
+type TransactionConnection struct {
+	Edges    []*TransactionEdge `json:"edges"`
+	PageInfo *PageInfo          `json:"pageInfo"`
+}
+
+type TransactionEdge struct {
+	Cursor string              `json:"cursor"`
+	Node   *models.Transaction `json:"node"`
+}
+
+type User struct {
+	ID      string           `json:"id"`
+	Wallets []*models.Wallet `json:"wallets"`
+}
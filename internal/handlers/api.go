@@ -1,27 +1,68 @@
 package handlers
 
 import (
+	"expvar"
+	"net"
+	"time"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
 	"github.com/rasul07/alif-task/internal/service"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"google.golang.org/grpc"
 
 	_ "github.com/rasul07/alif-task/api/docs"
 )
 
+// Services bundles the business services used by the HTTP handlers
+type Services struct {
+	Wallet         service.WalletService
+	Parental       service.ParentalControlService
+	PaymentRequest service.PaymentRequestService
+	QR             service.QRService
+	Merchant       service.MerchantService
+	Webhook        service.WebhookService
+	Split          service.SplitService
+	Payout         service.PayoutService
+	Reconciliation service.ReconciliationService
+	Snapshot       service.SnapshotService
+	Transaction    service.TransactionService
+	Search         service.SearchService
+	Receipt        service.ReceiptService
+	TopUp          service.TopUpService
+	Escrow         service.EscrowService
+	Voucher        service.VoucherService
+	Loyalty        service.LoyaltyService
+	Budget         service.BudgetService
+	Notification   service.NotificationService
+	Stream         service.StreamService
+	GraphQL        service.GraphQLService
+	Outbox         service.OutboxRelay
+	Nonce          service.NonceService
+}
+
 type API struct {
-	router        *gin.Engine
-	walletService service.WalletService
+	router     *gin.Engine
+	grpc       *grpc.Server
+	services   Services
+	adminToken string
+	v1Sunset   *time.Time
 }
 
-func NewAPI(walletService service.WalletService) *API {
+// NewAPI builds the HTTP and gRPC APIs. v1Sunset is when /v1 will be removed,
+// unannounced when nil.
+func NewAPI(services Services, adminToken string, v1Sunset *time.Time) *API {
 	api := &API{
-		router:        gin.New(),
-		walletService: walletService,
+		router:     gin.New(),
+		services:   services,
+		adminToken: adminToken,
+		v1Sunset:   v1Sunset,
 	}
 
 	api.setupRoutes()
+	api.grpc = newGRPCServer(NewHandler(services))
 	return api
 }
 
@@ -32,18 +73,179 @@ func (api *API) setupRoutes() {
 	cfg.AllowCredentials = true
 	api.router.Use(cors.New(cfg))
 
-	handler := NewHandler(api.walletService)
+	handler := NewHandler(api.services)
 
 	v1 := api.router.Group("/v1")
-	v1.Use(AuthMiddleware())
+	v1.Use(DeprecationMiddleware(models.APIV1DeprecatedAt, api.v1Sunset), AuthMiddleware())
 	{
 		v1.POST("/wallet/check", handler.CheckWalletExists)
 		v1.POST("/wallet/topup", handler.TopUpWallet)
 		v1.POST("/wallet/transactions", handler.GetTransactions)
+		v1.POST("/wallet/transactions/history", handler.GetTransactionHistory)
+		v1.POST("/wallet/transactions/update", handler.UpdateTransaction)
 		v1.POST("/wallet/balance", handler.GetBalance)
+		v1.POST("/wallet/balance/at", handler.GetBalanceAt)
+		v1.POST("/wallet/transfer", handler.Transfer)
+		v1.POST("/wallet/receipt", handler.GetReceipt)
+		v1.POST("/graphql", handler.GraphQL)
+	}
+	parental := v1.Group("/parental")
+	{
+		parental.POST("/child/create", handler.CreateChildAccount)
+		parental.POST("/controls/set", handler.SetParentalControls)
+		parental.POST("/controls", handler.GetParentalControls)
+		parental.POST("/approvals", handler.ListApprovals)
+		parental.POST("/approvals/approve", handler.ApproveTransfer)
+		parental.POST("/approvals/decline", handler.DeclineTransfer)
+	}
+	requests := v1.Group("/requests")
+	{
+		requests.POST("", handler.ListPaymentRequests)
+		requests.POST("/create", handler.CreatePaymentRequest)
+		requests.POST("/accept", handler.AcceptPaymentRequest)
+		requests.POST("/decline", handler.DeclinePaymentRequest)
+		requests.POST("/cancel", handler.CancelPaymentRequest)
+	}
+	qr := v1.Group("/qr")
+	{
+		qr.POST("/generate", handler.GenerateQR)
+		qr.POST("/image", handler.RenderQR)
+		qr.POST("/pay", handler.PayQR)
+	}
+	merchants := v1.Group("/merchants")
+	{
+		merchants.POST("", handler.GetMerchant)
+		merchants.POST("/create", handler.CreateMerchant)
+		merchants.POST("/credentials/rotate", handler.RotateMerchantCredentials)
+	}
+	orders := v1.Group("/orders")
+	{
+		orders.POST("", handler.GetOrder)
+		orders.POST("/confirm", handler.ConfirmOrder)
+	}
+	splits := v1.Group("/splits")
+	{
+		splits.POST("", handler.GetSplit)
+		splits.POST("/list", handler.ListSplits)
+		splits.POST("/create", handler.CreateSplit)
+		splits.POST("/pay", handler.PaySplit)
+		splits.POST("/decline", handler.DeclineSplit)
+		splits.POST("/remind", handler.RemindSplit)
+		splits.POST("/cancel", handler.CancelSplit)
+	}
+	payouts := v1.Group("/payouts")
+	{
+		payouts.POST("", handler.GetPayouts)
+		payouts.POST("/list", handler.ListPayouts)
+		payouts.POST("/upload", handler.UploadPayouts)
+		payouts.POST("/pause", handler.PausePayouts)
+		payouts.POST("/resume", handler.ResumePayouts)
+		payouts.POST("/report", handler.PayoutReport)
+	}
+	topups := v1.Group("/topups")
+	{
+		topups.POST("", handler.GetTopUp)
+		topups.POST("/list", handler.ListTopUps)
+		topups.POST("/create", handler.InitiateTopUp)
+	}
+	escrows := v1.Group("/escrows")
+	{
+		escrows.POST("", handler.GetEscrow)
+		escrows.POST("/list", handler.ListEscrows)
+		escrows.POST("/create", handler.CreateEscrow)
+		escrows.POST("/confirm", handler.ConfirmEscrow)
+		escrows.POST("/cancel", handler.CancelEscrow)
+		escrows.POST("/dispute", handler.DisputeEscrow)
+	}
+	vouchers := v1.Group("/vouchers")
+	{
+		vouchers.POST("/redeem", handler.RedeemVoucher)
+	}
+	loyalty := v1.Group("/loyalty")
+	{
+		loyalty.POST("", handler.GetLoyaltyBalance)
+		loyalty.POST("/history", handler.GetLoyaltyHistory)
+		loyalty.POST("/redeem", handler.RedeemPoints)
+	}
+	budgets := v1.Group("/budgets")
+	{
+		budgets.POST("", handler.GetBudgetProgress)
+		budgets.POST("/set", handler.SetBudget)
+		budgets.POST("/delete", handler.DeleteBudget)
+	}
+	notifications := v1.Group("/notifications")
+	{
+		notifications.POST("", handler.ListNotifications)
+		notifications.POST("/preferences", handler.ListNotificationPreferences)
+		notifications.POST("/preferences/set", handler.SetNotificationPreference)
+	}
+	webhooks := v1.Group("/webhooks")
+	{
+		webhooks.POST("", handler.ListWebhooks)
+		webhooks.POST("/create", handler.CreateWebhook)
+		webhooks.POST("/delete", handler.DeleteWebhook)
+		webhooks.POST("/deliveries", handler.ListWebhookDeliveries)
+	}
+
+	v2 := api.router.Group("/v2")
+	v2.Use(SignatureMiddleware(api.services.Nonce))
+	{
+		v2.GET("/events", handler.StreamWalletEvents)
+		v2.GET("/ws", handler.Realtime)
+		v2.POST("/graphql", handler.GraphQL)
+	}
+	wallets := v2.Group("/wallets/:id")
+	wallets.Use(walletResource())
+	{
+		wallets.GET("", handler.GetWalletV2)
+		wallets.GET("/balance", handler.GetWalletBalanceAtV2)
+		wallets.GET("/transactions", handler.ListTransactionsV2)
+		wallets.GET("/transactions/summary", handler.GetTransactionSummaryV2)
+		wallets.GET("/transactions/:transaction_id", handler.GetTransactionV2)
+		wallets.PATCH("/transactions/:transaction_id", handler.UpdateTransactionV2)
+		wallets.GET("/transactions/:transaction_id/receipt", handler.GetReceiptV2)
+		wallets.POST("/topups", handler.CreateTopUpV2)
+		wallets.POST("/transfers", handler.CreateTransferV2)
+	}
+
+	merchantAPI := api.router.Group("/merchant/v1")
+	merchantAPI.Use(MerchantAuthMiddleware(api.services.Merchant))
+	{
+		merchantAPI.POST("/orders/create", handler.CreateOrder)
+		merchantAPI.POST("/orders/status", handler.GetOrderStatus)
+		merchantAPI.POST("/orders/cancel", handler.CancelOrder)
+	}
+
+	admin := api.router.Group("/admin/v1")
+	admin.Use(AdminAuthMiddleware(api.adminToken))
+	{
+		admin.POST("/webhooks/dead-letters", handler.ListWebhookDeadLetters)
+		admin.POST("/webhooks/dead-letters/replay", handler.ReplayWebhookDeadLetter)
+		admin.POST("/outbox/dead-events", handler.ListDeadOutboxEvents)
+		admin.POST("/outbox/dead-events/replay", handler.ReplayOutboxEvent)
+		admin.POST("/reconciliation/run", handler.RunReconciliation)
+		admin.POST("/reconciliation/runs", handler.ListReconciliationRuns)
+		admin.POST("/reconciliation/runs/get", handler.GetReconciliationRun)
+		admin.POST("/reconciliation/cases", handler.ListCorrectionCases)
+		admin.POST("/reconciliation/cases/resolve", handler.ResolveCorrectionCase)
+		admin.POST("/balances/at", handler.GetWalletBalanceAt)
+		admin.POST("/balances/snapshots/run", handler.TakeBalanceSnapshots)
+		admin.POST("/transactions/search", handler.SearchTransactions)
+		admin.POST("/transactions/reverse", handler.ReverseTransaction)
+		admin.POST("/escrows/resolve", handler.ResolveEscrow)
+		admin.POST("/vouchers/batches", handler.ListVoucherBatches)
+		admin.POST("/vouchers/batches/create", handler.CreateVoucherBatch)
+		admin.POST("/vouchers/batches/revoke", handler.RevokeVoucherBatch)
+		admin.POST("/vouchers/batches/report", handler.VoucherBatchReport)
+		admin.POST("/loyalty/rules", handler.ListLoyaltyRules)
+		admin.POST("/loyalty/rules/create", handler.CreateLoyaltyRule)
+		admin.POST("/loyalty/rules/disable", handler.DisableLoyaltyRule)
+		admin.GET("/metrics", gin.WrapH(expvar.Handler()))
 	}
 	{
 		api.router.POST("/auth/digest", handler.GenerateDigest)
+		api.router.POST("/receipts/verify", handler.VerifyReceipt)
+		api.router.POST("/callbacks/topups/:provider", handler.TopUpCallback)
 	}
 
 	url := ginSwagger.URL("swagger/doc.json")
@@ -53,3 +255,12 @@ func (api *API) setupRoutes() {
 func (api *API) Run(addr string) error {
 	return api.router.Run(addr)
 }
+
+// RunGRPC serves the gRPC API on addr, next to the HTTP API of Run
+func (api *API) RunGRPC(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return api.grpc.Serve(listener)
+}
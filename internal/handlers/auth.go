@@ -4,13 +4,28 @@ import (
 	"bytes"
 	"crypto/hmac"
 	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
+	"errors"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/service"
 )
 
+// merchantIDKey is the context key MerchantAuthMiddleware stores the merchant id under
+const merchantIDKey = "merchant_id"
+
+// digestKey is the key users sign requests with
+var digestKey = []byte("secret")
+
 // AuthMiddleware checks the authenticity of the request based on X-UserId and X-Digest headers
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -48,8 +63,171 @@ func AuthMiddleware() gin.HandlerFunc {
 
 // computeHMAC computes the HMAC-SHA1 hash of a message
 func computeHMAC(message []byte) string {
-	key := []byte("secret")
-	h := hmac.New(sha1.New, key)
+	h := hmac.New(sha1.New, digestKey)
 	h.Write(message)
 	return hex.EncodeToString(h.Sum(nil))
 }
+
+// SignatureMiddleware authenticates /v2 requests by X-UserId, X-Timestamp,
+// X-Nonce and X-Signature. Unlike the digest of /v1, which only covers the body
+// and is the same for every bodiless request, the signature covers the method,
+// path, query, user, time and nonce of the request, see canonicalRequest. A
+// nonce is accepted once, so a captured request can't be replayed.
+func SignatureMiddleware(nonceService service.NonceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetHeader("X-UserId")
+		timestamp := c.GetHeader("X-Timestamp")
+		nonce := c.GetHeader("X-Nonce")
+		signature := c.GetHeader("X-Signature")
+
+		if userID == "" || timestamp == "" || !validNonce(nonce) || signature == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		if err := checkTimestamp(timestamp); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+
+		canonical := canonicalRequest(c.Request.Method, c.Request.URL.EscapedPath(), c.Request.URL.RawQuery, userID, timestamp, nonce, body)
+		if !hmac.Equal([]byte(signature), []byte(computeSignature(canonical))) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		// Only requests with a valid signature use up their nonce
+		if err := useNonce(nonceService, userID, nonce); err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+var (
+	errUnauthorized   = errors.New("Unauthorized")
+	errStaleTimestamp = errors.New("Request timestamp is too far from the server time")
+)
+
+func validNonce(nonce string) bool {
+	return nonce != "" && len(nonce) <= models.SignatureMaxNonceLength
+}
+
+// useNonce records the nonce of a signed request, a nonce that was used before
+// is a replay
+func useNonce(nonceService service.NonceService, userID, nonce string) error {
+	err := nonceService.Use(userID, nonce)
+	if err != nil && !errors.Is(err, service.ErrNonceUsed) {
+		return errNonceFailed
+	}
+	return err
+}
+
+var errNonceFailed = errors.New("Error checking the request nonce")
+
+// checkTimestamp checks a signature was made within SignatureMaxSkew of the
+// server time, timestamp is in unix seconds
+func checkTimestamp(timestamp string) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errUnauthorized
+	}
+	if skew := time.Since(time.Unix(seconds, 0)); skew > models.SignatureMaxSkew || skew < -models.SignatureMaxSkew {
+		return errStaleTimestamp
+	}
+	return nil
+}
+
+// canonicalRequest is the string a /v2 request is signed over: the method, the
+// escaped path, the query with its parameters sorted by name, the user id, the
+// timestamp in unix seconds, the nonce and the hex SHA-256 of the body, joined
+// by newlines
+func canonicalRequest(method, path, rawQuery, userID, timestamp, nonce string, body []byte) string {
+	// A query that can't be parsed is signed as it was sent
+	canonicalQuery := rawQuery
+	if query, err := url.ParseQuery(rawQuery); err == nil {
+		canonicalQuery = query.Encode()
+	}
+
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		canonicalQuery,
+		userID,
+		timestamp,
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+}
+
+// computeSignature computes the hex HMAC-SHA256 of a canonical request
+func computeSignature(canonical string) string {
+	h := hmac.New(sha256.New, digestKey)
+	h.Write([]byte(canonical))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// MerchantAuthMiddleware authenticates merchant API calls by the X-Merchant-Key header and
+// X-Digest, the HMAC-SHA256 of the request body under the merchant's API secret
+func MerchantAuthMiddleware(merchantService service.MerchantService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := c.GetHeader("X-Merchant-Key")
+		digest := c.GetHeader("X-Digest")
+
+		if apiKey == "" || digest == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+
+		merchant, err := merchantService.Authenticate(apiKey, digest, body)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		c.Set(merchantIDKey, merchant.ID)
+		c.Next()
+	}
+}
+
+// AdminAuthMiddleware protects operator endpoints with the shared token from the
+// X-Admin-Token header. Admin endpoints are disabled when no token is configured.
+func AdminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader("X-Admin-Token")
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
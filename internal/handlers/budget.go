@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// SetBudget godoc
+// @Summary Set a monthly budget
+// @Description Create the monthly budget of a transaction category or change its amount. Alerts are sent when debits of the category reach 80% and 100% of it.
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.SetBudgetRequest true "Category and amount"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/budgets/set [post]
+func (h *Handler) SetBudget(c *gin.Context) {
+	var request models.SetBudgetRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	budget, err := h.budgetService.SetBudget(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, budgetResponse(budget))
+}
+
+// GetBudgetProgress godoc
+// @Summary Get budget progress
+// @Description Show what was spent against each budget in a month, the current month unless month is set as YYYY-MM
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.BudgetProgressRequest true "Month"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/budgets [post]
+func (h *Handler) GetBudgetProgress(c *gin.Context) {
+	var request models.BudgetProgressRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	progress, err := h.budgetService.ListProgress(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]gin.H, 0, len(progress))
+	for i := range progress {
+		item := budgetResponse(&progress[i].Budget)
+		item["period"] = progress[i].Period
+		item["spent"] = models.FormatAmount(progress[i].Spent)
+		item["remaining"] = models.FormatAmount(max(progress[i].Budget.Amount-progress[i].Spent, 0))
+		item["percent"] = progress[i].Spent * 100 / progress[i].Budget.Amount
+		item["alerts"] = progress[i].Alerts
+		response = append(response, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"budgets": response})
+}
+
+// DeleteBudget godoc
+// @Summary Delete a budget
+// @Description Delete a budget of the caller together with its alerts
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.DeleteBudgetRequest true "Budget ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/budgets/delete [post]
+func (h *Handler) DeleteBudget(c *gin.Context) {
+	var request models.DeleteBudgetRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.budgetService.DeleteBudget(request.BudgetID, c.GetHeader("X-UserId")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"budget_id": request.BudgetID, "deleted": true})
+}
+
+func budgetResponse(budget *models.Budget) gin.H {
+	return gin.H{
+		"id":         budget.ID,
+		"category":   budget.Category,
+		"amount":     models.FormatAmount(budget.Amount),
+		"created_at": budget.CreatedAt,
+		"updated_at": budget.UpdatedAt,
+	}
+}
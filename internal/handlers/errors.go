@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/rasul07/alif-task/internal/notify"
+	"github.com/rasul07/alif-task/internal/payout"
+	"github.com/rasul07/alif-task/internal/qr"
+	"github.com/rasul07/alif-task/internal/service"
+	"github.com/rasul07/alif-task/internal/storage"
+	"github.com/rasul07/alif-task/internal/topup"
+	"github.com/rasul07/alif-task/internal/voucher"
+)
+
+// errorStatus maps domain errors to HTTP status codes
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows),
+		errors.Is(err, service.ErrApprovalNotFound),
+		errors.Is(err, service.ErrPaymentRequestNotFound),
+		errors.Is(err, service.ErrMerchantNotFound),
+		errors.Is(err, service.ErrOrderNotFound),
+		errors.Is(err, service.ErrWebhookNotFound),
+		errors.Is(err, service.ErrSplitNotFound),
+		errors.Is(err, service.ErrPayoutBatchNotFound),
+		errors.Is(err, service.ErrReconciliationRunNotFound),
+		errors.Is(err, service.ErrTransactionNotFound),
+		errors.Is(err, service.ErrReceiptNotFound),
+		errors.Is(err, service.ErrTopUpNotFound),
+		errors.Is(err, service.ErrEscrowNotFound),
+		errors.Is(err, service.ErrVoucherNotFound),
+		errors.Is(err, service.ErrVoucherBatchNotFound),
+		errors.Is(err, service.ErrLoyaltyRuleNotFound),
+		errors.Is(err, service.ErrBudgetNotFound),
+		errors.Is(err, topup.ErrUnknownReference):
+		return http.StatusNotFound
+	case errors.Is(err, qr.ErrMalformed),
+		errors.Is(err, qr.ErrInvalidCRC),
+		errors.Is(err, qr.ErrInvalidSignature),
+		errors.Is(err, qr.ErrUnknownIssuer),
+		errors.Is(err, service.ErrUnknownEventType),
+		errors.Is(err, service.ErrWebhookURLNotAllowed),
+		errors.Is(err, payout.ErrUnsupportedFormat),
+		errors.Is(err, payout.ErrEmptyFile),
+		errors.Is(err, payout.ErrTooManyRows),
+		errors.Is(err, payout.ErrMissingColumn),
+		errors.Is(err, service.ErrInvalidTags),
+		errors.Is(err, service.ErrInvalidMetadata),
+		errors.Is(err, service.ErrInvalidAmountRange),
+		errors.Is(err, service.ErrUnknownProvider),
+		errors.Is(err, topup.ErrUnsupportedSource),
+		errors.Is(err, topup.ErrInvalidCallback),
+		errors.Is(err, voucher.ErrMalformed),
+		errors.Is(err, voucher.ErrInvalidChecksum),
+		errors.Is(err, service.ErrInvalidBudgetMonth),
+		errors.Is(err, service.ErrEmptyCategory),
+		errors.Is(err, notify.ErrUnknownChannel),
+		errors.Is(err, notify.ErrInvalidAddress):
+		return http.StatusBadRequest
+	case errors.Is(err, topup.ErrInvalidSignature),
+		errors.Is(err, service.ErrNonceUsed):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrNotParent),
+		errors.Is(err, service.ErrNotEscrowBuyer),
+		errors.Is(err, service.ErrNotEscrowSeller):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrApprovalNotPending),
+		errors.Is(err, service.ErrPaymentRequestNotPending),
+		errors.Is(err, service.ErrQRAlreadyPaid),
+		errors.Is(err, service.ErrOrderNotPayable),
+		errors.Is(err, service.ErrOrderNotCancellable),
+		errors.Is(err, service.ErrDuplicateOrderReference),
+		errors.Is(err, service.ErrDeadLetterNotReplayable),
+		errors.Is(err, service.ErrOutboxEventNotReplayable),
+		errors.Is(err, service.ErrSplitNotOpen),
+		errors.Is(err, service.ErrShareNotPending),
+		errors.Is(err, service.ErrPayoutBatchNotRunning),
+		errors.Is(err, service.ErrPayoutBatchNotPaused),
+		errors.Is(err, service.ErrCaseNotOpen),
+		errors.Is(err, service.ErrNotReversible),
+		errors.Is(err, storage.ErrInvalidTransition),
+		errors.Is(err, service.ErrEscrowNotHeld),
+		errors.Is(err, service.ErrEscrowNotDisputed),
+		errors.Is(err, service.ErrEscrowNotPending),
+		errors.Is(err, service.ErrVoucherRedeemed),
+		errors.Is(err, service.ErrVoucherBatchNotActive):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrInsufficientFunds),
+		errors.Is(err, service.ErrSameWallet),
+		errors.Is(err, service.ErrMaxBalanceExceeded),
+		errors.Is(err, service.ErrCategoryBlocked),
+		errors.Is(err, service.ErrSpendingLimitExceeded),
+		errors.Is(err, service.ErrApprovalRequired),
+		errors.Is(err, service.ErrInvalidExpiry),
+		errors.Is(err, service.ErrSelfPaymentRequest),
+		errors.Is(err, service.ErrQRExpired),
+		errors.Is(err, service.ErrQRAmountMismatch),
+		errors.Is(err, service.ErrQRAmountRequired),
+		errors.Is(err, service.ErrQRUnsupportedCurrency),
+		errors.Is(err, service.ErrOrderExpired),
+		errors.Is(err, service.ErrInvalidShares),
+		errors.Is(err, service.ErrDuplicateParticipant),
+		errors.Is(err, service.ErrSelfSplit),
+		errors.Is(err, service.ErrReminderTooSoon),
+		errors.Is(err, service.ErrPayoutExceedsBalance),
+		errors.Is(err, service.ErrFutureBalance),
+		errors.Is(err, service.ErrReceiptUnavailable),
+		errors.Is(err, service.ErrInvalidEscrowSplit),
+		errors.Is(err, service.ErrVoucherExpired),
+		errors.Is(err, service.ErrVoucherRevoked),
+		errors.Is(err, service.ErrRedemptionTooSmall),
+		errors.Is(err, storage.ErrInsufficientPoints):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
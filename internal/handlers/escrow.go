@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// CreateEscrow godoc
+// @Summary Pay into escrow
+// @Description Take a marketplace payment from the buyer's wallet and hold it until the buyer confirms delivery. Held funds are released to the seller automatically after release_after_days unless the deal is disputed. A child's payment is subject to the parental controls and stays pending until the parent approves it.
+// @Tags escrows
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.CreateEscrowRequest true "Deal details"
+// @Success 201 {object} map[string]interface{}
+// @Router /v1/escrows/create [post]
+func (h *Handler) CreateEscrow(c *gin.Context) {
+	var request models.CreateEscrowRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	escrow, err := h.escrowService.Create(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, escrowResponse(escrow))
+}
+
+// GetEscrow godoc
+// @Summary Get an escrow
+// @Description Get an escrow the caller buys or sells in
+// @Tags escrows
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.EscrowRequest true "Escrow ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/escrows [post]
+func (h *Handler) GetEscrow(c *gin.Context) {
+	var request models.EscrowRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	escrow, err := h.escrowService.Get(request.EscrowID, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, escrowResponse(escrow))
+}
+
+// ListEscrows godoc
+// @Summary List escrows
+// @Description List the escrows the caller buys or sells in, newest first
+// @Tags escrows
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/escrows/list [post]
+func (h *Handler) ListEscrows(c *gin.Context) {
+	escrows, err := h.escrowService.List(c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]gin.H, 0, len(escrows))
+	for i := range escrows {
+		response = append(response, escrowResponse(&escrows[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"escrows": response})
+}
+
+// ConfirmEscrow godoc
+// @Summary Confirm delivery
+// @Description Release the held funds to the seller. Only the buyer can confirm.
+// @Tags escrows
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.EscrowRequest true "Escrow ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/escrows/confirm [post]
+func (h *Handler) ConfirmEscrow(c *gin.Context) {
+	var request models.EscrowRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	escrow, err := h.escrowService.Confirm(request.EscrowID, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, escrowResponse(escrow))
+}
+
+// CancelEscrow godoc
+// @Summary Cancel a deal
+// @Description Refund the held funds to the buyer. Only the seller can cancel, a buyer who didn't get the goods opens a dispute.
+// @Tags escrows
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.EscrowRequest true "Escrow ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/escrows/cancel [post]
+func (h *Handler) CancelEscrow(c *gin.Context) {
+	var request models.EscrowRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	escrow, err := h.escrowService.Cancel(request.EscrowID, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, escrowResponse(escrow))
+}
+
+// DisputeEscrow godoc
+// @Summary Dispute a deal
+// @Description Stop the funds from being released or refunded until an admin resolves the dispute. Either party can dispute a held escrow.
+// @Tags escrows
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.DisputeEscrowRequest true "Escrow ID and reason"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/escrows/dispute [post]
+func (h *Handler) DisputeEscrow(c *gin.Context) {
+	var request models.DisputeEscrowRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	escrow, err := h.escrowService.Dispute(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, escrowResponse(escrow))
+}
+
+// ResolveEscrow godoc
+// @Summary Resolve an escrow dispute
+// @Description Pay seller_amount of a disputed escrow to the seller and refund the rest to the buyer
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param request body models.ResolveEscrowRequest true "Decision"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/v1/escrows/resolve [post]
+func (h *Handler) ResolveEscrow(c *gin.Context) {
+	var request models.ResolveEscrowRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	escrow, err := h.escrowService.Resolve(request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, escrowResponse(escrow))
+}
+
+func escrowResponse(escrow *models.Escrow) gin.H {
+	return gin.H{
+		"id":                     escrow.ID,
+		"buyer_wallet_id":        escrow.BuyerWalletID,
+		"seller_wallet_id":       escrow.SellerWalletID,
+		"amount":                 models.FormatAmount(escrow.Amount),
+		"description":            escrow.Description,
+		"reference":              escrow.Reference,
+		"status":                 escrow.Status,
+		"released_amount":        models.FormatAmount(escrow.ReleasedAmount),
+		"refunded_amount":        models.FormatAmount(escrow.RefundedAmount),
+		"hold_transaction_id":    escrow.HoldTransactionID,
+		"release_transaction_id": escrow.ReleaseTransactionID,
+		"refund_transaction_id":  escrow.RefundTransactionID,
+		"dispute_reason":         escrow.DisputeReason,
+		"resolution":             escrow.Resolution,
+		"release_at":             escrow.ReleaseAt,
+		"created_at":             escrow.CreatedAt,
+		"settled_at":             escrow.SettledAt,
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/graphql"
+)
+
+// GraphQL godoc
+// @Summary GraphQL query
+// @Description Run a read-only GraphQL query over the caller's user, wallets and transactions. Transactions are paginated as connections with first and after, queries longer than 16 KiB, deeper than 10 levels or costing more than 1000 are rejected. A connection costs its page size times its selection.
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body graphql.Request true "Query"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /v1/graphql [post]
+func (h *Handler) GraphQL(c *gin.Context) {
+	var request graphql.Request
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	response := h.graphqlService.Execute(c.Request.Context(), request, c.GetHeader("X-UserId"))
+	if response.Data == nil {
+		// The query was rejected before it was executed
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/service"
+	"github.com/rasul07/alif-task/internal/walletpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcUserKey is the context key the auth interceptors store the caller under
+type grpcUserKey struct{}
+
+// grpcServer implements the wallet service of api/proto/wallet/v1/wallet.proto
+// with the services of the REST API. Calls are authenticated and their errors
+// mapped to statuses by the interceptors of newGRPCServer.
+type grpcServer struct {
+	walletpb.UnimplementedWalletServiceServer
+	handler *Handler
+}
+
+func newGRPCServer(h *Handler) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(grpcStatusUnaryInterceptor, grpcAuthUnaryInterceptor),
+		grpc.ChainStreamInterceptor(grpcStatusStreamInterceptor, grpcAuthStreamInterceptor),
+	)
+	walletpb.RegisterWalletServiceServer(server, &grpcServer{handler: h})
+	return server
+}
+
+// grpcAuthenticate is AuthMiddleware for gRPC: the x-userid and x-digest
+// metadata are checked with the encoded request message in place of the body.
+// Messages are encoded with their fields in number order, like protobuf
+// encoders of every language do.
+func grpcAuthenticate(ctx context.Context, message any) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var userID, digest string
+	if values := md.Get("x-userid"); len(values) > 0 {
+		userID = values[0]
+	}
+	if values := md.Get("x-digest"); len(values) > 0 {
+		digest = values[0]
+	}
+
+	request, ok := message.(proto.Message)
+	if userID == "" || digest == "" || !ok {
+		return "", errGRPCUnauthenticated
+	}
+	payload, err := proto.Marshal(request)
+	if err != nil || digest != computeHMAC(payload) {
+		return "", errGRPCUnauthenticated
+	}
+
+	return userID, nil
+}
+
+var errGRPCUnauthenticated = status.Error(codes.Unauthenticated, "Unauthorized")
+
+func grpcAuthUnaryInterceptor(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	userID, err := grpcAuthenticate(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return handler(context.WithValue(ctx, grpcUserKey{}, userID), request)
+}
+
+// grpcAuthStreamInterceptor authenticates the request message of a server
+// streaming call when the handler receives it, the caller is in the context of
+// the stream from then on
+func grpcAuthStreamInterceptor(server any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(server, &grpcAuthStream{ServerStream: stream, ctx: stream.Context()})
+}
+
+type grpcAuthStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *grpcAuthStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *grpcAuthStream) RecvMsg(message any) error {
+	if err := s.ServerStream.RecvMsg(message); err != nil {
+		return err
+	}
+	userID, err := grpcAuthenticate(s.ServerStream.Context(), message)
+	if err != nil {
+		return err
+	}
+	s.ctx = context.WithValue(s.ServerStream.Context(), grpcUserKey{}, userID)
+	return nil
+}
+
+func grpcUserID(ctx context.Context) string {
+	userID, _ := ctx.Value(grpcUserKey{}).(string)
+	return userID
+}
+
+func grpcStatusUnaryInterceptor(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	response, err := handler(ctx, request)
+	return response, grpcStatus(err)
+}
+
+func grpcStatusStreamInterceptor(server any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return grpcStatus(handler(server, stream))
+}
+
+// grpcStatus maps a domain error to the gRPC status matching its HTTP status,
+// so both APIs share one mapping. Statuses pass through.
+func grpcStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(grpcCode(errorStatus(err)), err.Error())
+}
+
+// grpcCode is the gRPC code with the meaning of an HTTP status
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusOK, http.StatusAccepted:
+		return codes.OK
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusUnprocessableEntity:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+var errGRPCInvalidPayload = status.Error(codes.InvalidArgument, "Invalid request payload")
+
+// grpcValidate validates the REST request model built from a message with the
+// binding rules of the REST API
+func grpcValidate(request any) error {
+	if err := binding.Validator.ValidateStruct(request); err != nil {
+		return errGRPCInvalidPayload
+	}
+	return nil
+}
+
+// grpcMetadata turns the JSON metadata of a message into a request field
+func grpcMetadata(metadata string) (json.RawMessage, error) {
+	if metadata == "" {
+		return nil, nil
+	}
+	if !json.Valid([]byte(metadata)) {
+		return nil, errGRPCInvalidPayload
+	}
+	return json.RawMessage(metadata), nil
+}
+
+func (s *grpcServer) CheckWalletExists(ctx context.Context, message *walletpb.WalletRequest) (*walletpb.CheckWalletExistsResponse, error) {
+	request := models.RequestModel{WalletID: message.GetWalletId()}
+	if err := grpcValidate(&request); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.handler.walletService.CheckWalletExists(request.WalletID, grpcUserID(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Error checking wallet")
+	}
+
+	return &walletpb.CheckWalletExistsResponse{Exists: exists}, nil
+}
+
+func (s *grpcServer) TopUpWallet(ctx context.Context, message *walletpb.TopUpRequest) (*walletpb.OperationResponse, error) {
+	metadata, err := grpcMetadata(message.GetMetadata())
+	if err != nil {
+		return nil, err
+	}
+	request := models.TopUpRequest{
+		WalletID:    message.GetWalletId(),
+		Amount:      message.GetAmount(),
+		Description: message.GetDescription(),
+		Category:    message.GetCategory(),
+		Tags:        message.GetTags(),
+		Metadata:    metadata,
+	}
+	if err := grpcValidate(&request); err != nil {
+		return nil, err
+	}
+
+	transactionID, err := s.handler.walletService.TopUpWallet(request, grpcUserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &walletpb.OperationResponse{TransactionId: transactionID, Message: "Wallet topped up successfully"}, nil
+}
+
+func (s *grpcServer) GetTransactions(ctx context.Context, message *walletpb.WalletRequest) (*walletpb.GetTransactionsResponse, error) {
+	request := models.RequestModel{WalletID: message.GetWalletId()}
+	if err := grpcValidate(&request); err != nil {
+		return nil, err
+	}
+
+	count, total, err := s.handler.walletService.GetTransactions(request.WalletID, grpcUserID(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &walletpb.GetTransactionsResponse{Count: int64(count), Total: total}, nil
+}
+
+func (s *grpcServer) GetBalance(ctx context.Context, message *walletpb.WalletRequest) (*walletpb.GetBalanceResponse, error) {
+	request := models.RequestModel{WalletID: message.GetWalletId()}
+	if err := grpcValidate(&request); err != nil {
+		return nil, err
+	}
+
+	balance, err := s.handler.walletService.GetBalance(request.WalletID, grpcUserID(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Error getting balance")
+	}
+
+	return &walletpb.GetBalanceResponse{Balance: balance}, nil
+}
+
+func (s *grpcServer) Transfer(ctx context.Context, message *walletpb.TransferRequest) (*walletpb.OperationResponse, error) {
+	metadata, err := grpcMetadata(message.GetMetadata())
+	if err != nil {
+		return nil, err
+	}
+	request := models.TransferRequest{
+		WalletID:         message.GetWalletId(),
+		ToWalletID:       message.GetToWalletId(),
+		Amount:           message.GetAmount(),
+		MerchantCategory: message.GetMerchantCategory(),
+		Reference:        message.GetReference(),
+		Description:      message.GetDescription(),
+		Category:         message.GetCategory(),
+		Tags:             message.GetTags(),
+		Metadata:         metadata,
+	}
+	if err := grpcValidate(&request); err != nil {
+		return nil, err
+	}
+
+	transactionID, err := s.handler.walletService.Transfer(request, grpcUserID(ctx))
+	if errors.Is(err, service.ErrApprovalRequired) {
+		return &walletpb.OperationResponse{Message: err.Error(), PendingApproval: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &walletpb.OperationResponse{TransactionId: transactionID, Message: "Transfer completed successfully"}, nil
+}
+
+// StreamTransactionHistory sends the history page by page, each page continuing
+// before the last id of the previous one
+func (s *grpcServer) StreamTransactionHistory(message *walletpb.TransactionHistoryRequest, stream walletpb.WalletService_StreamTransactionHistoryServer) error {
+	filter, err := grpcHistoryFilter(message)
+	if err != nil {
+		return err
+	}
+	if err := grpcValidate(&filter); err != nil {
+		return err
+	}
+	if filter.Limit == 0 {
+		filter.Limit = models.TransactionHistoryLimit
+	}
+
+	userID := grpcUserID(stream.Context())
+	for {
+		transactions, err := s.handler.transactionService.History(filter, userID)
+		if err != nil {
+			return err
+		}
+		for i := range transactions {
+			if err := stream.Send(grpcTransaction(&transactions[i])); err != nil {
+				return err
+			}
+		}
+		if len(transactions) < filter.Limit {
+			return nil
+		}
+		filter.BeforeID = transactions[len(transactions)-1].ID
+	}
+}
+
+func grpcHistoryFilter(message *walletpb.TransactionHistoryRequest) (models.TransactionFilter, error) {
+	metadata, err := grpcMetadata(message.GetMetadata())
+	if err != nil {
+		return models.TransactionFilter{}, err
+	}
+	filter := models.TransactionFilter{
+		WalletID:    message.GetWalletId(),
+		Type:        message.GetType(),
+		Status:      message.GetStatus(),
+		Category:    message.GetCategory(),
+		Tags:        message.GetTags(),
+		Description: message.GetDescription(),
+		Metadata:    metadata,
+		MinAmount:   message.GetMinAmount(),
+		MaxAmount:   message.GetMaxAmount(),
+		BeforeID:    message.GetBeforeId(),
+		Limit:       int(message.GetPageSize()),
+	}
+
+	for _, bound := range []struct {
+		value  string
+		target **time.Time
+	}{{message.GetFrom(), &filter.From}, {message.GetTo(), &filter.To}} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, bound.value)
+		if err != nil {
+			return models.TransactionFilter{}, errGRPCInvalidPayload
+		}
+		*bound.target = &t
+	}
+
+	return filter, nil
+}
+
+func grpcTransaction(t *models.Transaction) *walletpb.Transaction {
+	transactionType := models.TransactionTypeCredit
+	amount := t.Amount
+	if amount < 0 {
+		transactionType = models.TransactionTypeDebit
+		amount = -amount
+	}
+
+	message := &walletpb.Transaction{
+		Id:                   t.ID,
+		WalletId:             t.WalletID,
+		Type:                 transactionType,
+		Amount:               models.FormatAmount(amount),
+		CounterpartyWalletId: t.CounterpartyWalletID,
+		MerchantCategory:     t.MerchantCategory,
+		Reference:            t.Reference,
+		Description:          t.Description,
+		Category:             t.Category,
+		Tags:                 t.Tags,
+		Metadata:             string(t.Metadata),
+		Status:               t.Status,
+		FailureReason:        t.FailureReason,
+		CreatedAt:            t.CreatedAt.Format(time.RFC3339),
+		CompletedAt:          grpcTime(t.CompletedAt),
+		FailedAt:             grpcTime(t.FailedAt),
+		ReversedAt:           grpcTime(t.ReversedAt),
+	}
+	if t.ReversedBy != nil {
+		message.ReversedBy = *t.ReversedBy
+	}
+	return message
+}
+
+func grpcTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
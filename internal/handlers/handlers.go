@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"io"
 	"net/http"
 
@@ -10,11 +11,57 @@ import (
 )
 
 type Handler struct {
-	walletService service.WalletService
+	walletService         service.WalletService
+	parentalService       service.ParentalControlService
+	paymentRequestService service.PaymentRequestService
+	qrService             service.QRService
+	merchantService       service.MerchantService
+	webhookService        service.WebhookService
+	splitService          service.SplitService
+	payoutService         service.PayoutService
+	reconciliationService service.ReconciliationService
+	snapshotService       service.SnapshotService
+	transactionService    service.TransactionService
+	searchService         service.SearchService
+	receiptService        service.ReceiptService
+	topUpService          service.TopUpService
+	escrowService         service.EscrowService
+	voucherService        service.VoucherService
+	loyaltyService        service.LoyaltyService
+	budgetService         service.BudgetService
+	notificationService   service.NotificationService
+	streamService         service.StreamService
+	graphqlService        service.GraphQLService
+	outboxRelay           service.OutboxRelay
+	nonceService          service.NonceService
 }
 
-func NewHandler(walletService service.WalletService) *Handler {
-	return &Handler{walletService: walletService}
+func NewHandler(services Services) *Handler {
+	return &Handler{
+		walletService:         services.Wallet,
+		parentalService:       services.Parental,
+		paymentRequestService: services.PaymentRequest,
+		qrService:             services.QR,
+		merchantService:       services.Merchant,
+		webhookService:        services.Webhook,
+		splitService:          services.Split,
+		payoutService:         services.Payout,
+		reconciliationService: services.Reconciliation,
+		snapshotService:       services.Snapshot,
+		transactionService:    services.Transaction,
+		searchService:         services.Search,
+		receiptService:        services.Receipt,
+		topUpService:          services.TopUp,
+		escrowService:         services.Escrow,
+		voucherService:        services.Voucher,
+		loyaltyService:        services.Loyalty,
+		budgetService:         services.Budget,
+		notificationService:   services.Notification,
+		streamService:         services.Stream,
+		graphqlService:        services.GraphQL,
+		outboxRelay:           services.Outbox,
+		nonceService:          services.Nonce,
+	}
 }
 
 // GenerateDigest godoc
@@ -65,14 +112,14 @@ func (h *Handler) CheckWalletExists(c *gin.Context) {
 
 // TopUpWallet godoc
 // @Summary Top up a wallet
-// @Description Top up a wallet with the given amount
+// @Description Top up a wallet with the given amount. The response carries a signed receipt of the operation.
 // @Tags wallet
 // @Accept json
 // @Produce json
 // @Param X-UserId header string true "User ID"
 // @Param X-Digest header string true "Digest"
 // @Param request body models.TopUpRequest true "Top up request"
-// @Success 200 {object} map[string]string
+// @Success 200 {object} map[string]interface{}
 // @Router /v1/wallet/topup [post]
 func (h *Handler) TopUpWallet(c *gin.Context) {
 	var request models.TopUpRequest
@@ -82,12 +129,17 @@ func (h *Handler) TopUpWallet(c *gin.Context) {
 		return
 	}
 
-	if err := h.walletService.TopUpWallet(request.WalletID, c.GetHeader("X-UserId"), request.Amount); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	transactionID, err := h.walletService.TopUpWallet(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Wallet topped up successfully"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Wallet topped up successfully",
+		"transaction_id": transactionID,
+		"receipt":        h.receipt(transactionID, c.GetHeader("X-UserId")),
+	})
 }
 
 // GetTransactions godoc
@@ -148,3 +200,40 @@ func (h *Handler) GetBalance(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"balance": balance})
 }
+
+// Transfer godoc
+// @Summary Transfer funds
+// @Description Transfer funds from the caller's wallet to another wallet. Transfers of child accounts are checked against parental controls and may be queued for approval.
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.TransferRequest true "Transfer request"
+// @Success 200 {object} map[string]interface{}
+// @Success 202 {object} map[string]string
+// @Router /v1/wallet/transfer [post]
+func (h *Handler) Transfer(c *gin.Context) {
+	var request models.TransferRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	transactionID, err := h.walletService.Transfer(request, c.GetHeader("X-UserId"))
+	if errors.Is(err, service.ErrApprovalRequired) {
+		c.JSON(http.StatusAccepted, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Transfer completed successfully",
+		"transaction_id": transactionID,
+		"receipt":        h.receipt(transactionID, c.GetHeader("X-UserId")),
+	})
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// GetLoyaltyBalance godoc
+// @Summary Get loyalty points balance
+// @Description Get the points the caller can redeem and how many of them expire within 30 days
+// @Tags loyalty
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/loyalty [post]
+func (h *Handler) GetLoyaltyBalance(c *gin.Context) {
+	balance, err := h.loyaltyService.GetBalance(c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"points":          balance.Points,
+		"expiring_points": balance.ExpiringPoints,
+		"next_expiry_at":  balance.NextExpiryAt,
+	})
+}
+
+// GetLoyaltyHistory godoc
+// @Summary Get loyalty points history
+// @Description List the points earned, redeemed, given back and expired, newest first. Continue with before_id set to the last id of the previous page.
+// @Tags loyalty
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.LoyaltyHistoryRequest true "Page"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/loyalty/history [post]
+func (h *Handler) GetLoyaltyHistory(c *gin.Context) {
+	var request models.LoyaltyHistoryRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	entries, err := h.loyaltyService.History(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]gin.H, 0, len(entries))
+	for _, entry := range entries {
+		item := gin.H{
+			"id":         entry.ID,
+			"kind":       entry.Kind,
+			"points":     entry.Points,
+			"created_at": entry.CreatedAt,
+		}
+		if entry.Kind == models.LoyaltyEntryEarn {
+			item["remaining"] = entry.Remaining
+			item["expires_at"] = entry.ExpiresAt
+			item["transaction_id"] = entry.TransactionID
+		}
+		if entry.WalletID != "" {
+			item["wallet_id"] = entry.WalletID
+		}
+		if entry.RedemptionID != "" {
+			item["redemption_id"] = entry.RedemptionID
+		}
+		response = append(response, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": response})
+}
+
+// RedeemPoints godoc
+// @Summary Redeem loyalty points
+// @Description Convert points to credit of the caller's wallet at the configured rate. Points expiring first are used first, the wallet limits of a top-up apply.
+// @Tags loyalty
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.RedeemPointsRequest true "Wallet and points"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/loyalty/redeem [post]
+func (h *Handler) RedeemPoints(c *gin.Context) {
+	var request models.RedeemPointsRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	userID := c.GetHeader("X-UserId")
+	redemption, err := h.loyaltyService.Redeem(request, userID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"redemption_id":  redemption.ID,
+		"wallet_id":      redemption.WalletID,
+		"points":         redemption.Points,
+		"amount":         models.FormatAmount(redemption.Amount),
+		"transaction_id": redemption.TransactionID,
+		"completed_at":   redemption.CompletedAt,
+		"receipt":        h.receipt(redemption.TransactionID, userID),
+	})
+}
+
+// CreateLoyaltyRule godoc
+// @Summary Create a loyalty rule
+// @Description Award points_per_unit points for every whole unit of a wallet event of the type. Events below min_amount earn nothing.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param request body models.CreateLoyaltyRuleRequest true "Rule"
+// @Success 201 {object} map[string]interface{}
+// @Router /admin/v1/loyalty/rules/create [post]
+func (h *Handler) CreateLoyaltyRule(c *gin.Context) {
+	var request models.CreateLoyaltyRuleRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	rule, err := h.loyaltyService.CreateRule(request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, loyaltyRuleResponse(rule))
+}
+
+// ListLoyaltyRules godoc
+// @Summary List loyalty rules
+// @Description List the active and disabled loyalty rules
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/v1/loyalty/rules [post]
+func (h *Handler) ListLoyaltyRules(c *gin.Context) {
+	rules, err := h.loyaltyService.ListRules()
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]gin.H, 0, len(rules))
+	for i := range rules {
+		response = append(response, loyaltyRuleResponse(&rules[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": response})
+}
+
+// DisableLoyaltyRule godoc
+// @Summary Disable a loyalty rule
+// @Description Stop a rule from awarding points. Points it already awarded are kept.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param request body models.LoyaltyRuleRequest true "Rule ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/v1/loyalty/rules/disable [post]
+func (h *Handler) DisableLoyaltyRule(c *gin.Context) {
+	var request models.LoyaltyRuleRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.loyaltyService.DisableRule(request.RuleID); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rule_id": request.RuleID, "active": false})
+}
+
+func loyaltyRuleResponse(rule *models.LoyaltyRule) gin.H {
+	return gin.H{
+		"id":              rule.ID,
+		"event_type":      rule.EventType,
+		"points_per_unit": rule.PointsPerUnit,
+		"min_amount":      models.FormatAmount(rule.MinAmount),
+		"active":          rule.Active,
+		"created_at":      rule.CreatedAt,
+	}
+}
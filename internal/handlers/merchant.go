@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// CreateMerchant godoc
+// @Summary Register a merchant
+// @Description Register a merchant with a legal profile and settlement wallet owned by the caller. The API secret is only returned here and on rotation.
+// @Tags merchants
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.CreateMerchantRequest true "Merchant profile"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/merchants/create [post]
+func (h *Handler) CreateMerchant(c *gin.Context) {
+	var request models.CreateMerchantRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	merchant, err := h.merchantService.CreateMerchant(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := merchantResponse(merchant)
+	response["api_secret"] = merchant.APISecret
+	c.JSON(http.StatusOK, response)
+}
+
+// GetMerchant godoc
+// @Summary Get a merchant
+// @Description Get the profile of a merchant owned by the caller
+// @Tags merchants
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.MerchantRequest true "Merchant ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/merchants [post]
+func (h *Handler) GetMerchant(c *gin.Context) {
+	var request models.MerchantRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	merchant, err := h.merchantService.GetMerchant(request.MerchantID, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, merchantResponse(merchant))
+}
+
+// RotateMerchantCredentials godoc
+// @Summary Rotate merchant API credentials
+// @Description Issue a new API key and secret for a merchant owned by the caller
+// @Tags merchants
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.MerchantRequest true "Merchant ID"
+// @Success 200 {object} map[string]string
+// @Router /v1/merchants/credentials/rotate [post]
+func (h *Handler) RotateMerchantCredentials(c *gin.Context) {
+	var request models.MerchantRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	merchant, err := h.merchantService.RotateCredentials(request.MerchantID, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"api_key":    merchant.APIKey,
+		"api_secret": merchant.APISecret,
+	})
+}
+
+// CreateOrder godoc
+// @Summary Create an order
+// @Description Create an order the customer pays from their wallet
+// @Tags merchant-api
+// @Accept json
+// @Produce json
+// @Param X-Merchant-Key header string true "Merchant API key"
+// @Param X-Digest header string true "HMAC-SHA256 of the body under the API secret"
+// @Param request body models.CreateOrderRequest true "Order"
+// @Success 200 {object} map[string]interface{}
+// @Router /merchant/v1/orders/create [post]
+func (h *Handler) CreateOrder(c *gin.Context) {
+	var request models.CreateOrderRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	order, err := h.merchantService.CreateOrder(c.GetString(merchantIDKey), request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, orderResponse(order))
+}
+
+// GetOrderStatus godoc
+// @Summary Get order status
+// @Description Get an order of the merchant together with its status history
+// @Tags merchant-api
+// @Accept json
+// @Produce json
+// @Param X-Merchant-Key header string true "Merchant API key"
+// @Param X-Digest header string true "HMAC-SHA256 of the body under the API secret"
+// @Param request body models.OrderRequest true "Order ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /merchant/v1/orders/status [post]
+func (h *Handler) GetOrderStatus(c *gin.Context) {
+	var request models.OrderRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	order, events, err := h.merchantService.GetOrder(c.GetString(merchantIDKey), request.OrderID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	history := make([]gin.H, 0, len(events))
+	for _, event := range events {
+		history = append(history, gin.H{
+			"from_status": event.FromStatus,
+			"to_status":   event.ToStatus,
+			"details":     event.Details,
+			"created_at":  event.CreatedAt,
+		})
+	}
+
+	response := orderResponse(order)
+	response["history"] = history
+	c.JSON(http.StatusOK, response)
+}
+
+// CancelOrder godoc
+// @Summary Cancel an order
+// @Description Cancel an order that hasn't been paid yet
+// @Tags merchant-api
+// @Accept json
+// @Produce json
+// @Param X-Merchant-Key header string true "Merchant API key"
+// @Param X-Digest header string true "HMAC-SHA256 of the body under the API secret"
+// @Param request body models.OrderRequest true "Order ID"
+// @Success 200 {object} map[string]string
+// @Router /merchant/v1/orders/cancel [post]
+func (h *Handler) CancelOrder(c *gin.Context) {
+	var request models.OrderRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.merchantService.CancelOrder(c.GetString(merchantIDKey), request.OrderID); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order cancelled"})
+}
+
+// GetOrder godoc
+// @Summary Get an order to pay
+// @Description Get the order details a customer sees before confirming the payment
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.OrderRequest true "Order ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/orders [post]
+func (h *Handler) GetOrder(c *gin.Context) {
+	var request models.OrderRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	order, merchant, err := h.merchantService.GetOrderForCustomer(request.OrderID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":                order.ID,
+		"merchant_name":     merchant.LegalName,
+		"merchant_category": merchant.MerchantCategory,
+		"amount":            models.FormatAmount(order.Amount),
+		"description":       order.Description,
+		"status":            order.Status,
+		"expires_at":        order.ExpiresAt,
+	})
+}
+
+// ConfirmOrder godoc
+// @Summary Confirm an order
+// @Description Pay an order from the caller's wallet into the merchant's settlement wallet
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.ConfirmOrderRequest true "Order and wallet"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/orders/confirm [post]
+func (h *Handler) ConfirmOrder(c *gin.Context) {
+	var request models.ConfirmOrderRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	order, err := h.merchantService.ConfirmOrder(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Order paid successfully",
+		"order_id":       order.ID,
+		"transaction_id": order.TransactionID,
+	})
+}
+
+func merchantResponse(merchant *models.Merchant) gin.H {
+	return gin.H{
+		"id":                   merchant.ID,
+		"legal_name":           merchant.LegalName,
+		"tax_id":               merchant.TaxID,
+		"legal_address":        merchant.LegalAddress,
+		"settlement_wallet_id": merchant.SettlementWalletID,
+		"merchant_category":    merchant.MerchantCategory,
+		"api_key":              merchant.APIKey,
+		"created_at":           merchant.CreatedAt,
+	}
+}
+
+func orderResponse(order *models.MerchantOrder) gin.H {
+	response := gin.H{
+		"id":          order.ID,
+		"amount":      models.FormatAmount(order.Amount),
+		"reference":   order.Reference,
+		"description": order.Description,
+		"status":      order.Status,
+		"expires_at":  order.ExpiresAt,
+		"created_at":  order.CreatedAt,
+		"updated_at":  order.UpdatedAt,
+	}
+	if order.TransactionID != 0 {
+		response["customer_wallet_id"] = order.CustomerWalletID
+		response["transaction_id"] = order.TransactionID
+	}
+
+	return response
+}
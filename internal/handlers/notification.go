@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// SetNotificationPreference godoc
+// @Summary Set a notification channel
+// @Description Set where notifications of a channel go and turn the channel on or off. SMS takes a phone number in international format, email an address and push a device token.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.SetNotificationPreferenceRequest true "Channel preference"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/notifications/preferences/set [post]
+func (h *Handler) SetNotificationPreference(c *gin.Context) {
+	var request models.SetNotificationPreferenceRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	preference, err := h.notificationService.SetPreference(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, notificationPreferenceResponse(preference))
+}
+
+// ListNotificationPreferences godoc
+// @Summary List notification channels
+// @Description List the notification channels the caller has set up
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/notifications/preferences [post]
+func (h *Handler) ListNotificationPreferences(c *gin.Context) {
+	preferences, err := h.notificationService.ListPreferences(c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]gin.H, 0, len(preferences))
+	for i := range preferences {
+		response = append(response, notificationPreferenceResponse(&preferences[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preferences": response})
+}
+
+// ListNotifications godoc
+// @Summary List notifications
+// @Description List the latest notifications sent or queued for the caller, newest first
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/notifications [post]
+func (h *Handler) ListNotifications(c *gin.Context) {
+	notifications, err := h.notificationService.List(c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]gin.H, 0, len(notifications))
+	for _, n := range notifications {
+		response = append(response, gin.H{
+			"id":         n.ID,
+			"kind":       n.Kind,
+			"channel":    n.Channel,
+			"address":    n.Address,
+			"subject":    n.Subject,
+			"body":       n.Body,
+			"status":     n.Status,
+			"attempts":   n.Attempts,
+			"created_at": n.CreatedAt,
+			"sent_at":    n.SentAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notifications": response})
+}
+
+func notificationPreferenceResponse(preference *models.NotificationPreference) gin.H {
+	return gin.H{
+		"channel":    preference.Channel,
+		"address":    preference.Address,
+		"enabled":    preference.Enabled,
+		"updated_at": preference.UpdatedAt,
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// ListDeadOutboxEvents godoc
+// @Summary List dead domain events
+// @Description Events of the outbox that failed every attempt on some sink, newest first. published_sinks are the sinks that did publish them.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/v1/outbox/dead-events [post]
+func (h *Handler) ListDeadOutboxEvents(c *gin.Context) {
+	events, err := h.outboxRelay.ListDeadEvents()
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]gin.H, 0, len(events))
+	for _, event := range events {
+		response = append(response, gin.H{
+			"id":              event.ID,
+			"aggregate_id":    event.AggregateID,
+			"type":            event.Type,
+			"payload":         event.Payload,
+			"attempts":        event.Attempts,
+			"last_error":      event.LastError,
+			"published_sinks": event.PublishedSinks,
+			"dead_at":         event.DeadAt,
+			"created_at":      event.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": response})
+}
+
+// ReplayOutboxEvent godoc
+// @Summary Replay a dead domain event
+// @Description Hand a dead event again to the sinks that didn't publish it, with a fresh retry budget. It may arrive after later events of its wallet.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param request body models.ReplayOutboxEventRequest true "Event ID"
+// @Success 200 {object} map[string]string
+// @Router /admin/v1/outbox/dead-events/replay [post]
+func (h *Handler) ReplayOutboxEvent(c *gin.Context) {
+	var request models.ReplayOutboxEventRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.outboxRelay.ReplayDeadEvent(request.EventID); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Event queued"})
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// CreateChildAccount godoc
+// @Summary Create a child account
+// @Description Create a user and wallet for a minor linked to the caller as a parent
+// @Tags parental
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Success 200 {object} map[string]string
+// @Router /v1/parental/child/create [post]
+func (h *Handler) CreateChildAccount(c *gin.Context) {
+	childID, walletID, err := h.parentalService.CreateChildAccount(c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": "Error creating child account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"child_id":  childID,
+		"wallet_id": walletID,
+	})
+}
+
+// SetParentalControls godoc
+// @Summary Set parental controls
+// @Description Set per-transaction and monthly spending caps, approval threshold and blocked merchant categories for a child. Empty amounts disable the rule.
+// @Tags parental
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.ParentalControlsModel true "Parental controls"
+// @Success 200 {object} map[string]string
+// @Router /v1/parental/controls/set [post]
+func (h *Handler) SetParentalControls(c *gin.Context) {
+	var request models.ParentalControlsModel
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.parentalService.SetControls(c.GetHeader("X-UserId"), request); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Parental controls saved successfully"})
+}
+
+// GetParentalControls godoc
+// @Summary Get parental controls
+// @Description Get the spending rules set for a child
+// @Tags parental
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.ChildRequest true "Child ID"
+// @Success 200 {object} models.ParentalControlsModel
+// @Router /v1/parental/controls [post]
+func (h *Handler) GetParentalControls(c *gin.Context) {
+	var request models.ChildRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	controls, err := h.parentalService.GetControls(c.GetHeader("X-UserId"), request.ChildID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, controls)
+}
+
+// ListApprovals godoc
+// @Summary List pending approvals
+// @Description List transfers of the caller's children waiting for approval
+// @Tags parental
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/parental/approvals [post]
+func (h *Handler) ListApprovals(c *gin.Context) {
+	approvals, err := h.parentalService.ListApprovals(c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": "Error listing approvals"})
+		return
+	}
+
+	items := make([]gin.H, 0, len(approvals))
+	for _, approval := range approvals {
+		items = append(items, gin.H{
+			"id":                approval.ID,
+			"child_id":          approval.ChildID,
+			"wallet_id":         approval.WalletID,
+			"to_wallet_id":      approval.ToWalletID,
+			"amount":            models.FormatAmount(approval.Amount),
+			"merchant_category": approval.MerchantCategory,
+			"reference":         approval.Reference,
+			"status":            approval.Status,
+			"created_at":        approval.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"approvals": items})
+}
+
+// ApproveTransfer godoc
+// @Summary Approve a transfer
+// @Description Approve a child's pending transfer and execute it
+// @Tags parental
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.ApprovalDecisionRequest true "Approval ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/parental/approvals/approve [post]
+func (h *Handler) ApproveTransfer(c *gin.Context) {
+	var request models.ApprovalDecisionRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	transactionID, err := h.parentalService.Approve(c.GetHeader("X-UserId"), request.ApprovalID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Transfer approved successfully",
+		"transaction_id": transactionID,
+	})
+}
+
+// DeclineTransfer godoc
+// @Summary Decline a transfer
+// @Description Decline a child's pending transfer
+// @Tags parental
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.ApprovalDecisionRequest true "Approval ID"
+// @Success 200 {object} map[string]string
+// @Router /v1/parental/approvals/decline [post]
+func (h *Handler) DeclineTransfer(c *gin.Context) {
+	var request models.ApprovalDecisionRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.parentalService.Decline(c.GetHeader("X-UserId"), request.ApprovalID); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer declined"})
+}
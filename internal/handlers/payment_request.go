@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// CreatePaymentRequest godoc
+// @Summary Request money
+// @Description Ask another user to pay the given amount into the caller's wallet
+// @Tags payment-requests
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.CreatePaymentRequest true "Payment request"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/requests/create [post]
+func (h *Handler) CreatePaymentRequest(c *gin.Context) {
+	var request models.CreatePaymentRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	paymentRequest, err := h.paymentRequestService.Create(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, paymentRequestResponse(paymentRequest))
+}
+
+// ListPaymentRequests godoc
+// @Summary List payment requests
+// @Description List requests addressed to the caller (incoming) or made by the caller (outgoing)
+// @Tags payment-requests
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.ListPaymentRequests true "Filter"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/requests [post]
+func (h *Handler) ListPaymentRequests(c *gin.Context) {
+	var request models.ListPaymentRequests
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	paymentRequests, err := h.paymentRequestService.List(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": "Error listing payment requests"})
+		return
+	}
+
+	items := make([]gin.H, 0, len(paymentRequests))
+	for i := range paymentRequests {
+		items = append(items, paymentRequestResponse(&paymentRequests[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"requests": items})
+}
+
+// AcceptPaymentRequest godoc
+// @Summary Accept a payment request
+// @Description Pay a request addressed to the caller from the given wallet
+// @Tags payment-requests
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.AcceptPaymentRequest true "Request and payer wallet"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/requests/accept [post]
+func (h *Handler) AcceptPaymentRequest(c *gin.Context) {
+	var request models.AcceptPaymentRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	paymentRequest, err := h.paymentRequestService.Accept(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, paymentRequestResponse(paymentRequest))
+}
+
+// DeclinePaymentRequest godoc
+// @Summary Decline a payment request
+// @Description Decline a request addressed to the caller
+// @Tags payment-requests
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.PaymentRequestAction true "Request ID"
+// @Success 200 {object} map[string]string
+// @Router /v1/requests/decline [post]
+func (h *Handler) DeclinePaymentRequest(c *gin.Context) {
+	var request models.PaymentRequestAction
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.paymentRequestService.Decline(request.RequestID, c.GetHeader("X-UserId")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Payment request declined"})
+}
+
+// CancelPaymentRequest godoc
+// @Summary Cancel a payment request
+// @Description Cancel a pending request made by the caller
+// @Tags payment-requests
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.PaymentRequestAction true "Request ID"
+// @Success 200 {object} map[string]string
+// @Router /v1/requests/cancel [post]
+func (h *Handler) CancelPaymentRequest(c *gin.Context) {
+	var request models.PaymentRequestAction
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.paymentRequestService.Cancel(request.RequestID, c.GetHeader("X-UserId")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Payment request cancelled"})
+}
+
+func paymentRequestResponse(paymentRequest *models.PaymentRequest) gin.H {
+	response := gin.H{
+		"id":                  paymentRequest.ID,
+		"requester_id":        paymentRequest.RequesterID,
+		"requester_wallet_id": paymentRequest.RequesterWalletID,
+		"payer_id":            paymentRequest.PayerID,
+		"amount":              models.FormatAmount(paymentRequest.Amount),
+		"message":             paymentRequest.Message,
+		"status":              paymentRequest.Status,
+		"expires_at":          paymentRequest.ExpiresAt,
+		"created_at":          paymentRequest.CreatedAt,
+	}
+	if paymentRequest.TransactionID != 0 {
+		response["payer_wallet_id"] = paymentRequest.PayerWalletID
+		response["transaction_id"] = paymentRequest.TransactionID
+	}
+
+	return response
+}
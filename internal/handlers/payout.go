@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/payout"
+	"github.com/rasul07/alif-task/internal/service"
+)
+
+// UploadPayouts godoc
+// @Summary Upload a payout file
+// @Description Pay out from one of the caller's wallets to many wallets at once. The file is CSV with a wallet_id,amount[,description] header or a JSON array of {"wallet_id","amount","description"} objects. Every row is validated before anything is queued, invalid rows are returned with 422. Rows are paid in the background.
+// @Tags payouts
+// @Accept multipart/form-data
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest of the whole multipart body"
+// @Param wallet_id formData string true "Source wallet ID"
+// @Param format formData string false "csv or json, taken from the file extension by default"
+// @Param file formData file true "Payout file"
+// @Success 202 {object} map[string]interface{}
+// @Router /v1/payouts/upload [post]
+func (h *Handler) UploadPayouts(c *gin.Context) {
+	walletID := c.PostForm("wallet_id")
+	fileHeader, err := c.FormFile("file")
+	if err != nil || walletID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if fileHeader.Size > models.PayoutMaxFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Payout file is too large"})
+		return
+	}
+
+	format := strings.ToLower(c.PostForm("format"))
+	if format == "" {
+		format = strings.ToLower(strings.TrimPrefix(filepath.Ext(fileHeader.Filename), "."))
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Can't read the payout file"})
+		return
+	}
+	defer file.Close()
+
+	batch, err := h.payoutService.Upload(walletID, fileHeader.Filename, format, file, c.GetHeader("X-UserId"))
+	var validationErr *service.PayoutValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "rows": validationErr.Rows})
+		return
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, payoutBatchResponse(batch))
+}
+
+// GetPayouts godoc
+// @Summary Get a payout batch
+// @Description Get the status and progress of a payout batch
+// @Tags payouts
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.PayoutBatchRequest true "Batch ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/payouts [post]
+func (h *Handler) GetPayouts(c *gin.Context) {
+	var request models.PayoutBatchRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	batch, err := h.payoutService.Get(request.BatchID, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payoutBatchResponse(batch))
+}
+
+// ListPayouts godoc
+// @Summary List payout batches
+// @Description List the caller's payout batches, newest first
+// @Tags payouts
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/payouts/list [post]
+func (h *Handler) ListPayouts(c *gin.Context) {
+	batches, err := h.payoutService.List(c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": "Error listing payout batches"})
+		return
+	}
+
+	items := make([]gin.H, 0, len(batches))
+	for i := range batches {
+		items = append(items, payoutBatchResponse(&batches[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"batches": items})
+}
+
+// PausePayouts godoc
+// @Summary Pause a payout batch
+// @Description Stop paying out the remaining rows of a batch until it is resumed
+// @Tags payouts
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.PayoutBatchRequest true "Batch ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/payouts/pause [post]
+func (h *Handler) PausePayouts(c *gin.Context) {
+	var request models.PayoutBatchRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	batch, err := h.payoutService.Pause(request.BatchID, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payoutBatchResponse(batch))
+}
+
+// ResumePayouts godoc
+// @Summary Resume a payout batch
+// @Description Continue paying out a paused batch. Batches are also paused when the source wallet runs out of money.
+// @Tags payouts
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.PayoutBatchRequest true "Batch ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/payouts/resume [post]
+func (h *Handler) ResumePayouts(c *gin.Context) {
+	var request models.PayoutBatchRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	batch, err := h.payoutService.Resume(request.BatchID, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payoutBatchResponse(batch))
+}
+
+// PayoutReport godoc
+// @Summary Download a payout report
+// @Description Download the result of every row of a batch as CSV
+// @Tags payouts
+// @Accept json
+// @Produce text/csv
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.PayoutBatchRequest true "Batch ID"
+// @Success 200 {file} file
+// @Router /v1/payouts/report [post]
+func (h *Handler) PayoutReport(c *gin.Context) {
+	var request models.PayoutBatchRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	batch, rows, err := h.payoutService.Report(request.BatchID, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var report bytes.Buffer
+	if err := payout.WriteReport(&report, rows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error writing payout report"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="payout-`+batch.ID+`.csv"`)
+	c.Data(http.StatusOK, "text/csv", report.Bytes())
+}
+
+func payoutBatchResponse(batch *models.PayoutBatch) gin.H {
+	return gin.H{
+		"id":               batch.ID,
+		"wallet_id":        batch.WalletID,
+		"file_name":        batch.FileName,
+		"format":           batch.Format,
+		"status":           batch.Status,
+		"total_rows":       batch.TotalRows,
+		"total_amount":     models.FormatAmount(batch.TotalAmount),
+		"pending_rows":     batch.PendingRows,
+		"succeeded_rows":   batch.SucceededRows,
+		"failed_rows":      batch.FailedRows,
+		"succeeded_amount": models.FormatAmount(batch.SucceededAmount),
+		"created_at":       batch.CreatedAt,
+		"completed_at":     batch.CompletedAt,
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// GenerateQR godoc
+// @Summary Generate a QR payload
+// @Description Generate an EMVCo-style payload for the caller's wallet. With an amount the payload is dynamic and single-use, without one it is static.
+// @Tags qr
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.QRGenerateRequest true "QR parameters"
+// @Success 200 {object} map[string]string
+// @Router /v1/qr/generate [post]
+func (h *Handler) GenerateQR(c *gin.Context) {
+	var request models.QRGenerateRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	payload, err := h.qrService.Generate(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"payload": payload})
+}
+
+// RenderQR godoc
+// @Summary Render a QR payload
+// @Description Render a payload issued by this server as a PNG image
+// @Tags qr
+// @Accept json
+// @Produce png
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.QRImageRequest true "Payload and image size"
+// @Success 200 {file} binary
+// @Router /v1/qr/image [post]
+func (h *Handler) RenderQR(c *gin.Context) {
+	var request models.QRImageRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	image, err := h.qrService.Render(request.Payload, request.Size)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "image/png", image)
+}
+
+// PayQR godoc
+// @Summary Pay by QR payload
+// @Description Decode a scanned payload, verify its checksum and signature and pay the merchant from the caller's wallet
+// @Tags qr
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.QRPayRequest true "Payload and payer wallet"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/qr/pay [post]
+func (h *Handler) PayQR(c *gin.Context) {
+	var request models.QRPayRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	transactionID, err := h.qrService.Pay(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Payment completed successfully",
+		"transaction_id": transactionID,
+	})
+}
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gorilla/websocket"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/service"
+)
+
+// upgrader rejects cross-origin handshakes, browsers can't sign them anyway
+var upgrader = websocket.Upgrader{}
+
+// Realtime godoc
+// @Summary Real-time WebSocket API
+// @Description Open a WebSocket for live wallet events and commands. Every message is a JSON object with id, type, user_id, timestamp, signature and payload. user_id must be the caller of the handshake and each message is signed like a /v2 request: its type as the method, /v2/ws as the path, an empty query, its id as the nonce and the raw payload as the body. Like X-Nonce, an id is accepted once. Commands are subscribe and unsubscribe with {"topics": [...]}, balance with {"wallet_id"} and transfer with a transfer request. Answers are "result" or "error" messages with the id of the command, events arrive as "event" messages. Only served under /v2, the handshake is signed like any other request.
+// @Tags v2
+// @Param X-UserId header string true "User ID"
+// @Param X-Timestamp header string true "Unix time of the request"
+// @Param X-Nonce header string true "Unique id of the request, accepted once"
+// @Param X-Signature header string true "Signature"
+// @Success 101 {string} string "Switching Protocols"
+// @Router /v2/ws [get]
+func (h *Handler) Realtime(c *gin.Context) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade answered the request already
+		return
+	}
+
+	session := &realtimeSession{
+		handler: h,
+		conn:    conn,
+		userID:  c.GetHeader("X-UserId"),
+		path:    c.Request.URL.EscapedPath(),
+		send:    make(chan []byte, models.RealtimeSendQueueSize),
+		done:    make(chan struct{}),
+		topics:  make(map[string]bool),
+	}
+	session.run()
+}
+
+// realtimeSession serves one WebSocket connection. Commands are read and
+// answered in order by run, a single writer goroutine sends the queued messages.
+type realtimeSession struct {
+	handler *Handler
+	conn    *websocket.Conn
+	userID  string
+	// path is what messages are signed for
+	path string
+
+	// send queues messages for the writer, a client that lets it fill up is
+	// disconnected
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu           sync.Mutex
+	topics       map[string]bool
+	subscription *service.Subscription
+}
+
+func (s *realtimeSession) run() {
+	defer s.stop()
+
+	s.conn.SetReadLimit(models.RealtimeMaxMessageSize)
+	s.conn.SetReadDeadline(time.Now().Add(models.RealtimePongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(models.RealtimePongWait))
+	})
+
+	go s.write()
+
+	for {
+		op, data, err := s.conn.ReadMessage()
+		if err != nil {
+			// Client close frames and protocol errors are answered by the connection,
+			// anything else means it's gone
+			return
+		}
+		if op != websocket.TextMessage {
+			s.close(websocket.CloseUnsupportedData, "only text messages are supported")
+			continue
+		}
+
+		response, ok := s.handle(data)
+		if !ok {
+			continue
+		}
+		// Commands wait for room in the queue, so a client that doesn't read its
+		// answers stops being read
+		select {
+		case s.send <- response:
+		case <-s.done:
+		}
+	}
+}
+
+// write sends queued messages and pings until the session is closed. It is the
+// only writer of the connection, besides the control frames of close.
+func (s *realtimeSession) write() {
+	ticker := time.NewTicker(models.RealtimePingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case message := <-s.send:
+			s.conn.SetWriteDeadline(time.Now().Add(models.RealtimeWriteTimeout))
+			if err := s.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				s.conn.Close()
+				return
+			}
+		case <-ticker.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(models.RealtimeWriteTimeout)); err != nil {
+				s.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// close starts the closing handshake. Reading goes on until the client answers
+// or the grace period runs out.
+func (s *realtimeSession) close(code int, reason string) {
+	s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(models.RealtimeWriteTimeout))
+	s.conn.SetReadDeadline(time.Now().Add(models.RealtimeCloseGrace))
+}
+
+func (s *realtimeSession) stop() {
+	s.closeOnce.Do(func() { close(s.done) })
+
+	s.mu.Lock()
+	if s.subscription != nil {
+		s.handler.streamService.Unsubscribe(s.subscription)
+		s.subscription = nil
+	}
+	s.mu.Unlock()
+
+	s.conn.Close()
+}
+
+// handle runs one command and returns its answer, ok is false when there's
+// nothing to send
+func (s *realtimeSession) handle(data []byte) ([]byte, bool) {
+	var message models.RealtimeMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		return realtimeError("", http.StatusBadRequest, "Invalid message"), true
+	}
+
+	if status, err := s.verify(message); err != nil {
+		return realtimeError(message.ID, status, err.Error()), true
+	}
+
+	var (
+		result gin.H
+		status int
+		err    error
+	)
+	switch message.Type {
+	case models.RealtimeSubscribe:
+		result, status, err = s.subscribe(message.Payload)
+	case models.RealtimeUnsubscribe:
+		result, status, err = s.unsubscribe(message.Payload)
+	case models.RealtimeBalance:
+		result, status, err = s.balance(message.Payload)
+	case models.RealtimeTransfer:
+		result, status, err = s.transfer(message.Payload)
+	default:
+		return realtimeError(message.ID, http.StatusBadRequest, "Unknown message type"), true
+	}
+	if err != nil {
+		return realtimeError(message.ID, status, err.Error()), true
+	}
+
+	return realtimeMessage(gin.H{"id": message.ID, "type": models.RealtimeResult, "payload": result}), true
+}
+
+// verify does for a message what SignatureMiddleware does for a request. The
+// id of the message is its nonce, so the signature only holds for the command
+// it was made for and the command is run once.
+func (s *realtimeSession) verify(message models.RealtimeMessage) (int, error) {
+	if message.UserID != s.userID || !validNonce(message.ID) || message.Signature == "" {
+		return http.StatusUnauthorized, errUnauthorized
+	}
+	if err := checkTimestamp(message.Timestamp); err != nil {
+		return http.StatusUnauthorized, err
+	}
+
+	canonical := canonicalRequest(message.Type, s.path, "", message.UserID, message.Timestamp, message.ID, message.Payload)
+	if !hmac.Equal([]byte(message.Signature), []byte(computeSignature(canonical))) {
+		return http.StatusUnauthorized, errUnauthorized
+	}
+
+	if err := useNonce(s.handler.nonceService, message.UserID, message.ID); err != nil {
+		return errorStatus(err), err
+	}
+	return 0, nil
+}
+
+var errInvalidPayload = errors.New("Invalid request payload")
+
+func (s *realtimeSession) subscribe(payload json.RawMessage) (gin.H, int, error) {
+	var request models.SubscribeRequest
+	if err := bindRealtimePayload(payload, &request); err != nil {
+		return nil, http.StatusBadRequest, errInvalidPayload
+	}
+	topics := request.Topics
+	if len(topics) == 0 {
+		topics = models.WalletEventTypes
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, topic := range topics {
+		s.topics[topic] = true
+	}
+	if s.subscription == nil {
+		s.subscription = s.handler.streamService.Subscribe(s.userID)
+		go s.forward(s.subscription)
+	}
+
+	return gin.H{"topics": s.topicList()}, http.StatusOK, nil
+}
+
+func (s *realtimeSession) unsubscribe(payload json.RawMessage) (gin.H, int, error) {
+	var request models.SubscribeRequest
+	if err := bindRealtimePayload(payload, &request); err != nil {
+		return nil, http.StatusBadRequest, errInvalidPayload
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(request.Topics) == 0 {
+		s.topics = make(map[string]bool)
+	}
+	for _, topic := range request.Topics {
+		delete(s.topics, topic)
+	}
+	if len(s.topics) == 0 && s.subscription != nil {
+		s.handler.streamService.Unsubscribe(s.subscription)
+		s.subscription = nil
+	}
+
+	return gin.H{"topics": s.topicList()}, http.StatusOK, nil
+}
+
+// topicList returns the subscribed topics in order, the caller holds the lock
+func (s *realtimeSession) topicList() []string {
+	topics := make([]string, 0, len(s.topics))
+	for topic := range s.topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// forward queues the events of the subscribed topics. Events never wait for the
+// client: when the queue is full, or the stream dropped the subscription, the
+// client is disconnected and catches up through the history after reconnecting.
+func (s *realtimeSession) forward(subscription *service.Subscription) {
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-subscription.Done:
+			s.mu.Lock()
+			dropped := s.subscription == subscription
+			s.mu.Unlock()
+			if dropped {
+				s.close(websocket.CloseTryAgainLater, "too many pending events")
+			}
+			return
+		case event := <-subscription.Events:
+			s.mu.Lock()
+			subscribed := s.topics[event.Type]
+			s.mu.Unlock()
+			if !subscribed {
+				continue
+			}
+
+			select {
+			case s.send <- realtimeMessage(gin.H{"type": models.RealtimeEvent, "topic": event.Type, "payload": streamEventResponse(event)}):
+			default:
+				s.close(websocket.CloseTryAgainLater, "too many pending events")
+				return
+			}
+		}
+	}
+}
+
+func (s *realtimeSession) balance(payload json.RawMessage) (gin.H, int, error) {
+	var request models.RequestModel
+	if err := bindRealtimePayload(payload, &request); err != nil {
+		return nil, http.StatusBadRequest, errInvalidPayload
+	}
+
+	balance, err := s.handler.walletService.GetBalance(request.WalletID, s.userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Error getting balance")
+	}
+
+	return gin.H{"wallet_id": request.WalletID, "balance": balance}, http.StatusOK, nil
+}
+
+func (s *realtimeSession) transfer(payload json.RawMessage) (gin.H, int, error) {
+	var request models.TransferRequest
+	if err := bindRealtimePayload(payload, &request); err != nil {
+		return nil, http.StatusBadRequest, errInvalidPayload
+	}
+
+	transactionID, err := s.handler.walletService.Transfer(request, s.userID)
+	if errors.Is(err, service.ErrApprovalRequired) {
+		return gin.H{"status": http.StatusAccepted, "message": err.Error()}, http.StatusAccepted, nil
+	}
+	if err != nil {
+		return nil, errorStatus(err), err
+	}
+
+	return gin.H{
+		"message":        "Transfer completed successfully",
+		"transaction_id": transactionID,
+		"receipt":        s.handler.receipt(transactionID, s.userID),
+	}, http.StatusOK, nil
+}
+
+// bindRealtimePayload decodes and validates a command payload like a request body
+func bindRealtimePayload(payload json.RawMessage, obj any) error {
+	if len(payload) == 0 {
+		payload = json.RawMessage("{}")
+	}
+	return binding.JSON.BindBody(payload, obj)
+}
+
+func realtimeError(id string, status int, message string) []byte {
+	return realtimeMessage(gin.H{"id": id, "type": models.RealtimeError, "status": status, "error": message})
+}
+
+func realtimeMessage(message gin.H) []byte {
+	data, _ := json.Marshal(message)
+	return data
+}
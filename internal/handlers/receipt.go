@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/receipt"
+)
+
+// GetReceipt godoc
+// @Summary Get a transaction receipt
+// @Description Get the signed receipt of a transaction of the caller's wallet
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.ReceiptRequest true "Transaction ID"
+// @Success 200 {object} models.Receipt
+// @Router /v1/wallet/receipt [post]
+func (h *Handler) GetReceipt(c *gin.Context) {
+	var request models.ReceiptRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	r, err := h.receiptService.Get(request.TransactionID, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
+
+// VerifyReceipt godoc
+// @Summary Verify a receipt
+// @Description Check whether a receipt was issued by this server and wasn't changed. Anyone holding a receipt can call it.
+// @Tags receipts
+// @Accept json
+// @Produce json
+// @Param request body models.Receipt true "Receipt as received"
+// @Success 200 {object} map[string]bool
+// @Router /receipts/verify [post]
+func (h *Handler) VerifyReceipt(c *gin.Context) {
+	var r models.Receipt
+
+	if err := c.ShouldBindJSON(&r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	err := h.receiptService.Verify(r)
+	if errors.Is(err, receipt.ErrInvalidSignature) {
+		c.JSON(http.StatusOK, gin.H{"valid": false})
+		return
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": true})
+}
+
+// receipt returns the receipt of an operation that just completed. The operation
+// has succeeded even if the receipt can't be built, the client can fetch it later.
+func (h *Handler) receipt(transactionID int64, userID string) *models.Receipt {
+	r, err := h.receiptService.Get(transactionID, userID)
+	if err != nil {
+		return nil
+	}
+	return r
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// RunReconciliation godoc
+// @Summary Reconcile balances now
+// @Description Compare every wallet balance with the sum of its transactions and optionally open correction cases for mismatches
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param request body models.RunReconciliationRequest true "Options"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/v1/reconciliation/run [post]
+func (h *Handler) RunReconciliation(c *gin.Context) {
+	var request models.RunReconciliationRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	run, discrepancies, err := h.reconciliationService.Reconcile(request.OpenCases)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := reconciliationRunResponse(run)
+	response["discrepancies"] = discrepanciesResponse(discrepancies)
+	c.JSON(http.StatusOK, response)
+}
+
+// ListReconciliationRuns godoc
+// @Summary List reconciliation runs
+// @Description Latest reconciliation runs with their totals, newest first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/v1/reconciliation/runs [post]
+func (h *Handler) ListReconciliationRuns(c *gin.Context) {
+	runs, err := h.reconciliationService.ListRuns()
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]gin.H, 0, len(runs))
+	for i := range runs {
+		items = append(items, reconciliationRunResponse(&runs[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": items})
+}
+
+// GetReconciliationRun godoc
+// @Summary Get a reconciliation run
+// @Description Get a reconciliation run with every discrepancy it found
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param request body models.ReconciliationRunRequest true "Run ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/v1/reconciliation/runs/get [post]
+func (h *Handler) GetReconciliationRun(c *gin.Context) {
+	var request models.ReconciliationRunRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	run, discrepancies, err := h.reconciliationService.GetRun(request.RunID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := reconciliationRunResponse(run)
+	response["discrepancies"] = discrepanciesResponse(discrepancies)
+	c.JSON(http.StatusOK, response)
+}
+
+// ListCorrectionCases godoc
+// @Summary List correction cases
+// @Description List correction cases opened by reconciliation, optionally filtered by status
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param request body models.ListCorrectionCasesRequest true "Filter"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/v1/reconciliation/cases [post]
+func (h *Handler) ListCorrectionCases(c *gin.Context) {
+	var request models.ListCorrectionCasesRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	cases, err := h.reconciliationService.ListCases(request.Status)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]gin.H, 0, len(cases))
+	for _, correctionCase := range cases {
+		items = append(items, gin.H{
+			"id":             correctionCase.ID,
+			"wallet_id":      correctionCase.WalletID,
+			"run_id":         correctionCase.RunID,
+			"stored_balance": models.FormatAmount(correctionCase.StoredBalance),
+			"ledger_balance": models.FormatAmount(correctionCase.LedgerBalance),
+			"difference":     models.FormatAmount(correctionCase.Difference),
+			"status":         correctionCase.Status,
+			"resolution":     correctionCase.Resolution,
+			"created_at":     correctionCase.CreatedAt,
+			"resolved_at":    correctionCase.ResolvedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cases": items})
+}
+
+// ResolveCorrectionCase godoc
+// @Summary Resolve a correction case
+// @Description Close an open correction case with a note on how it was fixed
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param request body models.ResolveCaseRequest true "Case and resolution"
+// @Success 200 {object} map[string]string
+// @Router /admin/v1/reconciliation/cases/resolve [post]
+func (h *Handler) ResolveCorrectionCase(c *gin.Context) {
+	var request models.ResolveCaseRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.reconciliationService.ResolveCase(request.CaseID, request.Resolution); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Case resolved"})
+}
+
+func reconciliationRunResponse(run *models.ReconciliationRun) gin.H {
+	return gin.H{
+		"id":               run.ID,
+		"status":           run.Status,
+		"wallets_checked":  run.WalletsChecked,
+		"discrepancies":    run.Discrepancies,
+		"total_difference": models.FormatAmount(run.TotalDifference),
+		"cases_opened":     run.CasesOpened,
+		"error":            run.Error,
+		"started_at":       run.StartedAt,
+		"finished_at":      run.FinishedAt,
+	}
+}
+
+func discrepanciesResponse(discrepancies []models.BalanceDiscrepancy) []gin.H {
+	items := make([]gin.H, 0, len(discrepancies))
+	for _, d := range discrepancies {
+		items = append(items, gin.H{
+			"wallet_id":           d.WalletID,
+			"user_id":             d.UserID,
+			"stored_balance":      models.FormatAmount(d.StoredBalance),
+			"ledger_balance":      models.FormatAmount(d.LedgerBalance),
+			"difference":          models.FormatAmount(d.Difference),
+			"transaction_count":   d.TransactionCount,
+			"last_transaction_at": d.LastTransactionAt,
+			"case_id":             d.CaseID,
+		})
+	}
+	return items
+}
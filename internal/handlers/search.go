@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// SearchTransactions godoc
+// @Summary Search transactions
+// @Description Find transactions across all wallets by partial reference, amount, description or counterparty name. Results are ranked by relevance and paginated with page and limit.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param request body models.TransactionSearchRequest true "Search"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/v1/transactions/search [post]
+func (h *Handler) SearchTransactions(c *gin.Context) {
+	var request models.TransactionSearchRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	results, total, err := h.searchService.SearchTransactions(request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]gin.H, 0, len(results))
+	for i := range results {
+		item := transactionResponse(&results[i].Transaction)
+		item["counterparty_name"] = results[i].CounterpartyName
+		item["rank"] = results[i].Rank
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": items, "total": total})
+}
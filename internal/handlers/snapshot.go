@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// GetBalanceAt godoc
+// @Summary Get wallet balance at a point in time
+// @Description Get the balance of the caller's wallet at a past moment, replayed from the nearest end-of-day snapshot
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.BalanceAtRequest true "Wallet ID and time"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/wallet/balance/at [post]
+func (h *Handler) GetBalanceAt(c *gin.Context) {
+	var request models.BalanceAtRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	balance, err := h.snapshotService.BalanceAt(request.WalletID, c.GetHeader("X-UserId"), request.At)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, historicalBalanceResponse(balance))
+}
+
+// GetWalletBalanceAt godoc
+// @Summary Get any wallet balance at a point in time
+// @Description Get the balance of a wallet at a past moment for finance and regulatory reports
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param request body models.BalanceAtRequest true "Wallet ID and time"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/v1/balances/at [post]
+func (h *Handler) GetWalletBalanceAt(c *gin.Context) {
+	var request models.BalanceAtRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	balance, err := h.snapshotService.WalletBalanceAt(request.WalletID, request.At)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, historicalBalanceResponse(balance))
+}
+
+// TakeBalanceSnapshots godoc
+// @Summary Take balance snapshots now
+// @Description Save end-of-day balances for every finished day that doesn't have them yet
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} map[string]int
+// @Router /admin/v1/balances/snapshots/run [post]
+func (h *Handler) TakeBalanceSnapshots(c *gin.Context) {
+	created, err := h.snapshotService.TakeSnapshots()
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"snapshots_created": created})
+}
+
+func historicalBalanceResponse(balance *models.HistoricalBalance) gin.H {
+	response := gin.H{
+		"wallet_id":             balance.WalletID,
+		"at":                    balance.At,
+		"balance":               models.FormatAmount(balance.Balance),
+		"snapshot_date":         nil,
+		"transactions_replayed": balance.TransactionsReplayed,
+	}
+	if balance.SnapshotDate != nil {
+		response["snapshot_date"] = balance.SnapshotDate.Format(time.DateOnly)
+	}
+	return response
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// CreateSplit godoc
+// @Summary Split a bill
+// @Description Share a bill the caller paid with other users, either equally or with custom amounts. Shares are paid into the given wallet of the caller.
+// @Tags splits
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.CreateSplitRequest true "Split"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/splits/create [post]
+func (h *Handler) CreateSplit(c *gin.Context) {
+	var request models.CreateSplitRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	split, err := h.splitService.Create(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, splitResponse(split))
+}
+
+// GetSplit godoc
+// @Summary Get a split
+// @Description Get a split with the status of every share and the outstanding amount. Available to the originator and participants.
+// @Tags splits
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.SplitRequest true "Split ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/splits [post]
+func (h *Handler) GetSplit(c *gin.Context) {
+	var request models.SplitRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	split, err := h.splitService.Get(request.SplitID, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, splitResponse(split))
+}
+
+// ListSplits godoc
+// @Summary List splits
+// @Description List splits the caller owes a share of (incoming) or created (outgoing)
+// @Tags splits
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.ListSplitsRequest true "Filter"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/splits/list [post]
+func (h *Handler) ListSplits(c *gin.Context) {
+	var request models.ListSplitsRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	splits, err := h.splitService.List(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": "Error listing splits"})
+		return
+	}
+
+	items := make([]gin.H, 0, len(splits))
+	for i := range splits {
+		items = append(items, splitResponse(&splits[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"splits": items})
+}
+
+// PaySplit godoc
+// @Summary Pay a split share
+// @Description Pay the caller's share of a split from the given wallet
+// @Tags splits
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.PaySplitRequest true "Split and payer wallet"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/splits/pay [post]
+func (h *Handler) PaySplit(c *gin.Context) {
+	var request models.PaySplitRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	participant, err := h.splitService.Pay(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, splitParticipantResponse(participant))
+}
+
+// DeclineSplit godoc
+// @Summary Decline a split share
+// @Description Refuse to pay the caller's share of a split
+// @Tags splits
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.SplitRequest true "Split ID"
+// @Success 200 {object} map[string]string
+// @Router /v1/splits/decline [post]
+func (h *Handler) DeclineSplit(c *gin.Context) {
+	var request models.SplitRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.splitService.Decline(request.SplitID, c.GetHeader("X-UserId")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share declined"})
+}
+
+// RemindSplit godoc
+// @Summary Remind split participants
+// @Description Remind participants who haven't paid their share. Each participant is reminded at most once an hour.
+// @Tags splits
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.RemindSplitRequest true "Split and optional participant"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/splits/remind [post]
+func (h *Handler) RemindSplit(c *gin.Context) {
+	var request models.RemindSplitRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	reminded, err := h.splitService.Remind(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reminded": reminded})
+}
+
+// CancelSplit godoc
+// @Summary Cancel a split
+// @Description Cancel an open split created by the caller. Shares already paid are kept.
+// @Tags splits
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.SplitRequest true "Split ID"
+// @Success 200 {object} map[string]string
+// @Router /v1/splits/cancel [post]
+func (h *Handler) CancelSplit(c *gin.Context) {
+	var request models.SplitRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.splitService.Cancel(request.SplitID, c.GetHeader("X-UserId")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Split cancelled"})
+}
+
+func splitResponse(split *models.SplitBill) gin.H {
+	participants := make([]gin.H, 0, len(split.Participants))
+	for i := range split.Participants {
+		participants = append(participants, splitParticipantResponse(&split.Participants[i]))
+	}
+
+	return gin.H{
+		"id":            split.ID,
+		"originator_id": split.OriginatorID,
+		"wallet_id":     split.WalletID,
+		"total_amount":  models.FormatAmount(split.TotalAmount),
+		"outstanding":   models.FormatAmount(split.Outstanding()),
+		"description":   split.Description,
+		"status":        split.Status,
+		"participants":  participants,
+		"created_at":    split.CreatedAt,
+	}
+}
+
+func splitParticipantResponse(participant *models.SplitParticipant) gin.H {
+	response := gin.H{
+		"user_id":          participant.UserID,
+		"amount":           models.FormatAmount(participant.Amount),
+		"status":           participant.Status,
+		"reminder_count":   participant.ReminderCount,
+		"last_reminded_at": participant.LastRemindedAt,
+	}
+	if participant.Status == models.SplitParticipantPaid {
+		response["wallet_id"] = participant.WalletID
+		response["transaction_id"] = participant.TransactionID
+		response["paid_at"] = participant.PaidAt
+	}
+
+	return response
+}
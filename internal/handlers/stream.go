@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// StreamWalletEvents godoc
+// @Summary Stream wallet activity
+// @Description Stream balance changes of the caller's wallets as server-sent events. Every event has the id of its outbox domain event as its id, reconnecting with Last-Event-ID (or the last_event_id query parameter) replays what was missed. A heartbeat comment is sent every 15 seconds. Only served under /v2, the signature binds the stream to its user and path.
+// @Tags v2
+// @Produce text/event-stream
+// @Param X-UserId header string true "User ID"
+// @Param X-Timestamp header string true "Unix time of the request"
+// @Param X-Nonce header string true "Unique id of the request, accepted once"
+// @Param X-Signature header string true "Signature"
+// @Param Last-Event-ID header string false "Id of the last event received"
+// @Param last_event_id query string false "Id of the last event received"
+// @Success 200 {string} string "Event stream"
+// @Router /v2/events [get]
+func (h *Handler) StreamWalletEvents(c *gin.Context) {
+	userID := c.GetHeader("X-UserId")
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var lastID int64
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+			return
+		}
+		lastID = id
+	}
+
+	// Subscribe before replaying so nothing committed in between is lost, live
+	// events already replayed are skipped by id
+	subscription := h.streamService.Subscribe(userID)
+	defer h.streamService.Unsubscribe(subscription)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", models.StreamRetry)
+	c.Writer.Flush()
+
+	for {
+		events, err := h.streamService.Replay(userID, lastID, models.StreamReplayLimit)
+		if err != nil {
+			// The client reconnects and replays from the last event it got
+			return
+		}
+		for _, event := range events {
+			if err := writeStreamEvent(c.Writer, event); err != nil {
+				return
+			}
+			lastID = event.ID
+		}
+		c.Writer.Flush()
+		if len(events) < models.StreamReplayLimit {
+			break
+		}
+	}
+
+	heartbeat := time.NewTicker(models.StreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-subscription.Done:
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case event := <-subscription.Events:
+			if event.ID <= lastID {
+				continue
+			}
+			if err := writeStreamEvent(c.Writer, event); err != nil {
+				return
+			}
+			lastID = event.ID
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeStreamEvent writes one wallet event in the server-sent events format
+func writeStreamEvent(w io.Writer, event models.WalletEvent) error {
+	data, err := json.Marshal(streamEventResponse(event))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+func streamEventResponse(event models.WalletEvent) gin.H {
+	response := gin.H{
+		"type":           event.Type,
+		"wallet_id":      event.WalletID,
+		"transaction_id": event.TransactionID,
+		"amount":         models.FormatAmount(event.Amount),
+		"balance":        models.FormatAmount(event.Balance),
+		"created_at":     event.CreatedAt,
+	}
+	if event.CounterpartyWalletID != "" {
+		response["counterparty_wallet_id"] = event.CounterpartyWalletID
+	}
+	if event.Reference != "" {
+		response["reference"] = event.Reference
+	}
+	if event.Category != "" {
+		response["category"] = event.Category
+	}
+	return response
+}
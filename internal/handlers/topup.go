@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// InitiateTopUp godoc
+// @Summary Top up through a provider
+// @Description Start a top-up funded by a card, bank transfer or cash terminal. The wallet is credited once the provider confirms the payment, poll the top-up for its status.
+// @Tags topups
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.InitiateTopUpRequest true "Top-up details"
+// @Success 202 {object} map[string]interface{}
+// @Router /v1/topups/create [post]
+func (h *Handler) InitiateTopUp(c *gin.Context) {
+	var request models.InitiateTopUpRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	topUp, err := h.topUpService.Initiate(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, topUpResponse(topUp))
+}
+
+// GetTopUp godoc
+// @Summary Get a top-up
+// @Description Get the status of a top-up funded through a provider
+// @Tags topups
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.TopUpRequestByID true "Top-up ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/topups [post]
+func (h *Handler) GetTopUp(c *gin.Context) {
+	var request models.TopUpRequestByID
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	topUp, err := h.topUpService.Get(request.TopUpID, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, topUpResponse(topUp))
+}
+
+// ListTopUps godoc
+// @Summary List top-ups
+// @Description List the caller's top-ups funded through providers, newest first
+// @Tags topups
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/topups/list [post]
+func (h *Handler) ListTopUps(c *gin.Context) {
+	topUps, err := h.topUpService.List(c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]gin.H, 0, len(topUps))
+	for i := range topUps {
+		response = append(response, topUpResponse(&topUps[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"topups": response})
+}
+
+// TopUpCallback godoc
+// @Summary Top-up provider callback
+// @Description Called by a top-up provider when a payment succeeds or fails. The body and its signature are checked by the provider adapter.
+// @Tags topups
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Success 200 {object} map[string]string
+// @Router /callbacks/topups/{provider} [post]
+func (h *Handler) TopUpCallback(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Can't read the request body"})
+		return
+	}
+
+	if err := h.topUpService.HandleCallback(c.Param("provider"), c.Request.Header, body); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func topUpResponse(topUp *models.ProviderTopUp) gin.H {
+	return gin.H{
+		"id":             topUp.ID,
+		"wallet_id":      topUp.WalletID,
+		"provider":       topUp.Provider,
+		"source_type":    topUp.SourceType,
+		"amount":         models.FormatAmount(topUp.Amount),
+		"status":         topUp.Status,
+		"failure_reason": topUp.FailureReason,
+		"transaction_id": topUp.TransactionID,
+		"created_at":     topUp.CreatedAt,
+		"completed_at":   topUp.CompletedAt,
+	}
+}
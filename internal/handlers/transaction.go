@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// GetTransactionHistory godoc
+// @Summary Get transaction history
+// @Description List the wallet's transactions, newest first, filtered by type, status, category, tags, description, metadata, time and amount. Continue with before_id set to the last id of the previous page.
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.TransactionFilter true "Filter"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/wallet/transactions/history [post]
+func (h *Handler) GetTransactionHistory(c *gin.Context) {
+	var filter models.TransactionFilter
+
+	if err := c.ShouldBindJSON(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	transactions, err := h.transactionService.History(filter, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]gin.H, 0, len(transactions))
+	for i := range transactions {
+		items = append(items, transactionResponse(&transactions[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transactions": items})
+}
+
+// UpdateTransaction godoc
+// @Summary Edit transaction notes
+// @Description Change the description, category or tags of a transaction. Fields left out are kept, an empty tag list clears the tags.
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.UpdateTransactionRequest true "Changes"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/wallet/transactions/update [post]
+func (h *Handler) UpdateTransaction(c *gin.Context) {
+	var request models.UpdateTransactionRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	transaction, err := h.transactionService.UpdateDetails(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, transactionResponse(transaction))
+}
+
+// ReverseTransaction godoc
+// @Summary Reverse a transaction
+// @Description Undo a completed transaction by posting offsetting transactions. Both sides of a transfer are reversed, the reason becomes the description of the reversal.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param request body models.ReverseTransactionRequest true "Transaction and reason"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/v1/transactions/reverse [post]
+func (h *Handler) ReverseTransaction(c *gin.Context) {
+	var request models.ReverseTransactionRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	transaction, err := h.transactionService.Reverse(request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, transactionResponse(transaction))
+}
+
+func transactionResponse(t *models.Transaction) gin.H {
+	transactionType := models.TransactionTypeCredit
+	amount := t.Amount
+	if amount < 0 {
+		transactionType = models.TransactionTypeDebit
+		amount = -amount
+	}
+
+	tags := t.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	metadata := t.Metadata
+	if len(metadata) == 0 {
+		metadata = json.RawMessage("{}")
+	}
+
+	return gin.H{
+		"id":                     t.ID,
+		"wallet_id":              t.WalletID,
+		"type":                   transactionType,
+		"amount":                 models.FormatAmount(amount),
+		"counterparty_wallet_id": t.CounterpartyWalletID,
+		"merchant_category":      t.MerchantCategory,
+		"reference":              t.Reference,
+		"description":            t.Description,
+		"category":               t.Category,
+		"tags":                   tags,
+		"metadata":               metadata,
+		"status":                 t.Status,
+		"failure_reason":         t.FailureReason,
+		"reversed_by":            t.ReversedBy,
+		"created_at":             t.CreatedAt,
+		"updated_at":             t.UpdatedAt,
+		"pending_at":             t.PendingAt,
+		"completed_at":           t.CompletedAt,
+		"failed_at":              t.FailedAt,
+		"reversed_at":            t.ReversedAt,
+	}
+}
@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/service"
+)
+
+// DeprecationMiddleware announces on every response that the API is deprecated
+// (RFC 9745) and, when sunset is set, when it will be removed (RFC 8594). The
+// Link header points to the documentation of the successor.
+func DeprecationMiddleware(deprecatedAt time.Time, sunset *time.Time) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		if sunset != nil {
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		c.Header("Link", `</swagger/index.html>; rel="deprecation"; type="text/html"`)
+		c.Next()
+	}
+}
+
+// walletResource rejects wallet ids that can't exist before they reach storage
+func walletResource() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := uuid.Parse(c.Param("id")); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// bindResource fills request from the JSON body, or from the query of a GET
+// request, then lets fromPath copy the ids of the resource path into it and
+// validates the result with the binding rules of the request model
+func bindResource(c *gin.Context, request any, fromPath func()) bool {
+	var err error
+	if c.Request.Method == http.MethodGet {
+		err = binding.MapFormWithTag(request, c.Request.URL.Query(), "form")
+	} else {
+		err = json.NewDecoder(c.Request.Body).Decode(request)
+	}
+	if err == nil {
+		fromPath()
+		err = binding.Validator.ValidateStruct(request)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return false
+	}
+	return true
+}
+
+// transactionID parses the transaction id of the resource path
+func transactionID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("transaction_id"), 10, 64)
+	if err != nil || id < 1 {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrTransactionNotFound.Error()})
+		return 0, false
+	}
+	return id, true
+}
+
+// transactionLocation is the path of a transaction resource
+func transactionLocation(walletID string, transactionID int64) string {
+	return fmt.Sprintf("/v2/wallets/%s/transactions/%d", walletID, transactionID)
+}
+
+// GetWalletV2 godoc
+// @Summary Get a wallet
+// @Description Get the caller's wallet with its current balance
+// @Tags v2
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Timestamp header string true "Unix time of the request"
+// @Param X-Nonce header string true "Unique id of the request, accepted once"
+// @Param X-Signature header string true "Signature"
+// @Param id path string true "Wallet ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /v2/wallets/{id} [get]
+func (h *Handler) GetWalletV2(c *gin.Context) {
+	wallet, err := h.walletService.GetWallet(c.Param("id"), c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":      wallet.ID,
+		"balance": models.FormatAmount(wallet.Balance),
+	})
+}
+
+// GetWalletBalanceAtV2 godoc
+// @Summary Get a wallet balance at a point in time
+// @Description Get the balance of the caller's wallet at a past moment, replayed from the nearest end-of-day snapshot
+// @Tags v2
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Timestamp header string true "Unix time of the request"
+// @Param X-Nonce header string true "Unique id of the request, accepted once"
+// @Param X-Signature header string true "Signature"
+// @Param id path string true "Wallet ID"
+// @Param at query string true "RFC 3339 time"
+// @Success 200 {object} map[string]interface{}
+// @Router /v2/wallets/{id}/balance [get]
+func (h *Handler) GetWalletBalanceAtV2(c *gin.Context) {
+	var request models.BalanceAtRequest
+
+	if !bindResource(c, &request, func() { request.WalletID = c.Param("id") }) {
+		return
+	}
+
+	balance, err := h.snapshotService.BalanceAt(request.WalletID, c.GetHeader("X-UserId"), request.At)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, historicalBalanceResponse(balance))
+}
+
+// ListTransactionsV2 godoc
+// @Summary List wallet transactions
+// @Description List the wallet's transactions, newest first. Filters are query parameters, tags may be repeated and metadata is a JSON object. When the page is full the Link header has the next page.
+// @Tags v2
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Timestamp header string true "Unix time of the request"
+// @Param X-Nonce header string true "Unique id of the request, accepted once"
+// @Param X-Signature header string true "Signature"
+// @Param id path string true "Wallet ID"
+// @Param type query string false "credit or debit"
+// @Param status query string false "Status"
+// @Param category query string false "Category"
+// @Param tags query []string false "Tags" collectionFormat(multi)
+// @Param description query string false "Part of the description"
+// @Param metadata query string false "JSON object the metadata must contain"
+// @Param from query string false "RFC 3339 time"
+// @Param to query string false "RFC 3339 time"
+// @Param min_amount query string false "Minimum amount"
+// @Param max_amount query string false "Maximum amount"
+// @Param before_id query int false "Id of the last transaction of the previous page"
+// @Param limit query int false "Page size"
+// @Success 200 {object} map[string]interface{}
+// @Router /v2/wallets/{id}/transactions [get]
+func (h *Handler) ListTransactionsV2(c *gin.Context) {
+	var filter models.TransactionFilter
+
+	if !bindResource(c, &filter, func() {
+		filter.WalletID = c.Param("id")
+		if metadata := c.Query("metadata"); metadata != "" {
+			filter.Metadata = json.RawMessage(metadata)
+		}
+	}) {
+		return
+	}
+
+	transactions, err := h.transactionService.History(filter, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]gin.H, 0, len(transactions))
+	for i := range transactions {
+		items = append(items, transactionResponse(&transactions[i]))
+	}
+
+	limit := filter.Limit
+	if limit == 0 {
+		limit = models.TransactionHistoryLimit
+	}
+	if len(transactions) == limit {
+		next := c.Request.URL.Query()
+		next.Set("before_id", strconv.FormatInt(transactions[len(transactions)-1].ID, 10))
+		c.Header("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, c.Request.URL.Path, next.Encode()))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transactions": items})
+}
+
+// GetTransactionSummaryV2 godoc
+// @Summary Get the transactions of the current month
+// @Description Get the number and total amount of the wallet's transactions in the current month
+// @Tags v2
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Timestamp header string true "Unix time of the request"
+// @Param X-Nonce header string true "Unique id of the request, accepted once"
+// @Param X-Signature header string true "Signature"
+// @Param id path string true "Wallet ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /v2/wallets/{id}/transactions/summary [get]
+func (h *Handler) GetTransactionSummaryV2(c *gin.Context) {
+	count, total, err := h.walletService.GetTransactions(c.Param("id"), c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": count,
+		"total": total,
+	})
+}
+
+// GetTransactionV2 godoc
+// @Summary Get a transaction
+// @Description Get a transaction of the caller's wallet
+// @Tags v2
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Timestamp header string true "Unix time of the request"
+// @Param X-Nonce header string true "Unique id of the request, accepted once"
+// @Param X-Signature header string true "Signature"
+// @Param id path string true "Wallet ID"
+// @Param transaction_id path int true "Transaction ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /v2/wallets/{id}/transactions/{transaction_id} [get]
+func (h *Handler) GetTransactionV2(c *gin.Context) {
+	id, ok := transactionID(c)
+	if !ok {
+		return
+	}
+
+	transaction, err := h.transactionService.Get(c.Param("id"), id, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, transactionResponse(transaction))
+}
+
+// UpdateTransactionV2 godoc
+// @Summary Edit transaction notes
+// @Description Change the description, category or tags of a transaction. Fields left out are kept, an empty tag list clears the tags. wallet_id and transaction_id are taken from the path.
+// @Tags v2
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Timestamp header string true "Unix time of the request"
+// @Param X-Nonce header string true "Unique id of the request, accepted once"
+// @Param X-Signature header string true "Signature"
+// @Param id path string true "Wallet ID"
+// @Param transaction_id path int true "Transaction ID"
+// @Param request body models.UpdateTransactionRequest true "Changes"
+// @Success 200 {object} map[string]interface{}
+// @Router /v2/wallets/{id}/transactions/{transaction_id} [patch]
+func (h *Handler) UpdateTransactionV2(c *gin.Context) {
+	id, ok := transactionID(c)
+	if !ok {
+		return
+	}
+
+	var request models.UpdateTransactionRequest
+	if !bindResource(c, &request, func() { request.WalletID, request.TransactionID = c.Param("id"), id }) {
+		return
+	}
+
+	transaction, err := h.transactionService.UpdateDetails(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, transactionResponse(transaction))
+}
+
+// GetReceiptV2 godoc
+// @Summary Get a transaction receipt
+// @Description Get the signed receipt of a transaction of the caller's wallet
+// @Tags v2
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Timestamp header string true "Unix time of the request"
+// @Param X-Nonce header string true "Unique id of the request, accepted once"
+// @Param X-Signature header string true "Signature"
+// @Param id path string true "Wallet ID"
+// @Param transaction_id path int true "Transaction ID"
+// @Success 200 {object} models.Receipt
+// @Router /v2/wallets/{id}/transactions/{transaction_id}/receipt [get]
+func (h *Handler) GetReceiptV2(c *gin.Context) {
+	id, ok := transactionID(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetHeader("X-UserId")
+	if _, err := h.transactionService.Get(c.Param("id"), id, userID); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	r, err := h.receiptService.Get(id, userID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
+
+// CreateTopUpV2 godoc
+// @Summary Top up a wallet
+// @Description Top up the wallet with the given amount. wallet_id is taken from the path, the Location header points to the created transaction and the response carries its signed receipt.
+// @Tags v2
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Timestamp header string true "Unix time of the request"
+// @Param X-Nonce header string true "Unique id of the request, accepted once"
+// @Param X-Signature header string true "Signature"
+// @Param id path string true "Wallet ID"
+// @Param request body models.TopUpRequest true "Top up request"
+// @Success 201 {object} map[string]interface{}
+// @Router /v2/wallets/{id}/topups [post]
+func (h *Handler) CreateTopUpV2(c *gin.Context) {
+	var request models.TopUpRequest
+
+	if !bindResource(c, &request, func() { request.WalletID = c.Param("id") }) {
+		return
+	}
+
+	userID := c.GetHeader("X-UserId")
+	transactionID, err := h.walletService.TopUpWallet(request, userID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Location", transactionLocation(request.WalletID, transactionID))
+	c.JSON(http.StatusCreated, gin.H{
+		"transaction_id": transactionID,
+		"receipt":        h.receipt(transactionID, userID),
+	})
+}
+
+// CreateTransferV2 godoc
+// @Summary Transfer funds
+// @Description Transfer funds from the wallet to another wallet. wallet_id is taken from the path, the Location header points to the debit transaction. Transfers of child accounts may be queued for parental approval, which is answered with 202.
+// @Tags v2
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Timestamp header string true "Unix time of the request"
+// @Param X-Nonce header string true "Unique id of the request, accepted once"
+// @Param X-Signature header string true "Signature"
+// @Param id path string true "Wallet ID"
+// @Param request body models.TransferRequest true "Transfer request"
+// @Success 201 {object} map[string]interface{}
+// @Success 202 {object} map[string]string
+// @Router /v2/wallets/{id}/transfers [post]
+func (h *Handler) CreateTransferV2(c *gin.Context) {
+	var request models.TransferRequest
+
+	if !bindResource(c, &request, func() { request.WalletID = c.Param("id") }) {
+		return
+	}
+
+	userID := c.GetHeader("X-UserId")
+	transactionID, err := h.walletService.Transfer(request, userID)
+	if errors.Is(err, service.ErrApprovalRequired) {
+		c.JSON(http.StatusAccepted, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Location", transactionLocation(request.WalletID, transactionID))
+	c.JSON(http.StatusCreated, gin.H{
+		"transaction_id": transactionID,
+		"receipt":        h.receipt(transactionID, userID),
+	})
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// RedeemVoucher godoc
+// @Summary Redeem a voucher
+// @Description Credit a prepaid voucher or gift code to the caller's wallet. The code may be typed in any case, with or without dashes. The wallet limits of a top-up apply.
+// @Tags vouchers
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.RedeemVoucherRequest true "Wallet and code"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/vouchers/redeem [post]
+func (h *Handler) RedeemVoucher(c *gin.Context) {
+	var request models.RedeemVoucherRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	userID := c.GetHeader("X-UserId")
+	voucher, err := h.voucherService.Redeem(request, userID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"voucher_id":     voucher.ID,
+		"wallet_id":      voucher.WalletID,
+		"amount":         models.FormatAmount(voucher.Amount),
+		"transaction_id": voucher.TransactionID,
+		"redeemed_at":    voucher.RedeemedAt,
+		"receipt":        h.receipt(voucher.TransactionID, userID),
+	})
+}
+
+// CreateVoucherBatch godoc
+// @Summary Generate a voucher batch
+// @Description Generate count vouchers of the same amount. The codes are returned only in this response, only their hashes are stored.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param request body models.CreateVoucherBatchRequest true "Batch"
+// @Success 201 {object} map[string]interface{}
+// @Router /admin/v1/vouchers/batches/create [post]
+func (h *Handler) CreateVoucherBatch(c *gin.Context) {
+	var request models.CreateVoucherBatchRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	batch, codes, err := h.voucherService.CreateBatch(request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := voucherBatchResponse(batch)
+	response["codes"] = codes
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListVoucherBatches godoc
+// @Summary List voucher batches
+// @Description List all voucher batches, newest first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/v1/vouchers/batches [post]
+func (h *Handler) ListVoucherBatches(c *gin.Context) {
+	batches, err := h.voucherService.ListBatches()
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]gin.H, 0, len(batches))
+	for i := range batches {
+		response = append(response, voucherBatchResponse(&batches[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"batches": response})
+}
+
+// RevokeVoucherBatch godoc
+// @Summary Revoke a voucher batch
+// @Description Stop the vouchers of a batch that aren't redeemed yet from being redeemed, for example when cards were stolen
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param request body models.RevokeVoucherBatchRequest true "Batch and reason"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/v1/vouchers/batches/revoke [post]
+func (h *Handler) RevokeVoucherBatch(c *gin.Context) {
+	var request models.RevokeVoucherBatchRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	batch, err := h.voucherService.RevokeBatch(request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, voucherBatchResponse(batch))
+}
+
+// VoucherBatchReport godoc
+// @Summary Voucher batch usage
+// @Description Count the redeemed and outstanding vouchers of a batch and their value
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param request body models.VoucherBatchRequest true "Batch ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/v1/vouchers/batches/report [post]
+func (h *Handler) VoucherBatchReport(c *gin.Context) {
+	var request models.VoucherBatchRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	report, err := h.voucherService.Report(request.BatchID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"batch":              voucherBatchResponse(&report.Batch),
+		"available":          report.Available,
+		"redeemed":           report.Redeemed,
+		"redeemed_amount":    models.FormatAmount(report.RedeemedAmount),
+		"outstanding_amount": models.FormatAmount(report.OutstandingAmount),
+		"first_redeemed_at":  report.FirstRedeemedAt,
+		"last_redeemed_at":   report.LastRedeemedAt,
+	})
+}
+
+func voucherBatchResponse(batch *models.VoucherBatch) gin.H {
+	return gin.H{
+		"id":            batch.ID,
+		"name":          batch.Name,
+		"amount":        models.FormatAmount(batch.Amount),
+		"count":         batch.Count,
+		"status":        batch.Status,
+		"expires_at":    batch.ExpiresAt,
+		"revoke_reason": batch.RevokeReason,
+		"created_at":    batch.CreatedAt,
+		"revoked_at":    batch.RevokedAt,
+	}
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// CreateWebhook godoc
+// @Summary Subscribe to wallet events
+// @Description Register a URL that receives balance change events of the caller's wallets. The URL must use https and resolve to a public address, redirects are not followed. Requests are signed with the returned secret: X-Webhook-Signature is "sha256=" + hex HMAC-SHA256 of "<X-Webhook-Timestamp>.<body>". The secret is only returned here.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.CreateWebhookRequest true "Subscription, empty event types subscribe to every event"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/webhooks/create [post]
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var request models.CreateWebhookRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	subscription, err := h.webhookService.CreateSubscription(request, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := webhookResponse(subscription)
+	response["secret"] = subscription.Secret
+	c.JSON(http.StatusOK, response)
+}
+
+// ListWebhooks godoc
+// @Summary List webhook subscriptions
+// @Description List the caller's webhook subscriptions
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/webhooks [post]
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	subscriptions, err := h.webhookService.ListSubscriptions(c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]gin.H, 0, len(subscriptions))
+	for i := range subscriptions {
+		response = append(response, webhookResponse(&subscriptions[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": response})
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook subscription
+// @Description Stop sending events to a subscription and drop its pending deliveries
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.WebhookRequest true "Subscription ID"
+// @Success 200 {object} map[string]string
+// @Router /v1/webhooks/delete [post]
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	var request models.WebhookRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.webhookService.DeleteSubscription(request.SubscriptionID, c.GetHeader("X-UserId")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+}
+
+// ListWebhookDeliveries godoc
+// @Summary List webhook deliveries
+// @Description Delivery log of a subscription, newest first
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-UserId header string true "User ID"
+// @Param X-Digest header string true "Digest"
+// @Param request body models.WebhookRequest true "Subscription ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /v1/webhooks/deliveries [post]
+func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
+	var request models.WebhookRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(request.SubscriptionID, c.GetHeader("X-UserId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]gin.H, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		response = append(response, gin.H{
+			"id":              delivery.ID,
+			"event_type":      delivery.EventType,
+			"status":          delivery.Status,
+			"attempts":        delivery.Attempts,
+			"last_error":      delivery.LastError,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"created_at":      delivery.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": response})
+}
+
+// ListWebhookDeadLetters godoc
+// @Summary List dead webhook deliveries
+// @Description Deliveries that failed every retry, newest first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/v1/webhooks/dead-letters [post]
+func (h *Handler) ListWebhookDeadLetters(c *gin.Context) {
+	deadLetters, err := h.webhookService.ListDeadLetters()
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]gin.H, 0, len(deadLetters))
+	for _, deadLetter := range deadLetters {
+		response = append(response, gin.H{
+			"id":              deadLetter.ID,
+			"delivery_id":     deadLetter.DeliveryID,
+			"subscription_id": deadLetter.SubscriptionID,
+			"event_type":      deadLetter.EventType,
+			"payload":         deadLetter.Payload,
+			"attempts":        deadLetter.Attempts,
+			"last_error":      deadLetter.LastError,
+			"replayed_at":     deadLetter.ReplayedAt,
+			"created_at":      deadLetter.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"dead_letters": response})
+}
+
+// ReplayWebhookDeadLetter godoc
+// @Summary Replay a dead webhook delivery
+// @Description Queue a dead delivery again with a fresh retry budget
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param request body models.ReplayDeadLetterRequest true "Dead letter ID"
+// @Success 200 {object} map[string]string
+// @Router /admin/v1/webhooks/dead-letters/replay [post]
+func (h *Handler) ReplayWebhookDeadLetter(c *gin.Context) {
+	var request models.ReplayDeadLetterRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.webhookService.ReplayDeadLetter(request.DeadLetterID); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delivery queued"})
+}
+
+func webhookResponse(subscription *models.WebhookSubscription) gin.H {
+	return gin.H{
+		"id":          subscription.ID,
+		"url":         subscription.URL,
+		"event_types": subscription.EventTypes,
+		"created_at":  subscription.CreatedAt,
+	}
+}
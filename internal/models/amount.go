@@ -0,0 +1,28 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// ParseAmount converts a decimal amount such as "100.50" into minor units (10050)
+func ParseAmount(amount string) (int64, error) {
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid amount")
+	}
+
+	if value <= 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, fmt.Errorf("amount must be positive")
+	}
+
+	return int64(math.Round(value * 100)), nil
+}
+
+// FormatAmount converts minor units into a decimal string with two fraction digits
+func FormatAmount(amount int64) string {
+	return fmt.Sprintf("%.2f", float64(amount)/100)
+}
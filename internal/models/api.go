@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SignatureMaxSkew is how far the X-Timestamp of a signed /v2 request may be from
+// the server clock. Older requests are rejected, which bounds how long a captured
+// request can be replayed.
+const SignatureMaxSkew = 5 * time.Minute
+
+// SignatureMaxNonceLength bounds the X-Nonce of a signed /v2 request, the nonce
+// makes the request single use
+const SignatureMaxNonceLength = 64
+
+// APIV1DeprecatedAt is when /v2 superseded /v1, announced in the Deprecation
+// header of /v1 responses
+var APIV1DeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
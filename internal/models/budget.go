@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Budget is a monthly spending limit of a user for a transaction category
+type Budget struct {
+	ID        string    `db:"id"`
+	UserID    string    `db:"user_id"`
+	Category  string    `db:"category"`
+	Amount    int64     `db:"amount"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// BudgetProgress is what was spent against a budget in a month. Alerts are the
+// thresholds already alerted in that month.
+type BudgetProgress struct {
+	Budget Budget
+	Period string
+	Spent  int64
+	Alerts []int
+}
+
+// BudgetAlert is sent once per month when spending reaches a threshold percent
+// of the budget
+type BudgetAlert struct {
+	ID        string    `db:"id"`
+	BudgetID  string    `db:"budget_id"`
+	UserID    string    `db:"user_id"`
+	Category  string    `db:"category"`
+	Period    string    `db:"period"`
+	Threshold int       `db:"threshold"`
+	Amount    int64     `db:"amount"`
+	Spent     int64     `db:"spent"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// SetBudgetRequest creates the budget of the category or changes its amount
+type SetBudgetRequest struct {
+	Category string `json:"category" binding:"required,max=32"`
+	Amount   string `json:"amount" binding:"required"`
+}
+
+type DeleteBudgetRequest struct {
+	BudgetID string `json:"budget_id" binding:"required"`
+}
+
+// BudgetProgressRequest selects the month as YYYY-MM, the current month when empty
+type BudgetProgressRequest struct {
+	Month string `json:"month"`
+}
+
+// BudgetPeriodLayout formats the month a budget alert belongs to
+const BudgetPeriodLayout = "2006-01"
+
+// BudgetAlertThresholds are the percents of a budget that alert, highest last
+var BudgetAlertThresholds = []int{80, 100}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// Escrow holds a buyer's payment in the escrow system wallet until the deal is
+// settled. Amounts are in minor units.
+type Escrow struct {
+	ID                   string     `db:"id"`
+	BuyerID              string     `db:"buyer_id"`
+	BuyerWalletID        string     `db:"buyer_wallet_id"`
+	SellerID             string     `db:"seller_id"`
+	SellerWalletID       string     `db:"seller_wallet_id"`
+	Amount               int64      `db:"amount"`
+	Description          string     `db:"description"`
+	Reference            string     `db:"reference"`
+	Status               string     `db:"status"`
+	ReleasedAmount       int64      `db:"released_amount"`
+	RefundedAmount       int64      `db:"refunded_amount"`
+	HoldTransactionID    int64      `db:"hold_transaction_id"`
+	ReleaseTransactionID int64      `db:"release_transaction_id"`
+	RefundTransactionID  int64      `db:"refund_transaction_id"`
+	DisputeReason        string     `db:"dispute_reason"`
+	Resolution           string     `db:"resolution"`
+	ReleaseAt            time.Time  `db:"release_at"`
+	CreatedAt            time.Time  `db:"created_at"`
+	UpdatedAt            time.Time  `db:"updated_at"`
+	SettledAt            *time.Time `db:"settled_at"`
+}
+
+type CreateEscrowRequest struct {
+	// WalletID is the buyer's wallet the payment is taken from
+	WalletID       string `json:"wallet_id" binding:"required"`
+	SellerWalletID string `json:"seller_wallet_id" binding:"required"`
+	Amount         string `json:"amount" binding:"required"`
+	Description    string `json:"description" binding:"max=255"`
+	// Reference is the marketplace's id of the deal
+	Reference string `json:"reference" binding:"max=64"`
+	// ReleaseAfterDays releases the funds to the seller if the buyer neither
+	// confirms nor disputes in time, EscrowReleaseAfterDays by default
+	ReleaseAfterDays int `json:"release_after_days" binding:"min=0,max=90"`
+}
+
+type EscrowRequest struct {
+	EscrowID string `json:"escrow_id" binding:"required"`
+}
+
+type DisputeEscrowRequest struct {
+	EscrowID string `json:"escrow_id" binding:"required"`
+	Reason   string `json:"reason" binding:"required,max=255"`
+}
+
+// ResolveEscrowRequest splits a disputed escrow, the seller gets SellerAmount and
+// the rest goes back to the buyer
+type ResolveEscrowRequest struct {
+	EscrowID     string `json:"escrow_id" binding:"required"`
+	SellerAmount string `json:"seller_amount" binding:"required"`
+	Resolution   string `json:"resolution" binding:"required,max=255"`
+}
+
+// An escrow is pending until the buyer's payment goes through, which may wait for
+// a parent's approval, and failed if it doesn't. It is then held until the buyer
+// confirms delivery (released), the seller cancels (refunded) or either party
+// disputes it. A dispute is settled by an admin, who may split the funds (resolved).
+const (
+	EscrowPending  = "pending"
+	EscrowFailed   = "failed"
+	EscrowHeld     = "held"
+	EscrowDisputed = "disputed"
+	EscrowReleased = "released"
+	EscrowRefunded = "refunded"
+	EscrowResolved = "resolved"
+
+	EscrowReleaseAfterDays = 14
+
+	// SystemWalletEscrow is the system wallet holding the funds of open escrows
+	SystemWalletEscrow = "escrow"
+)
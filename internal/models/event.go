@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// WalletEvent describes a change of a wallet balance. It is published after the
+// change is committed.
+type WalletEvent struct {
+	// ID is the id of the domain event, streams resume after it
+	ID                   int64     `json:"id"`
+	Type                 string    `json:"type"`
+	UserID               string    `json:"user_id"`
+	WalletID             string    `json:"wallet_id"`
+	Amount               int64     `json:"amount"`
+	Balance              int64     `json:"balance"`
+	TransactionID        int64     `json:"transaction_id,omitempty"`
+	CounterpartyWalletID string    `json:"counterparty_wallet_id,omitempty"`
+	Reference            string    `json:"reference,omitempty"`
+	Category             string    `json:"category,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+const (
+	EventWalletToppedUp = "wallet.topped_up"
+	EventWalletDebited  = "wallet.debited"
+	EventWalletCredited = "wallet.credited"
+)
+
+// WalletEventTypes lists every event type a client can subscribe to
+var WalletEventTypes = []string{EventWalletToppedUp, EventWalletDebited, EventWalletCredited}
+
+// TransferResult is the outcome of a committed transfer
+type TransferResult struct {
+	DebitTransactionID  int64
+	CreditTransactionID int64
+	FromBalance         int64
+	ToBalance           int64
+}
@@ -0,0 +1,17 @@
+package models
+
+const (
+	// GraphQLMaxQueryLength is the longest GraphQL query in bytes
+	GraphQLMaxQueryLength = 16 << 10
+	// GraphQLMaxDepth is the deepest selection a GraphQL query may nest
+	GraphQLMaxDepth = 10
+	// GraphQLMaxComplexity caps the cost of a GraphQL query. Every field costs 1
+	// and a transactions connection multiplies the cost of its selection by the
+	// page size it asks for.
+	GraphQLMaxComplexity = 1000
+
+	// GraphQLPageSize is the default and GraphQLMaxPageSize the largest page of a
+	// transactions connection
+	GraphQLPageSize    = 20
+	GraphQLMaxPageSize = 100
+)
@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+// LoyaltyRule awards points for a wallet event. Points are per whole unit of the
+// event amount, events below MinAmount earn nothing.
+type LoyaltyRule struct {
+	ID            string    `db:"id"`
+	EventType     string    `db:"event_type"`
+	PointsPerUnit int64     `db:"points_per_unit"`
+	MinAmount     int64     `db:"min_amount"`
+	Active        bool      `db:"active"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+// LoyaltyEntry is a line of the points ledger. Earned entries are lots with an
+// expiry, Remaining is the part of the lot not yet redeemed or expired. The other
+// kinds point to the lot they changed.
+type LoyaltyEntry struct {
+	ID            int64      `db:"id"`
+	UserID        string     `db:"user_id"`
+	Kind          string     `db:"kind"`
+	Points        int64      `db:"points"`
+	Remaining     int64      `db:"remaining"`
+	ExpiresAt     *time.Time `db:"expires_at"`
+	LotID         int64      `db:"lot_id"`
+	RuleID        string     `db:"rule_id"`
+	WalletID      string     `db:"wallet_id"`
+	TransactionID int64      `db:"transaction_id"`
+	RedemptionID  string     `db:"redemption_id"`
+	CreatedAt     time.Time  `db:"created_at"`
+}
+
+// LoyaltyRedemption converts points to wallet credit
+type LoyaltyRedemption struct {
+	ID            string     `db:"id"`
+	UserID        string     `db:"user_id"`
+	WalletID      string     `db:"wallet_id"`
+	Points        int64      `db:"points"`
+	Amount        int64      `db:"amount"`
+	Status        string     `db:"status"`
+	TransactionID int64      `db:"transaction_id"`
+	CreatedAt     time.Time  `db:"created_at"`
+	CompletedAt   *time.Time `db:"completed_at"`
+}
+
+// LoyaltyBalance is the points a user can redeem and the next lot to expire
+type LoyaltyBalance struct {
+	Points         int64
+	ExpiringPoints int64
+	NextExpiryAt   *time.Time
+}
+
+type CreateLoyaltyRuleRequest struct {
+	EventType     string `json:"event_type" binding:"required"`
+	PointsPerUnit int64  `json:"points_per_unit" binding:"required,min=1,max=1000"`
+	MinAmount     string `json:"min_amount"`
+}
+
+type LoyaltyRuleRequest struct {
+	RuleID string `json:"rule_id" binding:"required"`
+}
+
+type RedeemPointsRequest struct {
+	WalletID string `json:"wallet_id" binding:"required"`
+	Points   int64  `json:"points" binding:"required,min=1"`
+}
+
+// LoyaltyHistoryRequest pages through the ledger, newest first. Continue with
+// BeforeID set to the last id of the previous page.
+type LoyaltyHistoryRequest struct {
+	BeforeID int64 `json:"before_id" binding:"min=0"`
+	Limit    int   `json:"limit" binding:"min=0,max=200"`
+}
+
+const (
+	LoyaltyEntryEarn   = "earn"
+	LoyaltyEntryRedeem = "redeem"
+	LoyaltyEntryRefund = "refund"
+	LoyaltyEntryExpire = "expire"
+
+	// A redemption completes with the credit paying it. Released redemptions
+	// gave their points back, they are left from before redemptions completed
+	// in the transaction of the credit.
+	LoyaltyRedemptionCompleted = "completed"
+	LoyaltyRedemptionReleased  = "released"
+
+	LoyaltyHistoryLimit = 50
+	// LoyaltyMinRedemption is the fewest points that can be redeemed at once
+	LoyaltyMinRedemption = 100
+)
@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// Merchant is a business accepting payments into its settlement wallet
+type Merchant struct {
+	ID                 string    `db:"id"`
+	OwnerID            string    `db:"owner_id"`
+	LegalName          string    `db:"legal_name"`
+	TaxID              string    `db:"tax_id"`
+	LegalAddress       string    `db:"legal_address"`
+	SettlementWalletID string    `db:"settlement_wallet_id"`
+	MerchantCategory   string    `db:"merchant_category"`
+	APIKey             string    `db:"api_key"`
+	APISecret          string    `db:"api_secret"`
+	CreatedAt          time.Time `db:"created_at"`
+}
+
+// MerchantOrder is a payment a merchant expects from a customer
+type MerchantOrder struct {
+	ID               string    `db:"id"`
+	MerchantID       string    `db:"merchant_id"`
+	Amount           int64     `db:"amount"`
+	Reference        string    `db:"reference"`
+	Description      string    `db:"description"`
+	Status           string    `db:"status"`
+	CustomerWalletID string    `db:"customer_wallet_id"`
+	TransactionID    int64     `db:"transaction_id"`
+	ExpiresAt        time.Time `db:"expires_at"`
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
+}
+
+// MerchantOrderEvent records a single status change of an order
+type MerchantOrderEvent struct {
+	ID         int64     `db:"id"`
+	OrderID    string    `db:"order_id"`
+	FromStatus string    `db:"from_status"`
+	ToStatus   string    `db:"to_status"`
+	Details    string    `db:"details"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+type CreateMerchantRequest struct {
+	LegalName          string `json:"legal_name" binding:"required,max=255"`
+	TaxID              string `json:"tax_id" binding:"required,max=32"`
+	LegalAddress       string `json:"legal_address"`
+	SettlementWalletID string `json:"settlement_wallet_id" binding:"required"`
+	MerchantCategory   string `json:"merchant_category" binding:"required,len=4,numeric"`
+}
+
+type MerchantRequest struct {
+	MerchantID string `json:"merchant_id" binding:"required"`
+}
+
+type CreateOrderRequest struct {
+	Amount           string `json:"amount" binding:"required"`
+	Reference        string `json:"reference" binding:"max=64"`
+	Description      string `json:"description"`
+	ExpiresInMinutes int    `json:"expires_in_minutes" binding:"min=0"`
+}
+
+type OrderRequest struct {
+	OrderID string `json:"order_id" binding:"required"`
+}
+
+type ConfirmOrderRequest struct {
+	OrderID  string `json:"order_id" binding:"required"`
+	WalletID string `json:"wallet_id" binding:"required"`
+}
+
+const (
+	OrderStatusCreated   = "created"
+	OrderStatusPaid      = "paid"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusExpired   = "expired"
+
+	// DefaultOrderTTL is used when an order is created without an expiry
+	DefaultOrderTTL = 30 * time.Minute
+)
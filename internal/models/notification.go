@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// NotificationPreference is where a user wants notifications of a channel, and
+// whether at all
+type NotificationPreference struct {
+	UserID    string    `db:"user_id"`
+	Channel   string    `db:"channel"`
+	Address   string    `db:"address"`
+	Enabled   bool      `db:"enabled"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// Notification is a rendered message in the outbox
+type Notification struct {
+	ID            string     `db:"id"`
+	UserID        string     `db:"user_id"`
+	EventID       int64      `db:"event_id"`
+	Kind          string     `db:"kind"`
+	Channel       string     `db:"channel"`
+	Address       string     `db:"address"`
+	Subject       string     `db:"subject"`
+	Body          string     `db:"body"`
+	Status        string     `db:"status"`
+	Attempts      int        `db:"attempts"`
+	LastError     string     `db:"last_error"`
+	NextAttemptAt time.Time  `db:"next_attempt_at"`
+	CreatedAt     time.Time  `db:"created_at"`
+	SentAt        *time.Time `db:"sent_at"`
+}
+
+// SetNotificationPreferenceRequest sets the address of a channel and turns it on
+// or off
+type SetNotificationPreferenceRequest struct {
+	Channel string `json:"channel" binding:"required,oneof=sms email push"`
+	Address string `json:"address" binding:"required,max=255"`
+	Enabled bool   `json:"enabled"`
+}
+
+const (
+	NotificationStatusPending = "pending"
+	NotificationStatusSent    = "sent"
+	NotificationStatusFailed  = "failed"
+
+	// NotificationMaxAttempts is how many times a notification is tried before it fails
+	NotificationMaxAttempts = 5
+	// NotificationBaseBackoff is the delay before the first retry, doubled on every next one
+	NotificationBaseBackoff = 30 * time.Second
+	NotificationMaxBackoff  = time.Hour
+	NotificationListLimit   = 50
+)
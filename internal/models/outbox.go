@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DomainEvent is an event of the transactional outbox. It is written in the same
+// database transaction as the change it describes, AggregateID is the wallet.
+type DomainEvent struct {
+	ID            int64           `db:"id"`
+	AggregateID   string          `db:"aggregate_id"`
+	Type          string          `db:"event_type"`
+	Payload       json.RawMessage `db:"payload"`
+	Attempts      int             `db:"attempts"`
+	LastError     string          `db:"last_error"`
+	NextAttemptAt time.Time       `db:"next_attempt_at"`
+	CreatedAt     time.Time       `db:"created_at"`
+	PublishedAt   *time.Time      `db:"published_at"`
+	// PublishedSinks names the sinks that already published the event
+	PublishedSinks []string   `db:"published_sinks"`
+	DeadAt         *time.Time `db:"dead_at"`
+}
+
+// ReplayOutboxEventRequest hands a dead event to the sinks that didn't publish it
+type ReplayOutboxEventRequest struct {
+	EventID int64 `json:"event_id" binding:"required,min=1"`
+}
+
+// WalletEventPayload is the payload of the wallet domain events
+type WalletEventPayload struct {
+	WalletID             string `json:"wallet_id"`
+	UserID               string `json:"user_id"`
+	Amount               int64  `json:"amount"`
+	Balance              int64  `json:"balance"`
+	TransactionID        int64  `json:"transaction_id,omitempty"`
+	CounterpartyWalletID string `json:"counterparty_wallet_id,omitempty"`
+	Reference            string `json:"reference,omitempty"`
+	Category             string `json:"category,omitempty"`
+}
+
+const (
+	DomainEventWalletCreated  = "WalletCreated"
+	DomainEventWalletToppedUp = "WalletToppedUp"
+	DomainEventWalletDebited  = "WalletDebited"
+	DomainEventWalletCredited = "WalletCredited"
+
+	// OutboxBaseBackoff is the delay before the first retry of an event, doubled on
+	// every next one. After OutboxMaxAttempts the event is dead, the later events of
+	// its wallet go on and it waits to be replayed.
+	OutboxBaseBackoff = 5 * time.Second
+	OutboxMaxBackoff  = 10 * time.Minute
+	OutboxMaxAttempts = 10
+)
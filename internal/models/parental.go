@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// ParentalControls are the spending rules a parent sets for a child account.
+// Amounts are stored in minor units, zero means the rule is disabled.
+type ParentalControls struct {
+	ChildID             string   `db:"child_id"`
+	PerTransactionLimit int64    `db:"per_transaction_limit"`
+	MonthlyLimit        int64    `db:"monthly_limit"`
+	ApprovalThreshold   int64    `db:"approval_threshold"`
+	BlockedCategories   []string `db:"blocked_categories"`
+}
+
+type ParentalControlsModel struct {
+	ChildID             string   `json:"child_id" binding:"required"`
+	PerTransactionLimit string   `json:"per_transaction_limit"`
+	MonthlyLimit        string   `json:"monthly_limit"`
+	ApprovalThreshold   string   `json:"approval_threshold"`
+	BlockedCategories   []string `json:"blocked_categories"`
+}
+
+type ChildRequest struct {
+	ChildID string `json:"child_id" binding:"required"`
+}
+
+type ApprovalDecisionRequest struct {
+	ApprovalID string `json:"approval_id" binding:"required"`
+}
+
+// ApprovalRequest is a child's transfer waiting for the parent's decision
+type ApprovalRequest struct {
+	ID               string     `db:"id"`
+	ParentID         string     `db:"parent_id"`
+	ChildID          string     `db:"child_id"`
+	WalletID         string     `db:"wallet_id"`
+	ToWalletID       string     `db:"to_wallet_id"`
+	Amount           int64      `db:"amount"`
+	MerchantCategory string     `db:"merchant_category"`
+	Reference        string     `db:"reference"`
+	Status           string     `db:"status"`
+	CreatedAt        time.Time  `db:"created_at"`
+	DecidedAt        *time.Time `db:"decided_at"`
+}
+
+const (
+	ApprovalStatusPending   = "pending"
+	ApprovalStatusApproved  = "approved"
+	ApprovalStatusDeclined  = "declined"
+	ApprovalStatusCompleted = "completed"
+	ApprovalStatusFailed    = "failed"
+)
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// PaymentRequest is a request from one user to another to pay a given amount
+type PaymentRequest struct {
+	ID                string    `db:"id"`
+	RequesterID       string    `db:"requester_id"`
+	RequesterWalletID string    `db:"requester_wallet_id"`
+	PayerID           string    `db:"payer_id"`
+	PayerWalletID     string    `db:"payer_wallet_id"`
+	Amount            int64     `db:"amount"`
+	Message           string    `db:"message"`
+	Status            string    `db:"status"`
+	TransactionID     int64     `db:"transaction_id"`
+	ExpiresAt         time.Time `db:"expires_at"`
+	CreatedAt         time.Time `db:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at"`
+}
+
+type CreatePaymentRequest struct {
+	WalletID  string     `json:"wallet_id" binding:"required"`
+	PayerID   string     `json:"payer_id" binding:"required"`
+	Amount    string     `json:"amount" binding:"required"`
+	Message   string     `json:"message"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type ListPaymentRequests struct {
+	// Role is either "incoming" (requests to pay) or "outgoing" (requests the caller made)
+	Role   string `json:"role" binding:"required,oneof=incoming outgoing"`
+	Status string `json:"status"`
+}
+
+type PaymentRequestAction struct {
+	RequestID string `json:"request_id" binding:"required"`
+}
+
+type AcceptPaymentRequest struct {
+	RequestID string `json:"request_id" binding:"required"`
+	WalletID  string `json:"wallet_id" binding:"required"`
+}
+
+const (
+	PaymentRequestStatusPending   = "pending"
+	PaymentRequestStatusPaid      = "paid"
+	PaymentRequestStatusDeclined  = "declined"
+	PaymentRequestStatusCancelled = "cancelled"
+	PaymentRequestStatusExpired   = "expired"
+
+	// DefaultPaymentRequestTTL is used when a request is created without an expiry
+	DefaultPaymentRequestTTL = 7 * 24 * time.Hour
+)
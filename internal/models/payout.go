@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// PayoutBatch is an uploaded file of payouts from one of the user's wallets.
+// Row counters are computed from the rows when the batch is loaded.
+type PayoutBatch struct {
+	ID          string     `db:"id"`
+	UserID      string     `db:"user_id"`
+	WalletID    string     `db:"wallet_id"`
+	FileName    string     `db:"file_name"`
+	Format      string     `db:"format"`
+	Status      string     `db:"status"`
+	TotalRows   int        `db:"total_rows"`
+	TotalAmount int64      `db:"total_amount"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+	CompletedAt *time.Time `db:"completed_at"`
+
+	PendingRows     int   `db:"-"`
+	SucceededRows   int   `db:"-"`
+	FailedRows      int   `db:"-"`
+	SucceededAmount int64 `db:"-"`
+}
+
+// PayoutRow is a single payout of a batch
+type PayoutRow struct {
+	ID            string     `db:"id"`
+	BatchID       string     `db:"batch_id"`
+	RowNumber     int        `db:"row_number"`
+	WalletID      string     `db:"wallet_id"`
+	Amount        int64      `db:"amount"`
+	Description   string     `db:"description"`
+	Status        string     `db:"status"`
+	TransactionID int64      `db:"transaction_id"`
+	Error         string     `db:"error"`
+	ProcessedAt   *time.Time `db:"processed_at"`
+}
+
+type PayoutBatchRequest struct {
+	BatchID string `json:"batch_id" binding:"required"`
+}
+
+const (
+	PayoutBatchProcessing = "processing"
+	PayoutBatchPaused     = "paused"
+	PayoutBatchCompleted  = "completed"
+
+	PayoutRowPending    = "pending"
+	PayoutRowProcessing = "processing"
+	PayoutRowSucceeded  = "succeeded"
+	PayoutRowFailed     = "failed"
+
+	// PayoutMaxFileSize limits the size of an uploaded payout file
+	PayoutMaxFileSize = 5 << 20
+)
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type QRGenerateRequest struct {
+	WalletID         string `json:"wallet_id" binding:"required"`
+	Amount           string `json:"amount"`
+	Reference        string `json:"reference" binding:"max=25"`
+	MerchantName     string `json:"merchant_name" binding:"max=25"`
+	MerchantCity     string `json:"merchant_city" binding:"max=15"`
+	MerchantCategory string `json:"merchant_category" binding:"omitempty,len=4,numeric"`
+}
+
+type QRImageRequest struct {
+	Payload string `json:"payload" binding:"required"`
+	Size    int    `json:"size"`
+}
+
+type QRPayRequest struct {
+	Payload  string `json:"payload" binding:"required"`
+	WalletID string `json:"wallet_id" binding:"required"`
+	// Amount is required for static payloads and must match for dynamic ones
+	Amount string `json:"amount"`
+}
+
+const (
+	// QRPaymentTTL is how long a dynamic QR payload can be paid
+	QRPaymentTTL = 15 * time.Minute
+
+	QRImageDefaultSize = 256
+	QRImageMaxSize     = 1024
+)
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RealtimeMessage is a command sent over the WebSocket API. Like a /v2 request,
+// every message carries the user id, the unix time it was signed at and its
+// signature. The message is signed as a request with its type as the method,
+// the path of the WebSocket, an empty query, its id as the nonce and the payload
+// as the body. An id is accepted once.
+type RealtimeMessage struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	UserID    string          `json:"user_id"`
+	Timestamp string          `json:"timestamp"`
+	Signature string          `json:"signature"`
+	Payload   json.RawMessage `json:"payload" swaggertype:"object"`
+}
+
+// SubscribeRequest picks the wallet event types a connection receives, all of
+// them when Topics is empty
+type SubscribeRequest struct {
+	Topics []string `json:"topics" binding:"dive,oneof=wallet.topped_up wallet.debited wallet.credited"`
+}
+
+// Client message types
+const (
+	RealtimeSubscribe   = "subscribe"
+	RealtimeUnsubscribe = "unsubscribe"
+	RealtimeBalance     = "balance"
+	RealtimeTransfer    = "transfer"
+)
+
+// Server message types. Results and errors echo the id of the command they
+// answer.
+const (
+	RealtimeResult = "result"
+	RealtimeError  = "error"
+	RealtimeEvent  = "event"
+)
+
+const (
+	// RealtimeMaxMessageSize is the largest message a client may send
+	RealtimeMaxMessageSize = 64 << 10
+	// RealtimeSendQueueSize is how many messages may wait for a slow client
+	// before its connection is closed
+	RealtimeSendQueueSize = 64
+	// RealtimePingInterval is how often the server pings, a client that doesn't
+	// answer within RealtimePongWait is disconnected
+	RealtimePingInterval = 30 * time.Second
+	RealtimePongWait     = 60 * time.Second
+	// RealtimeWriteTimeout bounds a single write to the client
+	RealtimeWriteTimeout = 10 * time.Second
+	// RealtimeCloseGrace is how long the server waits for the client to answer its
+	// close frame
+	RealtimeCloseGrace = 5 * time.Second
+)
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Receipt is the signed proof of a ledger entry given to the wallet owner. The
+// same JSON can be sent back to the verification endpoint as it was received.
+type Receipt struct {
+	TransactionID        int64     `json:"transaction_id" binding:"required"`
+	WalletID             string    `json:"wallet_id" binding:"required"`
+	Type                 string    `json:"type" binding:"required,oneof=credit debit"`
+	Amount               string    `json:"amount" binding:"required"`
+	Balance              string    `json:"balance" binding:"required"`
+	CounterpartyWalletID string    `json:"counterparty_wallet_id"`
+	Reference            string    `json:"reference"`
+	CreatedAt            time.Time `json:"created_at" binding:"required"`
+	Signature            string    `json:"signature" binding:"required"`
+}
+
+type ReceiptRequest struct {
+	TransactionID int64 `json:"transaction_id" binding:"required"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// ReconciliationRun is one comparison of every wallet balance with its ledger.
+// TotalDifference is the sum of absolute differences in minor units.
+type ReconciliationRun struct {
+	ID              string     `db:"id"`
+	Status          string     `db:"status"`
+	WalletsChecked  int        `db:"wallets_checked"`
+	Discrepancies   int        `db:"discrepancies"`
+	TotalDifference int64      `db:"total_difference"`
+	CasesOpened     int        `db:"cases_opened"`
+	Error           string     `db:"error"`
+	StartedAt       time.Time  `db:"started_at"`
+	FinishedAt      *time.Time `db:"finished_at"`
+}
+
+// BalanceDiscrepancy is a wallet whose stored balance differs from the sum of its
+// transactions. Difference is stored minus ledger balance.
+type BalanceDiscrepancy struct {
+	RunID             string     `db:"run_id"`
+	WalletID          string     `db:"wallet_id"`
+	UserID            string     `db:"user_id"`
+	StoredBalance     int64      `db:"stored_balance"`
+	LedgerBalance     int64      `db:"ledger_balance"`
+	Difference        int64      `db:"difference"`
+	TransactionCount  int        `db:"transaction_count"`
+	LastTransactionAt *time.Time `db:"last_transaction_at"`
+	CaseID            string     `db:"case_id"`
+}
+
+// CorrectionCase asks an operator to investigate and fix a discrepancy
+type CorrectionCase struct {
+	ID            string     `db:"id"`
+	WalletID      string     `db:"wallet_id"`
+	RunID         string     `db:"run_id"`
+	StoredBalance int64      `db:"stored_balance"`
+	LedgerBalance int64      `db:"ledger_balance"`
+	Difference    int64      `db:"difference"`
+	Status        string     `db:"status"`
+	Resolution    string     `db:"resolution"`
+	CreatedAt     time.Time  `db:"created_at"`
+	ResolvedAt    *time.Time `db:"resolved_at"`
+}
+
+type RunReconciliationRequest struct {
+	OpenCases bool `json:"open_cases"`
+}
+
+type ReconciliationRunRequest struct {
+	RunID string `json:"run_id" binding:"required"`
+}
+
+type ListCorrectionCasesRequest struct {
+	Status string `json:"status" binding:"omitempty,oneof=open resolved"`
+}
+
+type ResolveCaseRequest struct {
+	CaseID     string `json:"case_id" binding:"required"`
+	Resolution string `json:"resolution" binding:"required"`
+}
+
+const (
+	ReconciliationRunning   = "running"
+	ReconciliationCompleted = "completed"
+	ReconciliationFailed    = "failed"
+
+	CorrectionCaseOpen     = "open"
+	CorrectionCaseResolved = "resolved"
+)
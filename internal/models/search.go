@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// TransactionSearchRequest looks for transactions across all wallets. Query is
+// matched against the reference, description, category and counterparty name, and
+// against the amount when it is a number.
+type TransactionSearchRequest struct {
+	Query     string     `json:"query" binding:"required,min=2,max=100"`
+	WalletID  string     `json:"wallet_id"`
+	Type      string     `json:"type" binding:"omitempty,oneof=credit debit"`
+	Category  string     `json:"category"`
+	From      *time.Time `json:"from"`
+	To        *time.Time `json:"to"`
+	MinAmount string     `json:"min_amount"`
+	MaxAmount string     `json:"max_amount"`
+	Page      int        `json:"page" binding:"min=0"`
+	Limit     int        `json:"limit" binding:"min=0,max=100"`
+}
+
+// TransactionSearchResult is a transaction found by a search, better matches have
+// a higher Rank
+type TransactionSearchResult struct {
+	Transaction
+	CounterpartyName string  `db:"counterparty_name"`
+	Rank             float64 `db:"rank"`
+}
+
+const TransactionSearchLimit = 20
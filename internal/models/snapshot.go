@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// BalanceSnapshot is the balance of a wallet at the end of a day. TransactionCount
+// is the number of transactions replayed since the previous snapshot.
+type BalanceSnapshot struct {
+	WalletID         string    `db:"wallet_id"`
+	SnapshotDate     time.Time `db:"snapshot_date"`
+	Balance          int64     `db:"balance"`
+	TransactionCount int       `db:"transaction_count"`
+	CreatedAt        time.Time `db:"created_at"`
+}
+
+// HistoricalBalance is the balance of a wallet at a point in time, replayed from
+// the nearest snapshot before it. SnapshotDate is nil when no snapshot was taken
+// yet and the whole ledger was replayed.
+type HistoricalBalance struct {
+	WalletID             string
+	At                   time.Time
+	Balance              int64
+	SnapshotDate         *time.Time
+	TransactionsReplayed int
+}
+
+type BalanceAtRequest struct {
+	WalletID string    `json:"wallet_id" form:"-" binding:"required"`
+	At       time.Time `json:"at" form:"at" binding:"required"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+// SplitBill is a bill paid by the originator and shared with other users.
+// Amounts are in minor units.
+type SplitBill struct {
+	ID           string    `db:"id"`
+	OriginatorID string    `db:"originator_id"`
+	WalletID     string    `db:"wallet_id"`
+	TotalAmount  int64     `db:"total_amount"`
+	Description  string    `db:"description"`
+	Status       string    `db:"status"`
+	CreatedAt    time.Time `db:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at"`
+
+	Participants []SplitParticipant `db:"-"`
+}
+
+// Outstanding is the sum of shares that are not paid yet
+func (b *SplitBill) Outstanding() int64 {
+	var outstanding int64
+	for _, participant := range b.Participants {
+		if participant.Status == SplitParticipantPending {
+			outstanding += participant.Amount
+		}
+	}
+	return outstanding
+}
+
+// SplitParticipant is a user who owes a share of a split bill
+type SplitParticipant struct {
+	ID             string     `db:"id"`
+	SplitID        string     `db:"split_id"`
+	UserID         string     `db:"user_id"`
+	Amount         int64      `db:"amount"`
+	Status         string     `db:"status"`
+	WalletID       string     `db:"wallet_id"`
+	TransactionID  int64      `db:"transaction_id"`
+	ReminderCount  int        `db:"reminder_count"`
+	LastRemindedAt *time.Time `db:"last_reminded_at"`
+	PaidAt         *time.Time `db:"paid_at"`
+}
+
+type SplitShare struct {
+	UserID string `json:"user_id" binding:"required"`
+	// Amount is only used with custom shares
+	Amount string `json:"amount"`
+}
+
+type CreateSplitRequest struct {
+	// WalletID is the originator's wallet that receives the shares
+	WalletID    string `json:"wallet_id" binding:"required"`
+	TotalAmount string `json:"total_amount" binding:"required"`
+	Description string `json:"description" binding:"max=255"`
+	// Mode is "equal" to divide the total between everyone or "custom" to use the given amounts
+	Mode         string       `json:"mode" binding:"required,oneof=equal custom"`
+	Participants []SplitShare `json:"participants" binding:"required,min=1,dive"`
+	// IncludeSelf counts the originator as one of the people sharing an equal split
+	IncludeSelf bool `json:"include_self"`
+}
+
+type ListSplitsRequest struct {
+	// Role is either "incoming" (shares the caller owes) or "outgoing" (splits the caller created)
+	Role string `json:"role" binding:"required,oneof=incoming outgoing"`
+}
+
+type SplitRequest struct {
+	SplitID string `json:"split_id" binding:"required"`
+}
+
+type PaySplitRequest struct {
+	SplitID  string `json:"split_id" binding:"required"`
+	WalletID string `json:"wallet_id" binding:"required"`
+}
+
+type RemindSplitRequest struct {
+	SplitID string `json:"split_id" binding:"required"`
+	// UserID limits the reminder to one participant, everyone pending is reminded otherwise
+	UserID string `json:"user_id"`
+}
+
+const (
+	SplitStatusOpen      = "open"
+	SplitStatusSettled   = "settled"
+	SplitStatusCancelled = "cancelled"
+
+	SplitParticipantPending   = "pending"
+	SplitParticipantPaid      = "paid"
+	SplitParticipantDeclined  = "declined"
+	SplitParticipantCancelled = "cancelled"
+
+	SplitModeEqual  = "equal"
+	SplitModeCustom = "custom"
+
+	// SplitReminderInterval is the minimum time between two reminders to the same participant
+	SplitReminderInterval = time.Hour
+)
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+const (
+	// StreamNotifyChannel is the PostgreSQL channel the ids of balance change domain
+	// events are notified on once they commit
+	StreamNotifyChannel = "wallet_activity"
+
+	// StreamSourceBus feeds the stream from the outbox relay of the process and
+	// StreamSourceNotify from PostgreSQL LISTEN/NOTIFY
+	StreamSourceBus    = "bus"
+	StreamSourceNotify = "notify"
+
+	// StreamReplayLimit is the page size of the events replayed after a reconnect
+	StreamReplayLimit = 200
+	// StreamBufferSize is how many events a subscriber may fall behind before it
+	// is dropped and has to reconnect
+	StreamBufferSize = 64
+	// StreamHeartbeatInterval keeps idle connections open through proxies
+	StreamHeartbeatInterval = 15 * time.Second
+	// StreamRetry is the reconnect delay suggested to clients, in milliseconds
+	StreamRetry = 3000
+)
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// ProviderTopUp is a top-up funded through an external provider. It is credited to
+// the wallet only after the provider confirms the money was collected.
+type ProviderTopUp struct {
+	ID                string     `db:"id"`
+	UserID            string     `db:"user_id"`
+	WalletID          string     `db:"wallet_id"`
+	Provider          string     `db:"provider"`
+	SourceType        string     `db:"source_type"`
+	Amount            int64      `db:"amount"`
+	Status            string     `db:"status"`
+	ProviderReference string     `db:"provider_reference"`
+	FailureReason     string     `db:"failure_reason"`
+	TransactionID     int64      `db:"transaction_id"`
+	CreatedAt         time.Time  `db:"created_at"`
+	UpdatedAt         time.Time  `db:"updated_at"`
+	CompletedAt       *time.Time `db:"completed_at"`
+}
+
+type InitiateTopUpRequest struct {
+	WalletID   string `json:"wallet_id" binding:"required"`
+	Amount     string `json:"amount" binding:"required"`
+	Provider   string `json:"provider" binding:"required"`
+	SourceType string `json:"source_type" binding:"required,oneof=card bank_transfer cash"`
+	Source     string `json:"source" binding:"max=128"`
+}
+
+type TopUpRequestByID struct {
+	TopUpID string `json:"topup_id" binding:"required"`
+}
+
+// A top-up is initiated, then pending at the provider and finally succeeded or
+// failed. Its ledger transaction moves along from created through pending to
+// completed or failed.
+const (
+	TopUpInitiated = "initiated"
+	TopUpPending   = "pending"
+	TopUpSucceeded = "succeeded"
+	TopUpFailed    = "failed"
+)
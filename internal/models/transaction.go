@@ -0,0 +1,113 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Transaction is a ledger entry of a wallet. Positive amounts are credits and
+// negative amounts are debits. Only completed and reversed transactions have
+// moved the balance, see CanTransitionTransaction.
+type Transaction struct {
+	ID                   int64           `db:"id"`
+	WalletID             string          `db:"wallet_id"`
+	Amount               int64           `db:"amount"`
+	CounterpartyWalletID string          `db:"counterparty_wallet_id"`
+	MerchantCategory     string          `db:"merchant_category"`
+	Reference            string          `db:"reference"`
+	Description          string          `db:"description"`
+	Category             string          `db:"category"`
+	Tags                 []string        `db:"tags"`
+	Metadata             json.RawMessage `db:"metadata"`
+	BalanceAfter         *int64          `db:"balance_after"`
+	Status               string          `db:"status"`
+	FailureReason        string          `db:"failure_reason"`
+	ReversedBy           *int64          `db:"reversed_by"`
+	PairID               *int64          `db:"pair_id"`
+	CreatedAt            time.Time       `db:"created_at"`
+	UpdatedAt            *time.Time      `db:"updated_at"`
+	PendingAt            *time.Time      `db:"pending_at"`
+	CompletedAt          *time.Time      `db:"completed_at"`
+	FailedAt             *time.Time      `db:"failed_at"`
+	ReversedAt           *time.Time      `db:"reversed_at"`
+}
+
+// TransactionFilter narrows the history of a wallet. Empty fields match everything,
+// a transaction must carry all of the Tags and contain the Metadata object. The
+// form tags name the query parameters of the /v2 history, which takes the wallet
+// from the path and the metadata as a JSON string.
+type TransactionFilter struct {
+	WalletID    string          `json:"wallet_id" form:"-" binding:"required"`
+	Type        string          `json:"type" form:"type" binding:"omitempty,oneof=credit debit"`
+	Status      string          `json:"status" form:"status" binding:"omitempty,oneof=created pending completed failed reversed"`
+	Category    string          `json:"category" form:"category"`
+	Tags        []string        `json:"tags" form:"tags"`
+	Description string          `json:"description" form:"description"`
+	Metadata    json.RawMessage `json:"metadata" form:"-" swaggertype:"object"`
+	From        *time.Time      `json:"from" form:"from"`
+	To          *time.Time      `json:"to" form:"to"`
+	MinAmount   string          `json:"min_amount" form:"min_amount"`
+	MaxAmount   string          `json:"max_amount" form:"max_amount"`
+	BeforeID    int64           `json:"before_id" form:"before_id" binding:"min=0"`
+	Limit       int             `json:"limit" form:"limit" binding:"min=0,max=200"`
+
+	// Parsed amount bounds in minor units, set by the service
+	MinAmountUnits int64 `json:"-" form:"-"`
+	MaxAmountUnits int64 `json:"-" form:"-"`
+}
+
+// UpdateTransactionRequest edits the user-facing notes of a transaction, fields
+// left out are kept. Metadata can't be edited, it records the context the
+// operation was made in.
+type UpdateTransactionRequest struct {
+	WalletID      string    `json:"wallet_id" binding:"required"`
+	TransactionID int64     `json:"transaction_id" binding:"required"`
+	Description   *string   `json:"description" binding:"omitempty,max=255"`
+	Category      *string   `json:"category" binding:"omitempty,max=32"`
+	Tags          *[]string `json:"tags"`
+}
+
+// ReverseTransactionRequest undoes a completed transaction. Both sides of a
+// transfer are reversed together.
+type ReverseTransactionRequest struct {
+	TransactionID int64  `json:"transaction_id" binding:"required"`
+	Reason        string `json:"reason" binding:"required,max=255"`
+}
+
+// A transaction is created, may wait as pending and then completes or fails. A
+// completed transaction can be reversed once.
+const (
+	TransactionCreated   = "created"
+	TransactionPending   = "pending"
+	TransactionCompleted = "completed"
+	TransactionFailed    = "failed"
+	TransactionReversed  = "reversed"
+)
+
+var transactionTransitions = map[string][]string{
+	TransactionCreated:   {TransactionPending, TransactionCompleted, TransactionFailed},
+	TransactionPending:   {TransactionCompleted, TransactionFailed},
+	TransactionCompleted: {TransactionReversed},
+}
+
+// CanTransitionTransaction reports whether a transaction may move from one status
+// to the other. Failed and reversed transactions are final.
+func CanTransitionTransaction(from, to string) bool {
+	for _, status := range transactionTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	TransactionTypeCredit = "credit"
+	TransactionTypeDebit  = "debit"
+
+	TransactionHistoryLimit = 50
+	MaxTransactionTags      = 10
+	MaxTransactionTagLength = 32
+	MaxMetadataSize         = 4096
+	MaxFailureReasonLength  = 255
+)
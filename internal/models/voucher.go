@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// VoucherBatch is a set of prepaid vouchers of the same amount, printed on
+// scratch cards or sold as gift codes
+type VoucherBatch struct {
+	ID           string     `db:"id"`
+	Name         string     `db:"name"`
+	Amount       int64      `db:"amount"`
+	Count        int        `db:"voucher_count"`
+	Status       string     `db:"status"`
+	ExpiresAt    *time.Time `db:"expires_at"`
+	RevokeReason string     `db:"revoke_reason"`
+	CreatedAt    time.Time  `db:"created_at"`
+	RevokedAt    *time.Time `db:"revoked_at"`
+}
+
+// Voucher is a single code of a batch. Amount, ExpiresAt and BatchStatus come
+// from the batch.
+type Voucher struct {
+	ID            string     `db:"id"`
+	BatchID       string     `db:"batch_id"`
+	CodeHint      string     `db:"code_hint"`
+	Status        string     `db:"status"`
+	Amount        int64      `db:"amount"`
+	RedeemedBy    string     `db:"redeemed_by"`
+	WalletID      string     `db:"wallet_id"`
+	TransactionID int64      `db:"transaction_id"`
+	RedeemedAt    *time.Time `db:"redeemed_at"`
+	ExpiresAt     *time.Time `db:"expires_at"`
+	BatchStatus   string     `db:"batch_status"`
+}
+
+// VoucherUsageReport counts the vouchers of a batch by status. Outstanding is
+// the value of vouchers that can still be redeemed.
+type VoucherUsageReport struct {
+	Batch             VoucherBatch
+	Available         int
+	Redeemed          int
+	RedeemedAmount    int64
+	OutstandingAmount int64
+	FirstRedeemedAt   *time.Time
+	LastRedeemedAt    *time.Time
+}
+
+type CreateVoucherBatchRequest struct {
+	Name      string     `json:"name" binding:"required,max=64"`
+	Amount    string     `json:"amount" binding:"required"`
+	Count     int        `json:"count" binding:"required,min=1,max=10000"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type VoucherBatchRequest struct {
+	BatchID string `json:"batch_id" binding:"required"`
+}
+
+type RevokeVoucherBatchRequest struct {
+	BatchID string `json:"batch_id" binding:"required"`
+	Reason  string `json:"reason" binding:"required,max=255"`
+}
+
+type RedeemVoucherRequest struct {
+	WalletID string `json:"wallet_id" binding:"required"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
+
+// Batches are active until revoked, a voucher is available until it is redeemed
+const (
+	VoucherBatchActive  = "active"
+	VoucherBatchRevoked = "revoked"
+
+	VoucherAvailable = "available"
+	VoucherRedeemed  = "redeemed"
+)
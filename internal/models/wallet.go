@@ -1,5 +1,7 @@
 package models
 
+import "encoding/json"
+
 type Wallet struct {
 	ID      string `db:"id"`
 	UserID  string `db:"user_id"`
@@ -7,8 +9,15 @@ type Wallet struct {
 }
 
 type TopUpRequest struct {
-	WalletID string `json:"wallet_id" binding:"required"`
-	Amount   string `json:"amount" binding:"required"`
+	WalletID    string          `json:"wallet_id" binding:"required"`
+	Amount      string          `json:"amount" binding:"required"`
+	Description string          `json:"description" binding:"max=255"`
+	Category    string          `json:"category" binding:"max=32"`
+	Tags        []string        `json:"tags"`
+	Metadata    json.RawMessage `json:"metadata" swaggertype:"object"`
+
+	// Reference is set internally for top-ups funded through a provider
+	Reference string `json:"-"`
 }
 
 type DigestRequest interface{}
@@ -20,4 +29,31 @@ type RequestModel struct {
 const (
 	MaxBalanceUnidentified = 1000000
 	MaxBalanceIdentified   = 10000000
-)
\ No newline at end of file
+)
+
+type TransferRequest struct {
+	WalletID         string          `json:"wallet_id" binding:"required"`
+	ToWalletID       string          `json:"to_wallet_id" binding:"required"`
+	Amount           string          `json:"amount" binding:"required"`
+	MerchantCategory string          `json:"merchant_category"`
+	Reference        string          `json:"reference" binding:"max=64"`
+	Description      string          `json:"description" binding:"max=255"`
+	Category         string          `json:"category" binding:"max=32"`
+	Tags             []string        `json:"tags"`
+	Metadata         json.RawMessage `json:"metadata" swaggertype:"object"`
+
+	// ApprovalID is set internally when a parent approves a child's transfer
+	ApprovalID string `json:"-"`
+}
+
+// TransactionDetails is the context stored along with ledger entries of an operation.
+// Category and Tags belong to the user who made the operation, so on transfers they
+// are saved only on the debit entry.
+type TransactionDetails struct {
+	MerchantCategory string          `db:"merchant_category"`
+	Reference        string          `db:"reference"`
+	Description      string          `db:"description"`
+	Category         string          `db:"category"`
+	Tags             []string        `db:"tags"`
+	Metadata         json.RawMessage `db:"metadata"`
+}
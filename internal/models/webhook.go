@@ -0,0 +1,81 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookSubscription sends events about the user's wallets to a partner URL
+type WebhookSubscription struct {
+	ID         string    `db:"id"`
+	UserID     string    `db:"user_id"`
+	URL        string    `db:"url"`
+	Secret     string    `db:"secret"`
+	EventTypes []string  `db:"event_types"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// WebhookDelivery is a single event queued for a subscription
+type WebhookDelivery struct {
+	ID             string          `db:"id"`
+	SubscriptionID string          `db:"subscription_id"`
+	EventID        int64           `db:"event_id"`
+	EventType      string          `db:"event_type"`
+	Payload        json.RawMessage `db:"payload"`
+	Status         string          `db:"status"`
+	Attempts       int             `db:"attempts"`
+	LastError      string          `db:"last_error"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at"`
+	CreatedAt      time.Time       `db:"created_at"`
+
+	// URL and Secret are filled from the subscription when a delivery is claimed
+	URL    string `db:"-"`
+	Secret string `db:"-"`
+}
+
+// WebhookAttempt is the log record of one HTTP call for a delivery
+type WebhookAttempt struct {
+	DeliveryID  string    `db:"delivery_id"`
+	StatusCode  int       `db:"status_code"`
+	Error       string    `db:"error"`
+	DurationMs  int64     `db:"duration_ms"`
+	AttemptedAt time.Time `db:"attempted_at"`
+}
+
+// WebhookDeadLetter is a delivery that failed every retry
+type WebhookDeadLetter struct {
+	ID             string          `db:"id"`
+	DeliveryID     string          `db:"delivery_id"`
+	SubscriptionID string          `db:"subscription_id"`
+	EventType      string          `db:"event_type"`
+	Payload        json.RawMessage `db:"payload"`
+	Attempts       int             `db:"attempts"`
+	LastError      string          `db:"last_error"`
+	ReplayedAt     *time.Time      `db:"replayed_at"`
+	CreatedAt      time.Time       `db:"created_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url"`
+	EventTypes []string `json:"event_types"`
+}
+
+type WebhookRequest struct {
+	SubscriptionID string `json:"subscription_id" binding:"required"`
+}
+
+type ReplayDeadLetterRequest struct {
+	DeadLetterID string `json:"dead_letter_id" binding:"required"`
+}
+
+const (
+	WebhookStatusPending   = "pending"
+	WebhookStatusDelivered = "delivered"
+	WebhookStatusDead      = "dead"
+
+	// WebhookMaxAttempts is how many times a delivery is tried before it goes to the dead-letter table
+	WebhookMaxAttempts = 8
+	// WebhookBaseBackoff is the delay before the first retry, doubled on every next one
+	WebhookBaseBackoff = 30 * time.Second
+	WebhookMaxBackoff  = 6 * time.Hour
+)
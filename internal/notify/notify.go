@@ -0,0 +1,46 @@
+// Package notify renders user notifications and sends them over SMS, email and
+// push.
+//
+// Every channel is reached through a Sender. Messages are rendered from the
+// templates of their kind before they are queued, so a retry sends exactly what
+// was queued. The Sink sender writes messages to a file or the console and stands
+// in for the real gateways offline.
+package notify
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Channels a notification can be sent over
+const (
+	ChannelSMS   = "sms"
+	ChannelEmail = "email"
+	ChannelPush  = "push"
+)
+
+// Channels lists every supported channel
+var Channels = []string{ChannelSMS, ChannelEmail, ChannelPush}
+
+var (
+	ErrUnknownChannel  = errors.New("unknown notification channel")
+	ErrUnknownTemplate = errors.New("no notification template for this kind")
+	ErrInvalidAddress  = errors.New("invalid address for the notification channel")
+)
+
+// Message is a rendered notification for one channel. Address is a phone number
+// for SMS, an email address or a device token for push. SMS ignores the subject.
+type Message struct {
+	ID      string
+	Channel string
+	Address string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages of a channel. An error means the message may be
+// retried.
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	data := map[string]string{"WalletID": "w1", "Amount": "12.50", "Balance": "100.00", "Counterparty": "w2"}
+
+	t.Run("Every kind has every channel", func(t *testing.T) {
+		for kind := range templates {
+			for _, channel := range Channels {
+				_, body, err := Render(kind, channel, data)
+				require.NoError(t, err, "%s/%s", kind, channel)
+				assert.NotEmpty(t, body)
+			}
+		}
+	})
+
+	t.Run("Email", func(t *testing.T) {
+		subject, body, err := Render(KindWalletDebited, ChannelEmail, data)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Payment from your wallet", subject)
+		assert.Contains(t, body, "12.50 was paid from your wallet w1 to wallet w2")
+	})
+
+	t.Run("SMS fits one message", func(t *testing.T) {
+		subject, body, err := Render(KindWalletCredited, ChannelSMS, map[string]string{
+			"WalletID": "123e4567-e89b-12d3-a456-426614174000", "Amount": "100000.00", "Balance": "100000.00",
+		})
+
+		require.NoError(t, err)
+		assert.Empty(t, subject)
+		assert.LessOrEqual(t, len(body), 160)
+	})
+
+	t.Run("Unknown kind", func(t *testing.T) {
+		_, _, err := Render("wallet.closed", ChannelSMS, data)
+		assert.ErrorIs(t, err, ErrUnknownTemplate)
+	})
+
+	t.Run("Unknown channel", func(t *testing.T) {
+		_, _, err := Render(KindWalletDebited, "fax", data)
+		assert.ErrorIs(t, err, ErrUnknownChannel)
+	})
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		channel, address, want string
+		err                    error
+	}{
+		{ChannelSMS, "+992 90-123-4567", "+992901234567", nil},
+		{ChannelSMS, "901234567", "", ErrInvalidAddress},
+		{ChannelEmail, " user@example.com ", "user@example.com", nil},
+		{ChannelEmail, "User <user@example.com>", "", ErrInvalidAddress},
+		{ChannelEmail, "not-an-email", "", ErrInvalidAddress},
+		{ChannelPush, "device-token-1", "device-token-1", nil},
+		{ChannelPush, "  ", "", ErrInvalidAddress},
+		{"fax", "123", "", ErrUnknownChannel},
+	}
+	for _, tc := range tests {
+		t.Run(tc.channel+" "+tc.address, func(t *testing.T) {
+			got, err := NormalizeAddress(tc.channel, tc.address)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestSink(t *testing.T) {
+	t.Run("Writes a JSON line per message", func(t *testing.T) {
+		var out bytes.Buffer
+		sink := NewSink(&out)
+
+		require.NoError(t, sink.Send(context.Background(), Message{ID: "n1", Channel: ChannelPush, Address: "token", Subject: "Hi", Body: "+1.00"}))
+		require.NoError(t, sink.Send(context.Background(), Message{ID: "n2", Channel: ChannelSMS, Address: "+992901234567", Body: "text"}))
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 2)
+		var line sinkLine
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &line))
+		assert.Equal(t, "n2", line.ID)
+		assert.Equal(t, ChannelSMS, line.Channel)
+		assert.Equal(t, "text", line.Body)
+	})
+
+	t.Run("File sink appends", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "notifications.log")
+		for i := 0; i < 2; i++ {
+			sink, err := NewFileSink(path)
+			require.NoError(t, err)
+			require.NoError(t, sink.Send(context.Background(), Message{ID: "n", Channel: ChannelEmail, Body: "body"}))
+		}
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, 2, strings.Count(string(content), "\n"))
+	})
+
+	t.Run("Cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.ErrorIs(t, NewSink(&bytes.Buffer{}).Send(ctx, Message{}), context.Canceled)
+	})
+}
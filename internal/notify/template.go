@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"bytes"
+	"net/mail"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// Notification kinds
+const (
+	KindWalletToppedUp  = "wallet.topped_up"
+	KindWalletDebited   = "wallet.debited"
+	KindWalletCredited  = "wallet.credited"
+	KindBudgetThreshold = "budget.threshold"
+	KindApprovalRequest = "approval.requested"
+	KindApprovalDecided = "approval.decided"
+	KindShareRequested  = "split.share_requested"
+	KindShareReminder   = "split.share_reminder"
+)
+
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+func newTemplate(subject, body string) messageTemplate {
+	return messageTemplate{
+		subject: template.Must(template.New("subject").Parse(subject)),
+		body:    template.Must(template.New("body").Parse(body)),
+	}
+}
+
+// templates are keyed by kind and channel. SMS bodies are kept within a single
+// 160 character message for the usual values.
+var templates = map[string]map[string]messageTemplate{
+	KindWalletToppedUp: {
+		ChannelSMS:   newTemplate("", "Wallet {{.WalletID}} topped up by {{.Amount}}. Balance: {{.Balance}}"),
+		ChannelEmail: newTemplate("Your wallet was topped up", "Hello,\n\nyour wallet {{.WalletID}} was topped up by {{.Amount}}.\nThe balance is now {{.Balance}}.\n"),
+		ChannelPush:  newTemplate("Wallet topped up", "+{{.Amount}}, balance {{.Balance}}"),
+	},
+	KindWalletDebited: {
+		ChannelSMS:   newTemplate("", "Wallet {{.WalletID}} debited {{.Amount}}. Balance: {{.Balance}}"),
+		ChannelEmail: newTemplate("Payment from your wallet", "Hello,\n\n{{.Amount}} was paid from your wallet {{.WalletID}}{{if .Counterparty}} to wallet {{.Counterparty}}{{end}}.\nThe balance is now {{.Balance}}.\n"),
+		ChannelPush:  newTemplate("Payment sent", "-{{.Amount}}, balance {{.Balance}}"),
+	},
+	KindWalletCredited: {
+		ChannelSMS:   newTemplate("", "Wallet {{.WalletID}} received {{.Amount}}. Balance: {{.Balance}}"),
+		ChannelEmail: newTemplate("Money received", "Hello,\n\nyour wallet {{.WalletID}} received {{.Amount}}{{if .Counterparty}} from wallet {{.Counterparty}}{{end}}.\nThe balance is now {{.Balance}}.\n"),
+		ChannelPush:  newTemplate("Money received", "+{{.Amount}}, balance {{.Balance}}"),
+	},
+	KindBudgetThreshold: {
+		ChannelSMS:   newTemplate("", "You have spent {{.Spent}} of your {{.Amount}} {{.Category}} budget ({{.Threshold}}%)."),
+		ChannelEmail: newTemplate("{{.Threshold}}% of your {{.Category}} budget spent", "Hello,\n\nyou have spent {{.Spent}} of your {{.Amount}} budget for {{.Category}} in {{.Period}}.\n"),
+		ChannelPush:  newTemplate("{{.Category}} budget at {{.Threshold}}%", "{{.Spent}} of {{.Amount}} spent"),
+	},
+	KindApprovalRequest: {
+		ChannelSMS:   newTemplate("", "Your child asks to pay {{.Amount}} to wallet {{.ToWalletID}}. Approval {{.ApprovalID}}."),
+		ChannelEmail: newTemplate("Your child asks to pay {{.Amount}}", "Hello,\n\nyour child asks to pay {{.Amount}} from wallet {{.WalletID}} to wallet {{.ToWalletID}}.\nPlease approve or reject request {{.ApprovalID}}.\n"),
+		ChannelPush:  newTemplate("Approval needed", "{{.Amount}} to wallet {{.ToWalletID}}"),
+	},
+	KindApprovalDecided: {
+		ChannelSMS:   newTemplate("", "Your payment of {{.Amount}} to wallet {{.ToWalletID}} was {{.Status}}."),
+		ChannelEmail: newTemplate("Your payment was {{.Status}}", "Hello,\n\nyour payment of {{.Amount}} to wallet {{.ToWalletID}} was {{.Status}} by your parent.\n"),
+		ChannelPush:  newTemplate("Payment {{.Status}}", "{{.Amount}} to wallet {{.ToWalletID}}"),
+	},
+	KindShareRequested: {
+		ChannelSMS:   newTemplate("", "You owe {{.Amount}} for {{.Description}}. Split {{.SplitID}}."),
+		ChannelEmail: newTemplate("You owe {{.Amount}} for {{.Description}}", "Hello,\n\nyour share of {{.Description}} is {{.Amount}}.\nPlease pay split {{.SplitID}} from your wallet.\n"),
+		ChannelPush:  newTemplate("Your share of {{.Description}}", "{{.Amount}} to pay"),
+	},
+	KindShareReminder: {
+		ChannelSMS:   newTemplate("", "Reminder: you owe {{.Amount}} for {{.Description}}. Split {{.SplitID}}."),
+		ChannelEmail: newTemplate("Reminder: you owe {{.Amount}} for {{.Description}}", "Hello,\n\nyour share of {{.Description}} is still unpaid: {{.Amount}}.\nPlease pay split {{.SplitID}} from your wallet.\n"),
+		ChannelPush:  newTemplate("Reminder: {{.Description}}", "{{.Amount}} still to pay"),
+	},
+}
+
+// Render fills the template of the kind for the channel with data
+func Render(kind, channel string, data any) (subject, body string, err error) {
+	if !isChannel(channel) {
+		return "", "", ErrUnknownChannel
+	}
+	t, ok := templates[kind][channel]
+	if !ok {
+		return "", "", ErrUnknownTemplate
+	}
+
+	var buf bytes.Buffer
+	if err := t.subject.Execute(&buf, data); err != nil {
+		return "", "", err
+	}
+	subject = buf.String()
+
+	buf.Reset()
+	if err := t.body.Execute(&buf, data); err != nil {
+		return "", "", err
+	}
+
+	return subject, buf.String(), nil
+}
+
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// NormalizeAddress checks the address fits the channel: an E.164 phone number for
+// SMS, an email address or a device token for push
+func NormalizeAddress(channel, address string) (string, error) {
+	address = strings.TrimSpace(address)
+	switch channel {
+	case ChannelSMS:
+		address = strings.NewReplacer(" ", "", "-", "").Replace(address)
+		if !phonePattern.MatchString(address) {
+			return "", ErrInvalidAddress
+		}
+	case ChannelEmail:
+		parsed, err := mail.ParseAddress(address)
+		if err != nil || parsed.Name != "" {
+			return "", ErrInvalidAddress
+		}
+		address = parsed.Address
+	case ChannelPush:
+		if address == "" || strings.ContainsAny(address, " \t\n") {
+			return "", ErrInvalidAddress
+		}
+	default:
+		return "", ErrUnknownChannel
+	}
+
+	return address, nil
+}
+
+func isChannel(channel string) bool {
+	for _, c := range Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
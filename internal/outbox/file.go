@@ -0,0 +1,42 @@
+package outbox
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// FileSink appends every event as a JSON line to a file
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: file}, nil
+}
+
+// Publish writes the event and syncs the file, so a published event survives a crash
+func (s *FileSink) Publish(ctx context.Context, event models.DomainEvent) error {
+	line, err := Encode(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
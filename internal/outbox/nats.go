@@ -0,0 +1,109 @@
+package outbox
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// DefaultSubject prefixes the subjects events are published under, followed by
+// the event type
+const DefaultSubject = "wallet.events"
+
+const natsTimeout = 10 * time.Second
+
+// NATSSink publishes events to a NATS server under "<subject>.<event type>".
+// Every publish is flushed, so an event counts as published only once the
+// server has processed it.
+type NATSSink struct {
+	address string
+	subject string
+	user    *url.Userinfo
+
+	mu   sync.Mutex
+	conn *nats.Conn
+}
+
+// NewNATSSink connects lazily to the server of u. The URL path sets the subject
+// prefix, its user info the credentials: a user and password, or a token.
+func NewNATSSink(u *url.URL) *NATSSink {
+	subject := strings.Trim(u.Path, "/")
+	if subject == "" {
+		subject = DefaultSubject
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "4222")
+	}
+
+	return &NATSSink{address: address, subject: subject, user: u.User}
+}
+
+func (s *NATSSink) Publish(ctx context.Context, event models.DomainEvent) error {
+	body, err := Encode(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil || s.conn.IsClosed() {
+		if err := s.dial(); err != nil {
+			return errors.Wrap(err, "unable to connect to NATS")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, natsTimeout)
+	defer cancel()
+
+	err = s.conn.Publish(s.subject+"."+event.Type, body)
+	if err == nil {
+		err = s.conn.FlushWithContext(ctx)
+	}
+	// the server answers a rejected publish, a permissions violation, with an
+	// -ERR before the PONG of the flush. The client keeps it as the last error.
+	if err == nil {
+		err = s.conn.LastError()
+	}
+	if err != nil {
+		s.close()
+		return errors.Wrap(err, "unable to publish to NATS")
+	}
+
+	return nil
+}
+
+func (s *NATSSink) dial() error {
+	options := []nats.Option{nats.Name("alif-task-outbox"), nats.Timeout(natsTimeout)}
+	if s.user != nil {
+		if password, ok := s.user.Password(); ok {
+			options = append(options, nats.UserInfo(s.user.Username(), password))
+		} else {
+			options = append(options, nats.Token(s.user.Username()))
+		}
+	}
+
+	conn, err := nats.Connect("nats://"+s.address, options...)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+
+	return nil
+}
+
+func (s *NATSSink) close() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.conn = nil
+}
@@ -0,0 +1,98 @@
+// Package outbox publishes domain events from the transactional outbox to the
+// systems of other teams.
+//
+// The relay hands every event to the sinks of a Fanout and marks it published once
+// all of them returned without error. A failed publish is retried, so sinks deliver
+// at least once and consumers deduplicate by the event id. Events of a wallet are
+// handed over one at a time, in order.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+var ErrUnsupportedSink = errors.New("unsupported outbox sink, use file, http(s) or nats")
+
+// Sink publishes domain events
+type Sink interface {
+	Publish(ctx context.Context, event models.DomainEvent) error
+}
+
+// Fanout publishes every event to all of its sinks in the order of their names.
+// The names of the sinks that published an event are kept with it, so a sink
+// failing doesn't stop the others and a retry goes only to the sinks that failed.
+type Fanout map[string]Sink
+
+// Publish hands the event to the sinks not named in published and returns the
+// names of all sinks that published it. The error is the failure of the first
+// sink that failed, the sinks after it are still tried.
+func (f Fanout) Publish(ctx context.Context, event models.DomainEvent, published []string) ([]string, error) {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var firstErr error
+	for _, name := range names {
+		if slices.Contains(published, name) {
+			continue
+		}
+		if err := f[name].Publish(ctx, event); err != nil {
+			if firstErr == nil {
+				firstErr = errors.Wrap(err, name)
+			}
+			continue
+		}
+		published = append(published, name)
+	}
+	return published, firstErr
+}
+
+// Envelope is how an event is published by every sink
+type Envelope struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// Encode returns the JSON envelope of the event
+func Encode(event models.DomainEvent) ([]byte, error) {
+	return json.Marshal(Envelope{
+		ID:          event.ID,
+		Type:        event.Type,
+		AggregateID: event.AggregateID,
+		OccurredAt:  event.CreatedAt.UTC(),
+		Payload:     event.Payload,
+	})
+}
+
+// NewSink picks the sink by the URL scheme: file:///path appends to a file,
+// http(s):// posts to a webhook signed with secret and nats://host:port/subject
+// publishes to a NATS server under the subject prefix.
+func NewSink(rawURL, secret string) (Sink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid outbox sink URL")
+	}
+
+	switch u.Scheme {
+	case "file":
+		return NewFileSink(u.Path)
+	case "http", "https":
+		return NewWebhookSink(rawURL, secret), nil
+	case "nats":
+		return NewNATSSink(u), nil
+	default:
+		return nil, ErrUnsupportedSink
+	}
+}
@@ -0,0 +1,220 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(id int64) models.DomainEvent {
+	return models.DomainEvent{
+		ID:          id,
+		AggregateID: "wallet1",
+		Type:        models.DomainEventWalletToppedUp,
+		Payload:     json.RawMessage(`{"wallet_id":"wallet1","amount":500}`),
+		CreatedAt:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	t.Run("By scheme", func(t *testing.T) {
+		sink, err := NewSink("file://"+filepath.Join(t.TempDir(), "events.log"), "")
+		require.NoError(t, err)
+		assert.IsType(t, &FileSink{}, sink)
+
+		sink, err = NewSink("https://events.example.com/hook", "secret")
+		require.NoError(t, err)
+		assert.IsType(t, &WebhookSink{}, sink)
+
+		sink, err = NewSink("nats://token@localhost/payments", "")
+		require.NoError(t, err)
+		require.IsType(t, &NATSSink{}, sink)
+		nats := sink.(*NATSSink)
+		assert.Equal(t, "localhost:4222", nats.address)
+		assert.Equal(t, "payments", nats.subject)
+		assert.Equal(t, "token", nats.user.Username())
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := NewSink("kafka://localhost:9092", "")
+		assert.ErrorIs(t, err, ErrUnsupportedSink)
+	})
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	defer sink.Close()
+
+	require.NoError(t, sink.Publish(context.Background(), testEvent(1)))
+	require.NoError(t, sink.Publish(context.Background(), testEvent(2)))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &envelope))
+	assert.Equal(t, int64(2), envelope.ID)
+	assert.Equal(t, models.DomainEventWalletToppedUp, envelope.Type)
+	assert.JSONEq(t, `{"wallet_id":"wallet1","amount":500}`, string(envelope.Payload))
+}
+
+func TestWebhookSink(t *testing.T) {
+	t.Run("Signed", func(t *testing.T) {
+		var received http.Header
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header
+			body, _ = io.ReadAll(r.Body)
+		}))
+		defer server.Close()
+
+		require.NoError(t, NewWebhookSink(server.URL, "secret").Publish(context.Background(), testEvent(7)))
+
+		assert.Equal(t, "7", received.Get("X-Event-Id"))
+		assert.Equal(t, models.DomainEventWalletToppedUp, received.Get("X-Event-Type"))
+		assert.Equal(t, "sha256="+Sign("secret", received.Get("X-Event-Timestamp"), body), received.Get("X-Event-Signature"))
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		err := NewWebhookSink(server.URL, "secret").Publish(context.Background(), testEvent(7))
+		assert.ErrorContains(t, err, "503")
+	})
+}
+
+// fakeNATS accepts one client and records what it publishes
+func fakeNATS(t *testing.T, reject bool) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	published := make(chan string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		conn.Write([]byte("INFO {\"server_id\":\"test\",\"max_payload\":1048576}\r\n"))
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+			case line == "PING":
+				conn.Write([]byte("PONG\r\n"))
+			case strings.HasPrefix(line, "PUB "):
+				payload, _ := reader.ReadString('\n')
+				if reject {
+					conn.Write([]byte("-ERR 'Permissions Violation for Publish'\r\n"))
+					continue
+				}
+				published <- strings.Fields(line)[1] + " " + strings.TrimRight(payload, "\r\n")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), published
+}
+
+func TestNATSSink(t *testing.T) {
+	t.Run("Published under the event subject", func(t *testing.T) {
+		address, published := fakeNATS(t, false)
+		sink := NewNATSSink(&url.URL{Scheme: "nats", Host: address})
+
+		require.NoError(t, sink.Publish(context.Background(), testEvent(3)))
+		require.NoError(t, sink.Publish(context.Background(), testEvent(4)))
+
+		first := <-published
+		assert.True(t, strings.HasPrefix(first, "wallet.events.WalletToppedUp {"), first)
+		assert.Contains(t, <-published, `"id":4`)
+	})
+
+	t.Run("Server error", func(t *testing.T) {
+		address, _ := fakeNATS(t, true)
+		sink := NewNATSSink(&url.URL{Scheme: "nats", Host: address})
+
+		err := sink.Publish(context.Background(), testEvent(3))
+		assert.ErrorContains(t, err, "Permissions Violation")
+		assert.Nil(t, sink.conn)
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		sink := NewNATSSink(&url.URL{Scheme: "nats", Host: "127.0.0.1:1"})
+
+		assert.Error(t, sink.Publish(context.Background(), testEvent(3)))
+	})
+}
+
+type sinkFunc func(event models.DomainEvent) error
+
+func (f sinkFunc) Publish(ctx context.Context, event models.DomainEvent) error {
+	return f(event)
+}
+
+func TestFanout(t *testing.T) {
+	var published []string
+	sink := func(name string, err error) Sink {
+		return sinkFunc(func(event models.DomainEvent) error {
+			published = append(published, name)
+			return err
+		})
+	}
+
+	t.Run("Every sink in the order of names", func(t *testing.T) {
+		published = nil
+		names, err := Fanout{"webhooks": sink("webhooks", nil), "nats": sink("nats", nil)}.Publish(context.Background(), testEvent(1), nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"nats", "webhooks"}, published)
+		assert.Equal(t, []string{"nats", "webhooks"}, names)
+	})
+
+	t.Run("Failure doesn't stop the other sinks", func(t *testing.T) {
+		published = nil
+		names, err := Fanout{"budgets": sink("budgets", io.ErrClosedPipe), "nats": sink("nats", io.EOF), "webhooks": sink("webhooks", nil)}.
+			Publish(context.Background(), testEvent(1), nil)
+
+		assert.ErrorIs(t, err, io.ErrClosedPipe)
+		assert.Equal(t, "budgets: io: read/write on closed pipe", err.Error())
+		assert.Equal(t, []string{"budgets", "nats", "webhooks"}, published)
+		assert.Equal(t, []string{"webhooks"}, names)
+	})
+
+	t.Run("Sinks that published are skipped", func(t *testing.T) {
+		published = nil
+		names, err := Fanout{"nats": sink("nats", nil), "webhooks": sink("webhooks", nil)}.
+			Publish(context.Background(), testEvent(1), []string{"webhooks"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"nats"}, published)
+		assert.Equal(t, []string{"webhooks", "nats"}, names)
+	})
+}
@@ -0,0 +1,216 @@
+// Package payout reads bulk payout files and writes their result reports.
+//
+// A CSV file starts with a header naming the wallet_id and amount columns and an
+// optional description column, in any order. A JSON file is an array of objects
+// with the same fields. Amounts are decimal strings such as "150.00".
+package payout
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+
+	// MaxRows is the largest number of rows accepted in one file
+	MaxRows = 10000
+	// MaxDescriptionLength matches the size of the description column
+	MaxDescriptionLength = 255
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported payout file format")
+	ErrEmptyFile         = errors.New("payout file has no rows")
+	ErrTooManyRows       = errors.Errorf("payout file has more than %d rows", MaxRows)
+	ErrMissingColumn     = errors.New("payout file is missing a required column")
+)
+
+// Line is a valid row of a payout file
+type Line struct {
+	Number      int
+	WalletID    string
+	Amount      int64
+	Description string
+}
+
+// LineError explains why a row of a payout file was rejected
+type LineError struct {
+	Number int    `json:"row"`
+	Error  string `json:"error"`
+}
+
+type jsonLine struct {
+	WalletID    string `json:"wallet_id"`
+	Amount      string `json:"amount"`
+	Description string `json:"description"`
+}
+
+// Parse reads every row of the file. Rows that can't be paid out are returned as
+// line errors so the whole file can be fixed at once. An error is returned only
+// when the file itself can't be read.
+func Parse(format string, r io.Reader) ([]Line, []LineError, error) {
+	var raw []jsonLine
+	var err error
+
+	switch format {
+	case FormatCSV:
+		raw, err = readCSV(r)
+	case FormatJSON:
+		raw, err = readJSON(r)
+	default:
+		return nil, nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(raw) == 0 {
+		return nil, nil, ErrEmptyFile
+	}
+	if len(raw) > MaxRows {
+		return nil, nil, ErrTooManyRows
+	}
+
+	lines := make([]Line, 0, len(raw))
+	var lineErrors []LineError
+	seen := make(map[string]int, len(raw))
+	for i, row := range raw {
+		number := i + 1
+		line, err := validate(number, row)
+		if err == nil {
+			if first, ok := seen[line.WalletID]; ok {
+				err = fmt.Errorf("wallet is already paid in row %d", first)
+			}
+		}
+		if err != nil {
+			lineErrors = append(lineErrors, LineError{Number: number, Error: err.Error()})
+			continue
+		}
+		seen[line.WalletID] = number
+		lines = append(lines, line)
+	}
+
+	return lines, lineErrors, nil
+}
+
+func validate(number int, row jsonLine) (Line, error) {
+	walletID := strings.TrimSpace(row.WalletID)
+	if _, err := uuid.Parse(walletID); err != nil {
+		return Line{}, errors.New("invalid wallet id")
+	}
+
+	amount, err := models.ParseAmount(strings.TrimSpace(row.Amount))
+	if err != nil {
+		return Line{}, err
+	}
+
+	description := strings.TrimSpace(row.Description)
+	if len(description) > MaxDescriptionLength {
+		return Line{}, errors.New("description is too long")
+	}
+
+	return Line{Number: number, WalletID: walletID, Amount: amount, Description: description}, nil
+}
+
+func readCSV(r io.Reader) ([]jsonLine, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrEmptyFile
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read payout file")
+	}
+
+	columns := map[string]int{"wallet_id": -1, "amount": -1, "description": -1}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := columns[name]; ok {
+			columns[name] = i
+		}
+	}
+	if columns["wallet_id"] < 0 || columns["amount"] < 0 {
+		return nil, ErrMissingColumn
+	}
+
+	field := func(record []string, column string) string {
+		if i := columns[column]; i >= 0 && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var rows []jsonLine
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read payout file")
+		}
+		if len(rows) == MaxRows {
+			return nil, ErrTooManyRows
+		}
+		rows = append(rows, jsonLine{
+			WalletID:    field(record, "wallet_id"),
+			Amount:      field(record, "amount"),
+			Description: field(record, "description"),
+		})
+	}
+
+	return rows, nil
+}
+
+func readJSON(r io.Reader) ([]jsonLine, error) {
+	var rows []jsonLine
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, errors.Wrap(err, "unable to read payout file")
+	}
+	return rows, nil
+}
+
+// WriteReport writes the result of every row of a batch as CSV
+func WriteReport(w io.Writer, rows []models.PayoutRow) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"row", "wallet_id", "amount", "description", "status", "transaction_id", "error", "processed_at"}); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		var transactionID, processedAt string
+		if row.TransactionID != 0 {
+			transactionID = strconv.FormatInt(row.TransactionID, 10)
+		}
+		if row.ProcessedAt != nil {
+			processedAt = row.ProcessedAt.UTC().Format("2006-01-02T15:04:05Z")
+		}
+		err := writer.Write([]string{
+			strconv.Itoa(row.RowNumber),
+			row.WalletID,
+			models.FormatAmount(row.Amount),
+			row.Description,
+			row.Status,
+			transactionID,
+			row.Error,
+			processedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,83 @@
+package payout
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	first := uuid.New().String()
+	second := uuid.New().String()
+
+	t.Run("CSV with columns in any order", func(t *testing.T) {
+		file := "amount,wallet_id,description\n100.50," + first + ",May salary\n20," + second + ",\n"
+
+		lines, lineErrors, err := Parse(FormatCSV, strings.NewReader(file))
+
+		require.NoError(t, err)
+		assert.Empty(t, lineErrors)
+		assert.Equal(t, []Line{
+			{Number: 1, WalletID: first, Amount: 10050, Description: "May salary"},
+			{Number: 2, WalletID: second, Amount: 2000},
+		}, lines)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		file := `[{"wallet_id":"` + first + `","amount":"5.00"}]`
+
+		lines, lineErrors, err := Parse(FormatJSON, strings.NewReader(file))
+
+		require.NoError(t, err)
+		assert.Empty(t, lineErrors)
+		assert.Equal(t, []Line{{Number: 1, WalletID: first, Amount: 500}}, lines)
+	})
+
+	t.Run("Every invalid row is reported", func(t *testing.T) {
+		file := "wallet_id,amount\nnot-a-wallet,10\n" + first + ",-5\n" + second + ",1\n" + second + ",2\n"
+
+		lines, lineErrors, err := Parse(FormatCSV, strings.NewReader(file))
+
+		require.NoError(t, err)
+		assert.Len(t, lines, 1)
+		require.Len(t, lineErrors, 3)
+		assert.Equal(t, 1, lineErrors[0].Number)
+		assert.Equal(t, 2, lineErrors[1].Number)
+		assert.Equal(t, "wallet is already paid in row 3", lineErrors[2].Error)
+	})
+
+	t.Run("File errors", func(t *testing.T) {
+		_, _, err := Parse("xlsx", strings.NewReader(""))
+		assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+		_, _, err = Parse(FormatCSV, strings.NewReader("wallet,sum\n"))
+		assert.ErrorIs(t, err, ErrMissingColumn)
+
+		_, _, err = Parse(FormatCSV, strings.NewReader("wallet_id,amount\n"))
+		assert.ErrorIs(t, err, ErrEmptyFile)
+
+		_, _, err = Parse(FormatJSON, strings.NewReader("{"))
+		assert.Error(t, err)
+	})
+}
+
+func TestWriteReport(t *testing.T) {
+	processedAt := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	var report bytes.Buffer
+
+	err := WriteReport(&report, []models.PayoutRow{
+		{RowNumber: 1, WalletID: "w-1", Amount: 10050, Status: models.PayoutRowSucceeded, TransactionID: 7, ProcessedAt: &processedAt},
+		{RowNumber: 2, WalletID: "w-2", Amount: 100, Status: models.PayoutRowFailed, Error: "operation would exceed maximum balance", ProcessedAt: &processedAt},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "row,wallet_id,amount,description,status,transaction_id,error,processed_at\n"+
+		"1,w-1,100.50,,succeeded,7,,2024-05-01T09:30:00Z\n"+
+		"2,w-2,1.00,,failed,,operation would exceed maximum balance,2024-05-01T09:30:00Z\n", report.String())
+}
@@ -0,0 +1,300 @@
+// Package qr encodes and decodes EMVCo-style merchant presented QR payloads.
+//
+// A payload is a sequence of TLV fields: a two digit tag, a two digit length and
+// the value. Wallet specific data lives in merchant account template 26, the
+// server signature and expiry in template 80 and the payload ends with a
+// CRC16-CCITT checksum in tag 63.
+package qr
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	tagPayloadFormat   = "00"
+	tagInitiation      = "01"
+	tagMerchantAccount = "26"
+	tagCategory        = "52"
+	tagCurrency        = "53"
+	tagAmount          = "54"
+	tagCountry         = "58"
+	tagMerchantName    = "59"
+	tagMerchantCity    = "60"
+	tagAdditionalData  = "62"
+	tagCRC             = "63"
+	tagSignature       = "80"
+
+	subTagGUI       = "00"
+	subTagWalletID  = "01"
+	subTagReference = "05"
+	subTagExpiresAt = "01"
+	subTagSignature = "02"
+
+	initiationStatic  = "11"
+	initiationDynamic = "12"
+
+	// GUI identifies payloads issued by this wallet
+	GUI = "tj.alif.ewallet"
+
+	DefaultCurrency = "972"
+	DefaultCountry  = "TJ"
+)
+
+var (
+	ErrMalformed        = errors.New("malformed QR payload")
+	ErrInvalidCRC       = errors.New("QR payload checksum mismatch")
+	ErrInvalidSignature = errors.New("QR payload signature is invalid")
+	ErrUnknownIssuer    = errors.New("QR payload was issued by another provider")
+)
+
+// Payload is the decoded content of a QR code. Static payloads carry no amount
+// and can be paid many times, dynamic ones are bound to an amount and reference.
+type Payload struct {
+	Dynamic          bool
+	WalletID         string
+	MerchantName     string
+	MerchantCity     string
+	MerchantCategory string
+	Currency         string
+	Amount           int64
+	Reference        string
+	ExpiresAt        time.Time
+}
+
+// Encode serializes the payload, signs it with key and appends the checksum
+func Encode(p Payload, key []byte) (string, error) {
+	if p.WalletID == "" {
+		return "", errors.Wrap(ErrMalformed, "wallet id is required")
+	}
+	if p.Dynamic && p.Amount <= 0 {
+		return "", errors.Wrap(ErrMalformed, "dynamic payload requires an amount")
+	}
+
+	initiation := initiationStatic
+	if p.Dynamic {
+		initiation = initiationDynamic
+	}
+
+	currency := p.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	category := p.MerchantCategory
+	if category == "" {
+		category = "0000"
+	}
+
+	var amount, additionalData string
+	if p.Amount > 0 {
+		amount = formatAmount(p.Amount)
+	}
+	if p.Reference != "" {
+		additionalData = tlv(subTagReference, p.Reference)
+	}
+
+	// Empty values are left out of the payload
+	fields := []struct{ tag, value string }{
+		{tagPayloadFormat, "01"},
+		{tagInitiation, initiation},
+		{tagMerchantAccount, tlv(subTagGUI, GUI) + tlv(subTagWalletID, p.WalletID)},
+		{tagCategory, category},
+		{tagCurrency, currency},
+		{tagAmount, amount},
+		{tagCountry, DefaultCountry},
+		{tagMerchantName, p.MerchantName},
+		{tagMerchantCity, p.MerchantCity},
+		{tagAdditionalData, additionalData},
+	}
+
+	var b strings.Builder
+
+	for _, field := range fields {
+		if field.value == "" {
+			continue
+		}
+		if len(field.value) > 99 {
+			return "", errors.Wrapf(ErrMalformed, "field %s is too long", field.tag)
+		}
+		b.WriteString(tlv(field.tag, field.value))
+	}
+
+	signed := b.String()
+	var expiresAt string
+	if !p.ExpiresAt.IsZero() {
+		expiresAt = strconv.FormatInt(p.ExpiresAt.Unix(), 10)
+	}
+	var signature string
+	if expiresAt != "" {
+		signature = tlv(subTagExpiresAt, expiresAt)
+	}
+	signature += tlv(subTagSignature, sign(signed+expiresAt, key))
+	b.WriteString(tlv(tagSignature, signature))
+
+	b.WriteString(tagCRC + "04")
+	b.WriteString(fmt.Sprintf("%04X", CRC16(b.String())))
+
+	return b.String(), nil
+}
+
+// Decode parses the payload and verifies its checksum and signature.
+// Expiry is returned as is, checking it is up to the caller.
+func Decode(payload string, key []byte) (*Payload, error) {
+	if len(payload) < 8 || payload[len(payload)-8:len(payload)-4] != tagCRC+"04" {
+		return nil, errors.Wrap(ErrMalformed, "checksum is missing")
+	}
+
+	crc, err := strconv.ParseUint(payload[len(payload)-4:], 16, 16)
+	if err != nil {
+		return nil, errors.Wrap(ErrMalformed, "checksum is not hex")
+	}
+	if uint16(crc) != CRC16(payload[:len(payload)-4]) {
+		return nil, ErrInvalidCRC
+	}
+
+	fields, order, err := parse(payload[:len(payload)-8])
+	if err != nil {
+		return nil, err
+	}
+	if len(order) == 0 || order[0] != tagPayloadFormat || fields[tagPayloadFormat] != "01" {
+		return nil, errors.Wrap(ErrMalformed, "unsupported payload format")
+	}
+
+	account, _, err := parse(fields[tagMerchantAccount])
+	if err != nil {
+		return nil, err
+	}
+	if account[subTagGUI] != GUI {
+		return nil, ErrUnknownIssuer
+	}
+
+	// The signature template must be the last field before the checksum and
+	// covers everything in front of it
+	if order[len(order)-1] != tagSignature {
+		return nil, ErrInvalidSignature
+	}
+	signatureFields, _, err := parse(fields[tagSignature])
+	if err != nil {
+		return nil, err
+	}
+	signatureStart := len(payload) - 8 - 4 - len(fields[tagSignature])
+	expected := sign(payload[:signatureStart]+signatureFields[subTagExpiresAt], key)
+	if !hmac.Equal([]byte(expected), []byte(signatureFields[subTagSignature])) {
+		return nil, ErrInvalidSignature
+	}
+
+	p := &Payload{
+		Dynamic:          fields[tagInitiation] == initiationDynamic,
+		WalletID:         account[subTagWalletID],
+		MerchantName:     fields[tagMerchantName],
+		MerchantCity:     fields[tagMerchantCity],
+		MerchantCategory: fields[tagCategory],
+		Currency:         fields[tagCurrency],
+	}
+	if p.MerchantCategory == "0000" {
+		p.MerchantCategory = ""
+	}
+
+	if amount, ok := fields[tagAmount]; ok {
+		p.Amount, err = parseAmount(amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if additional, ok := fields[tagAdditionalData]; ok {
+		data, _, err := parse(additional)
+		if err != nil {
+			return nil, err
+		}
+		p.Reference = data[subTagReference]
+	}
+
+	if expiresAt, ok := signatureFields[subTagExpiresAt]; ok {
+		unix, err := strconv.ParseInt(expiresAt, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(ErrMalformed, "invalid expiry")
+		}
+		p.ExpiresAt = time.Unix(unix, 0)
+	}
+
+	return p, nil
+}
+
+// CRC16 computes the CRC16-CCITT (polynomial 0x1021, initial value 0xFFFF) checksum
+func CRC16(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func tlv(tag, value string) string {
+	return fmt.Sprintf("%s%02d%s", tag, len(value), value)
+}
+
+// parse splits TLV encoded data into a map of values and the order the tags appeared in
+func parse(data string) (map[string]string, []string, error) {
+	fields := make(map[string]string)
+	var order []string
+	for i := 0; i < len(data); {
+		if i+4 > len(data) {
+			return nil, nil, errors.Wrap(ErrMalformed, "truncated field header")
+		}
+
+		tag := data[i : i+2]
+		length, err := strconv.Atoi(data[i+2 : i+4])
+		if err != nil || length < 0 || i+4+length > len(data) {
+			return nil, nil, errors.Wrapf(ErrMalformed, "invalid length of field %s", tag)
+		}
+
+		if _, ok := fields[tag]; ok {
+			return nil, nil, errors.Wrapf(ErrMalformed, "duplicate field %s", tag)
+		}
+		fields[tag] = data[i+4 : i+4+length]
+		order = append(order, tag)
+		i += 4 + length
+	}
+
+	return fields, order, nil
+}
+
+func sign(data string, key []byte) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func formatAmount(amount int64) string {
+	return fmt.Sprintf("%d.%02d", amount/100, amount%100)
+}
+
+func parseAmount(amount string) (int64, error) {
+	whole, fraction, _ := strings.Cut(amount, ".")
+	if len(fraction) > 2 {
+		return 0, errors.Wrap(ErrMalformed, "invalid amount")
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+
+	units, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil || units <= 0 {
+		return 0, errors.Wrap(ErrMalformed, "invalid amount")
+	}
+	return units, nil
+}
@@ -0,0 +1,95 @@
+package qr
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRC16(t *testing.T) {
+	// Reference value of CRC16-CCITT-FALSE
+	assert.Equal(t, uint16(0x29B1), CRC16("123456789"))
+}
+
+func TestEncodeDecode(t *testing.T) {
+	key := []byte("secret")
+	walletID := uuid.New().String()
+
+	t.Run("Static payload", func(t *testing.T) {
+		payload, err := Encode(Payload{WalletID: walletID, MerchantName: "Coffee House", MerchantCity: "Dushanbe", MerchantCategory: "5814"}, key)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(payload, "000201010211"))
+
+		decoded, err := Decode(payload, key)
+
+		require.NoError(t, err)
+		assert.False(t, decoded.Dynamic)
+		assert.Equal(t, walletID, decoded.WalletID)
+		assert.Equal(t, "Coffee House", decoded.MerchantName)
+		assert.Equal(t, "5814", decoded.MerchantCategory)
+		assert.Equal(t, DefaultCurrency, decoded.Currency)
+		assert.Zero(t, decoded.Amount)
+		assert.True(t, decoded.ExpiresAt.IsZero())
+	})
+
+	t.Run("Dynamic payload", func(t *testing.T) {
+		expiresAt := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+		payload, err := Encode(Payload{Dynamic: true, WalletID: walletID, Amount: 12305, Reference: "order-17", ExpiresAt: expiresAt}, key)
+		require.NoError(t, err)
+		assert.Contains(t, payload, "5406123.05")
+
+		decoded, err := Decode(payload, key)
+
+		require.NoError(t, err)
+		assert.True(t, decoded.Dynamic)
+		assert.Equal(t, int64(12305), decoded.Amount)
+		assert.Equal(t, "order-17", decoded.Reference)
+		assert.True(t, expiresAt.Equal(decoded.ExpiresAt))
+	})
+
+	t.Run("Dynamic payload without amount", func(t *testing.T) {
+		_, err := Encode(Payload{Dynamic: true, WalletID: walletID}, key)
+
+		assert.ErrorIs(t, err, ErrMalformed)
+	})
+}
+
+func TestDecodeRejectsTampering(t *testing.T) {
+	key := []byte("secret")
+	payload, err := Encode(Payload{Dynamic: true, WalletID: uuid.New().String(), Amount: 10000, Reference: "ref"}, key)
+	require.NoError(t, err)
+
+	t.Run("Changed checksum", func(t *testing.T) {
+		broken := payload[:len(payload)-4] + "0000"
+
+		_, err := Decode(broken, key)
+
+		assert.ErrorIs(t, err, ErrInvalidCRC)
+	})
+
+	t.Run("Changed amount with recomputed checksum", func(t *testing.T) {
+		body := strings.Replace(payload[:len(payload)-4], "5406100.00", "5406001.00", 1)
+		forged := body + fmt.Sprintf("%04X", CRC16(body))
+
+		_, err := Decode(forged, key)
+
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("Another key", func(t *testing.T) {
+		_, err := Decode(payload, []byte("other"))
+
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("Garbage", func(t *testing.T) {
+		_, err := Decode("hello", key)
+
+		assert.ErrorIs(t, err, ErrMalformed)
+	})
+}
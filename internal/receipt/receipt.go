@@ -0,0 +1,52 @@
+// Package receipt signs and verifies transaction receipts.
+//
+// The signature is an HMAC-SHA256 over the receipt fields joined by newlines in a
+// fixed order, prefixed with the receipt version. Timestamps are signed in UTC
+// with nanosecond precision so a receipt read back from JSON verifies the same.
+package receipt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+// Version is signed along with the fields so the format can change later
+const Version = "v1"
+
+var ErrInvalidSignature = errors.New("receipt signature is invalid")
+
+// Sign returns the receipt signature as "v1=" followed by the hex HMAC
+func Sign(r models.Receipt, key []byte) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(canonical(r)))
+	return Version + "=" + hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify checks that the receipt was signed with key and wasn't changed since
+func Verify(r models.Receipt, key []byte) error {
+	if !hmac.Equal([]byte(Sign(r, key)), []byte(r.Signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func canonical(r models.Receipt) string {
+	return strings.Join([]string{
+		"receipt." + Version,
+		strconv.FormatInt(r.TransactionID, 10),
+		r.WalletID,
+		r.Type,
+		r.Amount,
+		r.Balance,
+		r.CounterpartyWalletID,
+		r.Reference,
+		r.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}, "\n")
+}
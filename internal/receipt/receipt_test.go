@@ -0,0 +1,49 @@
+package receipt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerify(t *testing.T) {
+	key := []byte("secret")
+	r := models.Receipt{
+		TransactionID: 42,
+		WalletID:      "9e323a19-73bf-4600-a049-7397dcea5751",
+		Type:          models.TransactionTypeCredit,
+		Amount:        "100.00",
+		Balance:       "1100.00",
+		CreatedAt:     time.Date(2024, 5, 1, 10, 30, 15, 123456000, time.FixedZone("TJT", 5*3600)),
+	}
+	r.Signature = Sign(r, key)
+
+	t.Run("Genuine receipt", func(t *testing.T) {
+		assert.NoError(t, Verify(r, key))
+	})
+
+	t.Run("Survives a JSON round trip", func(t *testing.T) {
+		data, err := json.Marshal(r)
+		require.NoError(t, err)
+
+		var decoded models.Receipt
+		require.NoError(t, json.Unmarshal(data, &decoded))
+
+		assert.NoError(t, Verify(decoded, key))
+	})
+
+	t.Run("Changed amount", func(t *testing.T) {
+		changed := r
+		changed.Amount = "1000.00"
+
+		assert.ErrorIs(t, Verify(changed, key), ErrInvalidSignature)
+	})
+
+	t.Run("Other key", func(t *testing.T) {
+		assert.ErrorIs(t, Verify(r, []byte("other")), ErrInvalidSignature)
+	})
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/outbox"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+type BudgetService interface {
+	SetBudget(request models.SetBudgetRequest, userID string) (*models.Budget, error)
+	ListProgress(request models.BudgetProgressRequest, userID string) ([]models.BudgetProgress, error)
+	DeleteBudget(budgetID, userID string) error
+	outbox.Sink
+}
+
+var (
+	ErrBudgetNotFound     = errors.New("budget not found")
+	ErrInvalidBudgetMonth = errors.New("month must be formatted as YYYY-MM")
+	ErrEmptyCategory      = errors.New("category is required")
+)
+
+// BudgetNotifier is called when spending reaches an alert threshold of a budget
+type BudgetNotifier interface {
+	BudgetThresholdReached(alert *models.BudgetAlert)
+}
+
+type logBudgetNotifier struct {
+	logger *log.Logger
+}
+
+// NewLogBudgetNotifier returns a notifier that only writes budget alerts to the log
+func NewLogBudgetNotifier(logger *log.Logger) BudgetNotifier {
+	return &logBudgetNotifier{logger: logger}
+}
+
+func (n *logBudgetNotifier) BudgetThresholdReached(alert *models.BudgetAlert) {
+	n.logger.Printf("Budget alert: budgetID=%s, userID=%s, category=%s, threshold=%d%%, spent=%d, amount=%d",
+		alert.BudgetID, alert.UserID, alert.Category, alert.Threshold, alert.Spent, alert.Amount)
+}
+
+type budgetService struct {
+	storage  storage.BudgetStorager
+	notifier BudgetNotifier
+	now      func() time.Time
+	logger   *log.Logger
+}
+
+// NewBudgetService sends budget alerts to notifier, they are only logged when it is nil
+func NewBudgetService(db *sql.DB, notifier BudgetNotifier) BudgetService {
+	logger := log.New(log.Writer(), "BudgetService: ", log.Ldate|log.Ltime|log.Lshortfile)
+	if notifier == nil {
+		notifier = NewLogBudgetNotifier(logger)
+	}
+	return &budgetService{
+		storage:  storage.NewBudgetStorage(db),
+		notifier: notifier,
+		now:      time.Now,
+		logger:   logger,
+	}
+}
+
+func (s *budgetService) SetBudget(request models.SetBudgetRequest, userID string) (*models.Budget, error) {
+	s.logger.Printf("Setting budget: userID=%s, category=%s, amount=%s", userID, request.Category, request.Amount)
+	category := normalizeCategory(request.Category)
+	if category == "" {
+		return nil, ErrEmptyCategory
+	}
+
+	amount, err := models.ParseAmount(request.Amount)
+	if err != nil {
+		s.logger.Printf("Error parsing amount: %v", err)
+		return nil, err
+	}
+
+	budget := &models.Budget{UserID: userID, Category: category, Amount: amount}
+	if err := s.storage.SetBudget(budget); err != nil {
+		s.logger.Printf("Error setting budget: %v", err)
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+func (s *budgetService) ListProgress(request models.BudgetProgressRequest, userID string) ([]models.BudgetProgress, error) {
+	month := budgetMonth(s.now())
+	if request.Month != "" {
+		var err error
+		month, err = time.Parse(models.BudgetPeriodLayout, request.Month)
+		if err != nil {
+			return nil, ErrInvalidBudgetMonth
+		}
+	}
+
+	progress, err := s.storage.ListProgress(userID, month.Format(models.BudgetPeriodLayout), month, month.AddDate(0, 1, 0))
+	if err != nil {
+		s.logger.Printf("Error listing budget progress: %v", err)
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+func (s *budgetService) DeleteBudget(budgetID, userID string) error {
+	s.logger.Printf("Deleting budget: budgetID=%s, userID=%s", budgetID, userID)
+	deleted, err := s.storage.DeleteBudget(budgetID, userID)
+	if err != nil {
+		s.logger.Printf("Error deleting budget: %v", err)
+		return err
+	}
+	if !deleted {
+		return ErrBudgetNotFound
+	}
+
+	return nil
+}
+
+// Publish evaluates the budget of the category after a debit of the outbox event.
+// Each threshold alerts once a month, when a debit crosses several only the
+// highest is sent.
+func (s *budgetService) Publish(ctx context.Context, domainEvent models.DomainEvent) error {
+	event, ok, err := walletEvent(domainEvent)
+	if err != nil || !ok {
+		return err
+	}
+	if event.Type != models.EventWalletDebited || event.Category == "" {
+		return nil
+	}
+
+	budget, err := s.storage.GetBudgetByCategory(event.UserID, event.Category)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "unable to get budget of %s for %s", event.UserID, event.Category)
+	}
+
+	month := budgetMonth(event.CreatedAt)
+	spent, err := s.storage.GetSpent(budget.UserID, budget.Category, month, month.AddDate(0, 1, 0))
+	if err != nil {
+		return errors.Wrapf(err, "unable to get spending of budget %s", budget.ID)
+	}
+
+	var reached *models.BudgetAlert
+	for _, threshold := range models.BudgetAlertThresholds {
+		if spent*100 < budget.Amount*int64(threshold) {
+			break
+		}
+
+		alert := &models.BudgetAlert{
+			BudgetID:  budget.ID,
+			UserID:    budget.UserID,
+			Category:  budget.Category,
+			Period:    month.Format(models.BudgetPeriodLayout),
+			Threshold: threshold,
+			Amount:    budget.Amount,
+			Spent:     spent,
+		}
+		recorded, err := s.storage.RecordAlert(alert)
+		if err != nil {
+			return errors.Wrapf(err, "unable to record alert of budget %s", budget.ID)
+		}
+		if recorded {
+			reached = alert
+		}
+	}
+
+	if reached != nil {
+		s.notifier.BudgetThresholdReached(reached)
+	}
+	return nil
+}
+
+// budgetMonth returns the start of the month budgets count t in, months are in UTC
+func budgetMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
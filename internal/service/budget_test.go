@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock implementation of BudgetStorage
+type MockBudgetStorage struct {
+	mock.Mock
+}
+
+func (m *MockBudgetStorage) SetBudget(budget *models.Budget) error {
+	args := m.Called(budget)
+	return args.Error(0)
+}
+
+func (m *MockBudgetStorage) GetBudgetByCategory(userID, category string) (*models.Budget, error) {
+	args := m.Called(userID, category)
+	return args.Get(0).(*models.Budget), args.Error(1)
+}
+
+func (m *MockBudgetStorage) ListProgress(userID, period string, from, to time.Time) ([]models.BudgetProgress, error) {
+	args := m.Called(userID, period, from, to)
+	return args.Get(0).([]models.BudgetProgress), args.Error(1)
+}
+
+func (m *MockBudgetStorage) DeleteBudget(budgetID, userID string) (bool, error) {
+	args := m.Called(budgetID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockBudgetStorage) GetSpent(userID, category string, from, to time.Time) (int64, error) {
+	args := m.Called(userID, category, from, to)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockBudgetStorage) RecordAlert(alert *models.BudgetAlert) (bool, error) {
+	args := m.Called(alert)
+	return args.Bool(0), args.Error(1)
+}
+
+// Mock implementation of BudgetNotifier
+type MockBudgetNotifier struct {
+	mock.Mock
+}
+
+func (m *MockBudgetNotifier) BudgetThresholdReached(alert *models.BudgetAlert) {
+	m.Called(alert)
+}
+
+func TestBudgetService_Publish(t *testing.T) {
+	createdAt := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	budget := &models.Budget{ID: "budget1", UserID: "user1", Category: "food", Amount: 10000}
+	debit := models.DomainEvent{
+		ID:        1,
+		Type:      models.DomainEventWalletDebited,
+		Payload:   json.RawMessage(`{"wallet_id":"wallet1","user_id":"user1","amount":-500,"category":"food"}`),
+		CreatedAt: createdAt,
+	}
+
+	newService := func() (*budgetService, *MockBudgetStorage, *MockBudgetNotifier) {
+		mockStorage := new(MockBudgetStorage)
+		mockNotifier := new(MockBudgetNotifier)
+		return &budgetService{storage: mockStorage, notifier: mockNotifier, now: time.Now, logger: log.Default()}, mockStorage, mockNotifier
+	}
+	threshold := func(threshold int) any {
+		return mock.MatchedBy(func(alert *models.BudgetAlert) bool {
+			return alert.Threshold == threshold && alert.Period == "2024-05"
+		})
+	}
+
+	t.Run("Below thresholds", func(t *testing.T) {
+		s, mockStorage, mockNotifier := newService()
+		mockStorage.On("GetBudgetByCategory", "user1", "food").Return(budget, nil)
+		mockStorage.On("GetSpent", "user1", "food", from, to).Return(int64(7999), nil)
+
+		assert.NoError(t, s.Publish(context.Background(), debit))
+
+		mockStorage.AssertNotCalled(t, "RecordAlert", mock.Anything)
+		mockNotifier.AssertNotCalled(t, "BudgetThresholdReached", mock.Anything)
+	})
+
+	t.Run("Alerts at 80 percent", func(t *testing.T) {
+		s, mockStorage, mockNotifier := newService()
+		mockStorage.On("GetBudgetByCategory", "user1", "food").Return(budget, nil)
+		mockStorage.On("GetSpent", "user1", "food", from, to).Return(int64(8000), nil)
+		mockStorage.On("RecordAlert", threshold(80)).Return(true, nil)
+		mockNotifier.On("BudgetThresholdReached", threshold(80)).Return()
+
+		assert.NoError(t, s.Publish(context.Background(), debit))
+
+		mockNotifier.AssertExpectations(t)
+	})
+
+	t.Run("Alerts once per threshold", func(t *testing.T) {
+		s, mockStorage, mockNotifier := newService()
+		mockStorage.On("GetBudgetByCategory", "user1", "food").Return(budget, nil)
+		mockStorage.On("GetSpent", "user1", "food", from, to).Return(int64(9000), nil)
+		mockStorage.On("RecordAlert", threshold(80)).Return(false, nil)
+
+		assert.NoError(t, s.Publish(context.Background(), debit))
+
+		mockNotifier.AssertNotCalled(t, "BudgetThresholdReached", mock.Anything)
+	})
+
+	t.Run("Crossing both sends the highest", func(t *testing.T) {
+		s, mockStorage, mockNotifier := newService()
+		mockStorage.On("GetBudgetByCategory", "user1", "food").Return(budget, nil)
+		mockStorage.On("GetSpent", "user1", "food", from, to).Return(int64(12000), nil)
+		mockStorage.On("RecordAlert", threshold(80)).Return(true, nil)
+		mockStorage.On("RecordAlert", threshold(100)).Return(true, nil)
+		mockNotifier.On("BudgetThresholdReached", threshold(100)).Return().Once()
+
+		assert.NoError(t, s.Publish(context.Background(), debit))
+
+		mockNotifier.AssertExpectations(t)
+		mockNotifier.AssertNumberOfCalls(t, "BudgetThresholdReached", 1)
+	})
+
+	t.Run("No budget for the category", func(t *testing.T) {
+		s, mockStorage, _ := newService()
+		mockStorage.On("GetBudgetByCategory", "user1", "food").Return((*models.Budget)(nil), sql.ErrNoRows)
+
+		assert.NoError(t, s.Publish(context.Background(), debit))
+
+		mockStorage.AssertNotCalled(t, "GetSpent", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Credits are ignored", func(t *testing.T) {
+		s, mockStorage, _ := newService()
+		credit := debit
+		credit.Type = models.DomainEventWalletCredited
+
+		assert.NoError(t, s.Publish(context.Background(), credit))
+
+		mockStorage.AssertNotCalled(t, "GetBudgetByCategory", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Storage errors are returned for a retry", func(t *testing.T) {
+		s, mockStorage, mockNotifier := newService()
+		mockStorage.On("GetBudgetByCategory", "user1", "food").Return(budget, nil)
+		mockStorage.On("GetSpent", "user1", "food", from, to).Return(int64(8000), nil)
+		mockStorage.On("RecordAlert", threshold(80)).Return(false, errors.New("connection reset"))
+
+		err := s.Publish(context.Background(), debit)
+
+		assert.Error(t, err)
+		mockNotifier.AssertNotCalled(t, "BudgetThresholdReached", mock.Anything)
+	})
+}
+
+func TestBudgetService_SetBudget(t *testing.T) {
+	mockStorage := new(MockBudgetStorage)
+	s := &budgetService{storage: mockStorage, now: time.Now, logger: log.Default()}
+
+	t.Run("Category is normalized", func(t *testing.T) {
+		mockStorage.On("SetBudget", &models.Budget{UserID: "user1", Category: "food", Amount: 30000}).Return(nil).Once()
+
+		budget, err := s.SetBudget(models.SetBudgetRequest{Category: " Food ", Amount: "300"}, "user1")
+
+		require.NoError(t, err)
+		assert.Equal(t, "food", budget.Category)
+	})
+
+	t.Run("Blank category", func(t *testing.T) {
+		_, err := s.SetBudget(models.SetBudgetRequest{Category: "  ", Amount: "300"}, "user1")
+
+		assert.ErrorIs(t, err, ErrEmptyCategory)
+	})
+}
+
+func TestBudgetService_ListProgress(t *testing.T) {
+	mockStorage := new(MockBudgetStorage)
+	now := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+	s := &budgetService{storage: mockStorage, now: func() time.Time { return now }, logger: log.Default()}
+
+	t.Run("Selected month", func(t *testing.T) {
+		from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+		mockStorage.On("ListProgress", "user1", "2024-02", from, from.AddDate(0, 1, 0)).Return([]models.BudgetProgress{}, nil).Once()
+
+		_, err := s.ListProgress(models.BudgetProgressRequest{Month: "2024-02"}, "user1")
+
+		require.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Invalid month", func(t *testing.T) {
+		_, err := s.ListProgress(models.BudgetProgressRequest{Month: "May 2024"}, "user1")
+
+		assert.ErrorIs(t, err, ErrInvalidBudgetMonth)
+	})
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+type EscrowService interface {
+	Create(request models.CreateEscrowRequest, userID string) (*models.Escrow, error)
+	Get(escrowID, userID string) (*models.Escrow, error)
+	List(userID string) ([]models.Escrow, error)
+	Confirm(escrowID, userID string) (*models.Escrow, error)
+	Cancel(escrowID, userID string) (*models.Escrow, error)
+	Dispute(request models.DisputeEscrowRequest, userID string) (*models.Escrow, error)
+	Resolve(request models.ResolveEscrowRequest) (*models.Escrow, error)
+	ReleaseDue(ctx context.Context) (int, error)
+	Run(ctx context.Context)
+}
+
+var (
+	ErrEscrowNotFound     = errors.New("escrow not found")
+	ErrEscrowNotPending   = storage.ErrEscrowNotPending
+	ErrEscrowNotHeld      = errors.New("escrow is no longer held")
+	ErrEscrowNotDisputed  = errors.New("escrow is not disputed")
+	ErrNotEscrowBuyer     = errors.New("only the buyer can confirm delivery")
+	ErrNotEscrowSeller    = errors.New("only the seller can cancel the deal")
+	ErrInvalidEscrowSplit = errors.New("seller amount can't exceed the escrow amount")
+)
+
+const escrowReleaseInterval = time.Minute
+
+type escrowService struct {
+	storage       storage.EscrowStorager
+	wallets       storage.WalletStorager
+	walletService WalletService
+	now           func() time.Time
+	logger        *log.Logger
+}
+
+func NewEscrowService(db *sql.DB, walletService WalletService) EscrowService {
+	return &escrowService{
+		storage:       storage.NewEscrowStorage(db),
+		wallets:       storage.NewWalletStorage(db),
+		walletService: walletService,
+		now:           time.Now,
+		logger:        log.New(log.Writer(), "EscrowService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+// Create takes the payment from the buyer's wallet into escrow. The payment is a
+// regular transfer into the escrow wallet, so a child's escrow is subject to the
+// parental controls and may wait for the parent's approval.
+func (s *escrowService) Create(request models.CreateEscrowRequest, userID string) (*models.Escrow, error) {
+	s.logger.Printf("Creating escrow: walletID=%s, sellerWalletID=%s, userID=%s, amount=%s",
+		request.WalletID, request.SellerWalletID, userID, request.Amount)
+	amount, err := models.ParseAmount(request.Amount)
+	if err != nil {
+		s.logger.Printf("Error parsing amount: %v", err)
+		return nil, err
+	}
+
+	if request.WalletID == request.SellerWalletID {
+		return nil, ErrSameWallet
+	}
+
+	wallet, err := s.wallets.GetWallet(request.WalletID, userID)
+	if err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return nil, errors.Wrap(err, "Error getting wallet")
+	}
+
+	seller, err := s.wallets.GetWalletByID(request.SellerWalletID)
+	if err != nil {
+		s.logger.Printf("Error getting seller wallet: %v", err)
+		return nil, errors.Wrap(err, "Error getting seller wallet")
+	}
+
+	if wallet.Balance < amount {
+		return nil, storage.ErrInsufficientFunds
+	}
+
+	escrowWalletID, err := s.storage.GetEscrowWallet()
+	if err != nil {
+		s.logger.Printf("Error getting escrow wallet: %v", err)
+		return nil, err
+	}
+
+	days := request.ReleaseAfterDays
+	if days == 0 {
+		days = models.EscrowReleaseAfterDays
+	}
+
+	escrow := &models.Escrow{
+		BuyerID:        userID,
+		BuyerWalletID:  wallet.ID,
+		SellerID:       seller.UserID,
+		SellerWalletID: seller.ID,
+		Amount:         amount,
+		Description:    strings.TrimSpace(request.Description),
+		Reference:      request.Reference,
+		Status:         models.EscrowPending,
+		ReleaseAt:      s.now().AddDate(0, 0, days),
+	}
+	if err := s.storage.CreateEscrow(escrow); err != nil {
+		s.logger.Printf("Error creating escrow: %v", err)
+		return nil, err
+	}
+
+	transactionID, err := s.walletService.Transfer(models.TransferRequest{
+		WalletID:    wallet.ID,
+		ToWalletID:  escrowWalletID,
+		Amount:      models.FormatAmount(amount),
+		Reference:   storage.EscrowReferencePrefix + escrow.ID,
+		Description: storage.EscrowDescription("Escrow hold", escrow.Description),
+	}, userID, s.storage.HoldEscrow(escrow.ID, wallet.ID))
+	if errors.Is(err, ErrApprovalRequired) {
+		// The escrow stays pending, the parent's approval pays for it
+		return nil, err
+	}
+	if err != nil {
+		s.logger.Printf("Error paying into escrow: %v", err)
+		if _, failErr := s.storage.FailEscrow(escrow.ID); failErr != nil {
+			s.logger.Printf("Error marking escrow as failed: %v", failErr)
+		}
+		return nil, err
+	}
+	escrow.Status = models.EscrowHeld
+	escrow.HoldTransactionID = transactionID
+
+	return escrow, nil
+}
+
+func (s *escrowService) Get(escrowID, userID string) (*models.Escrow, error) {
+	s.logger.Printf("Getting escrow: escrowID=%s, userID=%s", escrowID, userID)
+	escrow, err := s.storage.GetEscrow(escrowID)
+	if err == sql.ErrNoRows {
+		return nil, ErrEscrowNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting escrow: %v", err)
+		return nil, err
+	}
+
+	if escrow.BuyerID != userID && escrow.SellerID != userID {
+		return nil, ErrEscrowNotFound
+	}
+
+	return escrow, nil
+}
+
+func (s *escrowService) List(userID string) ([]models.Escrow, error) {
+	s.logger.Printf("Listing escrows: userID=%s", userID)
+	escrows, err := s.storage.ListEscrows(userID)
+	if err != nil {
+		s.logger.Printf("Error listing escrows: %v", err)
+		return nil, err
+	}
+
+	return escrows, nil
+}
+
+// Confirm releases the funds to the seller once the buyer got what they paid for
+func (s *escrowService) Confirm(escrowID, userID string) (*models.Escrow, error) {
+	escrow, err := s.Get(escrowID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if escrow.BuyerID != userID {
+		return nil, ErrNotEscrowBuyer
+	}
+
+	return s.settle(escrow, models.EscrowHeld, models.EscrowReleased, escrow.Amount, "confirmed by buyer")
+}
+
+// Cancel refunds the buyer when the seller can't deliver
+func (s *escrowService) Cancel(escrowID, userID string) (*models.Escrow, error) {
+	escrow, err := s.Get(escrowID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if escrow.SellerID != userID {
+		return nil, ErrNotEscrowSeller
+	}
+
+	return s.settle(escrow, models.EscrowHeld, models.EscrowRefunded, 0, "cancelled by seller")
+}
+
+// Dispute hands the escrow over to an admin, it is no longer released automatically
+func (s *escrowService) Dispute(request models.DisputeEscrowRequest, userID string) (*models.Escrow, error) {
+	escrow, err := s.Get(request.EscrowID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	disputed, err := s.storage.DisputeEscrow(escrow.ID, strings.TrimSpace(request.Reason))
+	if err != nil {
+		s.logger.Printf("Error disputing escrow: %v", err)
+		return nil, err
+	}
+	if !disputed {
+		return nil, ErrEscrowNotHeld
+	}
+	s.logger.Printf("Escrow disputed: escrowID=%s, userID=%s", escrow.ID, userID)
+
+	return s.storage.GetEscrow(escrow.ID)
+}
+
+// Resolve settles a dispute, splitting the funds between the seller and the buyer
+func (s *escrowService) Resolve(request models.ResolveEscrowRequest) (*models.Escrow, error) {
+	s.logger.Printf("Resolving escrow: escrowID=%s, sellerAmount=%s", request.EscrowID, request.SellerAmount)
+	sellerAmount, err := parseSellerAmount(request.SellerAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	escrow, err := s.storage.GetEscrow(request.EscrowID)
+	if err == sql.ErrNoRows {
+		return nil, ErrEscrowNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting escrow: %v", err)
+		return nil, err
+	}
+	if escrow.Status != models.EscrowDisputed {
+		return nil, ErrEscrowNotDisputed
+	}
+	if sellerAmount > escrow.Amount {
+		return nil, ErrInvalidEscrowSplit
+	}
+
+	status := models.EscrowResolved
+	switch sellerAmount {
+	case escrow.Amount:
+		status = models.EscrowReleased
+	case 0:
+		status = models.EscrowRefunded
+	}
+
+	resolved, err := s.settle(escrow, models.EscrowDisputed, status, sellerAmount, strings.TrimSpace(request.Resolution))
+	if errors.Is(err, ErrEscrowNotHeld) {
+		return nil, ErrEscrowNotDisputed
+	}
+	return resolved, err
+}
+
+// Run releases escrows whose buyers neither confirmed nor disputed in time until
+// ctx is cancelled
+func (s *escrowService) Run(ctx context.Context) {
+	ticker := time.NewTicker(escrowReleaseInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ReleaseDue(ctx); err != nil {
+				s.logger.Printf("Error releasing escrows: %v", err)
+			}
+		}
+	}
+}
+
+// ReleaseDue releases the held escrows past their release time. An escrow that
+// can't be released yet, for example because the seller's wallet is full, is
+// tried again on the next run.
+func (s *escrowService) ReleaseDue(ctx context.Context) (int, error) {
+	escrows, err := s.storage.ListDueEscrows(s.now())
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to list due escrows")
+	}
+
+	released := 0
+	for i := range escrows {
+		if ctx.Err() != nil {
+			break
+		}
+
+		_, err := s.settle(&escrows[i], models.EscrowHeld, models.EscrowReleased, escrows[i].Amount, "released automatically")
+		if err != nil {
+			s.logger.Printf("Error releasing escrow %s: %v", escrows[i].ID, err)
+			continue
+		}
+		released++
+	}
+
+	return released, nil
+}
+
+// settle pays out the escrow. Only the seller's share is checked against the
+// wallet limits, a refund just returns the buyer's own money.
+func (s *escrowService) settle(escrow *models.Escrow, fromStatus, toStatus string, sellerAmount int64, resolution string) (*models.Escrow, error) {
+	settled, err := s.storage.SettleEscrow(escrow.ID, fromStatus, toStatus, sellerAmount, resolution)
+	if err != nil {
+		s.logger.Printf("Error settling escrow: %v", err)
+		return nil, err
+	}
+	if !settled {
+		return nil, ErrEscrowNotHeld
+	}
+	s.logger.Printf("Escrow settled: escrowID=%s, status=%s, sellerAmount=%d", escrow.ID, toStatus, sellerAmount)
+
+	return s.storage.GetEscrow(escrow.ID)
+}
+
+// parseSellerAmount also accepts zero, which refunds everything to the buyer
+func parseSellerAmount(amount string) (int64, error) {
+	if value, err := strconv.ParseFloat(amount, 64); err == nil && value == 0 {
+		return 0, nil
+	}
+	return models.ParseAmount(amount)
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock implementation of EscrowStorage
+type MockEscrowStorage struct {
+	mock.Mock
+}
+
+func (m *MockEscrowStorage) GetEscrowWallet() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockEscrowStorage) CreateEscrow(escrow *models.Escrow) error {
+	args := m.Called(escrow)
+	return args.Error(0)
+}
+
+func (m *MockEscrowStorage) HoldEscrow(escrowID, buyerWalletID string) storage.TransferHook {
+	args := m.Called(escrowID, buyerWalletID)
+	return args.Get(0).(storage.TransferHook)
+}
+
+func (m *MockEscrowStorage) FailEscrow(escrowID string) (bool, error) {
+	args := m.Called(escrowID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockEscrowStorage) GetEscrow(escrowID string) (*models.Escrow, error) {
+	args := m.Called(escrowID)
+	return args.Get(0).(*models.Escrow), args.Error(1)
+}
+
+func (m *MockEscrowStorage) ListEscrows(userID string) ([]models.Escrow, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Escrow), args.Error(1)
+}
+
+func (m *MockEscrowStorage) ListDueEscrows(now time.Time) ([]models.Escrow, error) {
+	args := m.Called(now)
+	return args.Get(0).([]models.Escrow), args.Error(1)
+}
+
+func (m *MockEscrowStorage) DisputeEscrow(escrowID, reason string) (bool, error) {
+	args := m.Called(escrowID, reason)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockEscrowStorage) SettleEscrow(escrowID, fromStatus, toStatus string, sellerAmount int64, resolution string) (bool, error) {
+	args := m.Called(escrowID, fromStatus, toStatus, sellerAmount, resolution)
+	return args.Bool(0), args.Error(1)
+}
+
+func heldEscrow() *models.Escrow {
+	return &models.Escrow{
+		ID:             "escrow1",
+		BuyerID:        "buyer",
+		BuyerWalletID:  "buyerWallet",
+		SellerID:       "seller",
+		SellerWalletID: "sellerWallet",
+		Amount:         10000,
+		Status:         models.EscrowHeld,
+	}
+}
+
+func TestEscrowService_Create(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	setup := func() (*escrowService, *MockEscrowStorage, *MockWalletService) {
+		mockStorage := new(MockEscrowStorage)
+		mockWallets := new(MockWalletStorage)
+		mockWalletService := new(MockWalletService)
+		s := &escrowService{storage: mockStorage, wallets: mockWallets, walletService: mockWalletService,
+			now: func() time.Time { return now }, logger: log.Default()}
+
+		mockWallets.On("GetWallet", "buyerWallet", "buyer").Return(&models.Wallet{ID: "buyerWallet", UserID: "buyer", Balance: 50000}, nil)
+		mockWallets.On("GetWalletByID", "sellerWallet").Return(&models.Wallet{ID: "sellerWallet", UserID: "seller"}, nil)
+		mockStorage.On("GetEscrowWallet").Return("escrowWallet", nil)
+		mockStorage.On("CreateEscrow", mock.MatchedBy(func(e *models.Escrow) bool {
+			return e.BuyerID == "buyer" && e.SellerID == "seller" && e.Amount == 10000 &&
+				e.Status == models.EscrowPending && e.ReleaseAt.Equal(now.AddDate(0, 0, models.EscrowReleaseAfterDays))
+		})).Run(func(args mock.Arguments) {
+			args.Get(0).(*models.Escrow).ID = "escrow1"
+		}).Return(nil)
+
+		return s, mockStorage, mockWalletService
+	}
+	request := models.CreateEscrowRequest{
+		WalletID:       "buyerWallet",
+		SellerWalletID: "sellerWallet",
+		Amount:         "100.00",
+		Description:    " Used bike ",
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		s, mockStorage, mockWalletService := setup()
+
+		held := false
+		mockStorage.On("HoldEscrow", "escrow1", "buyerWallet").Return(storage.TransferHook(func(tx *sql.Tx, result *models.TransferResult) error {
+			held = result.DebitTransactionID == 42
+			return nil
+		}))
+		mockWalletService.On("Transfer", models.TransferRequest{
+			WalletID:    "buyerWallet",
+			ToWalletID:  "escrowWallet",
+			Amount:      "100.00",
+			Reference:   storage.EscrowReferencePrefix + "escrow1",
+			Description: "Escrow hold: Used bike",
+		}, "buyer").Return(int64(42), nil)
+
+		escrow, err := s.Create(request, "buyer")
+
+		require.NoError(t, err)
+		assert.True(t, held)
+		assert.Equal(t, "Used bike", escrow.Description)
+		assert.Equal(t, models.EscrowHeld, escrow.Status)
+		assert.Equal(t, int64(42), escrow.HoldTransactionID)
+		mockStorage.AssertExpectations(t)
+		mockWalletService.AssertExpectations(t)
+	})
+
+	t.Run("Waiting for parental approval", func(t *testing.T) {
+		s, mockStorage, mockWalletService := setup()
+
+		mockStorage.On("HoldEscrow", "escrow1", "buyerWallet").Return(storage.TransferHook(nil))
+		mockWalletService.On("Transfer", mock.Anything, "buyer").Return(int64(0), ErrApprovalRequired)
+
+		_, err := s.Create(request, "buyer")
+
+		assert.ErrorIs(t, err, ErrApprovalRequired)
+		mockStorage.AssertNotCalled(t, "FailEscrow", mock.Anything)
+	})
+
+	t.Run("Payment fails", func(t *testing.T) {
+		s, mockStorage, mockWalletService := setup()
+
+		mockStorage.On("HoldEscrow", "escrow1", "buyerWallet").Return(storage.TransferHook(nil))
+		mockWalletService.On("Transfer", mock.Anything, "buyer").Return(int64(0), ErrCategoryBlocked)
+		mockStorage.On("FailEscrow", "escrow1").Return(true, nil)
+
+		_, err := s.Create(request, "buyer")
+
+		assert.ErrorIs(t, err, ErrCategoryBlocked)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Insufficient funds", func(t *testing.T) {
+		mockStorage := new(MockEscrowStorage)
+		mockWallets := new(MockWalletStorage)
+		s := &escrowService{storage: mockStorage, wallets: mockWallets, now: func() time.Time { return now }, logger: log.Default()}
+
+		mockWallets.On("GetWallet", "buyerWallet", "buyer").Return(&models.Wallet{ID: "buyerWallet", UserID: "buyer", Balance: 500}, nil)
+		mockWallets.On("GetWalletByID", "sellerWallet").Return(&models.Wallet{ID: "sellerWallet", UserID: "seller"}, nil)
+
+		_, err := s.Create(models.CreateEscrowRequest{WalletID: "buyerWallet", SellerWalletID: "sellerWallet", Amount: "100.00"}, "buyer")
+
+		assert.ErrorIs(t, err, storage.ErrInsufficientFunds)
+		mockStorage.AssertNotCalled(t, "CreateEscrow", mock.Anything)
+	})
+}
+
+func TestEscrowService_Settle(t *testing.T) {
+	t.Run("Buyer confirms", func(t *testing.T) {
+		mockStorage := new(MockEscrowStorage)
+		mockWallets := new(MockWalletStorage)
+		s := &escrowService{storage: mockStorage, wallets: mockWallets, now: time.Now, logger: log.Default()}
+
+		mockStorage.On("GetEscrow", "escrow1").Return(heldEscrow(), nil)
+		mockStorage.On("SettleEscrow", "escrow1", models.EscrowHeld, models.EscrowReleased, int64(10000), "confirmed by buyer").Return(true, nil)
+
+		_, err := s.Confirm("escrow1", "buyer")
+
+		require.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Seller can't confirm", func(t *testing.T) {
+		mockStorage := new(MockEscrowStorage)
+		s := &escrowService{storage: mockStorage, wallets: new(MockWalletStorage), now: time.Now, logger: log.Default()}
+
+		mockStorage.On("GetEscrow", "escrow1").Return(heldEscrow(), nil)
+
+		_, err := s.Confirm("escrow1", "seller")
+
+		assert.ErrorIs(t, err, ErrNotEscrowBuyer)
+	})
+
+	t.Run("Seller cancels", func(t *testing.T) {
+		mockStorage := new(MockEscrowStorage)
+		s := &escrowService{storage: mockStorage, wallets: new(MockWalletStorage), now: time.Now, logger: log.Default()}
+
+		mockStorage.On("GetEscrow", "escrow1").Return(heldEscrow(), nil)
+		mockStorage.On("SettleEscrow", "escrow1", models.EscrowHeld, models.EscrowRefunded, int64(0), "cancelled by seller").Return(true, nil)
+
+		_, err := s.Cancel("escrow1", "seller")
+
+		require.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Already settled", func(t *testing.T) {
+		mockStorage := new(MockEscrowStorage)
+		s := &escrowService{storage: mockStorage, wallets: new(MockWalletStorage), now: time.Now, logger: log.Default()}
+
+		mockStorage.On("GetEscrow", "escrow1").Return(heldEscrow(), nil)
+		mockStorage.On("SettleEscrow", "escrow1", models.EscrowHeld, models.EscrowRefunded, int64(0), "cancelled by seller").Return(false, nil)
+
+		_, err := s.Cancel("escrow1", "seller")
+
+		assert.ErrorIs(t, err, ErrEscrowNotHeld)
+	})
+
+	t.Run("Strangers can't see the escrow", func(t *testing.T) {
+		mockStorage := new(MockEscrowStorage)
+		s := &escrowService{storage: mockStorage, wallets: new(MockWalletStorage), now: time.Now, logger: log.Default()}
+
+		mockStorage.On("GetEscrow", "escrow1").Return(heldEscrow(), nil)
+
+		_, err := s.Dispute(models.DisputeEscrowRequest{EscrowID: "escrow1", Reason: "Not delivered"}, "stranger")
+
+		assert.ErrorIs(t, err, ErrEscrowNotFound)
+	})
+}
+
+func TestEscrowService_Resolve(t *testing.T) {
+	disputed := heldEscrow()
+	disputed.Status = models.EscrowDisputed
+
+	t.Run("Split between the parties", func(t *testing.T) {
+		mockStorage := new(MockEscrowStorage)
+		mockWallets := new(MockWalletStorage)
+		s := &escrowService{storage: mockStorage, wallets: mockWallets, now: time.Now, logger: log.Default()}
+
+		mockStorage.On("GetEscrow", "escrow1").Return(disputed, nil)
+		mockStorage.On("SettleEscrow", "escrow1", models.EscrowDisputed, models.EscrowResolved, int64(4000), "Partly damaged").Return(true, nil)
+
+		_, err := s.Resolve(models.ResolveEscrowRequest{EscrowID: "escrow1", SellerAmount: "40", Resolution: "Partly damaged"})
+
+		require.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Full refund", func(t *testing.T) {
+		mockStorage := new(MockEscrowStorage)
+		s := &escrowService{storage: mockStorage, wallets: new(MockWalletStorage), now: time.Now, logger: log.Default()}
+
+		mockStorage.On("GetEscrow", "escrow1").Return(disputed, nil)
+		mockStorage.On("SettleEscrow", "escrow1", models.EscrowDisputed, models.EscrowRefunded, int64(0), "Never shipped").Return(true, nil)
+
+		_, err := s.Resolve(models.ResolveEscrowRequest{EscrowID: "escrow1", SellerAmount: "0.00", Resolution: "Never shipped"})
+
+		require.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("More than the escrow", func(t *testing.T) {
+		mockStorage := new(MockEscrowStorage)
+		s := &escrowService{storage: mockStorage, wallets: new(MockWalletStorage), now: time.Now, logger: log.Default()}
+
+		mockStorage.On("GetEscrow", "escrow1").Return(disputed, nil)
+
+		_, err := s.Resolve(models.ResolveEscrowRequest{EscrowID: "escrow1", SellerAmount: "150", Resolution: "Typo"})
+
+		assert.ErrorIs(t, err, ErrInvalidEscrowSplit)
+	})
+
+	t.Run("Not disputed", func(t *testing.T) {
+		mockStorage := new(MockEscrowStorage)
+		s := &escrowService{storage: mockStorage, wallets: new(MockWalletStorage), now: time.Now, logger: log.Default()}
+
+		mockStorage.On("GetEscrow", "escrow1").Return(heldEscrow(), nil)
+
+		_, err := s.Resolve(models.ResolveEscrowRequest{EscrowID: "escrow1", SellerAmount: "40", Resolution: "Early"})
+
+		assert.ErrorIs(t, err, ErrEscrowNotDisputed)
+	})
+}
+
+func TestEscrowService_ReleaseDue(t *testing.T) {
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	mockStorage := new(MockEscrowStorage)
+	s := &escrowService{storage: mockStorage, wallets: new(MockWalletStorage), now: func() time.Time { return now }, logger: log.Default()}
+
+	full := heldEscrow()
+	full.ID = "escrow2"
+	full.SellerWalletID = "fullWallet"
+
+	mockStorage.On("ListDueEscrows", now).Return([]models.Escrow{*heldEscrow(), *full}, nil)
+	mockStorage.On("SettleEscrow", "escrow1", models.EscrowHeld, models.EscrowReleased, int64(10000), "released automatically").Return(true, nil)
+	mockStorage.On("SettleEscrow", "escrow2", models.EscrowHeld, models.EscrowReleased, int64(10000), "released automatically").Return(false, storage.ErrMaxBalanceExceeded)
+	mockStorage.On("GetEscrow", "escrow1").Return(heldEscrow(), nil)
+
+	released, err := s.ReleaseDue(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+	mockStorage.AssertExpectations(t)
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+
+	gqlgen "github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/executor"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/google/uuid"
+	"github.com/graph-gophers/dataloader/v7"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/graphql"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+type GraphQLService interface {
+	Execute(ctx context.Context, request graphql.Request, userID string) *graphql.Response
+}
+
+var (
+	ErrInvalidCursor   = errors.New("invalid cursor")
+	ErrInvalidPageSize = errors.Errorf("first must be between 1 and %d", models.GraphQLMaxPageSize)
+	errGraphQLInternal = errors.New("internal error")
+)
+
+type graphqlService struct {
+	wallets      storage.WalletStorager
+	transactions storage.TransactionStorager
+	executor     *executor.Executor
+	logger       *log.Logger
+}
+
+func NewGraphQLService(db *sql.DB) GraphQLService {
+	s := &graphqlService{
+		wallets:      storage.NewWalletStorage(db),
+		transactions: storage.NewTransactionStorage(db),
+		logger:       log.New(log.Writer(), "GraphQLService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+	s.executor = s.buildExecutor()
+	return s
+}
+
+// buildExecutor serves the schema of internal/graphql with the service as its
+// resolvers. Queries over the limits are rejected before they are executed, a
+// connection costs its page size times its selection.
+func (s *graphqlService) buildExecutor() *executor.Executor {
+	config := graphql.Config{Resolvers: s}
+	config.Complexity.Wallet.Transactions = func(childComplexity int, first *int, after, typeArg, status, category *string) int {
+		size := models.GraphQLPageSize
+		if first != nil {
+			size = max(*first, 1)
+		}
+		return 1 + size*childComplexity
+	}
+
+	exec := executor.New(graphql.NewExecutableSchema(config))
+	exec.Use(graphql.Limits{MaxQueryLength: models.GraphQLMaxQueryLength, MaxDepth: models.GraphQLMaxDepth})
+	exec.Use(extension.FixedComplexityLimit(models.GraphQLMaxComplexity))
+	exec.SetRecoverFunc(func(ctx context.Context, err any) error {
+		return s.internal("resolving a field", errors.Errorf("panic: %v", err))
+	})
+	return exec
+}
+
+// graphqlLoaders batch the wallet loads of one request. Both only return the
+// viewer's wallets, so the schema can't reach data the REST API wouldn't show.
+type graphqlLoaders struct {
+	userID        string
+	walletsByUser *dataloader.Loader[string, []*models.Wallet]
+	walletsByID   *dataloader.Loader[string, *models.Wallet]
+}
+
+type graphqlLoadersKey struct{}
+
+// Execute runs a read-only query on behalf of the user
+func (s *graphqlService) Execute(ctx context.Context, request graphql.Request, userID string) *graphql.Response {
+	s.logger.Printf("Executing GraphQL query: operation=%q, userID=%s", request.OperationName, userID)
+
+	ctx = context.WithValue(gqlgen.StartOperationTrace(ctx), graphqlLoadersKey{}, s.newLoaders(userID))
+	operation, errs := s.executor.CreateOperationContext(ctx, &gqlgen.RawParams{
+		Query:         request.Query,
+		OperationName: request.OperationName,
+		Variables:     request.Variables,
+	})
+	if errs != nil {
+		s.logger.Printf("GraphQL query rejected: %s", errs[0].Message)
+		return s.executor.DispatchError(ctx, errs)
+	}
+
+	respond, ctx := s.executor.DispatchOperation(ctx, operation)
+	return respond(ctx)
+}
+
+func (s *graphqlService) newLoaders(userID string) *graphqlLoaders {
+	walletsByUser := func(ctx context.Context, userIDs []string) []*dataloader.Result[[]*models.Wallet] {
+		results := make([]*dataloader.Result[[]*models.Wallet], len(userIDs))
+		wallets, err := s.wallets.ListWalletsByUsers(userIDs)
+		if err != nil {
+			err = s.internal("listing wallets by user", err)
+		}
+		for i, id := range userIDs {
+			results[i] = &dataloader.Result[[]*models.Wallet]{Data: []*models.Wallet{}, Error: err}
+			for j := range wallets {
+				if wallets[j].UserID == id {
+					results[i].Data = append(results[i].Data, &wallets[j])
+				}
+			}
+		}
+		return results
+	}
+
+	walletsByID := func(ctx context.Context, walletIDs []string) []*dataloader.Result[*models.Wallet] {
+		results := make([]*dataloader.Result[*models.Wallet], len(walletIDs))
+		for i := range results {
+			results[i] = &dataloader.Result[*models.Wallet]{}
+		}
+
+		// Ids that aren't UUIDs can't exist and would fail the whole batch. The
+		// rest are sorted, the batch is collected from concurrent resolvers.
+		valid := make([]string, 0, len(walletIDs))
+		for _, id := range walletIDs {
+			if _, err := uuid.Parse(id); err == nil {
+				valid = append(valid, id)
+			}
+		}
+		if len(valid) == 0 {
+			return results
+		}
+		slices.Sort(valid)
+
+		wallets, err := s.wallets.ListWalletsByIDs(valid)
+		if err != nil {
+			err = s.internal("listing wallets by id", err)
+		}
+		for i, id := range walletIDs {
+			results[i].Error = err
+			for j := range wallets {
+				if wallets[j].ID == id && wallets[j].UserID == userID {
+					results[i].Data = &wallets[j]
+				}
+			}
+		}
+		return results
+	}
+
+	return &graphqlLoaders{
+		userID:        userID,
+		walletsByUser: dataloader.NewBatchedLoader(walletsByUser),
+		walletsByID:   dataloader.NewBatchedLoader(walletsByID),
+	}
+}
+
+// internal logs a storage error and hides it from the client
+func (s *graphqlService) internal(action string, err error) error {
+	s.logger.Printf("Error %s: %v", action, err)
+	return errGraphQLInternal
+}
+
+func loadersFrom(ctx context.Context) *graphqlLoaders {
+	return ctx.Value(graphqlLoadersKey{}).(*graphqlLoaders)
+}
+
+func (s *graphqlService) Query() graphql.QueryResolver             { return graphqlQuery{s} }
+func (s *graphqlService) User() graphql.UserResolver               { return graphqlUser{s} }
+func (s *graphqlService) Wallet() graphql.WalletResolver           { return graphqlWallet{s} }
+func (s *graphqlService) Transaction() graphql.TransactionResolver { return graphqlTransaction{s} }
+
+type graphqlQuery struct{ *graphqlService }
+
+func (graphqlQuery) Viewer(ctx context.Context) (*graphql.User, error) {
+	return &graphql.User{ID: loadersFrom(ctx).userID}, nil
+}
+
+func (graphqlQuery) Wallet(ctx context.Context, id string) (*models.Wallet, error) {
+	return loadersFrom(ctx).walletsByID.Load(ctx, id)()
+}
+
+type graphqlUser struct{ *graphqlService }
+
+func (graphqlUser) Wallets(ctx context.Context, user *graphql.User) ([]*models.Wallet, error) {
+	return loadersFrom(ctx).walletsByUser.Load(ctx, user.ID)()
+}
+
+type graphqlWallet struct{ *graphqlService }
+
+func (graphqlWallet) Balance(ctx context.Context, wallet *models.Wallet) (string, error) {
+	return models.FormatAmount(wallet.Balance), nil
+}
+
+// Transactions loads a page of the wallet's transactions, newest first. One more
+// transaction than asked for is fetched to tell whether there's a next page.
+func (r graphqlWallet) Transactions(ctx context.Context, wallet *models.Wallet, first *int, after, typeArg, status, category *string) (*graphql.TransactionConnection, error) {
+	size := models.GraphQLPageSize
+	if first != nil {
+		size = *first
+	}
+	if size < 1 || size > models.GraphQLMaxPageSize {
+		return nil, ErrInvalidPageSize
+	}
+
+	filter := models.TransactionFilter{WalletID: wallet.ID, Limit: size + 1}
+	if after != nil {
+		id, err := decodeCursor(*after)
+		if err != nil {
+			return nil, err
+		}
+		filter.BeforeID = id
+	}
+	if typeArg != nil {
+		if *typeArg != models.TransactionTypeCredit && *typeArg != models.TransactionTypeDebit {
+			return nil, errors.Errorf("type must be %s or %s", models.TransactionTypeCredit, models.TransactionTypeDebit)
+		}
+		filter.Type = *typeArg
+	}
+	if status != nil {
+		filter.Status = *status
+	}
+	if category != nil {
+		filter.Category = normalizeCategory(*category)
+	}
+
+	transactions, err := r.transactions.ListTransactions(filter)
+	if err != nil {
+		return nil, r.internal("listing transactions", err)
+	}
+
+	connection := &graphql.TransactionConnection{Edges: []*graphql.TransactionEdge{}, PageInfo: &graphql.PageInfo{}}
+	if len(transactions) > size {
+		transactions, connection.PageInfo.HasNextPage = transactions[:size], true
+	}
+	for i := range transactions {
+		connection.Edges = append(connection.Edges, &graphql.TransactionEdge{Cursor: encodeCursor(transactions[i].ID), Node: &transactions[i]})
+	}
+	if len(transactions) > 0 {
+		connection.PageInfo.EndCursor = optional(encodeCursor(transactions[len(transactions)-1].ID))
+	}
+	return connection, nil
+}
+
+type graphqlTransaction struct{ *graphqlService }
+
+func (graphqlTransaction) ID(ctx context.Context, transaction *models.Transaction) (string, error) {
+	return strconv.FormatInt(transaction.ID, 10), nil
+}
+
+func (graphqlTransaction) Type(ctx context.Context, transaction *models.Transaction) (string, error) {
+	if transaction.Amount < 0 {
+		return models.TransactionTypeDebit, nil
+	}
+	return models.TransactionTypeCredit, nil
+}
+
+func (graphqlTransaction) Amount(ctx context.Context, transaction *models.Transaction) (string, error) {
+	amount := transaction.Amount
+	if amount < 0 {
+		amount = -amount
+	}
+	return models.FormatAmount(amount), nil
+}
+
+func (graphqlTransaction) CounterpartyWalletID(ctx context.Context, transaction *models.Transaction) (*string, error) {
+	return optional(transaction.CounterpartyWalletID), nil
+}
+
+func (graphqlTransaction) CounterpartyWallet(ctx context.Context, transaction *models.Transaction) (*models.Wallet, error) {
+	if transaction.CounterpartyWalletID == "" {
+		return nil, nil
+	}
+	return loadersFrom(ctx).walletsByID.Load(ctx, transaction.CounterpartyWalletID)()
+}
+
+func (graphqlTransaction) MerchantCategory(ctx context.Context, transaction *models.Transaction) (*string, error) {
+	return optional(transaction.MerchantCategory), nil
+}
+
+func (graphqlTransaction) Reference(ctx context.Context, transaction *models.Transaction) (*string, error) {
+	return optional(transaction.Reference), nil
+}
+
+func (graphqlTransaction) Description(ctx context.Context, transaction *models.Transaction) (*string, error) {
+	return optional(transaction.Description), nil
+}
+
+func (graphqlTransaction) Category(ctx context.Context, transaction *models.Transaction) (*string, error) {
+	return optional(transaction.Category), nil
+}
+
+func (graphqlTransaction) Tags(ctx context.Context, transaction *models.Transaction) ([]string, error) {
+	if transaction.Tags == nil {
+		return []string{}, nil
+	}
+	return transaction.Tags, nil
+}
+
+func (graphqlTransaction) CreatedAt(ctx context.Context, transaction *models.Transaction) (string, error) {
+	return transaction.CreatedAt.Format(time.RFC3339), nil
+}
+
+func (graphqlTransaction) CompletedAt(ctx context.Context, transaction *models.Transaction) (*string, error) {
+	if transaction.CompletedAt == nil {
+		return nil, nil
+	}
+	return optional(transaction.CompletedAt.Format(time.RFC3339)), nil
+}
+
+// optional is null for the empty string
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// Cursors are opaque to clients, they encode the id of the transaction
+func encodeCursor(transactionID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(transactionID, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id < 1 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rasul07/alif-task/internal/graphql"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// graphqlError is an error of a response as a client decodes it
+type graphqlError struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path"`
+}
+
+// executeGraphQL runs the query for the user and decodes the response the way a
+// client would see it
+func executeGraphQL(t *testing.T, service *graphqlService, query string, variables map[string]any, userID string) (map[string]any, []graphqlError) {
+	t.Helper()
+
+	response := service.Execute(context.Background(), graphql.Request{Query: query, Variables: variables}, userID)
+	raw, err := json.Marshal(response)
+	require.NoError(t, err)
+	var decoded struct {
+		Data   map[string]any `json:"data"`
+		Errors []graphqlError `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	return decoded.Data, decoded.Errors
+}
+
+func TestGraphQLViewer(t *testing.T) {
+	mockWallets := new(MockWalletStorage)
+	mockTransactions := new(MockTransactionStorage)
+	service := &graphqlService{wallets: mockWallets, transactions: mockTransactions, logger: log.Default()}
+	service.executor = service.buildExecutor()
+
+	userID := uuid.New().String()
+	walletID1 := uuid.New().String()
+	walletID2 := uuid.New().String()
+	otherWalletID := uuid.New().String()
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	userWallets := []models.Wallet{{ID: walletID1, UserID: userID, Balance: 15000}, {ID: walletID2, UserID: userID, Balance: 250}}
+
+	t.Run("Wallets and transactions with batched counterparties", func(t *testing.T) {
+		mockWallets.On("ListWalletsByUsers", []string{userID}).Return(userWallets, nil).Once()
+		mockTransactions.On("ListTransactions", models.TransactionFilter{WalletID: walletID1, Limit: 2}).Return([]models.Transaction{
+			{ID: 12, Amount: -500, CounterpartyWalletID: walletID2, Status: "completed", CreatedAt: created},
+			{ID: 11, Amount: 1000, CounterpartyWalletID: otherWalletID, Status: "completed", CreatedAt: created},
+		}, nil).Once()
+		mockTransactions.On("ListTransactions", models.TransactionFilter{WalletID: walletID2, Limit: 2}).Return([]models.Transaction{
+			{ID: 9, Amount: 500, CounterpartyWalletID: walletID1, Status: "completed", CreatedAt: created, Tags: []string{"rent"}},
+		}, nil).Once()
+		// One query for the counterparties of both pages
+		counterparties := []string{walletID1, walletID2}
+		slices.Sort(counterparties)
+		mockWallets.On("ListWalletsByIDs", counterparties).Return(userWallets, nil).Once()
+
+		data, errs := executeGraphQL(t, service, `{
+			viewer {
+				wallets {
+					id balance
+					transactions(first: 1) {
+						edges { cursor node { id type amount tags counterpartyWallet { id } } }
+						pageInfo { hasNextPage endCursor }
+					}
+				}
+			}
+		}`, nil, userID)
+
+		require.Empty(t, errs)
+		walletsData := data["viewer"].(map[string]any)["wallets"].([]any)
+		require.Len(t, walletsData, 2)
+
+		first := walletsData[0].(map[string]any)
+		assert.Equal(t, "150.00", first["balance"])
+		connection := first["transactions"].(map[string]any)
+		edges := connection["edges"].([]any)
+		require.Len(t, edges, 1)
+		edge := edges[0].(map[string]any)
+		assert.Equal(t, map[string]any{
+			"id": "12", "type": "debit", "amount": "5.00", "tags": []any{},
+			"counterpartyWallet": map[string]any{"id": walletID2},
+		}, edge["node"])
+		assert.Equal(t, map[string]any{"hasNextPage": true, "endCursor": edge["cursor"]}, connection["pageInfo"])
+
+		second := walletsData[1].(map[string]any)["transactions"].(map[string]any)
+		assert.Equal(t, false, second["pageInfo"].(map[string]any)["hasNextPage"])
+		mockWallets.AssertExpectations(t)
+		mockTransactions.AssertExpectations(t)
+	})
+
+	t.Run("Storage errors are hidden", func(t *testing.T) {
+		mockWallets.On("ListWalletsByUsers", []string{userID}).Return([]models.Wallet(nil), errors.New("connection refused")).Once()
+
+		_, errs := executeGraphQL(t, service, `{ viewer { id wallets { id } } }`, nil, userID)
+
+		require.Len(t, errs, 1)
+		assert.Equal(t, "internal error", errs[0].Message)
+		assert.Equal(t, []any{"viewer", "wallets"}, errs[0].Path)
+	})
+
+	t.Run("Query over the complexity limit", func(t *testing.T) {
+		data, errs := executeGraphQL(t, service, `{ viewer { wallets { transactions(first: 100) {
+			edges { node { counterpartyWallet { transactions(first: 100) { edges { cursor } } } } }
+		} } } }`, nil, userID)
+
+		assert.Nil(t, data)
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Message, "complexity")
+		mockWallets.AssertNumberOfCalls(t, "ListWalletsByUsers", 2)
+	})
+
+	t.Run("Query over the depth and length limits", func(t *testing.T) {
+		deep := `{ viewer { wallets { transactions(first: 1) { edges { node { counterpartyWallet {
+			transactions(first: 1) { edges { node { counterpartyWallet { id } } } } } } } } } } }`
+		long := `{ viewer { id } }` + strings.Repeat(" ", models.GraphQLMaxQueryLength)
+
+		for query, message := range map[string]string{deep: "query depth exceeds", long: "query length exceeds"} {
+			data, errs := executeGraphQL(t, service, query, nil, userID)
+
+			assert.Nil(t, data)
+			require.Len(t, errs, 1)
+			assert.Contains(t, errs[0].Message, message)
+		}
+		mockWallets.AssertNumberOfCalls(t, "ListWalletsByUsers", 2)
+	})
+}
+
+func TestGraphQLWallet(t *testing.T) {
+	mockWallets := new(MockWalletStorage)
+	mockTransactions := new(MockTransactionStorage)
+	service := &graphqlService{wallets: mockWallets, transactions: mockTransactions, logger: log.Default()}
+	service.executor = service.buildExecutor()
+
+	userID := uuid.New().String()
+	walletID := uuid.New().String()
+	otherWalletID := uuid.New().String()
+	wallet := models.Wallet{ID: walletID, UserID: userID, Balance: 15000}
+
+	t.Run("Transactions continue after the cursor", func(t *testing.T) {
+		mockWallets.On("ListWalletsByIDs", []string{walletID}).Return([]models.Wallet{wallet}, nil).Once()
+		mockTransactions.On("ListTransactions", models.TransactionFilter{WalletID: walletID, BeforeID: 12, Type: "debit", Category: "food", Limit: 21}).
+			Return([]models.Transaction{}, nil).Once()
+
+		data, errs := executeGraphQL(t, service, `query($id: ID!, $after: String) {
+			wallet(id: $id) { transactions(after: $after, type: "debit", category: " Food ") { pageInfo { hasNextPage endCursor } } }
+		}`, map[string]any{"id": walletID, "after": encodeCursor(12)}, userID)
+
+		require.Empty(t, errs)
+		assert.Equal(t, map[string]any{"hasNextPage": false, "endCursor": nil},
+			data["wallet"].(map[string]any)["transactions"].(map[string]any)["pageInfo"])
+		mockWallets.AssertExpectations(t)
+		mockTransactions.AssertExpectations(t)
+	})
+
+	t.Run("Wallets of other users and invalid ids are null", func(t *testing.T) {
+		mockWallets.On("ListWalletsByIDs", []string{otherWalletID}).Return([]models.Wallet{{ID: otherWalletID, UserID: uuid.New().String()}}, nil).Once()
+
+		data, errs := executeGraphQL(t, service, `{ a: wallet(id: "`+otherWalletID+`") { id } b: wallet(id: "nope") { id } }`, nil, userID)
+
+		require.Empty(t, errs)
+		assert.Equal(t, map[string]any{"a": nil, "b": nil}, data)
+		mockWallets.AssertExpectations(t)
+	})
+
+	t.Run("Invalid transaction arguments", func(t *testing.T) {
+		for query, message := range map[string]string{
+			`{ wallet(id: "` + walletID + `") { transactions(first: 0) { edges { cursor } } } }`:       ErrInvalidPageSize.Error(),
+			`{ wallet(id: "` + walletID + `") { transactions(after: "!!") { edges { cursor } } } }`:    ErrInvalidCursor.Error(),
+			`{ wallet(id: "` + walletID + `") { transactions(type: "refund") { edges { cursor } } } }`: "type must be credit or debit",
+		} {
+			mockWallets.On("ListWalletsByIDs", []string{walletID}).Return([]models.Wallet{wallet}, nil).Once()
+
+			data, errs := executeGraphQL(t, service, query, nil, userID)
+
+			require.Len(t, errs, 1, query)
+			assert.Equal(t, message, errs[0].Message)
+			assert.Nil(t, data["wallet"], query)
+		}
+		mockTransactions.AssertNumberOfCalls(t, "ListTransactions", 1)
+	})
+}
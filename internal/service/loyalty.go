@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/outbox"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+type LoyaltyService interface {
+	GetBalance(userID string) (*models.LoyaltyBalance, error)
+	History(request models.LoyaltyHistoryRequest, userID string) ([]models.LoyaltyEntry, error)
+	Redeem(request models.RedeemPointsRequest, userID string) (*models.LoyaltyRedemption, error)
+	CreateRule(request models.CreateLoyaltyRuleRequest) (*models.LoyaltyRule, error)
+	ListRules() ([]models.LoyaltyRule, error)
+	DisableRule(ruleID string) error
+	ExpireDue() (int64, error)
+	Run(ctx context.Context)
+}
+
+var (
+	ErrLoyaltyRuleNotFound = errors.New("loyalty rule not found")
+	ErrRedemptionTooSmall  = errors.New("too few points to redeem")
+)
+
+const (
+	// loyaltyReferencePrefix marks the top-up of a points redemption, followed by its id
+	loyaltyReferencePrefix = "loyalty:"
+	// loyaltyExpiryNotice is how far ahead the balance reports points about to expire
+	loyaltyExpiryNotice   = 30 * 24 * time.Hour
+	loyaltyExpiryInterval = time.Hour
+)
+
+// loyaltyEarner awards points for wallet events. It is separate from the loyalty
+// service, the wallet service needs it before the loyalty service can be built.
+type loyaltyEarner struct {
+	storage storage.LoyaltyStorager
+	ttl     time.Duration
+	now     func() time.Time
+	logger  *log.Logger
+}
+
+// NewLoyaltyEarner returns the outbox sink that awards points for wallet events.
+// Earned points expire after ttl.
+func NewLoyaltyEarner(db *sql.DB, ttl time.Duration) outbox.Sink {
+	return &loyaltyEarner{
+		storage: storage.NewLoyaltyStorage(db),
+		ttl:     ttl,
+		now:     time.Now,
+		logger:  log.New(log.Writer(), "LoyaltyService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+// Publish awards the points of every active rule for the type of the outbox event.
+// Credits of points redemptions earn nothing. A transaction earns once per rule, so
+// an event handed over again after an error doesn't award twice.
+func (e *loyaltyEarner) Publish(ctx context.Context, domainEvent models.DomainEvent) error {
+	event, ok, err := walletEvent(domainEvent)
+	if err != nil || !ok {
+		return err
+	}
+	if strings.HasPrefix(event.Reference, loyaltyReferencePrefix) {
+		return nil
+	}
+
+	rules, err := e.storage.ListActiveRules(event.Type)
+	if err != nil {
+		return errors.Wrapf(err, "unable to list loyalty rules for %s", event.Type)
+	}
+
+	expiresAt := e.now().Add(e.ttl)
+	for _, rule := range rules {
+		if event.Amount < rule.MinAmount {
+			continue
+		}
+		points := event.Amount / 100 * rule.PointsPerUnit
+		if points <= 0 {
+			continue
+		}
+
+		entry := &models.LoyaltyEntry{
+			UserID:        event.UserID,
+			Points:        points,
+			ExpiresAt:     &expiresAt,
+			RuleID:        rule.ID,
+			WalletID:      event.WalletID,
+			TransactionID: event.TransactionID,
+		}
+		if _, err := e.storage.Earn(entry); err != nil {
+			return errors.Wrapf(err, "unable to award %d points to %s for transaction %d", points, event.UserID, event.TransactionID)
+		}
+	}
+
+	return nil
+}
+
+type loyaltyService struct {
+	storage       storage.LoyaltyStorager
+	wallets       storage.WalletStorager
+	walletService WalletService
+	pointValue    int64
+	now           func() time.Time
+	logger        *log.Logger
+}
+
+// NewLoyaltyService converts points to wallet credit at pointValue minor units per point
+func NewLoyaltyService(db *sql.DB, walletService WalletService, pointValue int64) LoyaltyService {
+	return &loyaltyService{
+		storage:       storage.NewLoyaltyStorage(db),
+		wallets:       storage.NewWalletStorage(db),
+		walletService: walletService,
+		pointValue:    pointValue,
+		now:           time.Now,
+		logger:        log.New(log.Writer(), "LoyaltyService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+func (s *loyaltyService) GetBalance(userID string) (*models.LoyaltyBalance, error) {
+	now := s.now()
+	balance, err := s.storage.GetBalance(userID, now, now.Add(loyaltyExpiryNotice))
+	if err != nil {
+		s.logger.Printf("Error getting points balance: %v", err)
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+func (s *loyaltyService) History(request models.LoyaltyHistoryRequest, userID string) ([]models.LoyaltyEntry, error) {
+	limit := request.Limit
+	if limit == 0 {
+		limit = models.LoyaltyHistoryLimit
+	}
+
+	entries, err := s.storage.ListEntries(userID, request.BeforeID, limit)
+	if err != nil {
+		s.logger.Printf("Error listing points history: %v", err)
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Redeem converts points to wallet credit through the regular top-up, so the
+// wallet limits apply. The points are taken in the transaction of the credit, a
+// credit that fails keeps them.
+func (s *loyaltyService) Redeem(request models.RedeemPointsRequest, userID string) (*models.LoyaltyRedemption, error) {
+	s.logger.Printf("Redeeming points: walletID=%s, userID=%s, points=%d", request.WalletID, userID, request.Points)
+	if request.Points < models.LoyaltyMinRedemption {
+		return nil, ErrRedemptionTooSmall
+	}
+
+	wallet, err := s.wallets.GetWallet(request.WalletID, userID)
+	if err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return nil, errors.Wrap(err, "Error getting wallet")
+	}
+
+	// Most redemptions over the balance are refused before a top-up is staged
+	now := s.now()
+	balance, err := s.storage.GetBalance(userID, now, now)
+	if err != nil {
+		s.logger.Printf("Error getting points balance: %v", err)
+		return nil, err
+	}
+	if balance.Points < request.Points {
+		return nil, storage.ErrInsufficientPoints
+	}
+
+	redemption := &models.LoyaltyRedemption{
+		ID:       uuid.New().String(),
+		UserID:   userID,
+		WalletID: wallet.ID,
+		Points:   request.Points,
+		Amount:   request.Points * s.pointValue,
+	}
+	_, err = s.walletService.TopUpWallet(models.TopUpRequest{
+		WalletID:    wallet.ID,
+		Amount:      models.FormatAmount(redemption.Amount),
+		Description: "Loyalty points redemption",
+		Reference:   loyaltyReferencePrefix + redemption.ID,
+	}, userID, s.storage.RedeemPoints(redemption, now))
+	if err != nil {
+		s.logger.Printf("Error redeeming points: %v", err)
+		return nil, err
+	}
+
+	return redemption, nil
+}
+
+func (s *loyaltyService) CreateRule(request models.CreateLoyaltyRuleRequest) (*models.LoyaltyRule, error) {
+	s.logger.Printf("Creating loyalty rule: eventType=%s, pointsPerUnit=%d", request.EventType, request.PointsPerUnit)
+	if !slices.Contains(models.WalletEventTypes, request.EventType) {
+		return nil, errors.Wrap(ErrUnknownEventType, request.EventType)
+	}
+
+	var minAmount int64
+	if request.MinAmount != "" {
+		var err error
+		minAmount, err = models.ParseAmount(request.MinAmount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rule := &models.LoyaltyRule{
+		EventType:     request.EventType,
+		PointsPerUnit: request.PointsPerUnit,
+		MinAmount:     minAmount,
+		Active:        true,
+	}
+	if err := s.storage.CreateRule(rule); err != nil {
+		s.logger.Printf("Error creating loyalty rule: %v", err)
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *loyaltyService) ListRules() ([]models.LoyaltyRule, error) {
+	rules, err := s.storage.ListRules()
+	if err != nil {
+		s.logger.Printf("Error listing loyalty rules: %v", err)
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (s *loyaltyService) DisableRule(ruleID string) error {
+	s.logger.Printf("Disabling loyalty rule: ruleID=%s", ruleID)
+	disabled, err := s.storage.DisableRule(ruleID)
+	if err != nil {
+		s.logger.Printf("Error disabling loyalty rule: %v", err)
+		return err
+	}
+	if !disabled {
+		return ErrLoyaltyRuleNotFound
+	}
+
+	return nil
+}
+
+// ExpireDue writes off the points whose lots are older than the points lifetime
+func (s *loyaltyService) ExpireDue() (int64, error) {
+	expired, err := s.storage.ExpirePoints(s.now())
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to expire points")
+	}
+	if expired > 0 {
+		s.logger.Printf("Expired %d points", expired)
+	}
+
+	return expired, nil
+}
+
+func (s *loyaltyService) Run(ctx context.Context) {
+	ticker := time.NewTicker(loyaltyExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ExpireDue(); err != nil {
+				s.logger.Printf("Error expiring points: %v", err)
+			}
+		}
+	}
+}
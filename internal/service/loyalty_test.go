@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock implementation of LoyaltyStorage
+type MockLoyaltyStorage struct {
+	mock.Mock
+}
+
+func (m *MockLoyaltyStorage) CreateRule(rule *models.LoyaltyRule) error {
+	args := m.Called(rule)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyStorage) ListRules() ([]models.LoyaltyRule, error) {
+	args := m.Called()
+	return args.Get(0).([]models.LoyaltyRule), args.Error(1)
+}
+
+func (m *MockLoyaltyStorage) ListActiveRules(eventType string) ([]models.LoyaltyRule, error) {
+	args := m.Called(eventType)
+	return args.Get(0).([]models.LoyaltyRule), args.Error(1)
+}
+
+func (m *MockLoyaltyStorage) DisableRule(ruleID string) (bool, error) {
+	args := m.Called(ruleID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLoyaltyStorage) Earn(entry *models.LoyaltyEntry) (bool, error) {
+	args := m.Called(entry)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLoyaltyStorage) GetBalance(userID string, now, soon time.Time) (*models.LoyaltyBalance, error) {
+	args := m.Called(userID, now, soon)
+	return args.Get(0).(*models.LoyaltyBalance), args.Error(1)
+}
+
+func (m *MockLoyaltyStorage) ListEntries(userID string, beforeID int64, limit int) ([]models.LoyaltyEntry, error) {
+	args := m.Called(userID, beforeID, limit)
+	return args.Get(0).([]models.LoyaltyEntry), args.Error(1)
+}
+
+// RedeemPoints completes the redemption like the storage does once the credit ran
+func (m *MockLoyaltyStorage) RedeemPoints(redemption *models.LoyaltyRedemption, now time.Time) storage.TransferHook {
+	args := m.Called(redemption, now)
+	return func(_ *sql.Tx, result *models.TransferResult) error {
+		if err := args.Error(0); err != nil {
+			return err
+		}
+		redemption.Status = models.LoyaltyRedemptionCompleted
+		redemption.TransactionID = result.CreditTransactionID
+		redemption.CompletedAt = &now
+		return nil
+	}
+}
+
+func (m *MockLoyaltyStorage) ExpirePoints(now time.Time) (int64, error) {
+	args := m.Called(now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestLoyaltyEarner_Publish(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(24 * time.Hour)
+
+	t.Run("Awards points per whole unit", func(t *testing.T) {
+		mockStorage := new(MockLoyaltyStorage)
+		e := &loyaltyEarner{storage: mockStorage, ttl: 24 * time.Hour, now: func() time.Time { return now }, logger: log.Default()}
+		mockStorage.On("ListActiveRules", models.EventWalletDebited).Return([]models.LoyaltyRule{
+			{ID: "base", PointsPerUnit: 1},
+			{ID: "big-spender", PointsPerUnit: 2, MinAmount: 100000},
+		}, nil)
+		mockStorage.On("Earn", &models.LoyaltyEntry{
+			UserID:        "user1",
+			Points:        125,
+			ExpiresAt:     &expiresAt,
+			RuleID:        "base",
+			WalletID:      "wallet1",
+			TransactionID: 7,
+		}).Return(true, nil)
+
+		err := e.Publish(context.Background(), models.DomainEvent{
+			ID:      1,
+			Type:    models.DomainEventWalletDebited,
+			Payload: json.RawMessage(`{"wallet_id":"wallet1","user_id":"user1","amount":-12599,"transaction_id":7}`),
+		})
+
+		assert.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+		mockStorage.AssertNumberOfCalls(t, "Earn", 1)
+	})
+
+	t.Run("Redemption credits earn nothing", func(t *testing.T) {
+		mockStorage := new(MockLoyaltyStorage)
+		e := &loyaltyEarner{storage: mockStorage, ttl: 24 * time.Hour, now: func() time.Time { return now }, logger: log.Default()}
+
+		err := e.Publish(context.Background(), models.DomainEvent{
+			ID:      2,
+			Type:    models.DomainEventWalletToppedUp,
+			Payload: json.RawMessage(`{"wallet_id":"wallet1","user_id":"user1","amount":10000,"reference":"` + loyaltyReferencePrefix + `r1"}`),
+		})
+
+		assert.NoError(t, err)
+		mockStorage.AssertNotCalled(t, "ListActiveRules", mock.Anything)
+	})
+
+	t.Run("Storage errors are returned for a retry", func(t *testing.T) {
+		mockStorage := new(MockLoyaltyStorage)
+		e := &loyaltyEarner{storage: mockStorage, ttl: 24 * time.Hour, now: func() time.Time { return now }, logger: log.Default()}
+		mockStorage.On("ListActiveRules", models.EventWalletCredited).Return([]models.LoyaltyRule{{ID: "base", PointsPerUnit: 1}}, nil)
+		mockStorage.On("Earn", mock.Anything).Return(false, errors.New("connection reset"))
+
+		err := e.Publish(context.Background(), models.DomainEvent{
+			ID:      3,
+			Type:    models.DomainEventWalletCredited,
+			Payload: json.RawMessage(`{"wallet_id":"wallet1","user_id":"user1","amount":500,"transaction_id":8}`),
+		})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestLoyaltyService_Redeem(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	wallet := &models.Wallet{ID: "wallet1", UserID: "user1"}
+
+	newService := func() (*loyaltyService, *MockLoyaltyStorage, *MockWalletStorage, *MockWalletService) {
+		mockStorage := new(MockLoyaltyStorage)
+		mockWallets := new(MockWalletStorage)
+		mockWalletService := new(MockWalletService)
+		return &loyaltyService{
+			storage:       mockStorage,
+			wallets:       mockWallets,
+			walletService: mockWalletService,
+			pointValue:    1,
+			now:           func() time.Time { return now },
+			logger:        log.Default(),
+		}, mockStorage, mockWallets, mockWalletService
+	}
+
+	t.Run("Points are taken with the top-up", func(t *testing.T) {
+		s, mockStorage, mockWallets, mockWalletService := newService()
+		mockWallets.On("GetWallet", "wallet1", "user1").Return(wallet, nil)
+		mockStorage.On("GetBalance", "user1", now, now).Return(&models.LoyaltyBalance{Points: 300}, nil)
+		mockStorage.On("RedeemPoints", mock.MatchedBy(func(r *models.LoyaltyRedemption) bool {
+			return r.UserID == "user1" && r.WalletID == "wallet1" && r.Points == 250 && r.Amount == 250
+		}), now).Return(nil)
+		mockWalletService.On("TopUpWallet", mock.MatchedBy(func(r models.TopUpRequest) bool {
+			return r.WalletID == "wallet1" && r.Amount == "2.50" && r.Description == "Loyalty points redemption" &&
+				strings.HasPrefix(r.Reference, "loyalty:")
+		}), "user1").Return(int64(42), nil)
+
+		redemption, err := s.Redeem(models.RedeemPointsRequest{WalletID: "wallet1", Points: 250}, "user1")
+
+		require.NoError(t, err)
+		assert.Equal(t, models.LoyaltyRedemptionCompleted, redemption.Status)
+		assert.Equal(t, int64(42), redemption.TransactionID)
+		request := mockWalletService.Calls[0].Arguments.Get(0).(models.TopUpRequest)
+		assert.Equal(t, "loyalty:"+redemption.ID, request.Reference)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Failed credit keeps the points", func(t *testing.T) {
+		s, mockStorage, mockWallets, mockWalletService := newService()
+		mockWallets.On("GetWallet", "wallet1", "user1").Return(wallet, nil)
+		mockStorage.On("GetBalance", "user1", now, now).Return(&models.LoyaltyBalance{Points: 300}, nil)
+		mockStorage.On("RedeemPoints", mock.AnythingOfType("*models.LoyaltyRedemption"), now).Return(nil)
+		mockWalletService.On("TopUpWallet", mock.AnythingOfType("models.TopUpRequest"), "user1").Return(int64(0), ErrMaxBalanceExceeded)
+
+		_, err := s.Redeem(models.RedeemPointsRequest{WalletID: "wallet1", Points: 250}, "user1")
+
+		assert.ErrorIs(t, err, ErrMaxBalanceExceeded)
+	})
+
+	t.Run("Not enough points", func(t *testing.T) {
+		s, mockStorage, mockWallets, mockWalletService := newService()
+		mockWallets.On("GetWallet", "wallet1", "user1").Return(wallet, nil)
+		mockStorage.On("GetBalance", "user1", now, now).Return(&models.LoyaltyBalance{Points: 200}, nil)
+
+		_, err := s.Redeem(models.RedeemPointsRequest{WalletID: "wallet1", Points: 250}, "user1")
+
+		assert.ErrorIs(t, err, storage.ErrInsufficientPoints)
+		mockWalletService.AssertNotCalled(t, "TopUpWallet", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Points spent concurrently", func(t *testing.T) {
+		s, mockStorage, mockWallets, mockWalletService := newService()
+		mockWallets.On("GetWallet", "wallet1", "user1").Return(wallet, nil)
+		mockStorage.On("GetBalance", "user1", now, now).Return(&models.LoyaltyBalance{Points: 300}, nil)
+		mockStorage.On("RedeemPoints", mock.AnythingOfType("*models.LoyaltyRedemption"), now).Return(storage.ErrInsufficientPoints)
+		mockWalletService.On("TopUpWallet", mock.AnythingOfType("models.TopUpRequest"), "user1").Return(int64(42), nil)
+
+		_, err := s.Redeem(models.RedeemPointsRequest{WalletID: "wallet1", Points: 250}, "user1")
+
+		assert.ErrorIs(t, err, storage.ErrInsufficientPoints)
+	})
+
+	t.Run("Below minimum", func(t *testing.T) {
+		s, _, _, _ := newService()
+
+		_, err := s.Redeem(models.RedeemPointsRequest{WalletID: "wallet1", Points: models.LoyaltyMinRedemption - 1}, "user1")
+
+		assert.ErrorIs(t, err, ErrRedemptionTooSmall)
+	})
+}
+
+func TestLoyaltyService_CreateRule(t *testing.T) {
+	mockStorage := new(MockLoyaltyStorage)
+	s := &loyaltyService{storage: mockStorage, now: time.Now, logger: log.Default()}
+
+	t.Run("Success", func(t *testing.T) {
+		mockStorage.On("CreateRule", &models.LoyaltyRule{
+			EventType:     models.EventWalletToppedUp,
+			PointsPerUnit: 3,
+			MinAmount:     5000,
+			Active:        true,
+		}).Return(nil).Once()
+
+		rule, err := s.CreateRule(models.CreateLoyaltyRuleRequest{EventType: models.EventWalletToppedUp, PointsPerUnit: 3, MinAmount: "50"})
+
+		require.NoError(t, err)
+		assert.True(t, rule.Active)
+	})
+
+	t.Run("Unknown event type", func(t *testing.T) {
+		_, err := s.CreateRule(models.CreateLoyaltyRuleRequest{EventType: "wallet.closed", PointsPerUnit: 1})
+
+		assert.ErrorIs(t, err, ErrUnknownEventType)
+	})
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+type MerchantService interface {
+	CreateMerchant(request models.CreateMerchantRequest, userID string) (*models.Merchant, error)
+	GetMerchant(merchantID, userID string) (*models.Merchant, error)
+	RotateCredentials(merchantID, userID string) (*models.Merchant, error)
+	Authenticate(apiKey, digest string, body []byte) (*models.Merchant, error)
+	CreateOrder(merchantID string, request models.CreateOrderRequest) (*models.MerchantOrder, error)
+	GetOrder(merchantID, orderID string) (*models.MerchantOrder, []models.MerchantOrderEvent, error)
+	CancelOrder(merchantID, orderID string) error
+	GetOrderForCustomer(orderID string) (*models.MerchantOrder, *models.Merchant, error)
+	ConfirmOrder(request models.ConfirmOrderRequest, userID string) (*models.MerchantOrder, error)
+}
+
+var (
+	ErrMerchantNotFound        = errors.New("merchant not found")
+	ErrInvalidCredentials      = errors.New("invalid merchant credentials")
+	ErrOrderNotFound           = errors.New("order not found")
+	ErrOrderNotPayable         = storage.ErrOrderNotPayable
+	ErrOrderNotCancellable     = errors.New("order can no longer be cancelled")
+	ErrOrderExpired            = errors.New("order has expired")
+	ErrDuplicateOrderReference = errors.New("order with this reference already exists")
+)
+
+const orderReferencePrefix = "order:"
+
+type merchantService struct {
+	storage       storage.MerchantStorager
+	wallets       storage.WalletStorager
+	walletService WalletService
+	logger        *log.Logger
+}
+
+func NewMerchantService(db *sql.DB, walletService WalletService) MerchantService {
+	return &merchantService{
+		storage:       storage.NewMerchantStorage(db),
+		wallets:       storage.NewWalletStorage(db),
+		walletService: walletService,
+		logger:        log.New(log.Writer(), "MerchantService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+func (s *merchantService) CreateMerchant(request models.CreateMerchantRequest, userID string) (*models.Merchant, error) {
+	s.logger.Printf("Creating merchant: userID=%s, legalName=%s", userID, request.LegalName)
+	wallet, err := s.wallets.GetWallet(request.SettlementWalletID, userID)
+	if err != nil {
+		s.logger.Printf("Error getting settlement wallet: %v", err)
+		return nil, errors.Wrap(err, "Error getting settlement wallet")
+	}
+
+	apiKey, apiSecret, err := generateCredentials()
+	if err != nil {
+		s.logger.Printf("Error generating credentials: %v", err)
+		return nil, err
+	}
+
+	merchant := &models.Merchant{
+		OwnerID:            userID,
+		LegalName:          request.LegalName,
+		TaxID:              request.TaxID,
+		LegalAddress:       request.LegalAddress,
+		SettlementWalletID: wallet.ID,
+		MerchantCategory:   request.MerchantCategory,
+		APIKey:             apiKey,
+		APISecret:          apiSecret,
+	}
+	if err := s.storage.CreateMerchant(merchant); err != nil {
+		s.logger.Printf("Error creating merchant: %v", err)
+		return nil, err
+	}
+
+	return merchant, nil
+}
+
+func (s *merchantService) GetMerchant(merchantID, userID string) (*models.Merchant, error) {
+	s.logger.Printf("Getting merchant: merchantID=%s, userID=%s", merchantID, userID)
+	merchant, err := s.storage.GetMerchant(merchantID)
+	if err == sql.ErrNoRows {
+		return nil, ErrMerchantNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting merchant: %v", err)
+		return nil, err
+	}
+
+	if merchant.OwnerID != userID {
+		return nil, ErrMerchantNotFound
+	}
+
+	return merchant, nil
+}
+
+// RotateCredentials replaces the API key and secret, the old ones stop working at once
+func (s *merchantService) RotateCredentials(merchantID, userID string) (*models.Merchant, error) {
+	merchant, err := s.GetMerchant(merchantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Printf("Rotating merchant credentials: merchantID=%s", merchantID)
+	merchant.APIKey, merchant.APISecret, err = generateCredentials()
+	if err != nil {
+		s.logger.Printf("Error generating credentials: %v", err)
+		return nil, err
+	}
+
+	if err := s.storage.UpdateCredentials(merchant.ID, merchant.APIKey, merchant.APISecret); err != nil {
+		s.logger.Printf("Error updating credentials: %v", err)
+		return nil, err
+	}
+
+	return merchant, nil
+}
+
+// Authenticate checks that digest is the HMAC-SHA256 of body under the merchant's secret
+func (s *merchantService) Authenticate(apiKey, digest string, body []byte) (*models.Merchant, error) {
+	merchant, err := s.storage.GetMerchantByAPIKey(apiKey)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		s.logger.Printf("Error getting merchant by API key: %v", err)
+		return nil, err
+	}
+
+	h := hmac.New(sha256.New, []byte(merchant.APISecret))
+	h.Write(body)
+	if !hmac.Equal([]byte(hex.EncodeToString(h.Sum(nil))), []byte(digest)) {
+		return nil, ErrInvalidCredentials
+	}
+
+	return merchant, nil
+}
+
+func (s *merchantService) CreateOrder(merchantID string, request models.CreateOrderRequest) (*models.MerchantOrder, error) {
+	s.logger.Printf("Creating order: merchantID=%s, amount=%s, reference=%s", merchantID, request.Amount, request.Reference)
+	amount, err := models.ParseAmount(request.Amount)
+	if err != nil {
+		s.logger.Printf("Error parsing amount: %v", err)
+		return nil, err
+	}
+
+	ttl := models.DefaultOrderTTL
+	if request.ExpiresInMinutes > 0 {
+		ttl = time.Duration(request.ExpiresInMinutes) * time.Minute
+	}
+
+	order := &models.MerchantOrder{
+		MerchantID:  merchantID,
+		Amount:      amount,
+		Reference:   request.Reference,
+		Description: request.Description,
+		Status:      models.OrderStatusCreated,
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+	if err := s.storage.CreateOrder(order); err != nil {
+		if storage.IsUniqueViolation(err) {
+			return nil, ErrDuplicateOrderReference
+		}
+		s.logger.Printf("Error creating order: %v", err)
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (s *merchantService) GetOrder(merchantID, orderID string) (*models.MerchantOrder, []models.MerchantOrderEvent, error) {
+	s.logger.Printf("Getting order: merchantID=%s, orderID=%s", merchantID, orderID)
+	order, err := s.getOrder(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if order.MerchantID != merchantID {
+		return nil, nil, ErrOrderNotFound
+	}
+
+	events, err := s.storage.ListOrderEvents(order.ID)
+	if err != nil {
+		s.logger.Printf("Error listing order events: %v", err)
+		return nil, nil, err
+	}
+
+	return order, events, nil
+}
+
+func (s *merchantService) CancelOrder(merchantID, orderID string) error {
+	s.logger.Printf("Cancelling order: merchantID=%s, orderID=%s", merchantID, orderID)
+	order, err := s.getOrder(orderID)
+	if err != nil {
+		return err
+	}
+
+	if order.MerchantID != merchantID {
+		return ErrOrderNotFound
+	}
+
+	updated, err := s.storage.UpdateOrderStatus(order.ID, models.OrderStatusCreated, models.OrderStatusCancelled, "cancelled by merchant")
+	if err != nil {
+		s.logger.Printf("Error cancelling order: %v", err)
+		return err
+	}
+	if !updated {
+		return ErrOrderNotCancellable
+	}
+
+	return nil
+}
+
+func (s *merchantService) GetOrderForCustomer(orderID string) (*models.MerchantOrder, *models.Merchant, error) {
+	s.logger.Printf("Getting order for customer: orderID=%s", orderID)
+	order, err := s.getOrder(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merchant, err := s.storage.GetMerchant(order.MerchantID)
+	if err != nil {
+		s.logger.Printf("Error getting merchant: %v", err)
+		return nil, nil, err
+	}
+
+	return order, merchant, nil
+}
+
+// ConfirmOrder pays the order from the customer's wallet into the merchant's settlement
+// wallet. The order is marked as paid in the transaction of the transfer, so it can't be
+// paid twice.
+func (s *merchantService) ConfirmOrder(request models.ConfirmOrderRequest, userID string) (*models.MerchantOrder, error) {
+	s.logger.Printf("Confirming order: orderID=%s, walletID=%s, userID=%s", request.OrderID, request.WalletID, userID)
+	order, err := s.getOrder(request.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status == models.OrderStatusExpired {
+		return nil, ErrOrderExpired
+	}
+
+	merchant, err := s.storage.GetMerchant(order.MerchantID)
+	if err != nil {
+		s.logger.Printf("Error getting merchant: %v", err)
+		return nil, err
+	}
+
+	if order.Status != models.OrderStatusCreated {
+		return nil, ErrOrderNotPayable
+	}
+
+	transactionID, err := s.walletService.Transfer(models.TransferRequest{
+		WalletID:         request.WalletID,
+		ToWalletID:       merchant.SettlementWalletID,
+		Amount:           models.FormatAmount(order.Amount),
+		MerchantCategory: merchant.MerchantCategory,
+		Reference:        orderReferencePrefix + order.ID,
+	}, userID, s.storage.CompleteOrder(order.ID, request.WalletID, "confirmed by customer "+userID))
+	if err != nil {
+		s.logger.Printf("Error paying order: %v", err)
+		return nil, err
+	}
+
+	order.Status = models.OrderStatusPaid
+	order.CustomerWalletID = request.WalletID
+	order.TransactionID = transactionID
+
+	return order, nil
+}
+
+// getOrder loads the order and expires it if it was not paid in time
+func (s *merchantService) getOrder(orderID string) (*models.MerchantOrder, error) {
+	order, err := s.storage.GetOrder(orderID)
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting order: %v", err)
+		return nil, err
+	}
+
+	if order.Status == models.OrderStatusCreated && time.Now().After(order.ExpiresAt) {
+		updated, err := s.storage.UpdateOrderStatus(order.ID, models.OrderStatusCreated, models.OrderStatusExpired, "")
+		if err != nil {
+			s.logger.Printf("Error expiring order: %v", err)
+			return nil, err
+		}
+		if updated {
+			order.Status = models.OrderStatusExpired
+		}
+	}
+
+	return order, nil
+}
+
+func generateCredentials() (string, string, error) {
+	key := make([]byte, 12)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	return "mk_" + hex.EncodeToString(key), hex.EncodeToString(secret), nil
+}
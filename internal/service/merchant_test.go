@@ -0,0 +1,179 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock implementation of MerchantStorage
+type MockMerchantStorage struct {
+	mock.Mock
+}
+
+func (m *MockMerchantStorage) CreateMerchant(merchant *models.Merchant) error {
+	args := m.Called(merchant)
+	return args.Error(0)
+}
+
+func (m *MockMerchantStorage) GetMerchant(merchantID string) (*models.Merchant, error) {
+	args := m.Called(merchantID)
+	return args.Get(0).(*models.Merchant), args.Error(1)
+}
+
+func (m *MockMerchantStorage) GetMerchantByAPIKey(apiKey string) (*models.Merchant, error) {
+	args := m.Called(apiKey)
+	return args.Get(0).(*models.Merchant), args.Error(1)
+}
+
+func (m *MockMerchantStorage) UpdateCredentials(merchantID, apiKey, apiSecret string) error {
+	args := m.Called(merchantID, apiKey, apiSecret)
+	return args.Error(0)
+}
+
+func (m *MockMerchantStorage) CreateOrder(order *models.MerchantOrder) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
+
+func (m *MockMerchantStorage) GetOrder(orderID string) (*models.MerchantOrder, error) {
+	args := m.Called(orderID)
+	return args.Get(0).(*models.MerchantOrder), args.Error(1)
+}
+
+func (m *MockMerchantStorage) UpdateOrderStatus(orderID, fromStatus, toStatus, details string) (bool, error) {
+	args := m.Called(orderID, fromStatus, toStatus, details)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMerchantStorage) CompleteOrder(orderID, customerWalletID, details string) storage.TransferHook {
+	args := m.Called(orderID, customerWalletID, details)
+	return hookReturning(args.Error(0))
+}
+
+func (m *MockMerchantStorage) ListOrderEvents(orderID string) ([]models.MerchantOrderEvent, error) {
+	args := m.Called(orderID)
+	return args.Get(0).([]models.MerchantOrderEvent), args.Error(1)
+}
+
+func TestMerchantAuthenticate(t *testing.T) {
+	mockStorage := new(MockMerchantStorage)
+	service := &merchantService{storage: mockStorage, logger: log.Default()}
+
+	merchant := &models.Merchant{ID: uuid.New().String(), APIKey: "mk_test", APISecret: "topsecret"}
+	body := []byte(`{"amount":"10.00"}`)
+	h := hmac.New(sha256.New, []byte(merchant.APISecret))
+	h.Write(body)
+	digest := hex.EncodeToString(h.Sum(nil))
+
+	t.Run("Valid digest", func(t *testing.T) {
+		mockStorage.On("GetMerchantByAPIKey", "mk_test").Return(merchant, nil).Once()
+
+		authenticated, err := service.Authenticate("mk_test", digest, body)
+
+		assert.NoError(t, err)
+		assert.Equal(t, merchant.ID, authenticated.ID)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Digest of another body", func(t *testing.T) {
+		mockStorage.On("GetMerchantByAPIKey", "mk_test").Return(merchant, nil).Once()
+
+		_, err := service.Authenticate("mk_test", digest, []byte(`{"amount":"1000.00"}`))
+
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		mockStorage.AssertExpectations(t)
+	})
+}
+
+func TestConfirmOrder(t *testing.T) {
+	mockStorage := new(MockMerchantStorage)
+	mockWalletService := new(MockWalletService)
+	service := &merchantService{storage: mockStorage, walletService: mockWalletService, logger: log.Default()}
+
+	userID := uuid.New().String()
+	walletID := uuid.New().String()
+	merchant := &models.Merchant{ID: uuid.New().String(), SettlementWalletID: uuid.New().String(), MerchantCategory: "5411"}
+
+	newOrder := func(expiresAt time.Time) *models.MerchantOrder {
+		return &models.MerchantOrder{ID: uuid.New().String(), MerchantID: merchant.ID, Amount: 9900, Status: models.OrderStatusCreated, ExpiresAt: expiresAt}
+	}
+
+	t.Run("Successful confirm", func(t *testing.T) {
+		order := newOrder(time.Now().Add(time.Hour))
+		mockStorage.On("GetOrder", order.ID).Return(order, nil).Once()
+		mockStorage.On("GetMerchant", merchant.ID).Return(merchant, nil).Once()
+		mockStorage.On("CompleteOrder", order.ID, walletID, "confirmed by customer "+userID).Return(nil).Once()
+		mockWalletService.On("Transfer", models.TransferRequest{
+			WalletID:         walletID,
+			ToWalletID:       merchant.SettlementWalletID,
+			Amount:           "99.00",
+			MerchantCategory: "5411",
+			Reference:        "order:" + order.ID,
+		}, userID).Return(int64(5), nil).Once()
+
+		paid, err := service.ConfirmOrder(models.ConfirmOrderRequest{OrderID: order.ID, WalletID: walletID}, userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.OrderStatusPaid, paid.Status)
+		mockStorage.AssertExpectations(t)
+		mockWalletService.AssertExpectations(t)
+	})
+
+	t.Run("Failed payment", func(t *testing.T) {
+		order := newOrder(time.Now().Add(time.Hour))
+		mockStorage.On("GetOrder", order.ID).Return(order, nil).Once()
+		mockStorage.On("GetMerchant", merchant.ID).Return(merchant, nil).Once()
+		mockStorage.On("CompleteOrder", order.ID, walletID, mock.Anything).Return(nil).Once()
+		mockWalletService.On("Transfer", mock.Anything, userID).Return(int64(0), errors.New("insufficient funds")).Once()
+
+		_, err := service.ConfirmOrder(models.ConfirmOrderRequest{OrderID: order.ID, WalletID: walletID}, userID)
+
+		assert.Error(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Order paid concurrently", func(t *testing.T) {
+		order := newOrder(time.Now().Add(time.Hour))
+		mockStorage.On("GetOrder", order.ID).Return(order, nil).Once()
+		mockStorage.On("GetMerchant", merchant.ID).Return(merchant, nil).Once()
+		mockStorage.On("CompleteOrder", order.ID, walletID, mock.Anything).Return(storage.ErrOrderNotPayable).Once()
+		mockWalletService.On("Transfer", mock.Anything, userID).Return(int64(6), nil).Once()
+
+		_, err := service.ConfirmOrder(models.ConfirmOrderRequest{OrderID: order.ID, WalletID: walletID}, userID)
+
+		assert.ErrorIs(t, err, ErrOrderNotPayable)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Expired order", func(t *testing.T) {
+		order := newOrder(time.Now().Add(-time.Minute))
+		mockStorage.On("GetOrder", order.ID).Return(order, nil).Once()
+		mockStorage.On("UpdateOrderStatus", order.ID, models.OrderStatusCreated, models.OrderStatusExpired, "").Return(true, nil).Once()
+
+		_, err := service.ConfirmOrder(models.ConfirmOrderRequest{OrderID: order.ID, WalletID: walletID}, userID)
+
+		assert.ErrorIs(t, err, ErrOrderExpired)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Order of another merchant can't be cancelled", func(t *testing.T) {
+		order := newOrder(time.Now().Add(time.Hour))
+		mockStorage.On("GetOrder", order.ID).Return(order, nil).Once()
+
+		err := service.CancelOrder(uuid.New().String(), order.ID)
+
+		assert.ErrorIs(t, err, ErrOrderNotFound)
+		mockStorage.AssertExpectations(t)
+	})
+}
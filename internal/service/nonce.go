@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+// NonceService makes signed requests single use. Every signed /v2 request and
+// WebSocket message carries a nonce, a nonce the user already sent is refused.
+type NonceService interface {
+	Use(userID, nonce string) error
+	Prune() (int64, error)
+	Run(ctx context.Context)
+}
+
+var ErrNonceUsed = errors.New("request was already received")
+
+// nonceTTL is how long a nonce is remembered. A request is accepted while its
+// timestamp is within SignatureMaxSkew of the server time, so a replay of a
+// request whose nonce was used at t passes the timestamp check until at most
+// t + 2 * SignatureMaxSkew.
+const nonceTTL = 2 * models.SignatureMaxSkew
+
+const noncePruneInterval = time.Minute
+
+type nonceService struct {
+	storage storage.NonceStorager
+	now     func() time.Time
+	logger  *log.Logger
+}
+
+func NewNonceService(db *sql.DB) NonceService {
+	return &nonceService{
+		storage: storage.NewNonceStorage(db),
+		now:     time.Now,
+		logger:  log.New(log.Writer(), "NonceService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+func (s *nonceService) Use(userID, nonce string) error {
+	fresh, err := s.storage.UseNonce(userID, nonce, s.now())
+	if err != nil {
+		s.logger.Printf("Error saving nonce: userID=%s: %v", userID, err)
+		return errors.Wrap(err, "unable to save nonce")
+	}
+	if !fresh {
+		s.logger.Printf("Replayed request refused: userID=%s, nonce=%s", userID, nonce)
+		return ErrNonceUsed
+	}
+	return nil
+}
+
+// Prune forgets the nonces no request could be replayed with anymore
+func (s *nonceService) Prune() (int64, error) {
+	deleted, err := s.storage.DeleteNoncesBefore(s.now().Add(-nonceTTL))
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to delete expired nonces")
+	}
+	return deleted, nil
+}
+
+// Run prunes expired nonces until ctx is cancelled
+func (s *nonceService) Run(ctx context.Context) {
+	ticker := time.NewTicker(noncePruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Prune(); err != nil {
+				s.logger.Printf("Error pruning nonces: %v", err)
+			}
+		}
+	}
+}
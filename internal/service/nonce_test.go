@@ -0,0 +1,79 @@
+package service
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock implementation of NonceStorage
+type MockNonceStorage struct {
+	mock.Mock
+}
+
+func (m *MockNonceStorage) UseNonce(userID, nonce string, now time.Time) (bool, error) {
+	args := m.Called(userID, nonce, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockNonceStorage) DeleteNoncesBefore(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestNonceUse(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Fresh nonce is accepted", func(t *testing.T) {
+		mockStorage := new(MockNonceStorage)
+		service := &nonceService{storage: mockStorage, now: func() time.Time { return now }, logger: log.Default()}
+		userID := uuid.New().String()
+
+		mockStorage.On("UseNonce", userID, "n-1", now).Return(true, nil)
+
+		assert.NoError(t, service.Use(userID, "n-1"))
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Used nonce is a replay", func(t *testing.T) {
+		mockStorage := new(MockNonceStorage)
+		service := &nonceService{storage: mockStorage, now: func() time.Time { return now }, logger: log.Default()}
+		userID := uuid.New().String()
+
+		mockStorage.On("UseNonce", userID, "n-1", now).Return(false, nil)
+
+		assert.ErrorIs(t, service.Use(userID, "n-1"), ErrNonceUsed)
+	})
+
+	t.Run("Storage error isn't taken for a replay", func(t *testing.T) {
+		mockStorage := new(MockNonceStorage)
+		service := &nonceService{storage: mockStorage, now: func() time.Time { return now }, logger: log.Default()}
+		userID := uuid.New().String()
+
+		mockStorage.On("UseNonce", userID, "n-1", now).Return(false, errors.New("connection refused"))
+
+		err := service.Use(userID, "n-1")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrNonceUsed)
+	})
+}
+
+func TestNoncePrune(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	mockStorage := new(MockNonceStorage)
+	service := &nonceService{storage: mockStorage, now: func() time.Time { return now }, logger: log.Default()}
+
+	// Nonces are kept as long as a replay could pass the timestamp check
+	mockStorage.On("DeleteNoncesBefore", now.Add(-2*models.SignatureMaxSkew)).Return(int64(3), nil)
+
+	deleted, err := service.Prune()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	mockStorage.AssertExpectations(t)
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/notify"
+	"github.com/rasul07/alif-task/internal/outbox"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+type NotificationService interface {
+	SetPreference(request models.SetNotificationPreferenceRequest, userID string) (*models.NotificationPreference, error)
+	ListPreferences(userID string) ([]models.NotificationPreference, error)
+	List(userID string) ([]models.Notification, error)
+	DispatchDue(ctx context.Context) (int, error)
+	Run(ctx context.Context)
+	outbox.Sink
+	BudgetNotifier
+	ApprovalNotifier
+	SplitNotifier
+}
+
+const (
+	notificationBatchSize    = 50
+	notificationLease        = time.Minute
+	notificationPollInterval = 5 * time.Second
+	notificationSendTimeout  = 10 * time.Second
+)
+
+type notificationService struct {
+	storage storage.NotificationStorager
+	senders map[string]notify.Sender
+	now     func() time.Time
+	logger  *log.Logger
+}
+
+// NewNotificationService sends notifications of every channel through its sender.
+// Channels without a sender are not notified.
+func NewNotificationService(db *sql.DB, senders map[string]notify.Sender) NotificationService {
+	return &notificationService{
+		storage: storage.NewNotificationStorage(db),
+		senders: senders,
+		now:     time.Now,
+		logger:  log.New(log.Writer(), "NotificationService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+func (s *notificationService) SetPreference(request models.SetNotificationPreferenceRequest, userID string) (*models.NotificationPreference, error) {
+	s.logger.Printf("Setting notification preference: userID=%s, channel=%s, enabled=%t", userID, request.Channel, request.Enabled)
+	address, err := notify.NormalizeAddress(request.Channel, request.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	preference := &models.NotificationPreference{
+		UserID:  userID,
+		Channel: request.Channel,
+		Address: address,
+		Enabled: request.Enabled,
+	}
+	if err := s.storage.SetPreference(preference); err != nil {
+		s.logger.Printf("Error setting notification preference: %v", err)
+		return nil, err
+	}
+
+	return preference, nil
+}
+
+func (s *notificationService) ListPreferences(userID string) ([]models.NotificationPreference, error) {
+	preferences, err := s.storage.ListPreferences(userID)
+	if err != nil {
+		s.logger.Printf("Error listing notification preferences: %v", err)
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+func (s *notificationService) List(userID string) ([]models.Notification, error) {
+	notifications, err := s.storage.ListNotifications(userID, models.NotificationListLimit)
+	if err != nil {
+		s.logger.Printf("Error listing notifications: %v", err)
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// Publish tells the wallet owner about the balance change of the outbox event. An
+// error makes the relay hand the event over again, notifications already queued
+// are not repeated.
+func (s *notificationService) Publish(ctx context.Context, domainEvent models.DomainEvent) error {
+	event, ok, err := walletEvent(domainEvent)
+	if err != nil || !ok {
+		return err
+	}
+
+	return s.enqueue(event.UserID, event.Type, domainEvent.ID, map[string]string{
+		"WalletID":     event.WalletID,
+		"Amount":       models.FormatAmount(event.Amount),
+		"Balance":      models.FormatAmount(event.Balance),
+		"Counterparty": event.CounterpartyWalletID,
+	})
+}
+
+func (s *notificationService) BudgetThresholdReached(alert *models.BudgetAlert) {
+	err := s.enqueue(alert.UserID, notify.KindBudgetThreshold, 0, map[string]any{
+		"Category":  alert.Category,
+		"Period":    alert.Period,
+		"Threshold": alert.Threshold,
+		"Amount":    models.FormatAmount(alert.Amount),
+		"Spent":     models.FormatAmount(alert.Spent),
+	})
+	if err != nil {
+		s.logger.Printf("Error queueing budget notification of %s: %v", alert.BudgetID, err)
+	}
+}
+
+// ApprovalRequested asks the parent to decide on the child's transfer
+func (s *notificationService) ApprovalRequested(approval *models.ApprovalRequest) {
+	s.notifyApproval(approval.ParentID, notify.KindApprovalRequest, approval)
+}
+
+// ApprovalDecided tells the child what the parent decided
+func (s *notificationService) ApprovalDecided(approval *models.ApprovalRequest) {
+	s.notifyApproval(approval.ChildID, notify.KindApprovalDecided, approval)
+}
+
+func (s *notificationService) notifyApproval(userID, kind string, approval *models.ApprovalRequest) {
+	err := s.enqueue(userID, kind, 0, map[string]string{
+		"ApprovalID": approval.ID,
+		"WalletID":   approval.WalletID,
+		"ToWalletID": approval.ToWalletID,
+		"Amount":     models.FormatAmount(approval.Amount),
+		"Status":     approval.Status,
+	})
+	if err != nil {
+		s.logger.Printf("Error queueing %s notification of %s: %v", kind, approval.ID, err)
+	}
+}
+
+// ShareRequested tells the participant about the share they owe
+func (s *notificationService) ShareRequested(split *models.SplitBill, participant *models.SplitParticipant) {
+	s.notifyShare(notify.KindShareRequested, split, participant)
+}
+
+// ShareReminder reminds the participant of a share they haven't paid yet
+func (s *notificationService) ShareReminder(split *models.SplitBill, participant *models.SplitParticipant) {
+	s.notifyShare(notify.KindShareReminder, split, participant)
+}
+
+func (s *notificationService) notifyShare(kind string, split *models.SplitBill, participant *models.SplitParticipant) {
+	description := split.Description
+	if description == "" {
+		description = "a shared bill"
+	}
+	err := s.enqueue(participant.UserID, kind, 0, map[string]string{
+		"SplitID":     split.ID,
+		"Description": description,
+		"Amount":      models.FormatAmount(participant.Amount),
+	})
+	if err != nil {
+		s.logger.Printf("Error queueing %s notification of %s: %v", kind, split.ID, err)
+	}
+}
+
+// enqueue renders the notification for every channel the user enabled and puts
+// it in the outbox. Sending happens in the background worker. Notifications of
+// an outbox event carry its id.
+func (s *notificationService) enqueue(userID, kind string, eventID int64, data any) error {
+	preferences, err := s.storage.ListPreferences(userID)
+	if err != nil {
+		return errors.Wrapf(err, "unable to list notification preferences of %s", userID)
+	}
+
+	for _, preference := range preferences {
+		if !preference.Enabled || s.senders[preference.Channel] == nil {
+			continue
+		}
+
+		subject, body, err := notify.Render(kind, preference.Channel, data)
+		if err != nil {
+			s.logger.Printf("Error rendering %s notification for %s: %v", kind, preference.Channel, err)
+			continue
+		}
+
+		notification := &models.Notification{
+			UserID:  userID,
+			EventID: eventID,
+			Kind:    kind,
+			Channel: preference.Channel,
+			Address: preference.Address,
+			Subject: subject,
+			Body:    body,
+			Status:  models.NotificationStatusPending,
+		}
+		if err := s.storage.CreateNotification(notification); err != nil {
+			return errors.Wrapf(err, "unable to queue %s notification: userID=%s, channel=%s", kind, userID, preference.Channel)
+		}
+	}
+
+	return nil
+}
+
+// Run sends due notifications until ctx is cancelled
+func (s *notificationService) Run(ctx context.Context) {
+	ticker := time.NewTicker(notificationPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DispatchDue(ctx); err != nil {
+				s.logger.Printf("Error dispatching notifications: %v", err)
+			}
+		}
+	}
+}
+
+// DispatchDue sends one batch of due notifications and returns how many were sent
+func (s *notificationService) DispatchDue(ctx context.Context) (int, error) {
+	notifications, err := s.storage.ClaimDueNotifications(notificationBatchSize, notificationLease)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to claim notifications")
+	}
+
+	sent := 0
+	for _, notification := range notifications {
+		if s.send(ctx, notification) {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// send makes one attempt and records the outcome: sent, retried later with
+// exponential backoff, or failed after the last attempt
+func (s *notificationService) send(ctx context.Context, notification models.Notification) bool {
+	sendErr := notify.ErrUnknownChannel
+	if sender := s.senders[notification.Channel]; sender != nil {
+		sendCtx, cancel := context.WithTimeout(ctx, notificationSendTimeout)
+		sendErr = sender.Send(sendCtx, notify.Message{
+			ID:      notification.ID,
+			Channel: notification.Channel,
+			Address: notification.Address,
+			Subject: notification.Subject,
+			Body:    notification.Body,
+		})
+		cancel()
+	}
+
+	notification.Attempts++
+	if sendErr == nil {
+		if err := s.storage.MarkSent(notification.ID, notification.Attempts); err != nil {
+			s.logger.Printf("Error marking notification sent: notificationID=%s: %v", notification.ID, err)
+		}
+		return true
+	}
+
+	if notification.Attempts >= models.NotificationMaxAttempts {
+		s.logger.Printf("Notification failed for good: notificationID=%s, attempts=%d: %v", notification.ID, notification.Attempts, sendErr)
+		if err := s.storage.MarkFailed(notification.ID, notification.Attempts, sendErr.Error()); err != nil {
+			s.logger.Printf("Error marking notification failed: notificationID=%s: %v", notification.ID, err)
+		}
+		return false
+	}
+
+	nextAttemptAt := s.now().Add(retryBackoff(models.NotificationBaseBackoff, models.NotificationMaxBackoff, notification.Attempts))
+	if err := s.storage.ScheduleRetry(notification.ID, notification.Attempts, nextAttemptAt, sendErr.Error()); err != nil {
+		s.logger.Printf("Error scheduling notification retry: notificationID=%s: %v", notification.ID, err)
+	}
+
+	return false
+}
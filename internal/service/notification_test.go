@@ -0,0 +1,234 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock implementation of NotificationStorage
+type MockNotificationStorage struct {
+	mock.Mock
+}
+
+func (m *MockNotificationStorage) SetPreference(preference *models.NotificationPreference) error {
+	args := m.Called(preference)
+	return args.Error(0)
+}
+
+func (m *MockNotificationStorage) ListPreferences(userID string) ([]models.NotificationPreference, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.NotificationPreference), args.Error(1)
+}
+
+func (m *MockNotificationStorage) CreateNotification(notification *models.Notification) error {
+	args := m.Called(notification)
+	return args.Error(0)
+}
+
+func (m *MockNotificationStorage) ListNotifications(userID string, limit int) ([]models.Notification, error) {
+	args := m.Called(userID, limit)
+	return args.Get(0).([]models.Notification), args.Error(1)
+}
+
+func (m *MockNotificationStorage) ClaimDueNotifications(limit int, lease time.Duration) ([]models.Notification, error) {
+	args := m.Called(limit, lease)
+	return args.Get(0).([]models.Notification), args.Error(1)
+}
+
+func (m *MockNotificationStorage) MarkSent(notificationID string, attempts int) error {
+	args := m.Called(notificationID, attempts)
+	return args.Error(0)
+}
+
+func (m *MockNotificationStorage) ScheduleRetry(notificationID string, attempts int, nextAttemptAt time.Time, lastError string) error {
+	args := m.Called(notificationID, attempts, nextAttemptAt, lastError)
+	return args.Error(0)
+}
+
+func (m *MockNotificationStorage) MarkFailed(notificationID string, attempts int, lastError string) error {
+	args := m.Called(notificationID, attempts, lastError)
+	return args.Error(0)
+}
+
+type failingSender struct{}
+
+func (failingSender) Send(ctx context.Context, message notify.Message) error {
+	return errors.New("gateway unavailable")
+}
+
+func TestNotificationService_Publish(t *testing.T) {
+	mockStorage := new(MockNotificationStorage)
+	sink := notify.NewSink(&bytes.Buffer{})
+	s := &notificationService{
+		storage: mockStorage,
+		senders: map[string]notify.Sender{notify.ChannelSMS: sink, notify.ChannelEmail: sink},
+		now:     time.Now,
+		logger:  log.Default(),
+	}
+
+	mockStorage.On("ListPreferences", "user1").Return([]models.NotificationPreference{
+		{Channel: notify.ChannelEmail, Address: "user@example.com", Enabled: true},
+		{Channel: notify.ChannelSMS, Address: "+992901234567", Enabled: false},
+		{Channel: notify.ChannelPush, Address: "token", Enabled: true},
+	}, nil)
+	mockStorage.On("CreateNotification", mock.MatchedBy(func(n *models.Notification) bool {
+		return n.Channel == notify.ChannelEmail && n.Address == "user@example.com" && n.EventID == 42 &&
+			n.Subject == "Money received" && n.Status == models.NotificationStatusPending
+	})).Return(nil).Once()
+
+	err := s.Publish(context.Background(), models.DomainEvent{
+		ID:      42,
+		Type:    models.DomainEventWalletCredited,
+		Payload: json.RawMessage(`{"wallet_id":"wallet1","user_id":"user1","amount":1250,"balance":5000}`),
+	})
+
+	assert.NoError(t, err)
+	// The disabled SMS and the push channel without a sender are skipped
+	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNumberOfCalls(t, "CreateNotification", 1)
+}
+
+func TestNotificationService_Notifiers(t *testing.T) {
+	sink := notify.NewSink(&bytes.Buffer{})
+	newService := func() (*notificationService, *MockNotificationStorage) {
+		mockStorage := new(MockNotificationStorage)
+		return &notificationService{
+			storage: mockStorage,
+			senders: map[string]notify.Sender{notify.ChannelSMS: sink},
+			now:     time.Now,
+			logger:  log.Default(),
+		}, mockStorage
+	}
+	preferences := []models.NotificationPreference{{Channel: notify.ChannelSMS, Address: "+992901234567", Enabled: true}}
+	approval := &models.ApprovalRequest{ID: "a1", ParentID: "parent1", ChildID: "child1", WalletID: "wallet1", ToWalletID: "wallet2", Amount: 1250, Status: models.ApprovalStatusApproved}
+
+	t.Run("Approval request goes to the parent", func(t *testing.T) {
+		s, mockStorage := newService()
+		mockStorage.On("ListPreferences", "parent1").Return(preferences, nil).Once()
+		mockStorage.On("CreateNotification", mock.MatchedBy(func(n *models.Notification) bool {
+			return n.UserID == "parent1" && n.Kind == notify.KindApprovalRequest &&
+				n.Body == "Your child asks to pay 12.50 to wallet wallet2. Approval a1."
+		})).Return(nil).Once()
+
+		s.ApprovalRequested(approval)
+
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Decision goes to the child", func(t *testing.T) {
+		s, mockStorage := newService()
+		mockStorage.On("ListPreferences", "child1").Return(preferences, nil).Once()
+		mockStorage.On("CreateNotification", mock.MatchedBy(func(n *models.Notification) bool {
+			return n.UserID == "child1" && n.Kind == notify.KindApprovalDecided &&
+				n.Body == "Your payment of 12.50 to wallet wallet2 was approved."
+		})).Return(nil).Once()
+
+		s.ApprovalDecided(approval)
+
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Reminder of a bill without description", func(t *testing.T) {
+		s, mockStorage := newService()
+		mockStorage.On("ListPreferences", "user2").Return(preferences, nil).Once()
+		mockStorage.On("CreateNotification", mock.MatchedBy(func(n *models.Notification) bool {
+			return n.UserID == "user2" && n.Kind == notify.KindShareReminder &&
+				n.Body == "Reminder: you owe 7.00 for a shared bill. Split s1."
+		})).Return(nil).Once()
+
+		s.ShareReminder(&models.SplitBill{ID: "s1"}, &models.SplitParticipant{UserID: "user2", Amount: 700})
+
+		mockStorage.AssertExpectations(t)
+	})
+}
+
+func TestNotificationService_DispatchDue(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var out bytes.Buffer
+
+	newService := func(mockStorage *MockNotificationStorage) *notificationService {
+		return &notificationService{
+			storage: mockStorage,
+			senders: map[string]notify.Sender{notify.ChannelSMS: notify.NewSink(&out), notify.ChannelPush: failingSender{}},
+			now:     func() time.Time { return now },
+			logger:  log.Default(),
+		}
+	}
+
+	t.Run("Sent", func(t *testing.T) {
+		mockStorage := new(MockNotificationStorage)
+		mockStorage.On("ClaimDueNotifications", notificationBatchSize, notificationLease).Return([]models.Notification{
+			{ID: "n1", Channel: notify.ChannelSMS, Address: "+992901234567", Body: "hello"},
+		}, nil)
+		mockStorage.On("MarkSent", "n1", 1).Return(nil)
+
+		sent, err := newService(mockStorage).DispatchDue(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		assert.Contains(t, out.String(), `"body":"hello"`)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Retried with backoff", func(t *testing.T) {
+		mockStorage := new(MockNotificationStorage)
+		mockStorage.On("ClaimDueNotifications", notificationBatchSize, notificationLease).Return([]models.Notification{
+			{ID: "n2", Channel: notify.ChannelPush, Attempts: 2},
+		}, nil)
+		mockStorage.On("ScheduleRetry", "n2", 3, now.Add(2*time.Minute), "gateway unavailable").Return(nil)
+
+		sent, err := newService(mockStorage).DispatchDue(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, sent)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Failed after the last attempt", func(t *testing.T) {
+		mockStorage := new(MockNotificationStorage)
+		mockStorage.On("ClaimDueNotifications", notificationBatchSize, notificationLease).Return([]models.Notification{
+			{ID: "n3", Channel: notify.ChannelPush, Attempts: models.NotificationMaxAttempts - 1},
+		}, nil)
+		mockStorage.On("MarkFailed", "n3", models.NotificationMaxAttempts, "gateway unavailable").Return(nil)
+
+		_, err := newService(mockStorage).DispatchDue(context.Background())
+
+		require.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+}
+
+func TestNotificationService_SetPreference(t *testing.T) {
+	mockStorage := new(MockNotificationStorage)
+	s := &notificationService{storage: mockStorage, now: time.Now, logger: log.Default()}
+
+	t.Run("Address is normalized", func(t *testing.T) {
+		mockStorage.On("SetPreference", &models.NotificationPreference{
+			UserID: "user1", Channel: notify.ChannelSMS, Address: "+992901234567", Enabled: true,
+		}).Return(nil).Once()
+
+		preference, err := s.SetPreference(models.SetNotificationPreferenceRequest{
+			Channel: notify.ChannelSMS, Address: "+992 90 123 4567", Enabled: true,
+		}, "user1")
+
+		require.NoError(t, err)
+		assert.Equal(t, "+992901234567", preference.Address)
+	})
+
+	t.Run("Invalid address", func(t *testing.T) {
+		_, err := s.SetPreference(models.SetNotificationPreferenceRequest{Channel: notify.ChannelEmail, Address: "nope"}, "user1")
+
+		assert.ErrorIs(t, err, notify.ErrInvalidAddress)
+	})
+}
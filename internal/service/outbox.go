@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/outbox"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+// OutboxRelay publishes the domain events of the transactional outbox
+type OutboxRelay interface {
+	PublishDue(ctx context.Context) (int, error)
+	ListDeadEvents() ([]models.DomainEvent, error)
+	ReplayDeadEvent(eventID int64) error
+	Run(ctx context.Context)
+}
+
+var ErrOutboxEventNotReplayable = errors.New("outbox event not found or not dead")
+
+const (
+	outboxBatchSize    = 100
+	outboxLease        = time.Minute
+	outboxPollInterval = time.Second
+	outboxListLimit    = 100
+)
+
+type outboxRelay struct {
+	storage storage.OutboxStorager
+	sinks   outbox.Fanout
+	now     func() time.Time
+	logger  *log.Logger
+}
+
+func NewOutboxRelay(db *sql.DB, sinks outbox.Fanout) OutboxRelay {
+	return &outboxRelay{
+		storage: storage.NewOutboxStorage(db),
+		sinks:   sinks,
+		now:     time.Now,
+		logger:  log.New(log.Writer(), "OutboxRelay: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+// Run publishes due events until ctx is cancelled. A batch takes one event per
+// wallet, so the relay keeps going while batches come back full.
+func (r *outboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				published, err := r.PublishDue(ctx)
+				if err != nil {
+					r.logger.Printf("Error publishing domain events: %v", err)
+				}
+				if published == 0 {
+					break
+				}
+			}
+		}
+	}
+}
+
+// PublishDue publishes one batch of due events and returns how many were
+// published. An event that fails on some sink is retried on it with backoff and
+// holds back the later events of its wallet until it is published or dead.
+func (r *outboxRelay) PublishDue(ctx context.Context) (int, error) {
+	events, err := r.storage.ClaimDueEvents(outboxBatchSize, outboxLease)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to claim domain events")
+	}
+
+	published := 0
+	for _, event := range events {
+		if ctx.Err() != nil {
+			break
+		}
+
+		event.Attempts++
+		publishedSinks, err := r.sinks.Publish(ctx, event, event.PublishedSinks)
+		if err != nil {
+			r.fail(event, publishedSinks, err)
+			continue
+		}
+
+		if err := r.storage.MarkPublished(event.ID, event.Attempts); err != nil {
+			// The lease runs out and the event is published again, consumers deduplicate by id
+			r.logger.Printf("Error marking domain event published: eventID=%d: %v", event.ID, err)
+			continue
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+// fail retries the event on the sinks that didn't publish it, or gives up on it
+// after the last attempt
+func (r *outboxRelay) fail(event models.DomainEvent, publishedSinks []string, publishErr error) {
+	if event.Attempts >= models.OutboxMaxAttempts {
+		r.logger.Printf("Domain event failed for good: eventID=%d, type=%s, attempts=%d: %v", event.ID, event.Type, event.Attempts, publishErr)
+		if err := r.storage.MarkDead(event.ID, event.Attempts, publishedSinks, publishErr.Error()); err != nil {
+			r.logger.Printf("Error marking domain event dead: eventID=%d: %v", event.ID, err)
+		}
+		return
+	}
+
+	r.logger.Printf("Error publishing domain event: eventID=%d, type=%s, attempts=%d: %v", event.ID, event.Type, event.Attempts, publishErr)
+	nextAttemptAt := r.now().Add(retryBackoff(models.OutboxBaseBackoff, models.OutboxMaxBackoff, event.Attempts))
+	if err := r.storage.ScheduleRetry(event.ID, event.Attempts, publishedSinks, nextAttemptAt, publishErr.Error()); err != nil {
+		r.logger.Printf("Error scheduling domain event retry: eventID=%d: %v", event.ID, err)
+	}
+}
+
+func (r *outboxRelay) ListDeadEvents() ([]models.DomainEvent, error) {
+	events, err := r.storage.ListDeadEvents(outboxListLimit)
+	if err != nil {
+		r.logger.Printf("Error listing dead domain events: %v", err)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// ReplayDeadEvent hands a dead event again to the sinks that didn't publish it
+func (r *outboxRelay) ReplayDeadEvent(eventID int64) error {
+	r.logger.Printf("Replaying dead domain event: eventID=%d", eventID)
+	replayed, err := r.storage.ReplayDeadEvent(eventID)
+	if err != nil {
+		r.logger.Printf("Error replaying dead domain event: %v", err)
+		return err
+	}
+	if !replayed {
+		return ErrOutboxEventNotReplayable
+	}
+
+	return nil
+}
+
+// walletEventTypes maps the domain events of balance changes to the events sent to clients
+var walletEventTypes = map[string]string{
+	models.DomainEventWalletToppedUp: models.EventWalletToppedUp,
+	models.DomainEventWalletDebited:  models.EventWalletDebited,
+	models.DomainEventWalletCredited: models.EventWalletCredited,
+}
+
+// walletEvent decodes a domain event of a balance change into the event sent to
+// clients. It returns false for events that don't change a balance.
+func walletEvent(event models.DomainEvent) (models.WalletEvent, bool, error) {
+	eventType, ok := walletEventTypes[event.Type]
+	if !ok {
+		return models.WalletEvent{}, false, nil
+	}
+
+	var payload models.WalletEventPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return models.WalletEvent{}, false, errors.Wrapf(err, "unable to decode domain event %d", event.ID)
+	}
+
+	// Clients get the amount moved, the direction is in the event type
+	amount := payload.Amount
+	if amount < 0 {
+		amount = -amount
+	}
+
+	return models.WalletEvent{
+		ID:                   event.ID,
+		Type:                 eventType,
+		UserID:               payload.UserID,
+		WalletID:             payload.WalletID,
+		Amount:               amount,
+		Balance:              payload.Balance,
+		TransactionID:        payload.TransactionID,
+		CounterpartyWalletID: payload.CounterpartyWalletID,
+		Reference:            payload.Reference,
+		Category:             payload.Category,
+		CreatedAt:            event.CreatedAt,
+	}, true, nil
+}
+
+// retryBackoff is the delay after the given number of failed attempts. It starts
+// at base and doubles with every attempt up to max. The outbox relay, webhook
+// deliveries and notifications all retry this way.
+func retryBackoff(base, max time.Duration, attempts int) time.Duration {
+	backoff := base
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= max {
+			return max
+		}
+	}
+	return backoff
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock implementation of OutboxStorage
+type MockOutboxStorage struct {
+	mock.Mock
+}
+
+func (m *MockOutboxStorage) ClaimDueEvents(limit int, lease time.Duration) ([]models.DomainEvent, error) {
+	args := m.Called(limit, lease)
+	return args.Get(0).([]models.DomainEvent), args.Error(1)
+}
+
+func (m *MockOutboxStorage) MarkPublished(eventID int64, attempts int) error {
+	args := m.Called(eventID, attempts)
+	return args.Error(0)
+}
+
+func (m *MockOutboxStorage) ScheduleRetry(eventID int64, attempts int, publishedSinks []string, nextAttemptAt time.Time, lastError string) error {
+	args := m.Called(eventID, attempts, publishedSinks, nextAttemptAt, lastError)
+	return args.Error(0)
+}
+
+func (m *MockOutboxStorage) MarkDead(eventID int64, attempts int, publishedSinks []string, lastError string) error {
+	args := m.Called(eventID, attempts, publishedSinks, lastError)
+	return args.Error(0)
+}
+
+func (m *MockOutboxStorage) ListDeadEvents(limit int) ([]models.DomainEvent, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]models.DomainEvent), args.Error(1)
+}
+
+func (m *MockOutboxStorage) ReplayDeadEvent(eventID int64) (bool, error) {
+	args := m.Called(eventID)
+	return args.Bool(0), args.Error(1)
+}
+
+// Mock implementation of outbox.Sink
+type MockOutboxSink struct {
+	mock.Mock
+}
+
+func (m *MockOutboxSink) Publish(ctx context.Context, event models.DomainEvent) error {
+	args := m.Called(event.ID)
+	return args.Error(0)
+}
+
+func TestOutboxRelay_PublishDue(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Failed sink is retried alone", func(t *testing.T) {
+		mockStorage := new(MockOutboxStorage)
+		webhooks, notifications := new(MockOutboxSink), new(MockOutboxSink)
+		r := &outboxRelay{storage: mockStorage, sinks: outbox.Fanout{"webhooks": webhooks, "notifications": notifications},
+			now: func() time.Time { return now }, logger: log.Default()}
+
+		mockStorage.On("ClaimDueEvents", outboxBatchSize, outboxLease).Return([]models.DomainEvent{
+			{ID: 1, AggregateID: "wallet1", Type: models.DomainEventWalletToppedUp},
+			{ID: 2, AggregateID: "wallet2", Type: models.DomainEventWalletDebited, Attempts: 1},
+		}, nil)
+		webhooks.On("Publish", int64(1)).Return(nil)
+		notifications.On("Publish", int64(1)).Return(nil)
+		webhooks.On("Publish", int64(2)).Return(errors.New("connection refused"))
+		notifications.On("Publish", int64(2)).Return(nil)
+		mockStorage.On("MarkPublished", int64(1), 1).Return(nil)
+		mockStorage.On("ScheduleRetry", int64(2), 2, []string{"notifications"}, now.Add(10*time.Second), "webhooks: connection refused").Return(nil)
+
+		published, err := r.PublishDue(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, published)
+		mockStorage.AssertExpectations(t)
+		notifications.AssertExpectations(t)
+	})
+
+	t.Run("Dead after the last attempt", func(t *testing.T) {
+		mockStorage := new(MockOutboxStorage)
+		webhooks, notifications := new(MockOutboxSink), new(MockOutboxSink)
+		r := &outboxRelay{storage: mockStorage, sinks: outbox.Fanout{"webhooks": webhooks, "notifications": notifications},
+			now: func() time.Time { return now }, logger: log.Default()}
+
+		mockStorage.On("ClaimDueEvents", outboxBatchSize, outboxLease).Return([]models.DomainEvent{
+			{ID: 3, AggregateID: "wallet1", Attempts: models.OutboxMaxAttempts - 1, PublishedSinks: []string{"notifications"}},
+		}, nil)
+		webhooks.On("Publish", int64(3)).Return(errors.New("connection refused"))
+		mockStorage.On("MarkDead", int64(3), models.OutboxMaxAttempts, []string{"notifications"}, "webhooks: connection refused").Return(nil)
+
+		published, err := r.PublishDue(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, published)
+		mockStorage.AssertExpectations(t)
+		// The sink that published the event isn't handed it again
+		notifications.AssertNotCalled(t, "Publish", mock.Anything)
+	})
+}
+
+func TestOutboxRelay_ReplayDeadEvent(t *testing.T) {
+	mockStorage := new(MockOutboxStorage)
+	r := &outboxRelay{storage: mockStorage, now: time.Now, logger: log.Default()}
+
+	t.Run("Dead event", func(t *testing.T) {
+		mockStorage.On("ReplayDeadEvent", int64(3)).Return(true, nil).Once()
+
+		assert.NoError(t, r.ReplayDeadEvent(3))
+	})
+
+	t.Run("Event that isn't dead", func(t *testing.T) {
+		mockStorage.On("ReplayDeadEvent", int64(4)).Return(false, nil).Once()
+
+		assert.ErrorIs(t, r.ReplayDeadEvent(4), ErrOutboxEventNotReplayable)
+	})
+}
+
+func TestRetryBackoff(t *testing.T) {
+	t.Run("Outbox", func(t *testing.T) {
+		assert.Equal(t, 5*time.Second, retryBackoff(models.OutboxBaseBackoff, models.OutboxMaxBackoff, 1))
+		assert.Equal(t, 20*time.Second, retryBackoff(models.OutboxBaseBackoff, models.OutboxMaxBackoff, 3))
+		assert.Equal(t, models.OutboxMaxBackoff, retryBackoff(models.OutboxBaseBackoff, models.OutboxMaxBackoff, 30))
+	})
+
+	t.Run("Webhook", func(t *testing.T) {
+		assert.Equal(t, 30*time.Second, retryBackoff(models.WebhookBaseBackoff, models.WebhookMaxBackoff, 1))
+		assert.Equal(t, time.Minute, retryBackoff(models.WebhookBaseBackoff, models.WebhookMaxBackoff, 2))
+		assert.Equal(t, 4*time.Minute, retryBackoff(models.WebhookBaseBackoff, models.WebhookMaxBackoff, 4))
+		assert.Equal(t, models.WebhookMaxBackoff, retryBackoff(models.WebhookBaseBackoff, models.WebhookMaxBackoff, 20))
+	})
+
+	t.Run("Notification", func(t *testing.T) {
+		assert.Equal(t, 30*time.Second, retryBackoff(models.NotificationBaseBackoff, models.NotificationMaxBackoff, 1))
+		assert.Equal(t, 2*time.Minute, retryBackoff(models.NotificationBaseBackoff, models.NotificationMaxBackoff, 3))
+		assert.Equal(t, models.NotificationMaxBackoff, retryBackoff(models.NotificationBaseBackoff, models.NotificationMaxBackoff, 20))
+	})
+}
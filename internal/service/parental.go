@@ -0,0 +1,273 @@
+package service
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+type ParentalControlService interface {
+	CreateChildAccount(parentID string) (string, string, error)
+	SetControls(parentID string, controls models.ParentalControlsModel) error
+	GetControls(parentID, childID string) (*models.ParentalControlsModel, error)
+	ListApprovals(parentID string) ([]models.ApprovalRequest, error)
+	Approve(parentID, approvalID string) (int64, error)
+	Decline(parentID, approvalID string) error
+}
+
+var (
+	ErrNotParent          = errors.New("user is not a parent of this child")
+	ErrApprovalNotFound   = errors.New("approval request not found")
+	ErrApprovalNotPending = errors.New("approval request is already decided")
+)
+
+// ApprovalNotifier is called when a child's transfer needs a parent's decision and
+// when the decision is made
+type ApprovalNotifier interface {
+	ApprovalRequested(approval *models.ApprovalRequest)
+	ApprovalDecided(approval *models.ApprovalRequest)
+}
+
+type logApprovalNotifier struct {
+	logger *log.Logger
+}
+
+// NewLogApprovalNotifier returns a notifier that only writes approval events to the log
+func NewLogApprovalNotifier(logger *log.Logger) ApprovalNotifier {
+	return &logApprovalNotifier{logger: logger}
+}
+
+func (n *logApprovalNotifier) ApprovalRequested(approval *models.ApprovalRequest) {
+	n.logger.Printf("Approval requested: id=%s, parentID=%s, childID=%s, amount=%d", approval.ID, approval.ParentID, approval.ChildID, approval.Amount)
+}
+
+func (n *logApprovalNotifier) ApprovalDecided(approval *models.ApprovalRequest) {
+	n.logger.Printf("Approval decided: id=%s, parentID=%s, status=%s", approval.ID, approval.ParentID, approval.Status)
+}
+
+type parentalControlService struct {
+	storage         storage.ParentalStorager
+	paymentRequests storage.PaymentRequestStorager
+	orders          storage.MerchantStorager
+	splits          storage.SplitStorager
+	escrows         storage.EscrowStorager
+	walletService   WalletService
+	notifier        ApprovalNotifier
+	logger          *log.Logger
+}
+
+// NewParentalControlService tells children about decisions through notifier, they
+// are only logged when it is nil
+func NewParentalControlService(db *sql.DB, walletService WalletService, notifier ApprovalNotifier) ParentalControlService {
+	logger := log.New(log.Writer(), "ParentalControlService: ", log.Ldate|log.Ltime|log.Lshortfile)
+	if notifier == nil {
+		notifier = NewLogApprovalNotifier(logger)
+	}
+	return &parentalControlService{
+		storage:         storage.NewParentalStorage(db),
+		paymentRequests: storage.NewPaymentRequestStorage(db),
+		orders:          storage.NewMerchantStorage(db),
+		splits:          storage.NewSplitStorage(db),
+		escrows:         storage.NewEscrowStorage(db),
+		walletService:   walletService,
+		notifier:        notifier,
+		logger:          logger,
+	}
+}
+
+func (s *parentalControlService) CreateChildAccount(parentID string) (string, string, error) {
+	s.logger.Printf("Creating child account: parentID=%s", parentID)
+	childID, walletID, err := s.storage.CreateChildAccount(parentID)
+	if err != nil {
+		s.logger.Printf("Error creating child account: %v", err)
+		return "", "", err
+	}
+
+	return childID, walletID, nil
+}
+
+func (s *parentalControlService) SetControls(parentID string, request models.ParentalControlsModel) error {
+	s.logger.Printf("Setting parental controls: parentID=%s, childID=%s", parentID, request.ChildID)
+	if err := s.checkParent(parentID, request.ChildID); err != nil {
+		return err
+	}
+
+	controls := &models.ParentalControls{
+		ChildID:           request.ChildID,
+		BlockedCategories: request.BlockedCategories,
+	}
+	if controls.BlockedCategories == nil {
+		controls.BlockedCategories = []string{}
+	}
+
+	limits := []struct {
+		value  string
+		target *int64
+	}{
+		{request.PerTransactionLimit, &controls.PerTransactionLimit},
+		{request.MonthlyLimit, &controls.MonthlyLimit},
+		{request.ApprovalThreshold, &controls.ApprovalThreshold},
+	}
+	for _, limit := range limits {
+		if limit.value == "" {
+			continue
+		}
+
+		amount, err := models.ParseAmount(limit.value)
+		if err != nil {
+			s.logger.Printf("Error parsing limit: %v", err)
+			return err
+		}
+		*limit.target = amount
+	}
+
+	if err := s.storage.SaveControls(controls); err != nil {
+		s.logger.Printf("Error saving parental controls: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *parentalControlService) GetControls(parentID, childID string) (*models.ParentalControlsModel, error) {
+	s.logger.Printf("Getting parental controls: parentID=%s, childID=%s", parentID, childID)
+	if err := s.checkParent(parentID, childID); err != nil {
+		return nil, err
+	}
+
+	controls, err := s.storage.GetControls(childID)
+	if err == sql.ErrNoRows {
+		return &models.ParentalControlsModel{ChildID: childID, BlockedCategories: []string{}}, nil
+	}
+	if err != nil {
+		s.logger.Printf("Error getting parental controls: %v", err)
+		return nil, err
+	}
+
+	return &models.ParentalControlsModel{
+		ChildID:             controls.ChildID,
+		PerTransactionLimit: formatLimit(controls.PerTransactionLimit),
+		MonthlyLimit:        formatLimit(controls.MonthlyLimit),
+		ApprovalThreshold:   formatLimit(controls.ApprovalThreshold),
+		BlockedCategories:   controls.BlockedCategories,
+	}, nil
+}
+
+func (s *parentalControlService) ListApprovals(parentID string) ([]models.ApprovalRequest, error) {
+	s.logger.Printf("Listing pending approvals: parentID=%s", parentID)
+	approvals, err := s.storage.ListApprovals(parentID, models.ApprovalStatusPending)
+	if err != nil {
+		s.logger.Printf("Error listing approvals: %v", err)
+		return nil, err
+	}
+
+	return approvals, nil
+}
+
+// Approve records the parent's consent and executes the queued transfer
+func (s *parentalControlService) Approve(parentID, approvalID string) (int64, error) {
+	s.logger.Printf("Approving: parentID=%s, approvalID=%s", parentID, approvalID)
+	approval, err := s.decide(parentID, approvalID, models.ApprovalStatusApproved)
+	if err != nil {
+		return 0, err
+	}
+
+	transactionID, err := s.walletService.Transfer(models.TransferRequest{
+		WalletID:         approval.WalletID,
+		ToWalletID:       approval.ToWalletID,
+		Amount:           models.FormatAmount(approval.Amount),
+		MerchantCategory: approval.MerchantCategory,
+		Reference:        approval.Reference,
+		ApprovalID:       approval.ID,
+	}, approval.ChildID, s.completionHooks(approval)...)
+	if err != nil {
+		s.logger.Printf("Error executing approved transfer: %v", err)
+		if _, updateErr := s.storage.UpdateApprovalStatus(approval.ID, models.ApprovalStatusApproved, models.ApprovalStatusFailed); updateErr != nil {
+			s.logger.Printf("Error marking approval as failed: %v", updateErr)
+		}
+		return 0, err
+	}
+
+	return transactionID, nil
+}
+
+// completionHooks settle what the approved transfer pays for, recognized by its reference,
+// together with the transfer
+func (s *parentalControlService) completionHooks(approval *models.ApprovalRequest) []storage.TransferHook {
+	if requestID, ok := strings.CutPrefix(approval.Reference, paymentRequestReferencePrefix); ok {
+		return []storage.TransferHook{s.paymentRequests.CompletePaymentRequest(requestID, approval.WalletID)}
+	}
+	if orderID, ok := strings.CutPrefix(approval.Reference, orderReferencePrefix); ok {
+		return []storage.TransferHook{s.orders.CompleteOrder(orderID, approval.WalletID, "approved by parent "+approval.ParentID)}
+	}
+	if participantID, ok := strings.CutPrefix(approval.Reference, splitReferencePrefix); ok {
+		return []storage.TransferHook{s.splits.CompleteParticipant(participantID, approval.WalletID)}
+	}
+	if escrowID, ok := strings.CutPrefix(approval.Reference, storage.EscrowReferencePrefix); ok {
+		return []storage.TransferHook{s.escrows.HoldEscrow(escrowID, approval.WalletID)}
+	}
+	return nil
+}
+
+func (s *parentalControlService) Decline(parentID, approvalID string) error {
+	s.logger.Printf("Declining: parentID=%s, approvalID=%s", parentID, approvalID)
+	_, err := s.decide(parentID, approvalID, models.ApprovalStatusDeclined)
+	return err
+}
+
+func (s *parentalControlService) decide(parentID, approvalID, status string) (*models.ApprovalRequest, error) {
+	approval, err := s.storage.GetApproval(approvalID)
+	if err == sql.ErrNoRows {
+		return nil, ErrApprovalNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting approval request: %v", err)
+		return nil, err
+	}
+
+	if approval.ParentID != parentID {
+		return nil, ErrApprovalNotFound
+	}
+
+	updated, err := s.storage.UpdateApprovalStatus(approvalID, models.ApprovalStatusPending, status)
+	if err != nil {
+		s.logger.Printf("Error updating approval request: %v", err)
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrApprovalNotPending
+	}
+
+	approval.Status = status
+	s.notifier.ApprovalDecided(approval)
+
+	return approval, nil
+}
+
+func (s *parentalControlService) checkParent(parentID, childID string) error {
+	actualParentID, err := s.storage.GetParentID(childID)
+	if err == sql.ErrNoRows {
+		return ErrNotParent
+	}
+	if err != nil {
+		s.logger.Printf("Error getting parent: %v", err)
+		return err
+	}
+
+	if actualParentID != parentID {
+		return ErrNotParent
+	}
+
+	return nil
+}
+
+func formatLimit(amount int64) string {
+	if amount == 0 {
+		return ""
+	}
+	return models.FormatAmount(amount)
+}
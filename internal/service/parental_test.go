@@ -0,0 +1,349 @@
+package service
+
+import (
+	"database/sql"
+	"log"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock implementation of ParentalStorage
+type MockParentalStorage struct {
+	mock.Mock
+}
+
+func (m *MockParentalStorage) CreateChildAccount(parentID string) (string, string, error) {
+	args := m.Called(parentID)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockParentalStorage) GetParentID(userID string) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockParentalStorage) SaveControls(controls *models.ParentalControls) error {
+	args := m.Called(controls)
+	return args.Error(0)
+}
+
+func (m *MockParentalStorage) GetControls(childID string) (*models.ParentalControls, error) {
+	args := m.Called(childID)
+	return args.Get(0).(*models.ParentalControls), args.Error(1)
+}
+
+func (m *MockParentalStorage) LimitMonthlySpending(childID string, limit int64) storage.TransferHook {
+	args := m.Called(childID, limit)
+	return hookReturning(args.Error(0))
+}
+
+func (m *MockParentalStorage) CreateApproval(approval *models.ApprovalRequest) error {
+	args := m.Called(approval)
+	return args.Error(0)
+}
+
+func (m *MockParentalStorage) GetApproval(approvalID string) (*models.ApprovalRequest, error) {
+	args := m.Called(approvalID)
+	return args.Get(0).(*models.ApprovalRequest), args.Error(1)
+}
+
+func (m *MockParentalStorage) ListApprovals(parentID, status string) ([]models.ApprovalRequest, error) {
+	args := m.Called(parentID, status)
+	return args.Get(0).([]models.ApprovalRequest), args.Error(1)
+}
+
+func (m *MockParentalStorage) UpdateApprovalStatus(approvalID, fromStatus, toStatus string) (bool, error) {
+	args := m.Called(approvalID, fromStatus, toStatus)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockParentalStorage) CompleteApproval(approvalID string) storage.TransferHook {
+	args := m.Called(approvalID)
+	return hookReturning(args.Error(0))
+}
+
+// Mock implementation of ApprovalNotifier
+type MockApprovalNotifier struct {
+	mock.Mock
+}
+
+func (m *MockApprovalNotifier) ApprovalRequested(approval *models.ApprovalRequest) {
+	m.Called(approval)
+}
+
+func (m *MockApprovalNotifier) ApprovalDecided(approval *models.ApprovalRequest) {
+	m.Called(approval)
+}
+
+func TestTransferWithParentalControls(t *testing.T) {
+	mockStorage := new(MockWalletStorage)
+	mockParental := new(MockParentalStorage)
+	mockNotifier := new(MockApprovalNotifier)
+	service := &walletService{storage: mockStorage, parental: mockParental, notifier: mockNotifier, logger: log.Default()}
+
+	parentID := uuid.New().String()
+	childID := uuid.New().String()
+	walletID := uuid.New().String()
+	toWalletID := uuid.New().String()
+	controls := &models.ParentalControls{
+		ChildID:             childID,
+		PerTransactionLimit: 50000,
+		MonthlyLimit:        100000,
+		ApprovalThreshold:   20000,
+		BlockedCategories:   []string{"7995"},
+	}
+
+	setup := func(category string) {
+		mockStorage.On("GetWallet", walletID, childID).Return(&models.Wallet{ID: walletID, UserID: childID, Balance: 90000}, nil).Once()
+		mockStorage.On("GetWalletByID", toWalletID).Return(&models.Wallet{ID: toWalletID, UserID: parentID, Balance: 0}, nil).Once()
+		mockStorage.On("GetMerchantCategory", toWalletID).Return(category, nil).Once()
+		mockParental.On("GetParentID", childID).Return(parentID, nil).Once()
+		mockParental.On("GetControls", childID).Return(controls, nil).Once()
+	}
+
+	t.Run("Blocked category", func(t *testing.T) {
+		setup("7995")
+
+		_, err := service.Transfer(models.TransferRequest{WalletID: walletID, ToWalletID: toWalletID, Amount: "10.00"}, childID)
+
+		assert.ErrorIs(t, err, ErrCategoryBlocked)
+		mockParental.AssertExpectations(t)
+	})
+
+	t.Run("Blocked category can't be overridden by the request", func(t *testing.T) {
+		setup("7995")
+
+		_, err := service.Transfer(models.TransferRequest{WalletID: walletID, ToWalletID: toWalletID, Amount: "10.00", MerchantCategory: "5411"}, childID)
+
+		assert.ErrorIs(t, err, ErrCategoryBlocked)
+		mockParental.AssertExpectations(t)
+	})
+
+	t.Run("Per-transaction limit", func(t *testing.T) {
+		setup("")
+
+		_, err := service.Transfer(models.TransferRequest{WalletID: walletID, ToWalletID: toWalletID, Amount: "600.00"}, childID)
+
+		assert.ErrorIs(t, err, ErrSpendingLimitExceeded)
+		mockParental.AssertExpectations(t)
+	})
+
+	t.Run("Monthly limit is checked in the transfer", func(t *testing.T) {
+		setup("")
+		mockParental.On("LimitMonthlySpending", childID, int64(100000)).Return(storage.ErrSpendingLimitExceeded).Once()
+		mockStorage.On("Transfer", walletID, toWalletID, int64(10000), models.TransactionDetails{}).Return(&models.TransferResult{DebitTransactionID: 9}, nil).Once()
+
+		_, err := service.Transfer(models.TransferRequest{WalletID: walletID, ToWalletID: toWalletID, Amount: "100.00"}, childID)
+
+		assert.ErrorIs(t, err, ErrSpendingLimitExceeded)
+		mockStorage.AssertExpectations(t)
+		mockParental.AssertExpectations(t)
+	})
+
+	t.Run("Above threshold is queued for approval", func(t *testing.T) {
+		setup("")
+		mockParental.On("LimitMonthlySpending", childID, int64(100000)).Return(nil).Once()
+		mockParental.On("CreateApproval", mock.MatchedBy(func(a *models.ApprovalRequest) bool {
+			return a.ParentID == parentID && a.ChildID == childID && a.Amount == 30000 && a.Reference == "order:1" && a.Status == models.ApprovalStatusPending
+		})).Return(nil).Once()
+		mockNotifier.On("ApprovalRequested", mock.Anything).Once()
+
+		_, err := service.Transfer(models.TransferRequest{WalletID: walletID, ToWalletID: toWalletID, Amount: "300.00", Reference: "order:1"}, childID)
+
+		assert.ErrorIs(t, err, ErrApprovalRequired)
+		mockParental.AssertExpectations(t)
+		mockNotifier.AssertExpectations(t)
+	})
+
+	t.Run("Approved transfer is executed", func(t *testing.T) {
+		approvalID := uuid.New().String()
+		setup("")
+		mockParental.On("LimitMonthlySpending", childID, int64(100000)).Return(nil).Once()
+		mockParental.On("GetApproval", approvalID).Return(&models.ApprovalRequest{
+			ID: approvalID, ChildID: childID, WalletID: walletID, ToWalletID: toWalletID, Amount: 30000, Reference: "order:1", Status: models.ApprovalStatusApproved,
+		}, nil).Once()
+		mockParental.On("CompleteApproval", approvalID).Return(nil).Once()
+		mockStorage.On("Transfer", walletID, toWalletID, int64(30000), models.TransactionDetails{Reference: "order:1"}).Return(&models.TransferResult{DebitTransactionID: 11, CreditTransactionID: 12}, nil).Once()
+
+		transactionID, err := service.Transfer(models.TransferRequest{WalletID: walletID, ToWalletID: toWalletID, Amount: "300.00", Reference: "order:1", ApprovalID: approvalID}, childID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(11), transactionID)
+		mockStorage.AssertExpectations(t)
+		mockParental.AssertExpectations(t)
+	})
+
+	t.Run("Approval for another reference", func(t *testing.T) {
+		approvalID := uuid.New().String()
+		setup("")
+		mockParental.On("LimitMonthlySpending", childID, int64(100000)).Return(nil).Once()
+		mockParental.On("GetApproval", approvalID).Return(&models.ApprovalRequest{
+			ID: approvalID, ChildID: childID, WalletID: walletID, ToWalletID: toWalletID, Amount: 30000, Reference: "order:1", Status: models.ApprovalStatusApproved,
+		}, nil).Once()
+
+		_, err := service.Transfer(models.TransferRequest{WalletID: walletID, ToWalletID: toWalletID, Amount: "300.00", Reference: "order:2", ApprovalID: approvalID}, childID)
+
+		assert.ErrorIs(t, err, ErrApprovalRequired)
+		mockParental.AssertExpectations(t)
+	})
+
+	t.Run("Approval used by a concurrent transfer", func(t *testing.T) {
+		approvalID := uuid.New().String()
+		setup("")
+		mockParental.On("LimitMonthlySpending", childID, int64(100000)).Return(nil).Once()
+		mockParental.On("GetApproval", approvalID).Return(&models.ApprovalRequest{
+			ID: approvalID, ChildID: childID, WalletID: walletID, ToWalletID: toWalletID, Amount: 30000, Status: models.ApprovalStatusApproved,
+		}, nil).Once()
+		mockParental.On("CompleteApproval", approvalID).Return(storage.ErrApprovalNotApproved).Once()
+		mockStorage.On("Transfer", walletID, toWalletID, int64(30000), models.TransactionDetails{}).Return(&models.TransferResult{DebitTransactionID: 13}, nil).Once()
+
+		_, err := service.Transfer(models.TransferRequest{WalletID: walletID, ToWalletID: toWalletID, Amount: "300.00", ApprovalID: approvalID}, childID)
+
+		assert.ErrorIs(t, err, ErrApprovalRequired)
+		mockStorage.AssertExpectations(t)
+		mockParental.AssertExpectations(t)
+	})
+}
+
+func TestParentalControlService(t *testing.T) {
+	mockParental := new(MockParentalStorage)
+	mockNotifier := new(MockApprovalNotifier)
+	mockPaymentRequests := new(MockPaymentRequestStorage)
+	mockEscrows := new(MockEscrowStorage)
+	mockWalletService := new(MockWalletService)
+	service := &parentalControlService{storage: mockParental, paymentRequests: mockPaymentRequests, escrows: mockEscrows, walletService: mockWalletService, notifier: mockNotifier, logger: log.Default()}
+
+	parentID := uuid.New().String()
+	childID := uuid.New().String()
+
+	t.Run("Set controls", func(t *testing.T) {
+		mockParental.On("GetParentID", childID).Return(parentID, nil).Once()
+		mockParental.On("SaveControls", &models.ParentalControls{
+			ChildID:             childID,
+			PerTransactionLimit: 5000,
+			MonthlyLimit:        100050,
+			BlockedCategories:   []string{},
+		}).Return(nil).Once()
+
+		err := service.SetControls(parentID, models.ParentalControlsModel{ChildID: childID, PerTransactionLimit: "50", MonthlyLimit: "1000.50"})
+
+		assert.NoError(t, err)
+		mockParental.AssertExpectations(t)
+	})
+
+	t.Run("Set controls for someone else's child", func(t *testing.T) {
+		mockParental.On("GetParentID", childID).Return(uuid.New().String(), nil).Once()
+
+		err := service.SetControls(parentID, models.ParentalControlsModel{ChildID: childID})
+
+		assert.ErrorIs(t, err, ErrNotParent)
+		mockParental.AssertExpectations(t)
+	})
+
+	t.Run("Get controls when none are set", func(t *testing.T) {
+		mockParental.On("GetParentID", childID).Return(parentID, nil).Once()
+		mockParental.On("GetControls", childID).Return((*models.ParentalControls)(nil), sql.ErrNoRows).Once()
+
+		controls, err := service.GetControls(parentID, childID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, childID, controls.ChildID)
+		assert.Empty(t, controls.MonthlyLimit)
+		mockParental.AssertExpectations(t)
+	})
+
+	t.Run("Decline", func(t *testing.T) {
+		approvalID := uuid.New().String()
+		mockParental.On("GetApproval", approvalID).Return(&models.ApprovalRequest{ID: approvalID, ParentID: parentID, Status: models.ApprovalStatusPending}, nil).Once()
+		mockParental.On("UpdateApprovalStatus", approvalID, models.ApprovalStatusPending, models.ApprovalStatusDeclined).Return(true, nil).Once()
+		mockNotifier.On("ApprovalDecided", mock.Anything).Once()
+
+		err := service.Decline(parentID, approvalID)
+
+		assert.NoError(t, err)
+		mockParental.AssertExpectations(t)
+		mockNotifier.AssertExpectations(t)
+	})
+
+	t.Run("Decline already decided", func(t *testing.T) {
+		approvalID := uuid.New().String()
+		mockParental.On("GetApproval", approvalID).Return(&models.ApprovalRequest{ID: approvalID, ParentID: parentID, Status: models.ApprovalStatusDeclined}, nil).Once()
+		mockParental.On("UpdateApprovalStatus", approvalID, models.ApprovalStatusPending, models.ApprovalStatusDeclined).Return(false, nil).Once()
+
+		err := service.Decline(parentID, approvalID)
+
+		assert.ErrorIs(t, err, ErrApprovalNotPending)
+		mockParental.AssertExpectations(t)
+	})
+
+	t.Run("Approve pays the payment request it was raised for", func(t *testing.T) {
+		approvalID := uuid.New().String()
+		requestID := uuid.New().String()
+		walletID := uuid.New().String()
+		toWalletID := uuid.New().String()
+		approval := &models.ApprovalRequest{
+			ID: approvalID, ParentID: parentID, ChildID: childID, WalletID: walletID, ToWalletID: toWalletID,
+			Amount: 30000, Reference: "payment_request:" + requestID, Status: models.ApprovalStatusPending,
+		}
+		mockParental.On("GetApproval", approvalID).Return(approval, nil).Once()
+		mockParental.On("UpdateApprovalStatus", approvalID, models.ApprovalStatusPending, models.ApprovalStatusApproved).Return(true, nil).Once()
+		mockNotifier.On("ApprovalDecided", mock.Anything).Once()
+		mockPaymentRequests.On("CompletePaymentRequest", requestID, walletID).Return(storage.ErrPaymentRequestNotPending).Once()
+		mockWalletService.On("Transfer", models.TransferRequest{
+			WalletID: walletID, ToWalletID: toWalletID, Amount: "300.00", Reference: "payment_request:" + requestID, ApprovalID: approvalID,
+		}, childID).Return(int64(15), nil).Once()
+		mockParental.On("UpdateApprovalStatus", approvalID, models.ApprovalStatusApproved, models.ApprovalStatusFailed).Return(true, nil).Once()
+
+		_, err := service.Approve(parentID, approvalID)
+
+		assert.ErrorIs(t, err, ErrPaymentRequestNotPending)
+		mockParental.AssertExpectations(t)
+		mockPaymentRequests.AssertExpectations(t)
+		mockWalletService.AssertExpectations(t)
+	})
+
+	t.Run("Approve holds the escrow it was raised for", func(t *testing.T) {
+		approvalID := uuid.New().String()
+		escrowID := uuid.New().String()
+		walletID := uuid.New().String()
+		escrowWalletID := uuid.New().String()
+		approval := &models.ApprovalRequest{
+			ID: approvalID, ParentID: parentID, ChildID: childID, WalletID: walletID, ToWalletID: escrowWalletID,
+			Amount: 30000, Reference: storage.EscrowReferencePrefix + escrowID, Status: models.ApprovalStatusPending,
+		}
+		held := false
+		mockParental.On("GetApproval", approvalID).Return(approval, nil).Once()
+		mockParental.On("UpdateApprovalStatus", approvalID, models.ApprovalStatusPending, models.ApprovalStatusApproved).Return(true, nil).Once()
+		mockNotifier.On("ApprovalDecided", mock.Anything).Once()
+		mockEscrows.On("HoldEscrow", escrowID, walletID).Return(storage.TransferHook(func(tx *sql.Tx, result *models.TransferResult) error {
+			held = true
+			return nil
+		})).Once()
+		mockWalletService.On("Transfer", models.TransferRequest{
+			WalletID: walletID, ToWalletID: escrowWalletID, Amount: "300.00", Reference: storage.EscrowReferencePrefix + escrowID, ApprovalID: approvalID,
+		}, childID).Return(int64(16), nil).Once()
+
+		transactionID, err := service.Approve(parentID, approvalID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(16), transactionID)
+		assert.True(t, held)
+		mockEscrows.AssertExpectations(t)
+		mockWalletService.AssertExpectations(t)
+	})
+
+	t.Run("Approval of another parent", func(t *testing.T) {
+		approvalID := uuid.New().String()
+		mockParental.On("GetApproval", approvalID).Return(&models.ApprovalRequest{ID: approvalID, ParentID: uuid.New().String()}, nil).Once()
+
+		_, err := service.Approve(parentID, approvalID)
+
+		assert.ErrorIs(t, err, ErrApprovalNotFound)
+		mockParental.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+type PaymentRequestService interface {
+	Create(request models.CreatePaymentRequest, userID string) (*models.PaymentRequest, error)
+	List(request models.ListPaymentRequests, userID string) ([]models.PaymentRequest, error)
+	Accept(request models.AcceptPaymentRequest, userID string) (*models.PaymentRequest, error)
+	Decline(requestID, userID string) error
+	Cancel(requestID, userID string) error
+}
+
+var (
+	ErrPaymentRequestNotFound   = errors.New("payment request not found")
+	ErrPaymentRequestNotPending = storage.ErrPaymentRequestNotPending
+	ErrInvalidExpiry            = errors.New("expiry must be in the future")
+	ErrSelfPaymentRequest       = errors.New("can't request money from yourself")
+)
+
+const paymentRequestReferencePrefix = "payment_request:"
+
+type paymentRequestService struct {
+	storage       storage.PaymentRequestStorager
+	wallets       storage.WalletStorager
+	walletService WalletService
+	logger        *log.Logger
+}
+
+func NewPaymentRequestService(db *sql.DB, walletService WalletService) PaymentRequestService {
+	return &paymentRequestService{
+		storage:       storage.NewPaymentRequestStorage(db),
+		wallets:       storage.NewWalletStorage(db),
+		walletService: walletService,
+		logger:        log.New(log.Writer(), "PaymentRequestService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+func (s *paymentRequestService) Create(request models.CreatePaymentRequest, userID string) (*models.PaymentRequest, error) {
+	s.logger.Printf("Creating payment request: walletID=%s, userID=%s, payerID=%s, amount=%s", request.WalletID, userID, request.PayerID, request.Amount)
+	amount, err := models.ParseAmount(request.Amount)
+	if err != nil {
+		s.logger.Printf("Error parsing amount: %v", err)
+		return nil, err
+	}
+
+	if request.PayerID == userID {
+		return nil, ErrSelfPaymentRequest
+	}
+
+	expiresAt := time.Now().Add(models.DefaultPaymentRequestTTL)
+	if request.ExpiresAt != nil {
+		if !request.ExpiresAt.After(time.Now()) {
+			return nil, ErrInvalidExpiry
+		}
+		expiresAt = *request.ExpiresAt
+	}
+
+	wallet, err := s.wallets.GetWallet(request.WalletID, userID)
+	if err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return nil, errors.Wrap(err, "Error getting wallet")
+	}
+
+	paymentRequest := &models.PaymentRequest{
+		RequesterID:       userID,
+		RequesterWalletID: wallet.ID,
+		PayerID:           request.PayerID,
+		Amount:            amount,
+		Message:           request.Message,
+		Status:            models.PaymentRequestStatusPending,
+		ExpiresAt:         expiresAt,
+	}
+	if err := s.storage.CreatePaymentRequest(paymentRequest); err != nil {
+		s.logger.Printf("Error creating payment request: %v", err)
+		return nil, err
+	}
+
+	return paymentRequest, nil
+}
+
+func (s *paymentRequestService) List(request models.ListPaymentRequests, userID string) ([]models.PaymentRequest, error) {
+	s.logger.Printf("Listing payment requests: userID=%s, role=%s, status=%s", userID, request.Role, request.Status)
+	requests, err := s.storage.ListPaymentRequests(userID, request.Role, request.Status)
+	if err != nil {
+		s.logger.Printf("Error listing payment requests: %v", err)
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range requests {
+		if requests[i].Status == models.PaymentRequestStatusPending && !requests[i].ExpiresAt.After(now) {
+			requests[i].Status = models.PaymentRequestStatusExpired
+		}
+	}
+
+	return requests, nil
+}
+
+// Accept pays the request from the payer's wallet. The request is marked as paid in the
+// transaction of the transfer, so it can't be paid twice. A transfer waiting for parental
+// approval leaves the request pending until the parent approves it.
+func (s *paymentRequestService) Accept(request models.AcceptPaymentRequest, userID string) (*models.PaymentRequest, error) {
+	s.logger.Printf("Accepting payment request: requestID=%s, walletID=%s, userID=%s", request.RequestID, request.WalletID, userID)
+	paymentRequest, err := s.getForPayer(request.RequestID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if paymentRequest.Status != models.PaymentRequestStatusPending {
+		return nil, ErrPaymentRequestNotPending
+	}
+
+	transactionID, err := s.walletService.Transfer(models.TransferRequest{
+		WalletID:   request.WalletID,
+		ToWalletID: paymentRequest.RequesterWalletID,
+		Amount:     models.FormatAmount(paymentRequest.Amount),
+		Reference:  paymentRequestReferencePrefix + paymentRequest.ID,
+	}, userID, s.storage.CompletePaymentRequest(paymentRequest.ID, request.WalletID))
+	if err != nil {
+		s.logger.Printf("Error paying payment request: %v", err)
+		return nil, err
+	}
+
+	paymentRequest.Status = models.PaymentRequestStatusPaid
+	paymentRequest.PayerWalletID = request.WalletID
+	paymentRequest.TransactionID = transactionID
+
+	return paymentRequest, nil
+}
+
+func (s *paymentRequestService) Decline(requestID, userID string) error {
+	s.logger.Printf("Declining payment request: requestID=%s, userID=%s", requestID, userID)
+	paymentRequest, err := s.getForPayer(requestID, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.updateStatus(paymentRequest.ID, models.PaymentRequestStatusPending, models.PaymentRequestStatusDeclined)
+}
+
+func (s *paymentRequestService) Cancel(requestID, userID string) error {
+	s.logger.Printf("Cancelling payment request: requestID=%s, userID=%s", requestID, userID)
+	paymentRequest, err := s.get(requestID)
+	if err != nil {
+		return err
+	}
+
+	if paymentRequest.RequesterID != userID {
+		return ErrPaymentRequestNotFound
+	}
+
+	return s.updateStatus(paymentRequest.ID, models.PaymentRequestStatusPending, models.PaymentRequestStatusCancelled)
+}
+
+func (s *paymentRequestService) get(requestID string) (*models.PaymentRequest, error) {
+	paymentRequest, err := s.storage.GetPaymentRequest(requestID)
+	if err == sql.ErrNoRows {
+		return nil, ErrPaymentRequestNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting payment request: %v", err)
+		return nil, err
+	}
+
+	return paymentRequest, nil
+}
+
+func (s *paymentRequestService) getForPayer(requestID, userID string) (*models.PaymentRequest, error) {
+	paymentRequest, err := s.get(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if paymentRequest.PayerID != userID {
+		return nil, ErrPaymentRequestNotFound
+	}
+
+	return paymentRequest, nil
+}
+
+func (s *paymentRequestService) updateStatus(requestID, fromStatus, toStatus string) error {
+	updated, err := s.storage.UpdatePaymentRequestStatus(requestID, fromStatus, toStatus)
+	if err != nil {
+		s.logger.Printf("Error updating payment request: %v", err)
+		return err
+	}
+	if !updated {
+		return ErrPaymentRequestNotPending
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock implementation of PaymentRequestStorage
+type MockPaymentRequestStorage struct {
+	mock.Mock
+}
+
+func (m *MockPaymentRequestStorage) CreatePaymentRequest(request *models.PaymentRequest) error {
+	args := m.Called(request)
+	return args.Error(0)
+}
+
+func (m *MockPaymentRequestStorage) GetPaymentRequest(requestID string) (*models.PaymentRequest, error) {
+	args := m.Called(requestID)
+	return args.Get(0).(*models.PaymentRequest), args.Error(1)
+}
+
+func (m *MockPaymentRequestStorage) ListPaymentRequests(userID, role, status string) ([]models.PaymentRequest, error) {
+	args := m.Called(userID, role, status)
+	return args.Get(0).([]models.PaymentRequest), args.Error(1)
+}
+
+func (m *MockPaymentRequestStorage) UpdatePaymentRequestStatus(requestID, fromStatus, toStatus string) (bool, error) {
+	args := m.Called(requestID, fromStatus, toStatus)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPaymentRequestStorage) CompletePaymentRequest(requestID, payerWalletID string) storage.TransferHook {
+	args := m.Called(requestID, payerWalletID)
+	return hookReturning(args.Error(0))
+}
+
+// Mock implementation of WalletService
+type MockWalletService struct {
+	mock.Mock
+}
+
+func (m *MockWalletService) CheckWalletExists(walletID, userID string) (bool, error) {
+	args := m.Called(walletID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWalletService) TopUpWallet(request models.TopUpRequest, userID string, hooks ...storage.TransferHook) (int64, error) {
+	args := m.Called(request, userID)
+	transactionID, err := args.Get(0).(int64), args.Error(1)
+	if err != nil {
+		return 0, err
+	}
+	return transactionID, runHooks(hooks, &models.TransferResult{CreditTransactionID: transactionID})
+}
+
+func (m *MockWalletService) GetWallet(walletID, userID string) (*models.Wallet, error) {
+	args := m.Called(walletID, userID)
+	return args.Get(0).(*models.Wallet), args.Error(1)
+}
+
+func (m *MockWalletService) GetTransactions(walletID, userID string) (int, string, error) {
+	args := m.Called(walletID, userID)
+	return args.Int(0), args.String(1), args.Error(2)
+}
+
+func (m *MockWalletService) GetBalance(walletID, userID string) (string, error) {
+	args := m.Called(walletID, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockWalletService) Transfer(request models.TransferRequest, userID string, hooks ...storage.TransferHook) (int64, error) {
+	args := m.Called(request, userID)
+	transactionID, err := args.Get(0).(int64), args.Error(1)
+	if err != nil {
+		return 0, err
+	}
+
+	return transactionID, runHooks(hooks, &models.TransferResult{DebitTransactionID: transactionID})
+}
+
+func TestCreatePaymentRequest(t *testing.T) {
+	mockStorage := new(MockPaymentRequestStorage)
+	mockWallets := new(MockWalletStorage)
+	service := &paymentRequestService{storage: mockStorage, wallets: mockWallets, logger: log.Default()}
+
+	userID := uuid.New().String()
+	payerID := uuid.New().String()
+	walletID := uuid.New().String()
+
+	t.Run("Successful create", func(t *testing.T) {
+		mockWallets.On("GetWallet", walletID, userID).Return(&models.Wallet{ID: walletID, UserID: userID}, nil).Once()
+		mockStorage.On("CreatePaymentRequest", mock.MatchedBy(func(r *models.PaymentRequest) bool {
+			return r.RequesterID == userID && r.PayerID == payerID && r.Amount == 2500 && r.Status == models.PaymentRequestStatusPending
+		})).Return(nil).Once()
+
+		paymentRequest, err := service.Create(models.CreatePaymentRequest{WalletID: walletID, PayerID: payerID, Amount: "25", Message: "Lunch"}, userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Lunch", paymentRequest.Message)
+		assert.WithinDuration(t, time.Now().Add(models.DefaultPaymentRequestTTL), paymentRequest.ExpiresAt, time.Minute)
+		mockStorage.AssertExpectations(t)
+		mockWallets.AssertExpectations(t)
+	})
+
+	t.Run("Expiry in the past", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour)
+
+		_, err := service.Create(models.CreatePaymentRequest{WalletID: walletID, PayerID: payerID, Amount: "25", ExpiresAt: &expiresAt}, userID)
+
+		assert.ErrorIs(t, err, ErrInvalidExpiry)
+	})
+
+	t.Run("Request from yourself", func(t *testing.T) {
+		_, err := service.Create(models.CreatePaymentRequest{WalletID: walletID, PayerID: userID, Amount: "25"}, userID)
+
+		assert.ErrorIs(t, err, ErrSelfPaymentRequest)
+	})
+}
+
+func TestAcceptPaymentRequest(t *testing.T) {
+	mockStorage := new(MockPaymentRequestStorage)
+	mockWalletService := new(MockWalletService)
+	service := &paymentRequestService{storage: mockStorage, walletService: mockWalletService, logger: log.Default()}
+
+	requesterID := uuid.New().String()
+	requesterWalletID := uuid.New().String()
+	payerID := uuid.New().String()
+	payerWalletID := uuid.New().String()
+
+	newRequest := func() *models.PaymentRequest {
+		return &models.PaymentRequest{
+			ID:                uuid.New().String(),
+			RequesterID:       requesterID,
+			RequesterWalletID: requesterWalletID,
+			PayerID:           payerID,
+			Amount:            2500,
+			Status:            models.PaymentRequestStatusPending,
+			ExpiresAt:         time.Now().Add(time.Hour),
+		}
+	}
+
+	t.Run("Successful accept", func(t *testing.T) {
+		paymentRequest := newRequest()
+		mockStorage.On("GetPaymentRequest", paymentRequest.ID).Return(paymentRequest, nil).Once()
+		mockStorage.On("CompletePaymentRequest", paymentRequest.ID, payerWalletID).Return(nil).Once()
+		mockWalletService.On("Transfer", models.TransferRequest{
+			WalletID:   payerWalletID,
+			ToWalletID: requesterWalletID,
+			Amount:     "25.00",
+			Reference:  "payment_request:" + paymentRequest.ID,
+		}, payerID).Return(int64(42), nil).Once()
+
+		paid, err := service.Accept(models.AcceptPaymentRequest{RequestID: paymentRequest.ID, WalletID: payerWalletID}, payerID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.PaymentRequestStatusPaid, paid.Status)
+		assert.Equal(t, int64(42), paid.TransactionID)
+		mockStorage.AssertExpectations(t)
+		mockWalletService.AssertExpectations(t)
+	})
+
+	t.Run("Failed transfer", func(t *testing.T) {
+		paymentRequest := newRequest()
+		mockStorage.On("GetPaymentRequest", paymentRequest.ID).Return(paymentRequest, nil).Once()
+		mockStorage.On("CompletePaymentRequest", paymentRequest.ID, payerWalletID).Return(nil).Once()
+		mockWalletService.On("Transfer", mock.Anything, payerID).Return(int64(0), errors.New("insufficient funds")).Once()
+
+		_, err := service.Accept(models.AcceptPaymentRequest{RequestID: paymentRequest.ID, WalletID: payerWalletID}, payerID)
+
+		assert.Error(t, err)
+		mockStorage.AssertExpectations(t)
+		mockWalletService.AssertExpectations(t)
+	})
+
+	t.Run("Paid concurrently", func(t *testing.T) {
+		paymentRequest := newRequest()
+		mockStorage.On("GetPaymentRequest", paymentRequest.ID).Return(paymentRequest, nil).Once()
+		mockStorage.On("CompletePaymentRequest", paymentRequest.ID, payerWalletID).Return(storage.ErrPaymentRequestNotPending).Once()
+		mockWalletService.On("Transfer", mock.Anything, payerID).Return(int64(43), nil).Once()
+
+		_, err := service.Accept(models.AcceptPaymentRequest{RequestID: paymentRequest.ID, WalletID: payerWalletID}, payerID)
+
+		assert.ErrorIs(t, err, ErrPaymentRequestNotPending)
+		mockStorage.AssertExpectations(t)
+		mockWalletService.AssertExpectations(t)
+	})
+
+	t.Run("Already paid", func(t *testing.T) {
+		paymentRequest := newRequest()
+		paymentRequest.Status = models.PaymentRequestStatusPaid
+		mockStorage.On("GetPaymentRequest", paymentRequest.ID).Return(paymentRequest, nil).Once()
+
+		_, err := service.Accept(models.AcceptPaymentRequest{RequestID: paymentRequest.ID, WalletID: payerWalletID}, payerID)
+
+		assert.ErrorIs(t, err, ErrPaymentRequestNotPending)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Only the requester can cancel", func(t *testing.T) {
+		paymentRequest := newRequest()
+		mockStorage.On("GetPaymentRequest", paymentRequest.ID).Return(paymentRequest, nil).Once()
+
+		err := service.Cancel(paymentRequest.ID, payerID)
+
+		assert.ErrorIs(t, err, ErrPaymentRequestNotFound)
+		mockStorage.AssertExpectations(t)
+	})
+}
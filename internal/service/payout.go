@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/payout"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+type PayoutService interface {
+	Upload(walletID, fileName, format string, file io.Reader, userID string) (*models.PayoutBatch, error)
+	Get(batchID, userID string) (*models.PayoutBatch, error)
+	List(userID string) ([]models.PayoutBatch, error)
+	Pause(batchID, userID string) (*models.PayoutBatch, error)
+	Resume(batchID, userID string) (*models.PayoutBatch, error)
+	Report(batchID, userID string) (*models.PayoutBatch, []models.PayoutRow, error)
+	ProcessDue(ctx context.Context) error
+	Run(ctx context.Context)
+}
+
+var (
+	ErrPayoutBatchNotFound   = errors.New("payout batch not found")
+	ErrPayoutBatchNotRunning = errors.New("payout batch is not being processed")
+	ErrPayoutBatchNotPaused  = errors.New("payout batch is not paused")
+	ErrPayoutExceedsBalance  = errors.New("payout total exceeds the wallet balance")
+)
+
+// PayoutValidationError lists every row of an uploaded file that can't be paid out
+type PayoutValidationError struct {
+	Rows []payout.LineError
+}
+
+func (e *PayoutValidationError) Error() string {
+	return fmt.Sprintf("payout file has %d invalid rows", len(e.Rows))
+}
+
+const (
+	payoutChunkSize    = 20
+	payoutPollInterval = 2 * time.Second
+
+	payoutReferencePrefix = "payout:"
+)
+
+// errPayoutPaused stops processing of a batch that was paused for lack of funds
+var errPayoutPaused = errors.New("payout batch paused")
+
+type payoutService struct {
+	storage       storage.PayoutStorager
+	wallets       storage.WalletStorager
+	walletService WalletService
+	logger        *log.Logger
+}
+
+func NewPayoutService(db *sql.DB, walletService WalletService) PayoutService {
+	return &payoutService{
+		storage:       storage.NewPayoutStorage(db),
+		wallets:       storage.NewWalletStorage(db),
+		walletService: walletService,
+		logger:        log.New(log.Writer(), "PayoutService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+// Upload validates every row of the file and queues the batch. Nothing is queued
+// unless the whole file is valid and the wallet can cover the total.
+func (s *payoutService) Upload(walletID, fileName, format string, file io.Reader, userID string) (*models.PayoutBatch, error) {
+	s.logger.Printf("Uploading payout batch: walletID=%s, userID=%s, file=%s, format=%s", walletID, userID, fileName, format)
+	wallet, err := s.wallets.GetWallet(walletID, userID)
+	if err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return nil, errors.Wrap(err, "Error getting wallet")
+	}
+
+	lines, lineErrors, err := payout.Parse(format, file)
+	if err != nil {
+		s.logger.Printf("Error parsing payout file: %v", err)
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(lines))
+	for _, line := range lines {
+		ids = append(ids, line.WalletID)
+	}
+	existing, err := s.storage.FindWallets(ids)
+	if err != nil {
+		s.logger.Printf("Error looking up payout wallets: %v", err)
+		return nil, err
+	}
+
+	var total int64
+	rows := make([]models.PayoutRow, 0, len(lines))
+	for _, line := range lines {
+		switch {
+		case line.WalletID == wallet.ID:
+			lineErrors = append(lineErrors, payout.LineError{Number: line.Number, Error: "can't pay out to the source wallet"})
+		case !existing[line.WalletID]:
+			lineErrors = append(lineErrors, payout.LineError{Number: line.Number, Error: "wallet not found"})
+		default:
+			total += line.Amount
+			rows = append(rows, models.PayoutRow{
+				RowNumber:   line.Number,
+				WalletID:    line.WalletID,
+				Amount:      line.Amount,
+				Description: line.Description,
+			})
+		}
+	}
+	if len(lineErrors) > 0 {
+		return nil, &PayoutValidationError{Rows: lineErrors}
+	}
+
+	if total > wallet.Balance {
+		return nil, ErrPayoutExceedsBalance
+	}
+
+	batch := &models.PayoutBatch{
+		UserID:      userID,
+		WalletID:    wallet.ID,
+		FileName:    fileName,
+		Format:      format,
+		Status:      models.PayoutBatchProcessing,
+		TotalRows:   len(rows),
+		TotalAmount: total,
+	}
+	if err := s.storage.CreateBatch(batch, rows); err != nil {
+		s.logger.Printf("Error creating payout batch: %v", err)
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+func (s *payoutService) Get(batchID, userID string) (*models.PayoutBatch, error) {
+	s.logger.Printf("Getting payout batch: batchID=%s, userID=%s", batchID, userID)
+	batch, err := s.storage.GetBatch(batchID)
+	if err == sql.ErrNoRows {
+		return nil, ErrPayoutBatchNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting payout batch: %v", err)
+		return nil, err
+	}
+
+	if batch.UserID != userID {
+		return nil, ErrPayoutBatchNotFound
+	}
+
+	return batch, nil
+}
+
+func (s *payoutService) List(userID string) ([]models.PayoutBatch, error) {
+	s.logger.Printf("Listing payout batches: userID=%s", userID)
+	batches, err := s.storage.ListBatches(userID)
+	if err != nil {
+		s.logger.Printf("Error listing payout batches: %v", err)
+		return nil, err
+	}
+
+	return batches, nil
+}
+
+// Pause stops the worker from taking new rows of the batch. Rows already being
+// paid are finished.
+func (s *payoutService) Pause(batchID, userID string) (*models.PayoutBatch, error) {
+	return s.transition(batchID, userID, models.PayoutBatchProcessing, models.PayoutBatchPaused, ErrPayoutBatchNotRunning)
+}
+
+func (s *payoutService) Resume(batchID, userID string) (*models.PayoutBatch, error) {
+	return s.transition(batchID, userID, models.PayoutBatchPaused, models.PayoutBatchProcessing, ErrPayoutBatchNotPaused)
+}
+
+func (s *payoutService) transition(batchID, userID, fromStatus, toStatus string, errWrongStatus error) (*models.PayoutBatch, error) {
+	batch, err := s.Get(batchID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Printf("Updating payout batch: batchID=%s, status=%s", batchID, toStatus)
+	updated, err := s.storage.UpdateBatchStatus(batch.ID, fromStatus, toStatus)
+	if err != nil {
+		s.logger.Printf("Error updating payout batch: %v", err)
+		return nil, err
+	}
+	if !updated {
+		return nil, errWrongStatus
+	}
+
+	batch.Status = toStatus
+	return batch, nil
+}
+
+func (s *payoutService) Report(batchID, userID string) (*models.PayoutBatch, []models.PayoutRow, error) {
+	batch, err := s.Get(batchID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := s.storage.ListRows(batch.ID)
+	if err != nil {
+		s.logger.Printf("Error listing payout rows: %v", err)
+		return nil, nil, err
+	}
+
+	return batch, rows, nil
+}
+
+// Run recovers rows interrupted by a restart and then processes batches until ctx is cancelled
+func (s *payoutService) Run(ctx context.Context) {
+	if err := s.recover(); err != nil {
+		s.logger.Printf("Error recovering payout rows: %v", err)
+	}
+
+	ticker := time.NewTicker(payoutPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ProcessDue(ctx); err != nil {
+				s.logger.Printf("Error processing payouts: %v", err)
+			}
+		}
+	}
+}
+
+// ProcessDue pays out the pending rows of every running batch
+func (s *payoutService) ProcessDue(ctx context.Context) error {
+	batches, err := s.storage.ListActiveBatches()
+	if err != nil {
+		return errors.Wrap(err, "unable to list payout batches")
+	}
+
+	for i := range batches {
+		if err := s.processBatch(ctx, &batches[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *payoutService) processBatch(ctx context.Context, batch *models.PayoutBatch) error {
+	for ctx.Err() == nil {
+		rows, err := s.storage.ClaimRows(batch.ID, payoutChunkSize)
+		if err != nil {
+			return errors.Wrapf(err, "unable to claim rows of payout batch %s", batch.ID)
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		for i, row := range rows {
+			err := s.pay(batch, row)
+			if err == nil {
+				continue
+			}
+
+			// The rest of the chunk goes back to the queue for when the batch is resumed
+			if releaseErr := s.release(rows[i:]); releaseErr != nil {
+				s.logger.Printf("Error releasing rows of payout batch %s: %v", batch.ID, releaseErr)
+			}
+			if err == errPayoutPaused {
+				return nil
+			}
+			return errors.Wrapf(err, "unable to pay out row %d of batch %s", row.RowNumber, batch.ID)
+		}
+	}
+
+	finished, err := s.storage.FinishBatch(batch.ID)
+	if err != nil {
+		return errors.Wrapf(err, "unable to finish payout batch %s", batch.ID)
+	}
+	if finished {
+		s.logger.Printf("Payout batch completed: batchID=%s", batch.ID)
+	}
+
+	return nil
+}
+
+// pay transfers a single row, the row is marked as succeeded in the transaction of the
+// transfer. Rows the transfer refused are failed. errPayoutPaused is returned when the
+// source wallet ran out of money, the batch is paused then so it can be topped up and
+// resumed. Other errors mean the row couldn't be settled and stop the batch.
+func (s *payoutService) pay(batch *models.PayoutBatch, row models.PayoutRow) error {
+	_, err := s.walletService.Transfer(models.TransferRequest{
+		WalletID:    batch.WalletID,
+		ToWalletID:  row.WalletID,
+		Amount:      models.FormatAmount(row.Amount),
+		Reference:   payoutReferencePrefix + row.ID,
+		Description: row.Description,
+	}, batch.UserID, s.storage.PayRow(row.ID))
+	if errors.Is(err, storage.ErrInsufficientFunds) {
+		s.logger.Printf("Pausing payout batch, wallet has insufficient funds: batchID=%s, row=%d", batch.ID, row.RowNumber)
+		if _, err := s.storage.UpdateBatchStatus(batch.ID, models.PayoutBatchProcessing, models.PayoutBatchPaused); err != nil {
+			return errors.Wrap(err, "unable to pause payout batch")
+		}
+		return errPayoutPaused
+	}
+	if errors.Is(err, storage.ErrPayoutRowNotProcessing) {
+		return err
+	}
+	if err != nil {
+		s.logger.Printf("Payout row failed: batchID=%s, row=%d: %v", batch.ID, row.RowNumber, err)
+		if err := s.storage.FailRow(row.ID, err.Error()); err != nil {
+			return errors.Wrap(err, "unable to fail payout row")
+		}
+	}
+
+	return nil
+}
+
+// recover settles rows left in processing by a restart
+func (s *payoutService) recover() error {
+	rows, err := s.storage.ListStuckRows()
+	if err != nil {
+		return err
+	}
+
+	return s.release(rows)
+}
+
+// release puts rows left in processing back to the queue. Rows whose transfer went
+// through are completed instead, so they are never paid twice.
+func (s *payoutService) release(rows []models.PayoutRow) error {
+	for _, row := range rows {
+		paid, err := s.wallets.HasReference(row.WalletID, payoutReferencePrefix+row.ID)
+		if err != nil {
+			return err
+		}
+		if paid {
+			err = s.storage.CompleteRow(row.ID, 0)
+		} else {
+			err = s.storage.ReleaseRow(row.ID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock implementation of PayoutStorage
+type MockPayoutStorage struct {
+	mock.Mock
+}
+
+func (m *MockPayoutStorage) CreateBatch(batch *models.PayoutBatch, rows []models.PayoutRow) error {
+	args := m.Called(batch, rows)
+	return args.Error(0)
+}
+
+func (m *MockPayoutStorage) GetBatch(batchID string) (*models.PayoutBatch, error) {
+	args := m.Called(batchID)
+	return args.Get(0).(*models.PayoutBatch), args.Error(1)
+}
+
+func (m *MockPayoutStorage) ListBatches(userID string) ([]models.PayoutBatch, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.PayoutBatch), args.Error(1)
+}
+
+func (m *MockPayoutStorage) ListActiveBatches() ([]models.PayoutBatch, error) {
+	args := m.Called()
+	return args.Get(0).([]models.PayoutBatch), args.Error(1)
+}
+
+func (m *MockPayoutStorage) UpdateBatchStatus(batchID, fromStatus, toStatus string) (bool, error) {
+	args := m.Called(batchID, fromStatus, toStatus)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPayoutStorage) FinishBatch(batchID string) (bool, error) {
+	args := m.Called(batchID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPayoutStorage) ClaimRows(batchID string, limit int) ([]models.PayoutRow, error) {
+	args := m.Called(batchID, limit)
+	return args.Get(0).([]models.PayoutRow), args.Error(1)
+}
+
+func (m *MockPayoutStorage) ListStuckRows() ([]models.PayoutRow, error) {
+	args := m.Called()
+	return args.Get(0).([]models.PayoutRow), args.Error(1)
+}
+
+func (m *MockPayoutStorage) PayRow(rowID string) storage.TransferHook {
+	args := m.Called(rowID)
+	return hookReturning(args.Error(0))
+}
+
+func (m *MockPayoutStorage) CompleteRow(rowID string, transactionID int64) error {
+	args := m.Called(rowID, transactionID)
+	return args.Error(0)
+}
+
+func (m *MockPayoutStorage) FailRow(rowID, reason string) error {
+	args := m.Called(rowID, reason)
+	return args.Error(0)
+}
+
+func (m *MockPayoutStorage) ReleaseRow(rowID string) error {
+	args := m.Called(rowID)
+	return args.Error(0)
+}
+
+func (m *MockPayoutStorage) ListRows(batchID string) ([]models.PayoutRow, error) {
+	args := m.Called(batchID)
+	return args.Get(0).([]models.PayoutRow), args.Error(1)
+}
+
+func (m *MockPayoutStorage) FindWallets(walletIDs []string) (map[string]bool, error) {
+	args := m.Called(walletIDs)
+	return args.Get(0).(map[string]bool), args.Error(1)
+}
+
+func TestUploadPayouts(t *testing.T) {
+	mockStorage := new(MockPayoutStorage)
+	mockWalletStorage := new(MockWalletStorage)
+	service := &payoutService{storage: mockStorage, wallets: mockWalletStorage, logger: log.Default()}
+
+	userID := uuid.New().String()
+	walletID := uuid.New().String()
+	first := uuid.New().String()
+	second := uuid.New().String()
+	file := "wallet_id,amount\n" + first + ",100\n" + second + ",50.25\n"
+
+	t.Run("Valid file is queued", func(t *testing.T) {
+		mockWalletStorage.On("GetWallet", walletID, userID).Return(&models.Wallet{ID: walletID, UserID: userID, Balance: 20000}, nil).Once()
+		mockStorage.On("FindWallets", []string{first, second}).Return(map[string]bool{first: true, second: true}, nil).Once()
+		mockStorage.On("CreateBatch", mock.MatchedBy(func(batch *models.PayoutBatch) bool {
+			return batch.TotalRows == 2 && batch.TotalAmount == 15025 && batch.Status == models.PayoutBatchProcessing
+		}), mock.MatchedBy(func(rows []models.PayoutRow) bool {
+			return len(rows) == 2 && rows[1].RowNumber == 2 && rows[1].Amount == 5025
+		})).Return(nil).Once()
+
+		batch, err := service.Upload(walletID, "payroll.csv", "csv", strings.NewReader(file), userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(15025), batch.TotalAmount)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Unknown wallet rejects the file", func(t *testing.T) {
+		mockWalletStorage.On("GetWallet", walletID, userID).Return(&models.Wallet{ID: walletID, UserID: userID, Balance: 20000}, nil).Once()
+		mockStorage.On("FindWallets", []string{first, second}).Return(map[string]bool{first: true}, nil).Once()
+
+		_, err := service.Upload(walletID, "payroll.csv", "csv", strings.NewReader(file), userID)
+
+		var validationErr *PayoutValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, 2, validationErr.Rows[0].Number)
+		assert.Equal(t, "wallet not found", validationErr.Rows[0].Error)
+	})
+
+	t.Run("Total exceeds balance", func(t *testing.T) {
+		mockWalletStorage.On("GetWallet", walletID, userID).Return(&models.Wallet{ID: walletID, UserID: userID, Balance: 10000}, nil).Once()
+		mockStorage.On("FindWallets", []string{first, second}).Return(map[string]bool{first: true, second: true}, nil).Once()
+
+		_, err := service.Upload(walletID, "payroll.csv", "csv", strings.NewReader(file), userID)
+
+		assert.ErrorIs(t, err, ErrPayoutExceedsBalance)
+	})
+}
+
+func TestProcessPayouts(t *testing.T) {
+	batch := models.PayoutBatch{ID: uuid.New().String(), UserID: uuid.New().String(), WalletID: uuid.New().String(), Status: models.PayoutBatchProcessing}
+	rows := []models.PayoutRow{
+		{ID: "row-1", RowNumber: 1, WalletID: uuid.New().String(), Amount: 1000},
+		{ID: "row-2", RowNumber: 2, WalletID: uuid.New().String(), Amount: 2000},
+		{ID: "row-3", RowNumber: 3, WalletID: uuid.New().String(), Amount: 3000},
+	}
+	transfer := func(row models.PayoutRow) models.TransferRequest {
+		return models.TransferRequest{
+			WalletID:   batch.WalletID,
+			ToWalletID: row.WalletID,
+			Amount:     models.FormatAmount(row.Amount),
+			Reference:  "payout:" + row.ID,
+		}
+	}
+
+	t.Run("Rows are paid and failures recorded", func(t *testing.T) {
+		mockStorage := new(MockPayoutStorage)
+		mockWalletService := new(MockWalletService)
+		service := &payoutService{storage: mockStorage, walletService: mockWalletService, logger: log.Default()}
+
+		mockStorage.On("ListActiveBatches").Return([]models.PayoutBatch{batch}, nil).Once()
+		mockStorage.On("ClaimRows", batch.ID, payoutChunkSize).Return(rows[:2], nil).Once()
+		mockStorage.On("ClaimRows", batch.ID, payoutChunkSize).Return([]models.PayoutRow{}, nil).Once()
+		mockWalletService.On("Transfer", transfer(rows[0]), batch.UserID).Return(int64(5), nil).Once()
+		mockWalletService.On("Transfer", transfer(rows[1]), batch.UserID).Return(int64(0), ErrMaxBalanceExceeded).Once()
+		mockStorage.On("PayRow", "row-1").Return(nil).Once()
+		mockStorage.On("PayRow", "row-2").Return(nil).Once()
+		mockStorage.On("FailRow", "row-2", ErrMaxBalanceExceeded.Error()).Return(nil).Once()
+		mockStorage.On("FinishBatch", batch.ID).Return(true, nil).Once()
+
+		err := service.ProcessDue(context.Background())
+
+		assert.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+		mockWalletService.AssertExpectations(t)
+	})
+
+	t.Run("Insufficient funds pauses the batch", func(t *testing.T) {
+		mockStorage := new(MockPayoutStorage)
+		mockWalletService := new(MockWalletService)
+		mockWalletStorage := new(MockWalletStorage)
+		service := &payoutService{storage: mockStorage, wallets: mockWalletStorage, walletService: mockWalletService, logger: log.Default()}
+
+		mockStorage.On("ListActiveBatches").Return([]models.PayoutBatch{batch}, nil).Once()
+		mockStorage.On("ClaimRows", batch.ID, payoutChunkSize).Return(rows, nil).Once()
+		mockWalletService.On("Transfer", transfer(rows[0]), batch.UserID).Return(int64(5), nil).Once()
+		mockWalletService.On("Transfer", transfer(rows[1]), batch.UserID).Return(int64(0), storage.ErrInsufficientFunds).Once()
+		mockStorage.On("PayRow", "row-1").Return(nil).Once()
+		mockStorage.On("PayRow", "row-2").Return(nil).Once()
+		mockStorage.On("UpdateBatchStatus", batch.ID, models.PayoutBatchProcessing, models.PayoutBatchPaused).Return(true, nil).Once()
+		for _, row := range rows[1:] {
+			mockWalletStorage.On("HasReference", row.WalletID, "payout:"+row.ID).Return(false, nil).Once()
+			mockStorage.On("ReleaseRow", row.ID).Return(nil).Once()
+		}
+
+		err := service.ProcessDue(context.Background())
+
+		assert.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+		mockWalletStorage.AssertExpectations(t)
+		mockStorage.AssertNotCalled(t, "ReleaseRow", "row-1")
+		mockStorage.AssertNotCalled(t, "FinishBatch", batch.ID)
+		mockWalletService.AssertNotCalled(t, "Transfer", transfer(rows[2]), batch.UserID)
+	})
+
+	t.Run("Unsettled row stops the batch", func(t *testing.T) {
+		mockStorage := new(MockPayoutStorage)
+		mockWalletService := new(MockWalletService)
+		mockWalletStorage := new(MockWalletStorage)
+		service := &payoutService{storage: mockStorage, wallets: mockWalletStorage, walletService: mockWalletService, logger: log.Default()}
+
+		mockStorage.On("ListActiveBatches").Return([]models.PayoutBatch{batch}, nil).Once()
+		mockStorage.On("ClaimRows", batch.ID, payoutChunkSize).Return(rows, nil).Once()
+		mockWalletService.On("Transfer", transfer(rows[0]), batch.UserID).Return(int64(5), nil).Once()
+		mockStorage.On("PayRow", "row-1").Return(storage.ErrPayoutRowNotProcessing).Once()
+		for _, row := range rows {
+			mockWalletStorage.On("HasReference", row.WalletID, "payout:"+row.ID).Return(false, nil).Once()
+			mockStorage.On("ReleaseRow", row.ID).Return(nil).Once()
+		}
+
+		err := service.ProcessDue(context.Background())
+
+		assert.Error(t, err)
+		mockStorage.AssertExpectations(t)
+		mockWalletStorage.AssertExpectations(t)
+		mockStorage.AssertNotCalled(t, "FailRow", "row-1", storage.ErrPayoutRowNotProcessing.Error())
+		mockStorage.AssertNotCalled(t, "FinishBatch", batch.ID)
+		mockWalletService.AssertNotCalled(t, "Transfer", transfer(rows[1]), batch.UserID)
+	})
+}
+
+func TestRecoverPayouts(t *testing.T) {
+	mockStorage := new(MockPayoutStorage)
+	mockWalletStorage := new(MockWalletStorage)
+	service := &payoutService{storage: mockStorage, wallets: mockWalletStorage, logger: log.Default()}
+
+	paid := models.PayoutRow{ID: "row-1", WalletID: uuid.New().String()}
+	unpaid := models.PayoutRow{ID: "row-2", WalletID: uuid.New().String()}
+	mockStorage.On("ListStuckRows").Return([]models.PayoutRow{paid, unpaid}, nil).Once()
+	mockWalletStorage.On("HasReference", paid.WalletID, "payout:row-1").Return(true, nil).Once()
+	mockWalletStorage.On("HasReference", unpaid.WalletID, "payout:row-2").Return(false, nil).Once()
+	mockStorage.On("CompleteRow", "row-1", int64(0)).Return(nil).Once()
+	mockStorage.On("ReleaseRow", "row-2").Return(nil).Once()
+
+	err := service.recover()
+
+	assert.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+	mockWalletStorage.AssertExpectations(t)
+}
+
+func TestPausePayouts(t *testing.T) {
+	mockStorage := new(MockPayoutStorage)
+	service := &payoutService{storage: mockStorage, logger: log.Default()}
+
+	userID := uuid.New().String()
+	batch := &models.PayoutBatch{ID: uuid.New().String(), UserID: userID, Status: models.PayoutBatchCompleted}
+	mockStorage.On("GetBatch", batch.ID).Return(batch, nil)
+	mockStorage.On("UpdateBatchStatus", batch.ID, models.PayoutBatchProcessing, models.PayoutBatchPaused).Return(false, nil).Once()
+
+	_, err := service.Pause(batch.ID, userID)
+	assert.ErrorIs(t, err, ErrPayoutBatchNotRunning)
+
+	_, err = service.Pause(batch.ID, uuid.New().String())
+	assert.ErrorIs(t, err, ErrPayoutBatchNotFound)
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/qr"
+	"github.com/rasul07/alif-task/internal/storage"
+	"github.com/skip2/go-qrcode"
+)
+
+type QRService interface {
+	Generate(request models.QRGenerateRequest, userID string) (string, error)
+	Render(payload string, size int) ([]byte, error)
+	Pay(request models.QRPayRequest, userID string) (int64, error)
+}
+
+var (
+	ErrQRExpired             = errors.New("QR payload has expired")
+	ErrQRAlreadyPaid         = storage.ErrQRAlreadyPaid
+	ErrQRAmountMismatch      = errors.New("amount doesn't match the QR payload")
+	ErrQRAmountRequired      = errors.New("amount is required for a static QR payload")
+	ErrQRUnsupportedCurrency = errors.New("QR payload currency is not supported")
+)
+
+type qrService struct {
+	storage       storage.WalletStorager
+	payments      storage.QRStorager
+	walletService WalletService
+	key           []byte
+	logger        *log.Logger
+}
+
+func NewQRService(db *sql.DB, walletService WalletService, secretKey string) QRService {
+	return &qrService{
+		storage:       storage.NewWalletStorage(db),
+		payments:      storage.NewQRStorage(db),
+		walletService: walletService,
+		key:           []byte(secretKey),
+		logger:        log.New(log.Writer(), "QRService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+// Generate issues a payload for the caller's wallet. Requests with an amount produce
+// a dynamic, single-use payload that expires, others a static one.
+func (s *qrService) Generate(request models.QRGenerateRequest, userID string) (string, error) {
+	s.logger.Printf("Generating QR payload: walletID=%s, userID=%s, amount=%s", request.WalletID, userID, request.Amount)
+	wallet, err := s.storage.GetWallet(request.WalletID, userID)
+	if err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return "", errors.Wrap(err, "Error getting wallet")
+	}
+
+	payload := qr.Payload{
+		WalletID:         wallet.ID,
+		MerchantName:     request.MerchantName,
+		MerchantCity:     request.MerchantCity,
+		MerchantCategory: request.MerchantCategory,
+		Reference:        request.Reference,
+	}
+
+	if request.Amount != "" {
+		payload.Amount, err = models.ParseAmount(request.Amount)
+		if err != nil {
+			s.logger.Printf("Error parsing amount: %v", err)
+			return "", err
+		}
+
+		payload.Dynamic = true
+		payload.ExpiresAt = time.Now().Add(models.QRPaymentTTL)
+		if payload.Reference == "" {
+			payload.Reference = uuid.New().String()
+		}
+	}
+
+	encoded, err := qr.Encode(payload, s.key)
+	if err != nil {
+		s.logger.Printf("Error encoding QR payload: %v", err)
+		return "", err
+	}
+
+	return encoded, nil
+}
+
+// Render draws a payload issued by this server as a PNG image
+func (s *qrService) Render(payload string, size int) ([]byte, error) {
+	if _, err := qr.Decode(payload, s.key); err != nil {
+		s.logger.Printf("Error decoding QR payload: %v", err)
+		return nil, err
+	}
+
+	if size <= 0 {
+		size = models.QRImageDefaultSize
+	}
+	if size > models.QRImageMaxSize {
+		size = models.QRImageMaxSize
+	}
+
+	image, err := qrcode.Encode(payload, qrcode.Medium, size)
+	if err != nil {
+		s.logger.Printf("Error rendering QR image: %v", err)
+		return nil, err
+	}
+
+	return image, nil
+}
+
+// Pay verifies the payload and transfers the amount from the caller's wallet to the merchant.
+// Dynamic payloads are claimed in the transaction of the transfer, so they are paid once.
+func (s *qrService) Pay(request models.QRPayRequest, userID string) (int64, error) {
+	s.logger.Printf("Paying QR payload: walletID=%s, userID=%s", request.WalletID, userID)
+	payload, err := qr.Decode(request.Payload, s.key)
+	if err != nil {
+		s.logger.Printf("Error decoding QR payload: %v", err)
+		return 0, err
+	}
+
+	if payload.Currency != qr.DefaultCurrency {
+		return 0, ErrQRUnsupportedCurrency
+	}
+
+	amount := payload.Amount
+	var hooks []storage.TransferHook
+	if payload.Dynamic {
+		if !payload.ExpiresAt.IsZero() && time.Now().After(payload.ExpiresAt) {
+			return 0, ErrQRExpired
+		}
+
+		if request.Amount != "" {
+			requested, err := models.ParseAmount(request.Amount)
+			if err != nil || requested != amount {
+				return 0, ErrQRAmountMismatch
+			}
+		}
+
+		hooks = append(hooks, s.payments.ClaimPayment(payload.WalletID, payload.Reference))
+	} else {
+		if request.Amount == "" {
+			return 0, ErrQRAmountRequired
+		}
+
+		amount, err = models.ParseAmount(request.Amount)
+		if err != nil {
+			s.logger.Printf("Error parsing amount: %v", err)
+			return 0, err
+		}
+	}
+
+	return s.walletService.Transfer(models.TransferRequest{
+		WalletID:         request.WalletID,
+		ToWalletID:       payload.WalletID,
+		Amount:           models.FormatAmount(amount),
+		MerchantCategory: payload.MerchantCategory,
+		Reference:        payload.Reference,
+	}, userID, hooks...)
+}
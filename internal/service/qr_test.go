@@ -0,0 +1,123 @@
+package service
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/qr"
+	"github.com/rasul07/alif-task/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock implementation of QRStorage
+type MockQRStorage struct {
+	mock.Mock
+}
+
+func (m *MockQRStorage) ClaimPayment(walletID, reference string) storage.TransferHook {
+	args := m.Called(walletID, reference)
+	return hookReturning(args.Error(0))
+}
+
+func TestQRGenerate(t *testing.T) {
+	mockStorage := new(MockWalletStorage)
+	key := []byte("secret")
+	service := &qrService{storage: mockStorage, key: key, logger: log.Default()}
+
+	userID := uuid.New().String()
+	walletID := uuid.New().String()
+
+	t.Run("Dynamic payload gets a unique reference", func(t *testing.T) {
+		mockStorage.On("GetWallet", walletID, userID).Return(&models.Wallet{ID: walletID, UserID: userID}, nil).Twice()
+
+		first, err := service.Generate(models.QRGenerateRequest{WalletID: walletID, Amount: "10.00"}, userID)
+		require.NoError(t, err)
+		second, err := service.Generate(models.QRGenerateRequest{WalletID: walletID, Amount: "10.00"}, userID)
+		require.NoError(t, err)
+
+		firstPayload, err := qr.Decode(first, key)
+		require.NoError(t, err)
+		secondPayload, err := qr.Decode(second, key)
+		require.NoError(t, err)
+		assert.NoError(t, uuid.Validate(firstPayload.Reference))
+		assert.NotEqual(t, firstPayload.Reference, secondPayload.Reference)
+		mockStorage.AssertExpectations(t)
+	})
+}
+
+func TestQRPay(t *testing.T) {
+	mockStorage := new(MockWalletStorage)
+	mockPayments := new(MockQRStorage)
+	mockWalletService := new(MockWalletService)
+	key := []byte("secret")
+	service := &qrService{storage: mockStorage, payments: mockPayments, walletService: mockWalletService, key: key, logger: log.Default()}
+
+	userID := uuid.New().String()
+	walletID := uuid.New().String()
+	merchantWalletID := uuid.New().String()
+
+	t.Run("Dynamic payload", func(t *testing.T) {
+		payload, err := qr.Encode(qr.Payload{Dynamic: true, WalletID: merchantWalletID, MerchantCategory: "5411", Amount: 4550, Reference: "order-1", ExpiresAt: time.Now().Add(time.Minute)}, key)
+		require.NoError(t, err)
+		mockPayments.On("ClaimPayment", merchantWalletID, "order-1").Return(nil).Once()
+		mockWalletService.On("Transfer", models.TransferRequest{
+			WalletID:         walletID,
+			ToWalletID:       merchantWalletID,
+			Amount:           "45.50",
+			MerchantCategory: "5411",
+			Reference:        "order-1",
+		}, userID).Return(int64(3), nil).Once()
+
+		transactionID, err := service.Pay(models.QRPayRequest{Payload: payload, WalletID: walletID}, userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), transactionID)
+		mockPayments.AssertExpectations(t)
+		mockWalletService.AssertExpectations(t)
+	})
+
+	t.Run("Dynamic payload paid twice", func(t *testing.T) {
+		payload, err := qr.Encode(qr.Payload{Dynamic: true, WalletID: merchantWalletID, Amount: 4550, Reference: "order-2"}, key)
+		require.NoError(t, err)
+		mockPayments.On("ClaimPayment", merchantWalletID, "order-2").Return(storage.ErrQRAlreadyPaid).Once()
+		mockWalletService.On("Transfer", mock.Anything, userID).Return(int64(4), nil).Once()
+
+		_, err = service.Pay(models.QRPayRequest{Payload: payload, WalletID: walletID}, userID)
+
+		assert.ErrorIs(t, err, ErrQRAlreadyPaid)
+		mockPayments.AssertExpectations(t)
+		mockWalletService.AssertExpectations(t)
+	})
+
+	t.Run("Expired payload", func(t *testing.T) {
+		payload, err := qr.Encode(qr.Payload{Dynamic: true, WalletID: merchantWalletID, Amount: 4550, Reference: "order-3", ExpiresAt: time.Now().Add(-time.Minute)}, key)
+		require.NoError(t, err)
+
+		_, err = service.Pay(models.QRPayRequest{Payload: payload, WalletID: walletID}, userID)
+
+		assert.ErrorIs(t, err, ErrQRExpired)
+	})
+
+	t.Run("Static payload without amount", func(t *testing.T) {
+		payload, err := qr.Encode(qr.Payload{WalletID: merchantWalletID}, key)
+		require.NoError(t, err)
+
+		_, err = service.Pay(models.QRPayRequest{Payload: payload, WalletID: walletID}, userID)
+
+		assert.ErrorIs(t, err, ErrQRAmountRequired)
+	})
+
+	t.Run("Forged payload", func(t *testing.T) {
+		payload, err := qr.Encode(qr.Payload{WalletID: merchantWalletID}, []byte("another key"))
+		require.NoError(t, err)
+
+		_, err = service.Pay(models.QRPayRequest{Payload: payload, WalletID: walletID, Amount: "10"}, userID)
+
+		assert.ErrorIs(t, err, qr.ErrInvalidSignature)
+	})
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"database/sql"
+	"log"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/receipt"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+type ReceiptService interface {
+	Get(transactionID int64, userID string) (*models.Receipt, error)
+	Verify(r models.Receipt) error
+}
+
+var (
+	ErrReceiptNotFound    = errors.New("receipt not found")
+	ErrReceiptUnavailable = errors.New("no receipt is available for this transaction")
+)
+
+type receiptService struct {
+	storage storage.TransactionStorager
+	wallets storage.WalletStorager
+	key     []byte
+	logger  *log.Logger
+}
+
+func NewReceiptService(db *sql.DB, secretKey string) ReceiptService {
+	return &receiptService{
+		storage: storage.NewTransactionStorage(db),
+		wallets: storage.NewWalletStorage(db),
+		key:     []byte(secretKey),
+		logger:  log.New(log.Writer(), "ReceiptService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+// Get builds and signs the receipt of a transaction of the user's wallet. The
+// receipt is built from the ledger every time, so it is the same on every call.
+func (s *receiptService) Get(transactionID int64, userID string) (*models.Receipt, error) {
+	s.logger.Printf("Getting receipt: transactionID=%d, userID=%s", transactionID, userID)
+	transaction, err := s.storage.GetTransaction(transactionID)
+	if err == sql.ErrNoRows {
+		return nil, ErrReceiptNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting transaction: %v", err)
+		return nil, err
+	}
+
+	_, err = s.wallets.GetWallet(transaction.WalletID, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrReceiptNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return nil, err
+	}
+
+	if transaction.BalanceAfter == nil {
+		return nil, ErrReceiptUnavailable
+	}
+
+	transactionType, amount := models.TransactionTypeCredit, transaction.Amount
+	if amount < 0 {
+		transactionType, amount = models.TransactionTypeDebit, -amount
+	}
+
+	r := &models.Receipt{
+		TransactionID:        transaction.ID,
+		WalletID:             transaction.WalletID,
+		Type:                 transactionType,
+		Amount:               models.FormatAmount(amount),
+		Balance:              models.FormatAmount(*transaction.BalanceAfter),
+		CounterpartyWalletID: transaction.CounterpartyWalletID,
+		Reference:            transaction.Reference,
+		CreatedAt:            transaction.CreatedAt,
+	}
+	r.Signature = receipt.Sign(*r, s.key)
+
+	return r, nil
+}
+
+// Verify checks that the receipt was issued by this server and wasn't changed
+func (s *receiptService) Verify(r models.Receipt) error {
+	s.logger.Printf("Verifying receipt: transactionID=%d", r.TransactionID)
+	return receipt.Verify(r, s.key)
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"database/sql"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/receipt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetReceipt(t *testing.T) {
+	mockStorage := new(MockTransactionStorage)
+	mockWallets := new(MockWalletStorage)
+	service := &receiptService{storage: mockStorage, wallets: mockWallets, key: []byte("secret"), logger: log.Default()}
+	wallet := &models.Wallet{ID: "wallet-1", UserID: "user-1"}
+	balance := int64(45000)
+
+	t.Run("Signed receipt of a debit", func(t *testing.T) {
+		mockStorage.On("GetTransaction", int64(9)).Return(&models.Transaction{
+			ID: 9, WalletID: "wallet-1", Amount: -5050, CounterpartyWalletID: "wallet-2", BalanceAfter: &balance, CreatedAt: time.Now(),
+		}, nil).Once()
+		mockWallets.On("GetWallet", "wallet-1", "user-1").Return(wallet, nil).Once()
+
+		r, err := service.Get(9, "user-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, models.TransactionTypeDebit, r.Type)
+		assert.Equal(t, "50.50", r.Amount)
+		assert.Equal(t, "450.00", r.Balance)
+		assert.NoError(t, receipt.Verify(*r, []byte("secret")))
+		assert.NoError(t, service.Verify(*r))
+	})
+
+	t.Run("Transaction of another user", func(t *testing.T) {
+		mockStorage.On("GetTransaction", int64(10)).Return(&models.Transaction{ID: 10, WalletID: "wallet-3", BalanceAfter: &balance}, nil).Once()
+		mockWallets.On("GetWallet", "wallet-3", "user-1").Return((*models.Wallet)(nil), sql.ErrNoRows).Once()
+
+		_, err := service.Get(10, "user-1")
+
+		assert.ErrorIs(t, err, ErrReceiptNotFound)
+	})
+
+	t.Run("Transaction without a balance", func(t *testing.T) {
+		mockStorage.On("GetTransaction", int64(1)).Return(&models.Transaction{ID: 1, WalletID: "wallet-1", Amount: 100000}, nil).Once()
+		mockWallets.On("GetWallet", "wallet-1", "user-1").Return(wallet, nil).Once()
+
+		_, err := service.Get(1, "user-1")
+
+		assert.ErrorIs(t, err, ErrReceiptUnavailable)
+	})
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+type ReconciliationService interface {
+	Reconcile(openCases bool) (*models.ReconciliationRun, []models.BalanceDiscrepancy, error)
+	ListRuns() ([]models.ReconciliationRun, error)
+	GetRun(runID string) (*models.ReconciliationRun, []models.BalanceDiscrepancy, error)
+	ListCases(status string) ([]models.CorrectionCase, error)
+	ResolveCase(caseID, resolution string) error
+	Run(ctx context.Context, interval time.Duration, openCases bool)
+}
+
+var (
+	ErrReconciliationRunNotFound = errors.New("reconciliation run not found")
+	ErrCaseNotOpen               = errors.New("correction case not found or already resolved")
+)
+
+const reconciliationListLimit = 50
+
+// reconciliationMetrics are published on the admin metrics endpoint
+var reconciliationMetrics = expvar.NewMap("reconciliation")
+
+type reconciliationService struct {
+	storage storage.ReconciliationStorager
+	logger  *log.Logger
+}
+
+func NewReconciliationService(db *sql.DB) ReconciliationService {
+	return &reconciliationService{
+		storage: storage.NewReconciliationStorage(db),
+		logger:  log.New(log.Writer(), "ReconciliationService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+// Reconcile compares every wallet balance with the sum of its transactions and
+// records the discrepancies. With openCases a correction case is opened for every
+// wallet that doesn't have one open yet.
+func (s *reconciliationService) Reconcile(openCases bool) (*models.ReconciliationRun, []models.BalanceDiscrepancy, error) {
+	s.logger.Printf("Reconciling balances: openCases=%t", openCases)
+	run := &models.ReconciliationRun{Status: models.ReconciliationRunning}
+	if err := s.storage.CreateRun(run); err != nil {
+		s.logger.Printf("Error creating reconciliation run: %v", err)
+		return nil, nil, err
+	}
+
+	discrepancies, err := s.reconcile(run, openCases)
+	if err != nil {
+		s.logger.Printf("Reconciliation run %s failed: %v", run.ID, err)
+		reconciliationMetrics.Add("failures_total", 1)
+		if failErr := s.storage.FailRun(run.ID, err.Error()); failErr != nil {
+			s.logger.Printf("Error recording failed reconciliation run: %v", failErr)
+		}
+		return nil, nil, err
+	}
+
+	reconciliationMetrics.Add("runs_total", 1)
+	setMetric("last_run_timestamp", run.StartedAt.Unix())
+	setMetric("wallets_checked", int64(run.WalletsChecked))
+	setMetric("discrepancies", int64(run.Discrepancies))
+	setMetric("total_difference", run.TotalDifference)
+	reconciliationMetrics.Add("cases_opened_total", int64(run.CasesOpened))
+
+	if run.Discrepancies > 0 {
+		s.logger.Printf("Reconciliation found discrepancies: runID=%s, wallets=%d, totalDifference=%d", run.ID, run.Discrepancies, run.TotalDifference)
+	}
+
+	return run, discrepancies, nil
+}
+
+func (s *reconciliationService) reconcile(run *models.ReconciliationRun, openCases bool) ([]models.BalanceDiscrepancy, error) {
+	checked, discrepancies, err := s.storage.FindDiscrepancies()
+	if err != nil {
+		return nil, err
+	}
+
+	run.WalletsChecked = checked
+	run.Discrepancies = len(discrepancies)
+	for i := range discrepancies {
+		d := &discrepancies[i]
+		d.RunID = run.ID
+		if d.Difference < 0 {
+			run.TotalDifference -= d.Difference
+		} else {
+			run.TotalDifference += d.Difference
+		}
+
+		s.logger.Printf("Balance mismatch: walletID=%s, stored=%d, ledger=%d, difference=%d, transactions=%d",
+			d.WalletID, d.StoredBalance, d.LedgerBalance, d.Difference, d.TransactionCount)
+
+		if !openCases {
+			continue
+		}
+		caseID, created, err := s.storage.OpenCase(*d)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to open correction case for wallet %s", d.WalletID)
+		}
+		d.CaseID = caseID
+		if created {
+			run.CasesOpened++
+		}
+	}
+
+	run.Status = models.ReconciliationCompleted
+	if err := s.storage.FinishRun(run, discrepancies); err != nil {
+		return nil, err
+	}
+
+	return discrepancies, nil
+}
+
+func (s *reconciliationService) ListRuns() ([]models.ReconciliationRun, error) {
+	s.logger.Printf("Listing reconciliation runs")
+	runs, err := s.storage.ListRuns(reconciliationListLimit)
+	if err != nil {
+		s.logger.Printf("Error listing reconciliation runs: %v", err)
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+func (s *reconciliationService) GetRun(runID string) (*models.ReconciliationRun, []models.BalanceDiscrepancy, error) {
+	s.logger.Printf("Getting reconciliation run: runID=%s", runID)
+	run, err := s.storage.GetRun(runID)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrReconciliationRunNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting reconciliation run: %v", err)
+		return nil, nil, err
+	}
+
+	discrepancies, err := s.storage.ListDiscrepancies(run.ID)
+	if err != nil {
+		s.logger.Printf("Error listing discrepancies: %v", err)
+		return nil, nil, err
+	}
+
+	return run, discrepancies, nil
+}
+
+func (s *reconciliationService) ListCases(status string) ([]models.CorrectionCase, error) {
+	s.logger.Printf("Listing correction cases: status=%s", status)
+	cases, err := s.storage.ListCases(status)
+	if err != nil {
+		s.logger.Printf("Error listing correction cases: %v", err)
+		return nil, err
+	}
+
+	return cases, nil
+}
+
+// ResolveCase closes a case with the operator's note. Fixing the balance or the
+// ledger is done separately, the next run shows whether the wallet is consistent.
+func (s *reconciliationService) ResolveCase(caseID, resolution string) error {
+	s.logger.Printf("Resolving correction case: caseID=%s", caseID)
+	resolved, err := s.storage.ResolveCase(caseID, resolution)
+	if err != nil {
+		s.logger.Printf("Error resolving correction case: %v", err)
+		return err
+	}
+	if !resolved {
+		return ErrCaseNotOpen
+	}
+
+	return nil
+}
+
+// Run reconciles balances every interval until ctx is cancelled
+func (s *reconciliationService) Run(ctx context.Context, interval time.Duration, openCases bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, _, err := s.Reconcile(openCases); err != nil {
+				s.logger.Printf("Error running scheduled reconciliation: %v", err)
+			}
+		}
+	}
+}
+
+func setMetric(name string, value int64) {
+	v := new(expvar.Int)
+	v.Set(value)
+	reconciliationMetrics.Set(name, v)
+}
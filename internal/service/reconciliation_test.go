@@ -0,0 +1,141 @@
+package service
+
+import (
+	"log"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock implementation of ReconciliationStorage
+type MockReconciliationStorage struct {
+	mock.Mock
+}
+
+func (m *MockReconciliationStorage) CreateRun(run *models.ReconciliationRun) error {
+	args := m.Called(run)
+	run.ID = "run-1"
+	return args.Error(0)
+}
+
+func (m *MockReconciliationStorage) FindDiscrepancies() (int, []models.BalanceDiscrepancy, error) {
+	args := m.Called()
+	return args.Int(0), args.Get(1).([]models.BalanceDiscrepancy), args.Error(2)
+}
+
+func (m *MockReconciliationStorage) OpenCase(discrepancy models.BalanceDiscrepancy) (string, bool, error) {
+	args := m.Called(discrepancy)
+	return args.String(0), args.Bool(1), args.Error(2)
+}
+
+func (m *MockReconciliationStorage) FinishRun(run *models.ReconciliationRun, discrepancies []models.BalanceDiscrepancy) error {
+	args := m.Called(run, discrepancies)
+	return args.Error(0)
+}
+
+func (m *MockReconciliationStorage) FailRun(runID, reason string) error {
+	args := m.Called(runID, reason)
+	return args.Error(0)
+}
+
+func (m *MockReconciliationStorage) GetRun(runID string) (*models.ReconciliationRun, error) {
+	args := m.Called(runID)
+	return args.Get(0).(*models.ReconciliationRun), args.Error(1)
+}
+
+func (m *MockReconciliationStorage) ListRuns(limit int) ([]models.ReconciliationRun, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]models.ReconciliationRun), args.Error(1)
+}
+
+func (m *MockReconciliationStorage) ListDiscrepancies(runID string) ([]models.BalanceDiscrepancy, error) {
+	args := m.Called(runID)
+	return args.Get(0).([]models.BalanceDiscrepancy), args.Error(1)
+}
+
+func (m *MockReconciliationStorage) ListCases(status string) ([]models.CorrectionCase, error) {
+	args := m.Called(status)
+	return args.Get(0).([]models.CorrectionCase), args.Error(1)
+}
+
+func (m *MockReconciliationStorage) ResolveCase(caseID, resolution string) (bool, error) {
+	args := m.Called(caseID, resolution)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestReconcile(t *testing.T) {
+	// Seed wallets: balances were inserted without the matching top-ups
+	found := func() []models.BalanceDiscrepancy {
+		return []models.BalanceDiscrepancy{
+			{WalletID: "wallet-1", StoredBalance: 500000, LedgerBalance: 300000, Difference: 200000, TransactionCount: 2},
+			{WalletID: "wallet-2", StoredBalance: 0, LedgerBalance: 1500, Difference: -1500, TransactionCount: 1},
+		}
+	}
+
+	t.Run("Discrepancies are reported", func(t *testing.T) {
+		mockStorage := new(MockReconciliationStorage)
+		service := &reconciliationService{storage: mockStorage, logger: log.Default()}
+
+		mockStorage.On("CreateRun", mock.Anything).Return(nil).Once()
+		mockStorage.On("FindDiscrepancies").Return(4, found(), nil).Once()
+		mockStorage.On("FinishRun", mock.MatchedBy(func(run *models.ReconciliationRun) bool {
+			return run.Status == models.ReconciliationCompleted && run.WalletsChecked == 4 &&
+				run.Discrepancies == 2 && run.TotalDifference == 201500 && run.CasesOpened == 0
+		}), mock.Anything).Return(nil).Once()
+
+		run, discrepancies, err := service.Reconcile(false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, run.Discrepancies)
+		assert.Equal(t, "run-1", discrepancies[0].RunID)
+		assert.Empty(t, discrepancies[0].CaseID)
+		mockStorage.AssertExpectations(t)
+		mockStorage.AssertNotCalled(t, "OpenCase", mock.Anything)
+	})
+
+	t.Run("Correction cases are opened once per wallet", func(t *testing.T) {
+		mockStorage := new(MockReconciliationStorage)
+		service := &reconciliationService{storage: mockStorage, logger: log.Default()}
+
+		mockStorage.On("CreateRun", mock.Anything).Return(nil).Once()
+		mockStorage.On("FindDiscrepancies").Return(4, found(), nil).Once()
+		mockStorage.On("OpenCase", mock.MatchedBy(func(d models.BalanceDiscrepancy) bool { return d.WalletID == "wallet-1" })).Return("case-1", false, nil).Once()
+		mockStorage.On("OpenCase", mock.MatchedBy(func(d models.BalanceDiscrepancy) bool { return d.WalletID == "wallet-2" })).Return("case-2", true, nil).Once()
+		mockStorage.On("FinishRun", mock.MatchedBy(func(run *models.ReconciliationRun) bool { return run.CasesOpened == 1 }), mock.Anything).Return(nil).Once()
+
+		_, discrepancies, err := service.Reconcile(true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "case-1", discrepancies[0].CaseID)
+		assert.Equal(t, "case-2", discrepancies[1].CaseID)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Failed run is recorded", func(t *testing.T) {
+		mockStorage := new(MockReconciliationStorage)
+		service := &reconciliationService{storage: mockStorage, logger: log.Default()}
+
+		mockStorage.On("CreateRun", mock.Anything).Return(nil).Once()
+		mockStorage.On("FindDiscrepancies").Return(0, []models.BalanceDiscrepancy(nil), errors.New("connection reset")).Once()
+		mockStorage.On("FailRun", "run-1", "connection reset").Return(nil).Once()
+
+		_, _, err := service.Reconcile(false)
+
+		assert.Error(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+}
+
+func TestResolveCase(t *testing.T) {
+	mockStorage := new(MockReconciliationStorage)
+	service := &reconciliationService{storage: mockStorage, logger: log.Default()}
+
+	mockStorage.On("ResolveCase", "case-1", "seed balance written off").Return(true, nil).Once()
+	mockStorage.On("ResolveCase", "case-2", "duplicate").Return(false, nil).Once()
+
+	assert.NoError(t, service.ResolveCase("case-1", "seed balance written off"))
+	assert.ErrorIs(t, service.ResolveCase("case-2", "duplicate"), ErrCaseNotOpen)
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+type SearchService interface {
+	SearchTransactions(request models.TransactionSearchRequest) ([]models.TransactionSearchResult, int, error)
+}
+
+type searchService struct {
+	storage storage.SearchStorager
+	logger  *log.Logger
+}
+
+func NewSearchService(db *sql.DB) SearchService {
+	return &searchService{
+		storage: storage.NewSearchStorage(db),
+		logger:  log.New(log.Writer(), "SearchService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+// SearchTransactions runs a support search across all wallets. It returns one page
+// of results and the total number of matches.
+func (s *searchService) SearchTransactions(request models.TransactionSearchRequest) ([]models.TransactionSearchResult, int, error) {
+	query := strings.TrimSpace(request.Query)
+	s.logger.Printf("Searching transactions: query=%q, walletID=%s, page=%d", query, request.WalletID, request.Page)
+
+	filter := models.TransactionFilter{
+		WalletID:  request.WalletID,
+		Type:      request.Type,
+		Category:  normalizeCategory(request.Category),
+		From:      request.From,
+		To:        request.To,
+		MinAmount: request.MinAmount,
+		MaxAmount: request.MaxAmount,
+		Limit:     request.Limit,
+	}
+	if err := parseAmountRange(&filter); err != nil {
+		return nil, 0, err
+	}
+	if filter.Limit == 0 {
+		filter.Limit = models.TransactionSearchLimit
+	}
+
+	// A query that reads as an amount also finds transactions of that amount
+	amount, err := models.ParseAmount(query)
+	if err != nil {
+		amount = 0
+	}
+
+	page := request.Page
+	if page < 1 {
+		page = 1
+	}
+
+	results, total, err := s.storage.SearchTransactions(query, amount, filter, (page-1)*filter.Limit)
+	if err != nil {
+		s.logger.Printf("Error searching transactions: %v", err)
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
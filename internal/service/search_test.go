@@ -0,0 +1,56 @@
+package service
+
+import (
+	"log"
+	"testing"
+
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock implementation of SearchStorage
+type MockSearchStorage struct {
+	mock.Mock
+}
+
+func (m *MockSearchStorage) SearchTransactions(query string, amount int64, filter models.TransactionFilter, offset int) ([]models.TransactionSearchResult, int, error) {
+	args := m.Called(query, amount, filter, offset)
+	return args.Get(0).([]models.TransactionSearchResult), args.Int(1), args.Error(2)
+}
+
+func TestSearchTransactions(t *testing.T) {
+	mockStorage := new(MockSearchStorage)
+	service := &searchService{storage: mockStorage, logger: log.Default()}
+
+	t.Run("Text query", func(t *testing.T) {
+		filter := models.TransactionFilter{Category: "food", Limit: models.TransactionSearchLimit}
+		mockStorage.On("SearchTransactions", "order:17", int64(0), filter, 0).
+			Return([]models.TransactionSearchResult{{Transaction: models.Transaction{ID: 3}, Rank: 0.8}}, 1, nil).Once()
+
+		results, total, err := service.SearchTransactions(models.TransactionSearchRequest{Query: " order:17 ", Category: "Food"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Len(t, results, 1)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Amount query on a later page", func(t *testing.T) {
+		filter := models.TransactionFilter{WalletID: "wallet-1", Limit: 10}
+		mockStorage.On("SearchTransactions", "150.50", int64(15050), filter, 20).
+			Return([]models.TransactionSearchResult{}, 20, nil).Once()
+
+		_, total, err := service.SearchTransactions(models.TransactionSearchRequest{Query: "150.50", WalletID: "wallet-1", Page: 3, Limit: 10})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 20, total)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Invalid amount range", func(t *testing.T) {
+		_, _, err := service.SearchTransactions(models.TransactionSearchRequest{Query: "coffee", MinAmount: "20", MaxAmount: "5"})
+
+		assert.ErrorIs(t, err, ErrInvalidAmountRange)
+	})
+}
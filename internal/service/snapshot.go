@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+type SnapshotService interface {
+	TakeSnapshots() (int, error)
+	BalanceAt(walletID, userID string, at time.Time) (*models.HistoricalBalance, error)
+	WalletBalanceAt(walletID string, at time.Time) (*models.HistoricalBalance, error)
+	Run(ctx context.Context)
+}
+
+var ErrFutureBalance = errors.New("balance can't be requested for a future time")
+
+// snapshotInterval is how often the job checks for days left to snapshot
+const snapshotInterval = time.Hour
+
+type snapshotService struct {
+	storage storage.WalletStorager
+	now     func() time.Time
+	logger  *log.Logger
+}
+
+func NewSnapshotService(db *sql.DB) SnapshotService {
+	return &snapshotService{
+		storage: storage.NewWalletStorage(db),
+		now:     time.Now,
+		logger:  log.New(log.Writer(), "SnapshotService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+// TakeSnapshots saves end-of-day balances for every finished day that doesn't have
+// them yet, so days missed while the server was down are filled in. It returns the
+// number of snapshots saved.
+func (s *snapshotService) TakeSnapshots() (int, error) {
+	day, ok, err := s.storage.NextSnapshotDate()
+	if err != nil {
+		s.logger.Printf("Error finding next snapshot date: %v", err)
+		return 0, err
+	}
+	if !ok {
+		return 0, nil
+	}
+
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+
+	total := 0
+	for ; day.Before(today); day = day.AddDate(0, 0, 1) {
+		s.logger.Printf("Taking balance snapshots: date=%s", day.Format(time.DateOnly))
+		created, err := s.storage.CreateSnapshots(day)
+		if err != nil {
+			s.logger.Printf("Error taking balance snapshots: %v", err)
+			return total, err
+		}
+		total += created
+	}
+
+	return total, nil
+}
+
+func (s *snapshotService) BalanceAt(walletID, userID string, at time.Time) (*models.HistoricalBalance, error) {
+	s.logger.Printf("Getting historical balance: walletID=%s, userID=%s, at=%s", walletID, userID, at.Format(time.RFC3339))
+	wallet, err := s.storage.GetWallet(walletID, userID)
+	if err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return nil, errors.Wrap(err, "Error getting wallet")
+	}
+
+	return s.balanceAt(wallet.ID, at)
+}
+
+// WalletBalanceAt is BalanceAt for operators, it doesn't check who owns the wallet
+func (s *snapshotService) WalletBalanceAt(walletID string, at time.Time) (*models.HistoricalBalance, error) {
+	s.logger.Printf("Getting historical balance: walletID=%s, at=%s", walletID, at.Format(time.RFC3339))
+	wallet, err := s.storage.GetWalletByID(walletID)
+	if err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return nil, errors.Wrap(err, "Error getting wallet")
+	}
+
+	return s.balanceAt(wallet.ID, at)
+}
+
+func (s *snapshotService) balanceAt(walletID string, at time.Time) (*models.HistoricalBalance, error) {
+	if at.After(s.now()) {
+		return nil, ErrFutureBalance
+	}
+
+	balance, err := s.storage.GetBalanceAt(walletID, at)
+	if err != nil {
+		s.logger.Printf("Error getting historical balance: %v", err)
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+// Run takes the missing snapshots on start and then every snapshotInterval until
+// ctx is cancelled
+func (s *snapshotService) Run(ctx context.Context) {
+	if _, err := s.TakeSnapshots(); err != nil {
+		s.logger.Printf("Error running scheduled snapshots: %v", err)
+	}
+
+	ticker := time.NewTicker(snapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.TakeSnapshots(); err != nil {
+				s.logger.Printf("Error running scheduled snapshots: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"database/sql"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTakeSnapshots(t *testing.T) {
+	now := time.Date(2024, 5, 4, 0, 30, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+
+	t.Run("Backfills every finished day", func(t *testing.T) {
+		mockStorage := new(MockWalletStorage)
+		service := &snapshotService{storage: mockStorage, now: func() time.Time { return now }, logger: log.Default()}
+		mockStorage.On("NextSnapshotDate").Return(day(1), true, nil).Once()
+		mockStorage.On("CreateSnapshots", day(1)).Return(3, nil).Once()
+		mockStorage.On("CreateSnapshots", day(2)).Return(3, nil).Once()
+		mockStorage.On("CreateSnapshots", day(3)).Return(4, nil).Once()
+
+		created, err := service.TakeSnapshots()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 10, created)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Today is not snapshotted", func(t *testing.T) {
+		mockStorage := new(MockWalletStorage)
+		service := &snapshotService{storage: mockStorage, now: func() time.Time { return now }, logger: log.Default()}
+		mockStorage.On("NextSnapshotDate").Return(day(4), true, nil).Once()
+
+		created, err := service.TakeSnapshots()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, created)
+		mockStorage.AssertNotCalled(t, "CreateSnapshots", mock.Anything)
+	})
+
+	t.Run("No transactions yet", func(t *testing.T) {
+		mockStorage := new(MockWalletStorage)
+		service := &snapshotService{storage: mockStorage, now: func() time.Time { return now }, logger: log.Default()}
+		mockStorage.On("NextSnapshotDate").Return(time.Time{}, false, nil).Once()
+
+		created, err := service.TakeSnapshots()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, created)
+		mockStorage.AssertNotCalled(t, "CreateSnapshots", mock.Anything)
+	})
+}
+
+func TestBalanceAt(t *testing.T) {
+	now := time.Date(2024, 5, 4, 12, 0, 0, 0, time.UTC)
+	mockStorage := new(MockWalletStorage)
+	service := &snapshotService{storage: mockStorage, now: func() time.Time { return now }, logger: log.Default()}
+
+	t.Run("Replays from the nearest snapshot", func(t *testing.T) {
+		at := time.Date(2024, 5, 2, 15, 0, 0, 0, time.UTC)
+		snapshotDate := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+		expected := &models.HistoricalBalance{WalletID: "wallet-1", At: at, Balance: 12500, SnapshotDate: &snapshotDate, TransactionsReplayed: 2}
+		mockStorage.On("GetWallet", "wallet-1", "user-1").Return(&models.Wallet{ID: "wallet-1", UserID: "user-1"}, nil).Once()
+		mockStorage.On("GetBalanceAt", "wallet-1", at).Return(expected, nil).Once()
+
+		balance, err := service.BalanceAt("wallet-1", "user-1", at)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, balance)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Wallet of another user", func(t *testing.T) {
+		mockStorage.On("GetWallet", "wallet-1", "user-2").Return((*models.Wallet)(nil), sql.ErrNoRows).Once()
+
+		_, err := service.BalanceAt("wallet-1", "user-2", now)
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Future time", func(t *testing.T) {
+		mockStorage.On("GetWalletByID", "wallet-1").Return(&models.Wallet{ID: "wallet-1"}, nil).Once()
+
+		_, err := service.WalletBalanceAt("wallet-1", now.Add(time.Minute))
+
+		assert.ErrorIs(t, err, ErrFutureBalance)
+		mockStorage.AssertNotCalled(t, "GetBalanceAt", "wallet-1", now.Add(time.Minute))
+	})
+}
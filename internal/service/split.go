@@ -0,0 +1,391 @@
+package service
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+type SplitService interface {
+	Create(request models.CreateSplitRequest, userID string) (*models.SplitBill, error)
+	Get(splitID, userID string) (*models.SplitBill, error)
+	List(request models.ListSplitsRequest, userID string) ([]models.SplitBill, error)
+	Pay(request models.PaySplitRequest, userID string) (*models.SplitParticipant, error)
+	Decline(splitID, userID string) error
+	Remind(request models.RemindSplitRequest, userID string) (int, error)
+	Cancel(splitID, userID string) error
+}
+
+var (
+	ErrSplitNotFound        = errors.New("split not found")
+	ErrSplitNotOpen         = storage.ErrSplitNotOpen
+	ErrShareNotPending      = storage.ErrShareNotPending
+	ErrInvalidShares        = errors.New("shares don't add up to the total amount")
+	ErrDuplicateParticipant = errors.New("participant is listed more than once")
+	ErrSelfSplit            = errors.New("originator can't owe a share of their own split")
+	ErrReminderTooSoon      = errors.New("participant was reminded recently")
+)
+
+const splitReferencePrefix = "split:"
+
+// SplitNotifier tells participants about shares they owe
+type SplitNotifier interface {
+	ShareRequested(split *models.SplitBill, participant *models.SplitParticipant)
+	ShareReminder(split *models.SplitBill, participant *models.SplitParticipant)
+}
+
+type logSplitNotifier struct {
+	logger *log.Logger
+}
+
+// NewLogSplitNotifier returns a notifier that only writes split events to the log
+func NewLogSplitNotifier(logger *log.Logger) SplitNotifier {
+	return &logSplitNotifier{logger: logger}
+}
+
+func (n *logSplitNotifier) ShareRequested(split *models.SplitBill, participant *models.SplitParticipant) {
+	n.logger.Printf("Share requested: splitID=%s, userID=%s, amount=%d", split.ID, participant.UserID, participant.Amount)
+}
+
+func (n *logSplitNotifier) ShareReminder(split *models.SplitBill, participant *models.SplitParticipant) {
+	n.logger.Printf("Share reminder: splitID=%s, userID=%s, amount=%d", split.ID, participant.UserID, participant.Amount)
+}
+
+type splitService struct {
+	storage       storage.SplitStorager
+	wallets       storage.WalletStorager
+	walletService WalletService
+	notifier      SplitNotifier
+	logger        *log.Logger
+}
+
+// NewSplitService tells participants about their shares through notifier, they are
+// only logged when it is nil
+func NewSplitService(db *sql.DB, walletService WalletService, notifier SplitNotifier) SplitService {
+	logger := log.New(log.Writer(), "SplitService: ", log.Ldate|log.Ltime|log.Lshortfile)
+	if notifier == nil {
+		notifier = NewLogSplitNotifier(logger)
+	}
+	return &splitService{
+		storage:       storage.NewSplitStorage(db),
+		wallets:       storage.NewWalletStorage(db),
+		walletService: walletService,
+		notifier:      notifier,
+		logger:        logger,
+	}
+}
+
+func (s *splitService) Create(request models.CreateSplitRequest, userID string) (*models.SplitBill, error) {
+	s.logger.Printf("Creating split: walletID=%s, userID=%s, total=%s, mode=%s", request.WalletID, userID, request.TotalAmount, request.Mode)
+	total, err := models.ParseAmount(request.TotalAmount)
+	if err != nil {
+		s.logger.Printf("Error parsing amount: %v", err)
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(request.Participants))
+	for _, share := range request.Participants {
+		if share.UserID == userID {
+			return nil, ErrSelfSplit
+		}
+		if seen[share.UserID] {
+			return nil, ErrDuplicateParticipant
+		}
+		seen[share.UserID] = true
+	}
+
+	amounts, err := splitShares(total, request)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.wallets.GetWallet(request.WalletID, userID)
+	if err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return nil, errors.Wrap(err, "Error getting wallet")
+	}
+
+	split := &models.SplitBill{
+		OriginatorID: userID,
+		WalletID:     wallet.ID,
+		TotalAmount:  total,
+		Description:  request.Description,
+		Status:       models.SplitStatusOpen,
+	}
+	for i, share := range request.Participants {
+		split.Participants = append(split.Participants, models.SplitParticipant{
+			UserID: share.UserID,
+			Amount: amounts[i],
+			Status: models.SplitParticipantPending,
+		})
+	}
+	if err := s.storage.CreateSplit(split); err != nil {
+		s.logger.Printf("Error creating split: %v", err)
+		return nil, err
+	}
+
+	for i := range split.Participants {
+		s.notifier.ShareRequested(split, &split.Participants[i])
+	}
+
+	return split, nil
+}
+
+// splitShares works out what every participant owes. Equal splits give the odd
+// minor units to the first participants. Custom shares must add up to the total,
+// or to less than the total when the originator covers the rest.
+func splitShares(total int64, request models.CreateSplitRequest) ([]int64, error) {
+	amounts := make([]int64, len(request.Participants))
+
+	if request.Mode == models.SplitModeEqual {
+		people := int64(len(request.Participants))
+		if request.IncludeSelf {
+			people++
+		}
+		if total < people {
+			return nil, ErrInvalidShares
+		}
+		for i := range amounts {
+			amounts[i] = total / people
+			if int64(i) < total%people {
+				amounts[i]++
+			}
+		}
+		return amounts, nil
+	}
+
+	var sum int64
+	for i, share := range request.Participants {
+		amount, err := models.ParseAmount(share.Amount)
+		if err != nil {
+			return nil, errors.Wrapf(err, "share of %s", share.UserID)
+		}
+		amounts[i] = amount
+		sum += amount
+	}
+	if sum > total || (sum < total && !request.IncludeSelf) || (sum == total && request.IncludeSelf) {
+		return nil, ErrInvalidShares
+	}
+
+	return amounts, nil
+}
+
+// Get returns the split to its originator or to one of its participants
+func (s *splitService) Get(splitID, userID string) (*models.SplitBill, error) {
+	s.logger.Printf("Getting split: splitID=%s, userID=%s", splitID, userID)
+	split, err := s.get(splitID)
+	if err != nil {
+		return nil, err
+	}
+
+	if split.OriginatorID != userID && findParticipant(split, userID) == nil {
+		return nil, ErrSplitNotFound
+	}
+
+	return split, nil
+}
+
+func (s *splitService) List(request models.ListSplitsRequest, userID string) ([]models.SplitBill, error) {
+	s.logger.Printf("Listing splits: userID=%s, role=%s", userID, request.Role)
+	splits, err := s.storage.ListSplits(userID, request.Role)
+	if err != nil {
+		s.logger.Printf("Error listing splits: %v", err)
+		return nil, err
+	}
+
+	return splits, nil
+}
+
+// Pay settles the caller's share with a transfer into the originator's wallet. The share
+// is marked as paid in the transaction of the transfer, so it can't be paid twice.
+func (s *splitService) Pay(request models.PaySplitRequest, userID string) (*models.SplitParticipant, error) {
+	s.logger.Printf("Paying split share: splitID=%s, walletID=%s, userID=%s", request.SplitID, request.WalletID, userID)
+	split, participant, err := s.getShare(request.SplitID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if split.Status != models.SplitStatusOpen {
+		return nil, ErrSplitNotOpen
+	}
+	if participant.Status != models.SplitParticipantPending {
+		return nil, ErrShareNotPending
+	}
+
+	transactionID, err := s.walletService.Transfer(models.TransferRequest{
+		WalletID:    request.WalletID,
+		ToWalletID:  split.WalletID,
+		Amount:      models.FormatAmount(participant.Amount),
+		Reference:   splitReferencePrefix + participant.ID,
+		Description: split.Description,
+	}, userID, s.storage.CompleteParticipant(participant.ID, request.WalletID))
+	if err != nil {
+		s.logger.Printf("Error paying split share: %v", err)
+		return nil, err
+	}
+
+	now := time.Now()
+	participant.Status = models.SplitParticipantPaid
+	participant.WalletID = request.WalletID
+	participant.TransactionID = transactionID
+	participant.PaidAt = &now
+
+	return participant, nil
+}
+
+func (s *splitService) Decline(splitID, userID string) error {
+	s.logger.Printf("Declining split share: splitID=%s, userID=%s", splitID, userID)
+	split, participant, err := s.getShare(splitID, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.updateStatus(split, participant, models.SplitParticipantPending, models.SplitParticipantDeclined)
+}
+
+// Remind notifies participants who haven't paid yet and returns how many were reminded.
+// Participants reminded within SplitReminderInterval are skipped.
+func (s *splitService) Remind(request models.RemindSplitRequest, userID string) (int, error) {
+	s.logger.Printf("Reminding split participants: splitID=%s, userID=%s, participant=%s", request.SplitID, userID, request.UserID)
+	split, err := s.getOwned(request.SplitID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if split.Status != models.SplitStatusOpen {
+		return 0, ErrSplitNotOpen
+	}
+
+	var due []*models.SplitParticipant
+	if request.UserID != "" {
+		participant := findParticipant(split, request.UserID)
+		if participant == nil {
+			return 0, ErrSplitNotFound
+		}
+		if participant.Status != models.SplitParticipantPending {
+			return 0, ErrShareNotPending
+		}
+		if remindedRecently(participant) {
+			return 0, ErrReminderTooSoon
+		}
+		due = append(due, participant)
+	} else {
+		for i := range split.Participants {
+			participant := &split.Participants[i]
+			if participant.Status == models.SplitParticipantPending && !remindedRecently(participant) {
+				due = append(due, participant)
+			}
+		}
+	}
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, 0, len(due))
+	for _, participant := range due {
+		ids = append(ids, participant.ID)
+	}
+	if err := s.storage.MarkReminded(ids); err != nil {
+		s.logger.Printf("Error recording reminders: %v", err)
+		return 0, err
+	}
+
+	for _, participant := range due {
+		s.notifier.ShareReminder(split, participant)
+	}
+
+	return len(due), nil
+}
+
+func (s *splitService) Cancel(splitID, userID string) error {
+	s.logger.Printf("Cancelling split: splitID=%s, userID=%s", splitID, userID)
+	split, err := s.getOwned(splitID, userID)
+	if err != nil {
+		return err
+	}
+
+	cancelled, err := s.storage.CancelSplit(split.ID)
+	if err != nil {
+		s.logger.Printf("Error cancelling split: %v", err)
+		return err
+	}
+	if !cancelled {
+		return ErrSplitNotOpen
+	}
+
+	return nil
+}
+
+func (s *splitService) get(splitID string) (*models.SplitBill, error) {
+	split, err := s.storage.GetSplit(splitID)
+	if err == sql.ErrNoRows {
+		return nil, ErrSplitNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting split: %v", err)
+		return nil, err
+	}
+
+	return split, nil
+}
+
+func (s *splitService) getOwned(splitID, userID string) (*models.SplitBill, error) {
+	split, err := s.get(splitID)
+	if err != nil {
+		return nil, err
+	}
+
+	if split.OriginatorID != userID {
+		return nil, ErrSplitNotFound
+	}
+
+	return split, nil
+}
+
+func (s *splitService) getShare(splitID, userID string) (*models.SplitBill, *models.SplitParticipant, error) {
+	split, err := s.get(splitID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	participant := findParticipant(split, userID)
+	if participant == nil {
+		return nil, nil, ErrSplitNotFound
+	}
+
+	return split, participant, nil
+}
+
+func (s *splitService) updateStatus(split *models.SplitBill, participant *models.SplitParticipant, fromStatus, toStatus string) error {
+	if split.Status != models.SplitStatusOpen {
+		return ErrSplitNotOpen
+	}
+
+	updated, err := s.storage.UpdateParticipantStatus(participant.ID, fromStatus, toStatus)
+	if err != nil {
+		s.logger.Printf("Error updating split share: %v", err)
+		return err
+	}
+	if !updated {
+		return ErrShareNotPending
+	}
+
+	participant.Status = toStatus
+	return nil
+}
+
+func findParticipant(split *models.SplitBill, userID string) *models.SplitParticipant {
+	for i := range split.Participants {
+		if split.Participants[i].UserID == userID {
+			return &split.Participants[i]
+		}
+	}
+	return nil
+}
+
+func remindedRecently(participant *models.SplitParticipant) bool {
+	return participant.LastRemindedAt != nil && time.Since(*participant.LastRemindedAt) < models.SplitReminderInterval
+}
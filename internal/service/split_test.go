@@ -0,0 +1,270 @@
+package service
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock implementation of SplitStorage
+type MockSplitStorage struct {
+	mock.Mock
+}
+
+func (m *MockSplitStorage) CreateSplit(split *models.SplitBill) error {
+	args := m.Called(split)
+	return args.Error(0)
+}
+
+func (m *MockSplitStorage) GetSplit(splitID string) (*models.SplitBill, error) {
+	args := m.Called(splitID)
+	return args.Get(0).(*models.SplitBill), args.Error(1)
+}
+
+func (m *MockSplitStorage) ListSplits(userID, role string) ([]models.SplitBill, error) {
+	args := m.Called(userID, role)
+	return args.Get(0).([]models.SplitBill), args.Error(1)
+}
+
+func (m *MockSplitStorage) UpdateParticipantStatus(participantID, fromStatus, toStatus string) (bool, error) {
+	args := m.Called(participantID, fromStatus, toStatus)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSplitStorage) CompleteParticipant(participantID, walletID string) storage.TransferHook {
+	args := m.Called(participantID, walletID)
+	return hookReturning(args.Error(0))
+}
+
+func (m *MockSplitStorage) MarkReminded(participantIDs []string) error {
+	args := m.Called(participantIDs)
+	return args.Error(0)
+}
+
+func (m *MockSplitStorage) CancelSplit(splitID string) (bool, error) {
+	args := m.Called(splitID)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestSplitShares(t *testing.T) {
+	participants := []models.SplitShare{{UserID: "a", Amount: "10"}, {UserID: "b", Amount: "5.01"}}
+
+	t.Run("Equal split gives odd units to the first participants", func(t *testing.T) {
+		amounts, err := splitShares(1001, models.CreateSplitRequest{Mode: models.SplitModeEqual, Participants: participants, IncludeSelf: true})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{334, 334}, amounts)
+
+		amounts, err = splitShares(1001, models.CreateSplitRequest{Mode: models.SplitModeEqual, Participants: participants})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{501, 500}, amounts)
+	})
+
+	t.Run("Custom shares must add up", func(t *testing.T) {
+		amounts, err := splitShares(1501, models.CreateSplitRequest{Mode: models.SplitModeCustom, Participants: participants})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1000, 501}, amounts)
+
+		_, err = splitShares(2000, models.CreateSplitRequest{Mode: models.SplitModeCustom, Participants: participants})
+		assert.ErrorIs(t, err, ErrInvalidShares)
+
+		_, err = splitShares(2000, models.CreateSplitRequest{Mode: models.SplitModeCustom, Participants: participants, IncludeSelf: true})
+		assert.NoError(t, err)
+
+		_, err = splitShares(1000, models.CreateSplitRequest{Mode: models.SplitModeCustom, Participants: participants})
+		assert.ErrorIs(t, err, ErrInvalidShares)
+	})
+}
+
+func TestCreateSplit(t *testing.T) {
+	mockStorage := new(MockSplitStorage)
+	mockWalletStorage := new(MockWalletStorage)
+	service := &splitService{storage: mockStorage, wallets: mockWalletStorage, notifier: NewLogSplitNotifier(log.Default()), logger: log.Default()}
+
+	userID := uuid.New().String()
+	walletID := uuid.New().String()
+
+	t.Run("Equal split", func(t *testing.T) {
+		mockWalletStorage.On("GetWallet", walletID, userID).Return(&models.Wallet{ID: walletID, UserID: userID}, nil).Once()
+		mockStorage.On("CreateSplit", mock.MatchedBy(func(split *models.SplitBill) bool {
+			return split.TotalAmount == 9000 && len(split.Participants) == 2 &&
+				split.Participants[0].Amount == 3000 && split.Participants[1].Amount == 3000
+		})).Return(nil).Once()
+
+		split, err := service.Create(models.CreateSplitRequest{
+			WalletID:     walletID,
+			TotalAmount:  "90",
+			Mode:         models.SplitModeEqual,
+			Participants: []models.SplitShare{{UserID: "friend-1"}, {UserID: "friend-2"}},
+			IncludeSelf:  true,
+		}, userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(6000), split.Outstanding())
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Originator can't be a participant", func(t *testing.T) {
+		_, err := service.Create(models.CreateSplitRequest{
+			WalletID:     walletID,
+			TotalAmount:  "90",
+			Mode:         models.SplitModeEqual,
+			Participants: []models.SplitShare{{UserID: userID}},
+		}, userID)
+
+		assert.ErrorIs(t, err, ErrSelfSplit)
+	})
+
+	t.Run("Duplicate participant", func(t *testing.T) {
+		_, err := service.Create(models.CreateSplitRequest{
+			WalletID:     walletID,
+			TotalAmount:  "90",
+			Mode:         models.SplitModeEqual,
+			Participants: []models.SplitShare{{UserID: "friend-1"}, {UserID: "friend-1"}},
+		}, userID)
+
+		assert.ErrorIs(t, err, ErrDuplicateParticipant)
+	})
+}
+
+func TestPaySplit(t *testing.T) {
+	mockStorage := new(MockSplitStorage)
+	mockWalletService := new(MockWalletService)
+	service := &splitService{storage: mockStorage, walletService: mockWalletService, logger: log.Default()}
+
+	originatorWalletID := uuid.New().String()
+	payerID := uuid.New().String()
+	payerWalletID := uuid.New().String()
+
+	newSplit := func() *models.SplitBill {
+		return &models.SplitBill{
+			ID:           uuid.New().String(),
+			OriginatorID: uuid.New().String(),
+			WalletID:     originatorWalletID,
+			TotalAmount:  6000,
+			Status:       models.SplitStatusOpen,
+			Participants: []models.SplitParticipant{
+				{ID: uuid.New().String(), UserID: payerID, Amount: 3000, Status: models.SplitParticipantPending},
+			},
+		}
+	}
+
+	t.Run("Successful payment", func(t *testing.T) {
+		split := newSplit()
+		participantID := split.Participants[0].ID
+		mockStorage.On("GetSplit", split.ID).Return(split, nil).Once()
+		mockStorage.On("CompleteParticipant", participantID, payerWalletID).Return(nil).Once()
+		mockWalletService.On("Transfer", models.TransferRequest{
+			WalletID:   payerWalletID,
+			ToWalletID: originatorWalletID,
+			Amount:     "30.00",
+			Reference:  "split:" + participantID,
+		}, payerID).Return(int64(17), nil).Once()
+
+		participant, err := service.Pay(models.PaySplitRequest{SplitID: split.ID, WalletID: payerWalletID}, payerID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.SplitParticipantPaid, participant.Status)
+		assert.Equal(t, int64(17), participant.TransactionID)
+		mockStorage.AssertExpectations(t)
+		mockWalletService.AssertExpectations(t)
+	})
+
+	t.Run("Failed transfer", func(t *testing.T) {
+		split := newSplit()
+		participantID := split.Participants[0].ID
+		mockStorage.On("GetSplit", split.ID).Return(split, nil).Once()
+		mockStorage.On("CompleteParticipant", participantID, payerWalletID).Return(nil).Once()
+		mockWalletService.On("Transfer", mock.Anything, payerID).Return(int64(0), errors.New("insufficient funds")).Once()
+
+		_, err := service.Pay(models.PaySplitRequest{SplitID: split.ID, WalletID: payerWalletID}, payerID)
+
+		assert.Error(t, err)
+		mockStorage.AssertExpectations(t)
+		mockWalletService.AssertExpectations(t)
+	})
+
+	t.Run("Split cancelled during the payment", func(t *testing.T) {
+		split := newSplit()
+		participantID := split.Participants[0].ID
+		mockStorage.On("GetSplit", split.ID).Return(split, nil).Once()
+		mockStorage.On("CompleteParticipant", participantID, payerWalletID).Return(storage.ErrSplitNotOpen).Once()
+		mockWalletService.On("Transfer", mock.Anything, payerID).Return(int64(18), nil).Once()
+
+		_, err := service.Pay(models.PaySplitRequest{SplitID: split.ID, WalletID: payerWalletID}, payerID)
+
+		assert.ErrorIs(t, err, ErrSplitNotOpen)
+		mockStorage.AssertExpectations(t)
+		mockWalletService.AssertExpectations(t)
+	})
+
+	t.Run("Not a participant", func(t *testing.T) {
+		split := newSplit()
+		mockStorage.On("GetSplit", split.ID).Return(split, nil).Once()
+
+		_, err := service.Pay(models.PaySplitRequest{SplitID: split.ID, WalletID: payerWalletID}, uuid.New().String())
+
+		assert.ErrorIs(t, err, ErrSplitNotFound)
+	})
+
+	t.Run("Cancelled split", func(t *testing.T) {
+		split := newSplit()
+		split.Status = models.SplitStatusCancelled
+		mockStorage.On("GetSplit", split.ID).Return(split, nil).Once()
+
+		_, err := service.Pay(models.PaySplitRequest{SplitID: split.ID, WalletID: payerWalletID}, payerID)
+
+		assert.ErrorIs(t, err, ErrSplitNotOpen)
+	})
+}
+
+func TestRemindSplit(t *testing.T) {
+	mockStorage := new(MockSplitStorage)
+	service := &splitService{storage: mockStorage, notifier: NewLogSplitNotifier(log.Default()), logger: log.Default()}
+
+	originatorID := uuid.New().String()
+	recently := time.Now().Add(-10 * time.Minute)
+	split := &models.SplitBill{
+		ID:           uuid.New().String(),
+		OriginatorID: originatorID,
+		Status:       models.SplitStatusOpen,
+		Participants: []models.SplitParticipant{
+			{ID: "p-1", UserID: "u-1", Amount: 100, Status: models.SplitParticipantPending},
+			{ID: "p-2", UserID: "u-2", Amount: 100, Status: models.SplitParticipantPending, LastRemindedAt: &recently},
+			{ID: "p-3", UserID: "u-3", Amount: 100, Status: models.SplitParticipantPaid},
+		},
+	}
+
+	t.Run("Reminds pending participants not reminded recently", func(t *testing.T) {
+		mockStorage.On("GetSplit", split.ID).Return(split, nil).Once()
+		mockStorage.On("MarkReminded", []string{"p-1"}).Return(nil).Once()
+
+		reminded, err := service.Remind(models.RemindSplitRequest{SplitID: split.ID}, originatorID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, reminded)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Single participant reminded too soon", func(t *testing.T) {
+		mockStorage.On("GetSplit", split.ID).Return(split, nil).Once()
+
+		_, err := service.Remind(models.RemindSplitRequest{SplitID: split.ID, UserID: "u-2"}, originatorID)
+
+		assert.ErrorIs(t, err, ErrReminderTooSoon)
+	})
+
+	t.Run("Only the originator can remind", func(t *testing.T) {
+		mockStorage.On("GetSplit", split.ID).Return(split, nil).Once()
+
+		_, err := service.Remind(models.RemindSplitRequest{SplitID: split.ID}, "u-1")
+
+		assert.ErrorIs(t, err, ErrSplitNotFound)
+	})
+}
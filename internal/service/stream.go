@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/outbox"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+// StreamService fans committed wallet events out to the live streams of their
+// owners. Events come either from the outbox relay as a Sink or from PostgreSQL
+// notifications, see Listen.
+type StreamService interface {
+	outbox.Sink
+	Subscribe(userID string) *Subscription
+	Unsubscribe(subscription *Subscription)
+	Replay(userID string, afterID int64, limit int) ([]models.WalletEvent, error)
+	Listen(ctx context.Context, databaseURL string) error
+}
+
+// Subscription is the live feed of one stream. Done is closed when the subscriber
+// fell too far behind and was dropped, it should reconnect and replay from the
+// last event it saw.
+type Subscription struct {
+	UserID string
+	Events <-chan models.WalletEvent
+	Done   <-chan struct{}
+
+	events chan models.WalletEvent
+	done   chan struct{}
+}
+
+const streamListenerPingInterval = 90 * time.Second
+
+type streamService struct {
+	storage storage.StreamStorager
+	logger  *log.Logger
+
+	mu          sync.Mutex
+	subscribers map[string]map[*Subscription]struct{}
+}
+
+func NewStreamService(db *sql.DB) StreamService {
+	return &streamService{
+		storage:     storage.NewStreamStorage(db),
+		logger:      log.New(log.Writer(), "StreamService: ", log.Ldate|log.Ltime|log.Lshortfile),
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+func (s *streamService) Subscribe(userID string) *Subscription {
+	events := make(chan models.WalletEvent, models.StreamBufferSize)
+	done := make(chan struct{})
+	subscription := &Subscription{UserID: userID, Events: events, Done: done, events: events, done: done}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscribers[userID] == nil {
+		s.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	s.subscribers[userID][subscription] = struct{}{}
+
+	return subscription
+}
+
+func (s *streamService) Unsubscribe(subscription *Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(subscription)
+}
+
+// remove drops a subscription, the caller holds the lock
+func (s *streamService) remove(subscription *Subscription) {
+	subscriptions := s.subscribers[subscription.UserID]
+	if _, ok := subscriptions[subscription]; !ok {
+		return
+	}
+	delete(subscriptions, subscription)
+	if len(subscriptions) == 0 {
+		delete(s.subscribers, subscription.UserID)
+	}
+	close(subscription.done)
+}
+
+// Replay returns the events of the user after the given domain event id, oldest
+// first. Streams call it on connect to catch up from Last-Event-ID.
+func (s *streamService) Replay(userID string, afterID int64, limit int) ([]models.WalletEvent, error) {
+	if limit <= 0 || limit > models.StreamReplayLimit {
+		limit = models.StreamReplayLimit
+	}
+	domainEvents, err := s.storage.ListActivity(userID, afterID, limit)
+	if err != nil {
+		s.logger.Printf("Error listing wallet activity: userID=%s, afterID=%d: %v", userID, afterID, err)
+		return nil, err
+	}
+
+	events := make([]models.WalletEvent, 0, len(domainEvents))
+	for _, domainEvent := range domainEvents {
+		event, ok, err := walletEvent(domainEvent)
+		if err != nil {
+			s.logger.Printf("Error decoding wallet activity: userID=%s: %v", userID, err)
+			return nil, err
+		}
+		if ok {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// Publish feeds the streams from the outbox relay, which publishes the events of a
+// wallet in order after commit
+func (s *streamService) Publish(ctx context.Context, domainEvent models.DomainEvent) error {
+	event, ok, err := walletEvent(domainEvent)
+	if err != nil || !ok {
+		return err
+	}
+
+	s.broadcast(event)
+	return nil
+}
+
+// broadcast sends the event to every stream of its owner without blocking. A
+// stream whose buffer is full is dropped rather than holding up the others.
+func (s *streamService) broadcast(event models.WalletEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for subscription := range s.subscribers[event.UserID] {
+		select {
+		case subscription.events <- event:
+		default:
+			s.logger.Printf("Dropping slow stream subscriber: userID=%s", event.UserID)
+			s.remove(subscription)
+		}
+	}
+}
+
+// Listen feeds the streams from PostgreSQL notifications of balance change domain
+// events until ctx is cancelled. Notifications are only delivered once the
+// transaction writing the event commits, so this also covers changes made by
+// other instances.
+func (s *streamService) Listen(ctx context.Context, databaseURL string) error {
+	listener := pq.NewListener(databaseURL, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			s.logger.Printf("Wallet activity listener event %d: %v", event, err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(models.StreamNotifyChannel); err != nil {
+		return errors.Wrap(err, "unable to listen for wallet activity")
+	}
+
+	ticker := time.NewTicker(streamListenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := listener.Ping(); err != nil {
+				s.logger.Printf("Error pinging wallet activity listener: %v", err)
+			}
+		case notification := <-listener.Notify:
+			// A nil notification follows a reconnect, streams catch up on their own
+			// reconnect with Last-Event-ID
+			if notification == nil {
+				continue
+			}
+			s.handleNotification(notification.Extra)
+		}
+	}
+}
+
+func (s *streamService) handleNotification(payload string) {
+	eventID, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		s.logger.Printf("Invalid wallet activity notification %q: %v", payload, err)
+		return
+	}
+
+	domainEvent, err := s.storage.GetActivity(eventID)
+	if err != nil {
+		s.logger.Printf("Error getting wallet activity: eventID=%d: %v", eventID, err)
+		return
+	}
+
+	event, ok, err := walletEvent(*domainEvent)
+	if err != nil {
+		s.logger.Printf("Error decoding wallet activity: eventID=%d: %v", eventID, err)
+		return
+	}
+	if ok {
+		s.broadcast(event)
+	}
+}
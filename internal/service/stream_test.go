@@ -0,0 +1,140 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock implementation of StreamStorage
+type MockStreamStorage struct {
+	mock.Mock
+}
+
+func (m *MockStreamStorage) ListActivity(userID string, afterID int64, limit int) ([]models.DomainEvent, error) {
+	args := m.Called(userID, afterID, limit)
+	return args.Get(0).([]models.DomainEvent), args.Error(1)
+}
+
+func (m *MockStreamStorage) GetActivity(eventID int64) (*models.DomainEvent, error) {
+	args := m.Called(eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DomainEvent), args.Error(1)
+}
+
+func TestStreamBroadcast(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	service := &streamService{storage: mockStorage, logger: log.Default(), subscribers: make(map[string]map[*Subscription]struct{})}
+
+	t.Run("Events reach the streams of the owner only", func(t *testing.T) {
+		userID := uuid.New().String()
+		first := service.Subscribe(userID)
+		second := service.Subscribe(userID)
+		other := service.Subscribe(uuid.New().String())
+
+		service.broadcast(models.WalletEvent{Type: models.EventWalletToppedUp, UserID: userID, TransactionID: 7})
+
+		assert.Equal(t, int64(7), (<-first.Events).TransactionID)
+		assert.Equal(t, int64(7), (<-second.Events).TransactionID)
+		assert.Empty(t, other.Events)
+	})
+
+	t.Run("Subscriber that fell behind is dropped", func(t *testing.T) {
+		userID := uuid.New().String()
+		slow := service.Subscribe(userID)
+
+		for i := 0; i <= models.StreamBufferSize; i++ {
+			service.broadcast(models.WalletEvent{UserID: userID, TransactionID: int64(i + 1)})
+		}
+
+		select {
+		case <-slow.Done:
+		default:
+			t.Fatal("slow subscriber wasn't dropped")
+		}
+		assert.NotContains(t, service.subscribers, userID)
+		// Unsubscribing a dropped subscription is a no-op
+		service.Unsubscribe(slow)
+	})
+
+	t.Run("Nothing is delivered after unsubscribe", func(t *testing.T) {
+		userID := uuid.New().String()
+		subscription := service.Subscribe(userID)
+		service.Unsubscribe(subscription)
+
+		service.broadcast(models.WalletEvent{UserID: userID, TransactionID: 1})
+
+		assert.Empty(t, subscription.Events)
+		_, open := <-subscription.Done
+		assert.False(t, open)
+	})
+}
+
+func TestStreamReplay(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	service := &streamService{storage: mockStorage, logger: log.Default(), subscribers: make(map[string]map[*Subscription]struct{})}
+
+	userID := uuid.New().String()
+
+	t.Run("Replay after the last event id", func(t *testing.T) {
+		events := []models.DomainEvent{{
+			ID:      11,
+			Type:    models.DomainEventWalletDebited,
+			Payload: json.RawMessage(`{"wallet_id":"wallet-1","user_id":"` + userID + `","amount":-500,"balance":1000,"transaction_id":7}`),
+		}}
+		mockStorage.On("ListActivity", userID, int64(10), models.StreamReplayLimit).Return(events, nil).Once()
+
+		replayed, err := service.Replay(userID, 10, 0)
+
+		assert.NoError(t, err)
+		if assert.Len(t, replayed, 1) {
+			assert.Equal(t, int64(11), replayed[0].ID)
+			assert.Equal(t, int64(7), replayed[0].TransactionID)
+			assert.Equal(t, models.EventWalletDebited, replayed[0].Type)
+			assert.Equal(t, int64(500), replayed[0].Amount)
+		}
+		mockStorage.AssertExpectations(t)
+	})
+}
+
+func TestStreamHandleNotification(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	service := &streamService{storage: mockStorage, logger: log.Default(), subscribers: make(map[string]map[*Subscription]struct{})}
+
+	userID := uuid.New().String()
+
+	t.Run("Notified event is broadcast", func(t *testing.T) {
+		subscription := service.Subscribe(userID)
+		defer service.Unsubscribe(subscription)
+		mockStorage.On("GetActivity", int64(42)).Return(&models.DomainEvent{
+			ID:      42,
+			Type:    models.DomainEventWalletCredited,
+			Payload: json.RawMessage(`{"wallet_id":"wallet-1","user_id":"` + userID + `","amount":500,"balance":1500,"counterparty_wallet_id":"wallet-2"}`),
+		}, nil).Once()
+
+		service.handleNotification("42")
+
+		event := <-subscription.Events
+		assert.Equal(t, int64(42), event.ID)
+		assert.Equal(t, models.EventWalletCredited, event.Type)
+		assert.Equal(t, int64(1500), event.Balance)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Malformed payload is ignored", func(t *testing.T) {
+		subscription := service.Subscribe(userID)
+		defer service.Unsubscribe(subscription)
+
+		service.handleNotification("not-a-number")
+
+		assert.Empty(t, subscription.Events)
+		mockStorage.AssertNumberOfCalls(t, "GetActivity", 1)
+	})
+}
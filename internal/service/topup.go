@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+	"github.com/rasul07/alif-task/internal/topup"
+)
+
+type TopUpService interface {
+	Initiate(request models.InitiateTopUpRequest, userID string) (*models.ProviderTopUp, error)
+	Get(topUpID, userID string) (*models.ProviderTopUp, error)
+	List(userID string) ([]models.ProviderTopUp, error)
+	HandleCallback(provider string, header http.Header, body []byte) error
+	ProcessPending(ctx context.Context) error
+	Run(ctx context.Context)
+}
+
+var (
+	ErrTopUpNotFound   = errors.New("top-up not found")
+	ErrUnknownProvider = errors.New("unknown top-up provider")
+)
+
+const (
+	topUpPollInterval    = 5 * time.Second
+	topUpProviderTimeout = 30 * time.Second
+)
+
+type topUpService struct {
+	storage   storage.TopUpStorager
+	wallets   storage.WalletStorager
+	providers map[string]topup.Provider
+	logger    *log.Logger
+}
+
+func NewTopUpService(db *sql.DB, providers ...topup.Provider) TopUpService {
+	registry := make(map[string]topup.Provider, len(providers))
+	for _, provider := range providers {
+		registry[provider.Name()] = provider
+	}
+
+	return &topUpService{
+		storage:   storage.NewTopUpStorage(db),
+		wallets:   storage.NewWalletStorage(db),
+		providers: registry,
+		logger:    log.New(log.Writer(), "TopUpService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+// Initiate asks the provider to collect the money. The top-up is recorded with its
+// transaction staged, the wallet is credited once the provider reports the payment
+// succeeded.
+func (s *topUpService) Initiate(request models.InitiateTopUpRequest, userID string) (*models.ProviderTopUp, error) {
+	s.logger.Printf("Initiating top-up: walletID=%s, userID=%s, amount=%s, provider=%s, source=%s",
+		request.WalletID, userID, request.Amount, request.Provider, request.SourceType)
+	amount, err := models.ParseAmount(request.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, ok := s.providers[request.Provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	if !provider.Supports(request.SourceType) {
+		return nil, topup.ErrUnsupportedSource
+	}
+
+	wallet, err := s.wallets.GetWallet(request.WalletID, userID)
+	if err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return nil, errors.Wrap(err, "Error getting wallet")
+	}
+
+	topUp := &models.ProviderTopUp{
+		UserID:     userID,
+		WalletID:   wallet.ID,
+		Provider:   provider.Name(),
+		SourceType: request.SourceType,
+		Amount:     amount,
+		Status:     models.TopUpInitiated,
+	}
+	if err := s.storage.CreateTopUp(topUp); err != nil {
+		s.logger.Printf("Error creating top-up: %v", err)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), topUpProviderTimeout)
+	defer cancel()
+	result, err := provider.Initiate(ctx, topup.Payment{
+		TopUpID:    topUp.ID,
+		Amount:     amount,
+		SourceType: request.SourceType,
+		Source:     request.Source,
+	})
+	if err != nil {
+		s.logger.Printf("Provider rejected top-up %s: %v", topUp.ID, err)
+		if _, failErr := s.storage.FailTopUp(topUp.ID, models.TopUpInitiated, err.Error()); failErr != nil {
+			s.logger.Printf("Error failing top-up: %v", failErr)
+			return nil, failErr
+		}
+		return s.storage.GetTopUp(topUp.ID)
+	}
+
+	if _, err := s.storage.MarkTopUpPending(topUp.ID, result.Reference); err != nil {
+		s.logger.Printf("Error saving provider reference: %v", err)
+		return nil, err
+	}
+	topUp.Status = models.TopUpPending
+	topUp.ProviderReference = result.Reference
+
+	if err := s.apply(topUp, result); err != nil {
+		return nil, err
+	}
+
+	return s.storage.GetTopUp(topUp.ID)
+}
+
+func (s *topUpService) Get(topUpID, userID string) (*models.ProviderTopUp, error) {
+	s.logger.Printf("Getting top-up: topUpID=%s, userID=%s", topUpID, userID)
+	topUp, err := s.storage.GetTopUp(topUpID)
+	if err == sql.ErrNoRows {
+		return nil, ErrTopUpNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting top-up: %v", err)
+		return nil, err
+	}
+
+	if topUp.UserID != userID {
+		return nil, ErrTopUpNotFound
+	}
+
+	return topUp, nil
+}
+
+func (s *topUpService) List(userID string) ([]models.ProviderTopUp, error) {
+	s.logger.Printf("Listing top-ups: userID=%s", userID)
+	topUps, err := s.storage.ListTopUps(userID)
+	if err != nil {
+		s.logger.Printf("Error listing top-ups: %v", err)
+		return nil, err
+	}
+
+	return topUps, nil
+}
+
+// HandleCallback applies the result a provider reported. Providers may repeat
+// callbacks, a top-up that is already final is left as it is.
+func (s *topUpService) HandleCallback(providerName string, header http.Header, body []byte) error {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return ErrUnknownProvider
+	}
+
+	result, err := provider.ParseCallback(header, body)
+	if err != nil {
+		s.logger.Printf("Rejected callback from %s: %v", providerName, err)
+		return err
+	}
+	s.logger.Printf("Top-up callback: provider=%s, reference=%s, status=%s", providerName, result.Reference, result.Status)
+
+	topUp, err := s.storage.GetTopUpByReference(provider.Name(), result.Reference)
+	if err == sql.ErrNoRows {
+		return ErrTopUpNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting top-up: %v", err)
+		return err
+	}
+
+	return s.apply(topUp, result)
+}
+
+// Run polls providers for pending top-ups until ctx is cancelled
+func (s *topUpService) Run(ctx context.Context) {
+	ticker := time.NewTicker(topUpPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ProcessPending(ctx); err != nil {
+				s.logger.Printf("Error processing top-ups: %v", err)
+			}
+		}
+	}
+}
+
+// ProcessPending asks the providers about every pending top-up, for providers
+// whose callbacks got lost
+func (s *topUpService) ProcessPending(ctx context.Context) error {
+	topUps, err := s.storage.ListTopUpsByStatus(models.TopUpPending)
+	if err != nil {
+		return errors.Wrap(err, "unable to list pending top-ups")
+	}
+
+	for i := range topUps {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		topUp := &topUps[i]
+		provider, ok := s.providers[topUp.Provider]
+		if !ok {
+			s.logger.Printf("Top-up %s uses provider %s which is not configured", topUp.ID, topUp.Provider)
+			continue
+		}
+
+		statusCtx, cancel := context.WithTimeout(ctx, topUpProviderTimeout)
+		result, err := provider.Status(statusCtx, topUp.ProviderReference)
+		cancel()
+		if err != nil {
+			s.logger.Printf("Error getting status of top-up %s: %v", topUp.ID, err)
+			continue
+		}
+
+		if err := s.apply(topUp, result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply moves a pending top-up to the status the provider reported. Its ledger
+// transaction completes with it, which credits the wallet.
+func (s *topUpService) apply(topUp *models.ProviderTopUp, result topup.Result) error {
+	switch result.Status {
+	case topup.StatusFailed:
+		failed, err := s.storage.FailTopUp(topUp.ID, models.TopUpPending, result.FailureReason)
+		if err != nil {
+			return errors.Wrapf(err, "unable to fail top-up %s", topUp.ID)
+		}
+		if failed {
+			s.logger.Printf("Top-up failed: topUpID=%s, reason=%s", topUp.ID, result.FailureReason)
+		}
+		return nil
+	case topup.StatusSucceeded:
+	default:
+		return nil
+	}
+
+	completed, err := s.storage.CompleteTopUp(topUp.ID)
+	if err != nil {
+		// The money is collected, so the top-up stays pending and is credited on a later poll
+		s.logger.Printf("Error crediting top-up %s: %v", topUp.ID, err)
+		return nil
+	}
+	if completed {
+		s.logger.Printf("Top-up succeeded: topUpID=%s, transactionID=%d", topUp.ID, topUp.TransactionID)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/topup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock implementation of TopUpStorage
+type MockTopUpStorage struct {
+	mock.Mock
+}
+
+func (m *MockTopUpStorage) CreateTopUp(topUp *models.ProviderTopUp) error {
+	args := m.Called(topUp)
+	return args.Error(0)
+}
+
+func (m *MockTopUpStorage) GetTopUp(topUpID string) (*models.ProviderTopUp, error) {
+	args := m.Called(topUpID)
+	return args.Get(0).(*models.ProviderTopUp), args.Error(1)
+}
+
+func (m *MockTopUpStorage) GetTopUpByReference(provider, reference string) (*models.ProviderTopUp, error) {
+	args := m.Called(provider, reference)
+	return args.Get(0).(*models.ProviderTopUp), args.Error(1)
+}
+
+func (m *MockTopUpStorage) ListTopUps(userID string) ([]models.ProviderTopUp, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.ProviderTopUp), args.Error(1)
+}
+
+func (m *MockTopUpStorage) ListTopUpsByStatus(status string) ([]models.ProviderTopUp, error) {
+	args := m.Called(status)
+	return args.Get(0).([]models.ProviderTopUp), args.Error(1)
+}
+
+func (m *MockTopUpStorage) MarkTopUpPending(topUpID, reference string) (bool, error) {
+	args := m.Called(topUpID, reference)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTopUpStorage) FailTopUp(topUpID, fromStatus, reason string) (bool, error) {
+	args := m.Called(topUpID, fromStatus, reason)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTopUpStorage) CompleteTopUp(topUpID string) (bool, error) {
+	args := m.Called(topUpID)
+	return args.Bool(0), args.Error(1)
+}
+
+func newTestTopUpService(topUps *MockTopUpStorage, wallets *MockWalletStorage, simulator *topup.Simulator) *topUpService {
+	return &topUpService{
+		storage:   topUps,
+		wallets:   wallets,
+		providers: map[string]topup.Provider{simulator.Name(): simulator},
+		logger:    log.Default(),
+	}
+}
+
+// initiateSimulated starts a simulator top-up of 25.00 and returns its provider reference
+func initiateSimulated(t *testing.T, s *topUpService, topUps *MockTopUpStorage, wallets *MockWalletStorage, source string) string {
+	wallets.On("GetWallet", "wallet1", "user1").Return(&models.Wallet{ID: "wallet1", UserID: "user1"}, nil)
+	topUps.On("CreateTopUp", mock.AnythingOfType("*models.ProviderTopUp")).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.ProviderTopUp).ID = "topup1"
+	}).Return(nil)
+
+	var reference string
+	topUps.On("MarkTopUpPending", "topup1", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		reference = args.String(1)
+	}).Return(true, nil)
+	topUps.On("GetTopUp", "topup1").Return(&models.ProviderTopUp{ID: "topup1", Status: models.TopUpPending}, nil).Once()
+
+	topUp, err := s.Initiate(models.InitiateTopUpRequest{
+		WalletID:   "wallet1",
+		Amount:     "25.00",
+		Provider:   topup.SimulatorName,
+		SourceType: topup.SourceCard,
+		Source:     source,
+	}, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, models.TopUpPending, topUp.Status)
+	require.NotEmpty(t, reference)
+
+	return reference
+}
+
+func pendingTopUp(reference string) *models.ProviderTopUp {
+	return &models.ProviderTopUp{
+		ID:                "topup1",
+		UserID:            "user1",
+		WalletID:          "wallet1",
+		Provider:          topup.SimulatorName,
+		Amount:            2500,
+		Status:            models.TopUpPending,
+		ProviderReference: reference,
+	}
+}
+
+func TestTopUpService_Initiate(t *testing.T) {
+	t.Run("Unknown provider", func(t *testing.T) {
+		s := newTestTopUpService(new(MockTopUpStorage), new(MockWalletStorage), topup.NewSimulator("secret", 0, ""))
+
+		_, err := s.Initiate(models.InitiateTopUpRequest{
+			WalletID: "wallet1", Amount: "25.00", Provider: "acquirer", SourceType: topup.SourceCard,
+		}, "user1")
+
+		assert.ErrorIs(t, err, ErrUnknownProvider)
+	})
+
+	t.Run("Invalid amount", func(t *testing.T) {
+		s := newTestTopUpService(new(MockTopUpStorage), new(MockWalletStorage), topup.NewSimulator("secret", 0, ""))
+
+		_, err := s.Initiate(models.InitiateTopUpRequest{
+			WalletID: "wallet1", Amount: "-5", Provider: topup.SimulatorName, SourceType: topup.SourceCard,
+		}, "user1")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestTopUpService_Callback(t *testing.T) {
+	t.Run("Succeeded payment credits the wallet", func(t *testing.T) {
+		topUps := new(MockTopUpStorage)
+		wallets := new(MockWalletStorage)
+		simulator := topup.NewSimulator("secret", 0, "")
+		s := newTestTopUpService(topUps, wallets, simulator)
+
+		reference := initiateSimulated(t, s, topUps, wallets, "4111")
+
+		topUps.On("GetTopUpByReference", topup.SimulatorName, reference).Return(pendingTopUp(reference), nil)
+		topUps.On("CompleteTopUp", "topup1").Return(true, nil)
+
+		header, body, err := simulator.Callback(reference)
+		require.NoError(t, err)
+		require.NoError(t, s.HandleCallback(topup.SimulatorName, header, body))
+
+		topUps.AssertExpectations(t)
+	})
+
+	t.Run("Declined payment fails the top-up", func(t *testing.T) {
+		topUps := new(MockTopUpStorage)
+		wallets := new(MockWalletStorage)
+		simulator := topup.NewSimulator("secret", 0, "")
+		s := newTestTopUpService(topUps, wallets, simulator)
+
+		reference := initiateSimulated(t, s, topUps, wallets, topup.SimulatorDeclinedSource)
+
+		topUps.On("GetTopUpByReference", topup.SimulatorName, reference).Return(pendingTopUp(reference), nil)
+		topUps.On("FailTopUp", "topup1", models.TopUpPending, "declined by issuer").Return(true, nil)
+
+		header, body, err := simulator.Callback(reference)
+		require.NoError(t, err)
+		require.NoError(t, s.HandleCallback(topup.SimulatorName, header, body))
+
+		topUps.AssertExpectations(t)
+		topUps.AssertNotCalled(t, "CompleteTopUp", mock.Anything)
+	})
+
+	t.Run("Repeated callback is ignored", func(t *testing.T) {
+		topUps := new(MockTopUpStorage)
+		wallets := new(MockWalletStorage)
+		simulator := topup.NewSimulator("secret", 0, "")
+		s := newTestTopUpService(topUps, wallets, simulator)
+
+		reference := initiateSimulated(t, s, topUps, wallets, "4111")
+
+		topUps.On("GetTopUpByReference", topup.SimulatorName, reference).Return(pendingTopUp(reference), nil)
+		topUps.On("CompleteTopUp", "topup1").Return(false, nil)
+
+		header, body, err := simulator.Callback(reference)
+		require.NoError(t, err)
+		require.NoError(t, s.HandleCallback(topup.SimulatorName, header, body))
+
+		topUps.AssertExpectations(t)
+	})
+
+	t.Run("Forged callback", func(t *testing.T) {
+		s := newTestTopUpService(new(MockTopUpStorage), new(MockWalletStorage), topup.NewSimulator("secret", 0, ""))
+
+		header := http.Header{}
+		header.Set(topup.SimulatorSignatureHeader, "forged")
+		err := s.HandleCallback(topup.SimulatorName, header, []byte(`{"reference":"sim_1","status":"succeeded"}`))
+
+		assert.ErrorIs(t, err, topup.ErrInvalidSignature)
+	})
+}
+
+func TestTopUpService_ProcessPending(t *testing.T) {
+	t.Run("Polling settles pending top-ups", func(t *testing.T) {
+		topUps := new(MockTopUpStorage)
+		wallets := new(MockWalletStorage)
+		simulator := topup.NewSimulator("secret", 0, "")
+		s := newTestTopUpService(topUps, wallets, simulator)
+
+		reference := initiateSimulated(t, s, topUps, wallets, "4111")
+
+		topUps.On("ListTopUpsByStatus", models.TopUpPending).Return([]models.ProviderTopUp{*pendingTopUp(reference)}, nil)
+		topUps.On("CompleteTopUp", "topup1").Return(true, nil)
+
+		require.NoError(t, s.ProcessPending(context.Background()))
+
+		topUps.AssertExpectations(t)
+	})
+
+	t.Run("Refused credit stays pending", func(t *testing.T) {
+		topUps := new(MockTopUpStorage)
+		wallets := new(MockWalletStorage)
+		simulator := topup.NewSimulator("secret", 0, "")
+		s := newTestTopUpService(topUps, wallets, simulator)
+
+		reference := initiateSimulated(t, s, topUps, wallets, "4111")
+
+		topUps.On("ListTopUpsByStatus", models.TopUpPending).Return([]models.ProviderTopUp{*pendingTopUp(reference)}, nil)
+		topUps.On("CompleteTopUp", "topup1").Return(false, ErrMaxBalanceExceeded)
+
+		require.NoError(t, s.ProcessPending(context.Background()))
+
+		topUps.AssertExpectations(t)
+		topUps.AssertNotCalled(t, "FailTopUp", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+type TransactionService interface {
+	History(filter models.TransactionFilter, userID string) ([]models.Transaction, error)
+	Get(walletID string, transactionID int64, userID string) (*models.Transaction, error)
+	UpdateDetails(request models.UpdateTransactionRequest, userID string) (*models.Transaction, error)
+	Reverse(request models.ReverseTransactionRequest) (*models.Transaction, error)
+}
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrInvalidTags         = errors.Errorf("up to %d tags of at most %d characters are allowed", models.MaxTransactionTags, models.MaxTransactionTagLength)
+	ErrInvalidMetadata     = errors.Errorf("metadata must be a JSON object of at most %d bytes", models.MaxMetadataSize)
+	ErrInvalidAmountRange  = errors.New("min_amount can't be greater than max_amount")
+	ErrNotReversible       = errors.New("only completed transactions can be reversed")
+)
+
+type transactionService struct {
+	storage storage.TransactionStorager
+	wallets storage.WalletStorager
+	logger  *log.Logger
+}
+
+func NewTransactionService(db *sql.DB) TransactionService {
+	return &transactionService{
+		storage: storage.NewTransactionStorage(db),
+		wallets: storage.NewWalletStorage(db),
+		logger:  log.New(log.Writer(), "TransactionService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+func (s *transactionService) History(filter models.TransactionFilter, userID string) ([]models.Transaction, error) {
+	s.logger.Printf("Getting transaction history: walletID=%s, userID=%s", filter.WalletID, userID)
+	if _, err := s.wallets.GetWallet(filter.WalletID, userID); err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return nil, errors.Wrap(err, "Error getting wallet")
+	}
+
+	err := parseAmountRange(&filter)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Tags, err = normalizeTags(filter.Tags); err != nil {
+		return nil, err
+	}
+	if filter.Metadata, err = normalizeMetadata(filter.Metadata); err != nil {
+		return nil, err
+	}
+	filter.Category = normalizeCategory(filter.Category)
+	if filter.Limit == 0 {
+		filter.Limit = models.TransactionHistoryLimit
+	}
+
+	transactions, err := s.storage.ListTransactions(filter)
+	if err != nil {
+		s.logger.Printf("Error listing transactions: %v", err)
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+func (s *transactionService) UpdateDetails(request models.UpdateTransactionRequest, userID string) (*models.Transaction, error) {
+	s.logger.Printf("Updating transaction details: walletID=%s, transactionID=%d, userID=%s", request.WalletID, request.TransactionID, userID)
+	if _, err := s.wallets.GetWallet(request.WalletID, userID); err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return nil, errors.Wrap(err, "Error getting wallet")
+	}
+
+	if request.Description != nil {
+		description := strings.TrimSpace(*request.Description)
+		request.Description = &description
+	}
+	if request.Category != nil {
+		category := normalizeCategory(*request.Category)
+		request.Category = &category
+	}
+	if request.Tags != nil {
+		tags, err := normalizeTags(*request.Tags)
+		if err != nil {
+			return nil, err
+		}
+		request.Tags = &tags
+	}
+
+	transaction, err := s.storage.UpdateTransactionDetails(request)
+	if err == sql.ErrNoRows {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error updating transaction details: %v", err)
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// Get returns a transaction of the user's wallet
+func (s *transactionService) Get(walletID string, transactionID int64, userID string) (*models.Transaction, error) {
+	s.logger.Printf("Getting transaction: walletID=%s, transactionID=%d, userID=%s", walletID, transactionID, userID)
+	if _, err := s.wallets.GetWallet(walletID, userID); err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return nil, errors.Wrap(err, "Error getting wallet")
+	}
+
+	transaction, err := s.storage.GetTransaction(transactionID)
+	if err == sql.ErrNoRows {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting transaction: %v", err)
+		return nil, err
+	}
+	if transaction.WalletID != walletID {
+		return nil, ErrTransactionNotFound
+	}
+
+	return transaction, nil
+}
+
+// Reverse undoes a completed transaction with offsetting transactions, for
+// support staff correcting mistakes
+func (s *transactionService) Reverse(request models.ReverseTransactionRequest) (*models.Transaction, error) {
+	s.logger.Printf("Reversing transaction: transactionID=%d, reason=%s", request.TransactionID, request.Reason)
+	transaction, err := s.storage.GetTransaction(request.TransactionID)
+	if err == sql.ErrNoRows {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting transaction: %v", err)
+		return nil, err
+	}
+	if transaction.Status != models.TransactionCompleted {
+		return nil, ErrNotReversible
+	}
+
+	reversed, err := s.storage.TransitionTransaction(transaction.ID, models.TransactionCompleted, models.TransactionReversed, strings.TrimSpace(request.Reason))
+	if errors.Is(err, storage.ErrInvalidTransition) {
+		return nil, ErrNotReversible
+	}
+	if err != nil {
+		s.logger.Printf("Error reversing transaction: %v", err)
+		return nil, err
+	}
+	if !reversed {
+		return nil, ErrNotReversible
+	}
+
+	return s.storage.GetTransaction(transaction.ID)
+}
+
+// parseAmountRange converts the amount bounds of the filter into minor units
+func parseAmountRange(filter *models.TransactionFilter) error {
+	var err error
+	if filter.MinAmount != "" {
+		if filter.MinAmountUnits, err = models.ParseAmount(filter.MinAmount); err != nil {
+			return err
+		}
+	}
+	if filter.MaxAmount != "" {
+		if filter.MaxAmountUnits, err = models.ParseAmount(filter.MaxAmount); err != nil {
+			return err
+		}
+	}
+	if filter.MaxAmountUnits > 0 && filter.MinAmountUnits > filter.MaxAmountUnits {
+		return ErrInvalidAmountRange
+	}
+
+	return nil
+}
+
+// newTransactionDetails validates the context a user attached to an operation
+func newTransactionDetails(description, category string, tags []string, metadata json.RawMessage) (models.TransactionDetails, error) {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return models.TransactionDetails{}, err
+	}
+	metadata, err = normalizeMetadata(metadata)
+	if err != nil {
+		return models.TransactionDetails{}, err
+	}
+
+	return models.TransactionDetails{
+		Description: strings.TrimSpace(description),
+		Category:    normalizeCategory(category),
+		Tags:        tags,
+		Metadata:    metadata,
+	}, nil
+}
+
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// normalizeTags lowercases the tags and drops empty and repeated ones
+func normalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > models.MaxTransactionTagLength {
+			return nil, ErrInvalidTags
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > models.MaxTransactionTags {
+		return nil, ErrInvalidTags
+	}
+
+	return normalized, nil
+}
+
+// normalizeMetadata accepts a JSON object, null and a missing value are treated as no metadata
+func normalizeMetadata(metadata json.RawMessage) (json.RawMessage, error) {
+	metadata = bytes.TrimSpace(metadata)
+	if len(metadata) == 0 || string(metadata) == "null" {
+		return nil, nil
+	}
+	if len(metadata) > models.MaxMetadataSize {
+		return nil, ErrInvalidMetadata
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(metadata, &object); err != nil {
+		return nil, ErrInvalidMetadata
+	}
+
+	return metadata, nil
+}
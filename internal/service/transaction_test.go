@@ -0,0 +1,221 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"testing"
+
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock implementation of TransactionStorage
+type MockTransactionStorage struct {
+	mock.Mock
+}
+
+func (m *MockTransactionStorage) GetTransaction(transactionID int64) (*models.Transaction, error) {
+	args := m.Called(transactionID)
+	return args.Get(0).(*models.Transaction), args.Error(1)
+}
+
+func (m *MockTransactionStorage) ListTransactions(filter models.TransactionFilter) ([]models.Transaction, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.Transaction), args.Error(1)
+}
+
+func (m *MockTransactionStorage) UpdateTransactionDetails(request models.UpdateTransactionRequest) (*models.Transaction, error) {
+	args := m.Called(request)
+	return args.Get(0).(*models.Transaction), args.Error(1)
+}
+
+func (m *MockTransactionStorage) CreateTransaction(transaction *models.Transaction) error {
+	args := m.Called(transaction)
+	return args.Error(0)
+}
+
+func (m *MockTransactionStorage) TransitionTransaction(transactionID int64, fromStatus, toStatus, reason string) (bool, error) {
+	args := m.Called(transactionID, fromStatus, toStatus, reason)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestTransactionHistory(t *testing.T) {
+	mockStorage := new(MockTransactionStorage)
+	mockWallets := new(MockWalletStorage)
+	service := &transactionService{storage: mockStorage, wallets: mockWallets, logger: log.Default()}
+	wallet := &models.Wallet{ID: "wallet-1", UserID: "user-1"}
+
+	t.Run("Normalizes the filter", func(t *testing.T) {
+		mockWallets.On("GetWallet", "wallet-1", "user-1").Return(wallet, nil).Once()
+		mockStorage.On("ListTransactions", models.TransactionFilter{
+			WalletID:       "wallet-1",
+			Category:       "food",
+			Tags:           []string{"trip"},
+			Metadata:       json.RawMessage(`{"order":"42"}`),
+			MinAmount:      "10",
+			MinAmountUnits: 1000,
+			Limit:          models.TransactionHistoryLimit,
+		}).Return([]models.Transaction{{ID: 7}}, nil).Once()
+
+		transactions, err := service.History(models.TransactionFilter{
+			WalletID:  "wallet-1",
+			Category:  " Food",
+			Tags:      []string{"Trip", "trip"},
+			Metadata:  json.RawMessage(` {"order":"42"} `),
+			MinAmount: "10",
+		}, "user-1")
+
+		assert.NoError(t, err)
+		assert.Len(t, transactions, 1)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Metadata must be an object", func(t *testing.T) {
+		mockWallets.On("GetWallet", "wallet-1", "user-1").Return(wallet, nil).Once()
+
+		_, err := service.History(models.TransactionFilter{WalletID: "wallet-1", Metadata: json.RawMessage(`["a"]`)}, "user-1")
+
+		assert.ErrorIs(t, err, ErrInvalidMetadata)
+	})
+
+	t.Run("Amount range is checked", func(t *testing.T) {
+		mockWallets.On("GetWallet", "wallet-1", "user-1").Return(wallet, nil).Once()
+
+		_, err := service.History(models.TransactionFilter{WalletID: "wallet-1", MinAmount: "50", MaxAmount: "10"}, "user-1")
+
+		assert.ErrorIs(t, err, ErrInvalidAmountRange)
+	})
+}
+
+func TestUpdateTransactionDetails(t *testing.T) {
+	mockStorage := new(MockTransactionStorage)
+	mockWallets := new(MockWalletStorage)
+	service := &transactionService{storage: mockStorage, wallets: mockWallets, logger: log.Default()}
+	wallet := &models.Wallet{ID: "wallet-1", UserID: "user-1"}
+
+	t.Run("Updates the given fields", func(t *testing.T) {
+		description := "Dinner"
+		tags := []string{"friends"}
+		mockWallets.On("GetWallet", "wallet-1", "user-1").Return(wallet, nil).Once()
+		mockStorage.On("UpdateTransactionDetails", models.UpdateTransactionRequest{
+			WalletID: "wallet-1", TransactionID: 7, Description: &description, Tags: &tags,
+		}).Return(&models.Transaction{ID: 7, Description: description, Tags: tags}, nil).Once()
+
+		rawDescription := " Dinner "
+		rawTags := []string{"Friends"}
+		transaction, err := service.UpdateDetails(models.UpdateTransactionRequest{
+			WalletID: "wallet-1", TransactionID: 7, Description: &rawDescription, Tags: &rawTags,
+		}, "user-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Dinner", transaction.Description)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Transaction of another wallet", func(t *testing.T) {
+		mockWallets.On("GetWallet", "wallet-1", "user-1").Return(wallet, nil).Once()
+		mockStorage.On("UpdateTransactionDetails", mock.Anything).Return((*models.Transaction)(nil), sql.ErrNoRows).Once()
+
+		_, err := service.UpdateDetails(models.UpdateTransactionRequest{WalletID: "wallet-1", TransactionID: 8}, "user-1")
+
+		assert.ErrorIs(t, err, ErrTransactionNotFound)
+	})
+
+	t.Run("Too many tags", func(t *testing.T) {
+		tags := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}
+		mockWallets.On("GetWallet", "wallet-1", "user-1").Return(wallet, nil).Once()
+
+		_, err := service.UpdateDetails(models.UpdateTransactionRequest{WalletID: "wallet-1", TransactionID: 7, Tags: &tags}, "user-1")
+
+		assert.ErrorIs(t, err, ErrInvalidTags)
+	})
+}
+
+func TestGetTransaction(t *testing.T) {
+	mockStorage := new(MockTransactionStorage)
+	mockWallets := new(MockWalletStorage)
+	service := &transactionService{storage: mockStorage, wallets: mockWallets, logger: log.Default()}
+	wallet := &models.Wallet{ID: "wallet-1", UserID: "user-1"}
+
+	t.Run("Success", func(t *testing.T) {
+		mockWallets.On("GetWallet", "wallet-1", "user-1").Return(wallet, nil).Once()
+		mockStorage.On("GetTransaction", int64(7)).Return(&models.Transaction{ID: 7, WalletID: "wallet-1"}, nil).Once()
+
+		transaction, err := service.Get("wallet-1", 7, "user-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), transaction.ID)
+	})
+
+	t.Run("Transaction of another wallet", func(t *testing.T) {
+		mockWallets.On("GetWallet", "wallet-1", "user-1").Return(wallet, nil).Once()
+		mockStorage.On("GetTransaction", int64(8)).Return(&models.Transaction{ID: 8, WalletID: "wallet-2"}, nil).Once()
+
+		_, err := service.Get("wallet-1", 8, "user-1")
+
+		assert.ErrorIs(t, err, ErrTransactionNotFound)
+	})
+
+	t.Run("Wallet of another user", func(t *testing.T) {
+		mockWallets.On("GetWallet", "wallet-1", "user-2").Return((*models.Wallet)(nil), sql.ErrNoRows).Once()
+
+		_, err := service.Get("wallet-1", 9, "user-2")
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		mockStorage.AssertNotCalled(t, "GetTransaction", int64(9))
+	})
+}
+
+func TestReverseTransaction(t *testing.T) {
+	mockStorage := new(MockTransactionStorage)
+	service := &transactionService{storage: mockStorage, logger: log.Default()}
+
+	t.Run("Success", func(t *testing.T) {
+		mockStorage.On("GetTransaction", int64(7)).Return(&models.Transaction{ID: 7, Status: models.TransactionCompleted}, nil).Once()
+		mockStorage.On("TransitionTransaction", int64(7), models.TransactionCompleted, models.TransactionReversed, "Duplicate charge").Return(true, nil).Once()
+		mockStorage.On("GetTransaction", int64(7)).Return(&models.Transaction{ID: 7, Status: models.TransactionReversed}, nil).Once()
+
+		transaction, err := service.Reverse(models.ReverseTransactionRequest{TransactionID: 7, Reason: " Duplicate charge "})
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.TransactionReversed, transaction.Status)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Not completed", func(t *testing.T) {
+		mockStorage.On("GetTransaction", int64(8)).Return(&models.Transaction{ID: 8, Status: models.TransactionFailed}, nil).Once()
+
+		_, err := service.Reverse(models.ReverseTransactionRequest{TransactionID: 8, Reason: "Mistake"})
+
+		assert.ErrorIs(t, err, ErrNotReversible)
+	})
+
+	t.Run("Reversed concurrently", func(t *testing.T) {
+		mockStorage.On("GetTransaction", int64(9)).Return(&models.Transaction{ID: 9, Status: models.TransactionCompleted}, nil).Once()
+		mockStorage.On("TransitionTransaction", int64(9), models.TransactionCompleted, models.TransactionReversed, "Mistake").Return(false, nil).Once()
+
+		_, err := service.Reverse(models.ReverseTransactionRequest{TransactionID: 9, Reason: "Mistake"})
+
+		assert.ErrorIs(t, err, ErrNotReversible)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mockStorage.On("GetTransaction", int64(10)).Return((*models.Transaction)(nil), sql.ErrNoRows).Once()
+
+		_, err := service.Reverse(models.ReverseTransactionRequest{TransactionID: 10, Reason: "Mistake"})
+
+		assert.ErrorIs(t, err, ErrTransactionNotFound)
+	})
+}
+
+func TestCanTransitionTransaction(t *testing.T) {
+	assert.True(t, models.CanTransitionTransaction(models.TransactionCreated, models.TransactionPending))
+	assert.True(t, models.CanTransitionTransaction(models.TransactionPending, models.TransactionCompleted))
+	assert.True(t, models.CanTransitionTransaction(models.TransactionPending, models.TransactionFailed))
+	assert.True(t, models.CanTransitionTransaction(models.TransactionCompleted, models.TransactionReversed))
+	assert.False(t, models.CanTransitionTransaction(models.TransactionFailed, models.TransactionCompleted))
+	assert.False(t, models.CanTransitionTransaction(models.TransactionReversed, models.TransactionCompleted))
+	assert.False(t, models.CanTransitionTransaction(models.TransactionPending, models.TransactionReversed))
+}
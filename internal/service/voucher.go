@@ -0,0 +1,213 @@
+package service
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+	"github.com/rasul07/alif-task/internal/voucher"
+)
+
+type VoucherService interface {
+	CreateBatch(request models.CreateVoucherBatchRequest) (*models.VoucherBatch, []string, error)
+	ListBatches() ([]models.VoucherBatch, error)
+	RevokeBatch(request models.RevokeVoucherBatchRequest) (*models.VoucherBatch, error)
+	Report(batchID string) (*models.VoucherUsageReport, error)
+	Redeem(request models.RedeemVoucherRequest, userID string) (*models.Voucher, error)
+}
+
+var (
+	ErrVoucherNotFound       = errors.New("voucher not found")
+	ErrVoucherBatchNotFound  = errors.New("voucher batch not found")
+	ErrVoucherBatchNotActive = errors.New("voucher batch is already revoked")
+	ErrVoucherRedeemed       = errors.New("voucher was already redeemed")
+	ErrVoucherExpired        = errors.New("voucher has expired")
+	ErrVoucherRevoked        = errors.New("voucher was revoked")
+)
+
+// voucherReferencePrefix marks the top-up of a redeemed voucher, followed by its id
+const voucherReferencePrefix = "voucher:"
+
+type voucherService struct {
+	storage       storage.VoucherStorager
+	wallets       storage.WalletStorager
+	walletService WalletService
+	key           []byte
+	now           func() time.Time
+	logger        *log.Logger
+}
+
+func NewVoucherService(db *sql.DB, walletService WalletService, secretKey string) VoucherService {
+	return &voucherService{
+		storage:       storage.NewVoucherStorage(db),
+		wallets:       storage.NewWalletStorage(db),
+		walletService: walletService,
+		key:           []byte(secretKey),
+		now:           time.Now,
+		logger:        log.New(log.Writer(), "VoucherService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+// CreateBatch generates the vouchers of a new batch. The codes are returned only
+// here, the database keeps their hashes.
+func (s *voucherService) CreateBatch(request models.CreateVoucherBatchRequest) (*models.VoucherBatch, []string, error) {
+	s.logger.Printf("Creating voucher batch: name=%s, amount=%s, count=%d", request.Name, request.Amount, request.Count)
+	amount, err := models.ParseAmount(request.Amount)
+	if err != nil {
+		s.logger.Printf("Error parsing amount: %v", err)
+		return nil, nil, err
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(s.now()) {
+		return nil, nil, ErrInvalidExpiry
+	}
+
+	codes := make([]string, request.Count)
+	hashes := make([]string, request.Count)
+	hints := make([]string, request.Count)
+	for i := range codes {
+		code, err := voucher.Generate()
+		if err != nil {
+			return nil, nil, err
+		}
+		canonical := strings.ReplaceAll(code, "-", "")
+		codes[i] = code
+		hashes[i] = voucher.Hash(canonical, s.key)
+		hints[i] = voucher.Hint(canonical)
+	}
+
+	batch := &models.VoucherBatch{
+		Name:      strings.TrimSpace(request.Name),
+		Amount:    amount,
+		Count:     request.Count,
+		Status:    models.VoucherBatchActive,
+		ExpiresAt: request.ExpiresAt,
+	}
+	if err := s.storage.CreateBatch(batch, hashes, hints); err != nil {
+		s.logger.Printf("Error creating voucher batch: %v", err)
+		return nil, nil, err
+	}
+
+	return batch, codes, nil
+}
+
+func (s *voucherService) ListBatches() ([]models.VoucherBatch, error) {
+	batches, err := s.storage.ListBatches()
+	if err != nil {
+		s.logger.Printf("Error listing voucher batches: %v", err)
+		return nil, err
+	}
+
+	return batches, nil
+}
+
+func (s *voucherService) RevokeBatch(request models.RevokeVoucherBatchRequest) (*models.VoucherBatch, error) {
+	s.logger.Printf("Revoking voucher batch: batchID=%s, reason=%s", request.BatchID, request.Reason)
+	revoked, err := s.storage.RevokeBatch(request.BatchID, strings.TrimSpace(request.Reason))
+	if err != nil {
+		s.logger.Printf("Error revoking voucher batch: %v", err)
+		return nil, err
+	}
+
+	batch, err := s.storage.GetBatch(request.BatchID)
+	if err == sql.ErrNoRows {
+		return nil, ErrVoucherBatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		return nil, ErrVoucherBatchNotActive
+	}
+
+	return batch, nil
+}
+
+func (s *voucherService) Report(batchID string) (*models.VoucherUsageReport, error) {
+	report, err := s.storage.GetBatchReport(batchID)
+	if err == sql.ErrNoRows {
+		return nil, ErrVoucherBatchNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting voucher batch report: %v", err)
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// Redeem credits the voucher amount to the wallet through the regular top-up, so
+// the wallet limits apply. The voucher is marked redeemed in the transaction of the
+// credit, a code redeemed twice at the same time credits only one wallet.
+func (s *voucherService) Redeem(request models.RedeemVoucherRequest, userID string) (*models.Voucher, error) {
+	code, err := voucher.Normalize(request.Code)
+	if err != nil {
+		return nil, err
+	}
+	hash := voucher.Hash(code, s.key)
+	s.logger.Printf("Redeeming voucher: walletID=%s, userID=%s, hint=%s", request.WalletID, userID, voucher.Hint(code))
+
+	wallet, err := s.wallets.GetWallet(request.WalletID, userID)
+	if err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return nil, errors.Wrap(err, "Error getting wallet")
+	}
+
+	v, err := s.getRedeemable(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	transactionID, err := s.walletService.TopUpWallet(models.TopUpRequest{
+		WalletID:    wallet.ID,
+		Amount:      models.FormatAmount(v.Amount),
+		Description: "Voucher redemption",
+		Reference:   voucherReferencePrefix + v.ID,
+	}, userID, s.storage.RedeemVoucher(v.ID, userID, wallet.ID, now))
+	if errors.Is(err, storage.ErrVoucherNotAvailable) {
+		// Redeemed, revoked or expired since it was read
+		_, err = s.getRedeemable(hash)
+		if err == nil {
+			err = ErrVoucherRedeemed
+		}
+		return nil, err
+	}
+	if err != nil {
+		s.logger.Printf("Error crediting voucher %s: %v", v.ID, err)
+		return nil, err
+	}
+
+	v.Status = models.VoucherRedeemed
+	v.RedeemedBy = userID
+	v.WalletID = wallet.ID
+	v.TransactionID = transactionID
+	v.RedeemedAt = &now
+	return v, nil
+}
+
+// getRedeemable returns the voucher of the code hash, or why it can't be redeemed
+func (s *voucherService) getRedeemable(hash string) (*models.Voucher, error) {
+	v, err := s.storage.GetVoucherByHash(hash)
+	if err == sql.ErrNoRows {
+		return nil, ErrVoucherNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting voucher: %v", err)
+		return nil, err
+	}
+
+	switch {
+	case v.Status != models.VoucherAvailable:
+		return nil, ErrVoucherRedeemed
+	case v.BatchStatus == models.VoucherBatchRevoked:
+		return nil, ErrVoucherRevoked
+	case v.ExpiresAt != nil && !v.ExpiresAt.After(s.now()):
+		return nil, ErrVoucherExpired
+	default:
+		return v, nil
+	}
+}
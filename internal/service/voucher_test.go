@@ -0,0 +1,206 @@
+package service
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
+	"github.com/rasul07/alif-task/internal/voucher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock implementation of VoucherStorage
+type MockVoucherStorage struct {
+	mock.Mock
+}
+
+func (m *MockVoucherStorage) CreateBatch(batch *models.VoucherBatch, hashes, hints []string) error {
+	args := m.Called(batch, hashes, hints)
+	return args.Error(0)
+}
+
+func (m *MockVoucherStorage) GetBatch(batchID string) (*models.VoucherBatch, error) {
+	args := m.Called(batchID)
+	return args.Get(0).(*models.VoucherBatch), args.Error(1)
+}
+
+func (m *MockVoucherStorage) ListBatches() ([]models.VoucherBatch, error) {
+	args := m.Called()
+	return args.Get(0).([]models.VoucherBatch), args.Error(1)
+}
+
+func (m *MockVoucherStorage) RevokeBatch(batchID, reason string) (bool, error) {
+	args := m.Called(batchID, reason)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockVoucherStorage) GetBatchReport(batchID string) (*models.VoucherUsageReport, error) {
+	args := m.Called(batchID)
+	return args.Get(0).(*models.VoucherUsageReport), args.Error(1)
+}
+
+func (m *MockVoucherStorage) GetVoucherByHash(hash string) (*models.Voucher, error) {
+	args := m.Called(hash)
+	return args.Get(0).(*models.Voucher), args.Error(1)
+}
+
+func (m *MockVoucherStorage) RedeemVoucher(voucherID, userID, walletID string, now time.Time) storage.TransferHook {
+	args := m.Called(voucherID, userID, walletID, now)
+	return hookReturning(args.Error(0))
+}
+
+func TestVoucherService_CreateBatch(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mockStorage := new(MockVoucherStorage)
+	s := &voucherService{storage: mockStorage, key: []byte("secret"), now: func() time.Time { return now }, logger: log.Default()}
+
+	t.Run("Stores only hashes", func(t *testing.T) {
+		var hashes, hints []string
+		mockStorage.On("CreateBatch", mock.AnythingOfType("*models.VoucherBatch"), mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			hashes, hints = args.Get(1).([]string), args.Get(2).([]string)
+		}).Return(nil).Once()
+
+		batch, codes, err := s.CreateBatch(models.CreateVoucherBatchRequest{Name: "Scratch cards", Amount: "50", Count: 3})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(5000), batch.Amount)
+		require.Len(t, codes, 3)
+		for i, code := range codes {
+			canonical, err := voucher.Normalize(code)
+			require.NoError(t, err)
+			assert.Equal(t, voucher.Hash(canonical, []byte("secret")), hashes[i])
+			assert.Equal(t, voucher.Hint(canonical), hints[i])
+			assert.NotContains(t, hashes[i], canonical)
+		}
+	})
+
+	t.Run("Expiry in the past", func(t *testing.T) {
+		past := now.Add(-time.Hour)
+		_, _, err := s.CreateBatch(models.CreateVoucherBatchRequest{Name: "Old", Amount: "50", Count: 1, ExpiresAt: &past})
+
+		assert.ErrorIs(t, err, ErrInvalidExpiry)
+	})
+}
+
+func TestVoucherService_Redeem(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	code, err := voucher.Generate()
+	require.NoError(t, err)
+	canonical := strings.ReplaceAll(code, "-", "")
+	hash := voucher.Hash(canonical, []byte("secret"))
+	wallet := &models.Wallet{ID: "wallet1", UserID: "user1"}
+
+	newService := func() (*voucherService, *MockVoucherStorage, *MockWalletStorage, *MockWalletService) {
+		mockStorage := new(MockVoucherStorage)
+		mockWallets := new(MockWalletStorage)
+		mockWalletService := new(MockWalletService)
+		return &voucherService{
+			storage:       mockStorage,
+			wallets:       mockWallets,
+			walletService: mockWalletService,
+			key:           []byte("secret"),
+			now:           func() time.Time { return now },
+			logger:        log.Default(),
+		}, mockStorage, mockWallets, mockWalletService
+	}
+
+	available := func() *models.Voucher {
+		return &models.Voucher{ID: "v1", Amount: 5000, Status: models.VoucherAvailable, BatchStatus: models.VoucherBatchActive}
+	}
+
+	t.Run("Redeemed with the top-up", func(t *testing.T) {
+		s, mockStorage, mockWallets, mockWalletService := newService()
+		mockWallets.On("GetWallet", "wallet1", "user1").Return(wallet, nil)
+		mockStorage.On("GetVoucherByHash", hash).Return(available(), nil)
+		mockStorage.On("RedeemVoucher", "v1", "user1", "wallet1", now).Return(nil)
+		mockWalletService.On("TopUpWallet", models.TopUpRequest{
+			WalletID:    "wallet1",
+			Amount:      "50.00",
+			Description: "Voucher redemption",
+			Reference:   "voucher:v1",
+		}, "user1").Return(int64(42), nil)
+
+		redeemed, err := s.Redeem(models.RedeemVoucherRequest{WalletID: "wallet1", Code: strings.ToLower(code)}, "user1")
+
+		require.NoError(t, err)
+		assert.Equal(t, models.VoucherRedeemed, redeemed.Status)
+		assert.Equal(t, int64(42), redeemed.TransactionID)
+		assert.Equal(t, "wallet1", redeemed.WalletID)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Top-up limit", func(t *testing.T) {
+		s, mockStorage, mockWallets, mockWalletService := newService()
+		mockWallets.On("GetWallet", "wallet1", "user1").Return(wallet, nil)
+		mockStorage.On("GetVoucherByHash", hash).Return(available(), nil)
+		mockStorage.On("RedeemVoucher", "v1", "user1", "wallet1", now).Return(nil)
+		mockWalletService.On("TopUpWallet", mock.AnythingOfType("models.TopUpRequest"), "user1").Return(int64(0), ErrMaxBalanceExceeded)
+
+		_, err := s.Redeem(models.RedeemVoucherRequest{WalletID: "wallet1", Code: code}, "user1")
+
+		assert.ErrorIs(t, err, ErrMaxBalanceExceeded)
+	})
+
+	t.Run("Redeemed concurrently", func(t *testing.T) {
+		s, mockStorage, mockWallets, mockWalletService := newService()
+		mockWallets.On("GetWallet", "wallet1", "user1").Return(wallet, nil)
+		mockStorage.On("GetVoucherByHash", hash).Return(available(), nil).Once()
+		mockStorage.On("RedeemVoucher", "v1", "user1", "wallet1", now).Return(storage.ErrVoucherNotAvailable)
+		mockWalletService.On("TopUpWallet", mock.AnythingOfType("models.TopUpRequest"), "user1").Return(int64(42), nil)
+		mockStorage.On("GetVoucherByHash", hash).Return(&models.Voucher{ID: "v1", Status: models.VoucherRedeemed, BatchStatus: models.VoucherBatchActive}, nil).Once()
+
+		_, err := s.Redeem(models.RedeemVoucherRequest{WalletID: "wallet1", Code: code}, "user1")
+
+		assert.ErrorIs(t, err, ErrVoucherRedeemed)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Typo is caught before lookup", func(t *testing.T) {
+		s, mockStorage, _, _ := newService()
+		typo := []byte(canonical)
+		typo[0] = map[bool]byte{true: 'A', false: 'B'}[typo[0] != 'A']
+
+		_, err := s.Redeem(models.RedeemVoucherRequest{WalletID: "wallet1", Code: string(typo)}, "user1")
+
+		assert.ErrorIs(t, err, voucher.ErrInvalidChecksum)
+		mockStorage.AssertNotCalled(t, "GetVoucherByHash", mock.Anything)
+	})
+
+	unavailable := []struct {
+		name    string
+		voucher *models.Voucher
+		err     error
+	}{
+		{"Already redeemed", &models.Voucher{Status: models.VoucherRedeemed, BatchStatus: models.VoucherBatchActive}, ErrVoucherRedeemed},
+		{"Revoked", &models.Voucher{Status: models.VoucherAvailable, BatchStatus: models.VoucherBatchRevoked}, ErrVoucherRevoked},
+		{"Expired", &models.Voucher{Status: models.VoucherAvailable, BatchStatus: models.VoucherBatchActive, ExpiresAt: &now}, ErrVoucherExpired},
+	}
+	for _, tc := range unavailable {
+		t.Run(tc.name, func(t *testing.T) {
+			s, mockStorage, mockWallets, mockWalletService := newService()
+			mockWallets.On("GetWallet", "wallet1", "user1").Return(wallet, nil)
+			mockStorage.On("GetVoucherByHash", hash).Return(tc.voucher, nil)
+
+			_, err := s.Redeem(models.RedeemVoucherRequest{WalletID: "wallet1", Code: code}, "user1")
+
+			assert.ErrorIs(t, err, tc.err)
+			mockWalletService.AssertNotCalled(t, "TopUpWallet", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("Unknown code", func(t *testing.T) {
+		s, mockStorage, mockWallets, _ := newService()
+		mockWallets.On("GetWallet", "wallet1", "user1").Return(wallet, nil)
+		mockStorage.On("GetVoucherByHash", hash).Return((*models.Voucher)(nil), sql.ErrNoRows)
+
+		_, err := s.Redeem(models.RedeemVoucherRequest{WalletID: "wallet1", Code: code}, "user1")
+
+		assert.ErrorIs(t, err, ErrVoucherNotFound)
+	})
+}
@@ -2,9 +2,7 @@ package service
 
 import (
 	"database/sql"
-	"fmt"
 	"log"
-	"strconv"
 
 	"github.com/pkg/errors"
 	"github.com/rasul07/alif-task/internal/models"
@@ -13,20 +11,42 @@ import (
 
 type WalletService interface {
 	CheckWalletExists(walletID, userID string) (bool, error)
-	TopUpWallet(walletID, userID, amount string) error
+	TopUpWallet(request models.TopUpRequest, userID string, hooks ...storage.TransferHook) (int64, error)
 	GetTransactions(walletID, userID string) (int, string, error)
 	GetBalance(walletID, userID string) (string, error)
+	GetWallet(walletID, userID string) (*models.Wallet, error)
+	Transfer(request models.TransferRequest, userID string, hooks ...storage.TransferHook) (int64, error)
 }
 
+var (
+	ErrSameWallet            = errors.New("can't transfer to the same wallet")
+	ErrMaxBalanceExceeded    = storage.ErrMaxBalanceExceeded
+	ErrCategoryBlocked       = errors.New("merchant category is blocked by parental controls")
+	ErrSpendingLimitExceeded = storage.ErrSpendingLimitExceeded
+	ErrApprovalRequired      = errors.New("transfer is waiting for parental approval")
+)
+
+// walletService changes balances. Every change writes its domain event to the
+// outbox in the same database transaction, the outbox relay publishes it.
 type walletService struct {
-	storage storage.WalletStorager
-	logger  *log.Logger
+	storage  storage.WalletStorager
+	parental storage.ParentalStorager
+	notifier ApprovalNotifier
+	logger   *log.Logger
 }
 
-func NewWalletService(db *sql.DB) WalletService {
+// NewWalletService asks parents to approve transfers through notifier, the requests
+// are only logged when it is nil
+func NewWalletService(db *sql.DB, notifier ApprovalNotifier) WalletService {
+	logger := log.New(log.Writer(), "WalletService: ", log.Ldate|log.Ltime|log.Lshortfile)
+	if notifier == nil {
+		notifier = NewLogApprovalNotifier(logger)
+	}
 	return &walletService{
-		storage: storage.NewWalletStorage(db),
-		logger:  log.New(log.Writer(), "WalletService: ", log.Ldate|log.Ltime|log.Lshortfile),
+		storage:  storage.NewWalletStorage(db),
+		parental: storage.NewParentalStorage(db),
+		notifier: notifier,
+		logger:   logger,
 	}
 }
 
@@ -41,49 +61,36 @@ func (s *walletService) CheckWalletExists(walletID, userID string) (bool, error)
 	return exists, nil
 }
 
-func (s *walletService) TopUpWallet(walletID, userID, amount string) error {
-	s.logger.Printf("Topping up wallet: walletID=%s, userID=%s, amount=%s", walletID, userID, amount)
-	wallet, err := s.storage.GetWallet(walletID, userID)
+// TopUpWallet credits the wallet, the hooks run in the transaction of the credit
+func (s *walletService) TopUpWallet(request models.TopUpRequest, userID string, hooks ...storage.TransferHook) (int64, error) {
+	s.logger.Printf("Topping up wallet: walletID=%s, userID=%s, amount=%s", request.WalletID, userID, request.Amount)
+	details, err := newTransactionDetails(request.Description, request.Category, request.Tags, request.Metadata)
 	if err != nil {
-		s.logger.Printf("Error getting wallet: %v", err)
-		return errors.Wrap(err, "Error getting wallet")
+		return 0, err
 	}
+	details.Reference = request.Reference
 
-	// Check if user is identified
-	isIdentified, err := s.storage.IsIdentified(wallet.UserID)
+	wallet, err := s.storage.GetWallet(request.WalletID, userID)
 	if err != nil {
-		s.logger.Printf("Error checking if user is identified: %v", err)
-		return err
+		s.logger.Printf("Error getting wallet: %v", err)
+		return 0, errors.Wrap(err, "Error getting wallet")
 	}
 
-	// Convert amount being added to numeric type
-	amountInt, err := strconv.ParseFloat(amount, 64)
+	// Convert amount being added to minor units, cents of provider top-ups must not be lost
+	newAmount, err := models.ParseAmount(request.Amount)
 	if err != nil {
 		s.logger.Printf("Error parsing amount: %v", err)
-		return err
-	}
-	newAmount := int64(amountInt)*100
-
-	newBalance := wallet.Balance + newAmount
-	maxBalance := models.MaxBalanceUnidentified // if user is not identified max balance is 10.000
-	if isIdentified {
-		// if user is identified max balance is 100.000
-		maxBalance = models.MaxBalanceIdentified
-	}
-
-	// Check possible balance overflow
-	if newBalance > int64(maxBalance) {
-		s.logger.Printf("Top-up would exceed maximum balance: current=%d, new=%d, max=%d", wallet.Balance, newBalance, maxBalance)
-		return fmt.Errorf("top-up would exceed maximum balance")
+		return 0, err
 	}
 
-	err = s.storage.UpdateWalletBalance(wallet.ID, userID, newBalance, newAmount)
+	// The maximum balance of the owner is enforced by the credit itself
+	transactionID, err := s.storage.CreditWallet(wallet.ID, newAmount, details, hooks...)
 	if err != nil {
-		s.logger.Printf("Error updating wallet balance: %v", err)
-		return err
+		s.logger.Printf("Error crediting wallet: %v", err)
+		return 0, err
 	}
 
-	return nil
+	return transactionID, nil
 }
 
 func (s *walletService) GetTransactions(walletID, userID string) (int, string, error) {
@@ -100,7 +107,7 @@ func (s *walletService) GetTransactions(walletID, userID string) (int, string, e
 		return 0, "", err
 	}
 
-	totalStr := fmt.Sprintf("%.2f", float64(total/100))
+	totalStr := models.FormatAmount(total)
 
 	return trCount, totalStr, err
 }
@@ -113,8 +120,169 @@ func (s *walletService) GetBalance(walletID, userID string) (string, error) {
 		return "", err
 	}
 
-	balanceStr := fmt.Sprintf("%.2f", float64(balance/100))
+	balanceStr := models.FormatAmount(balance)
 	s.logger.Printf("Balance retrieved: %s", balanceStr)
 
 	return balanceStr, err
 }
+
+// GetWallet returns the user's wallet, sql.ErrNoRows when the user has no such wallet
+func (s *walletService) GetWallet(walletID, userID string) (*models.Wallet, error) {
+	s.logger.Printf("Getting wallet: walletID=%s, userID=%s", walletID, userID)
+	wallet, err := s.storage.GetWallet(walletID, userID)
+	if err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// Transfer moves money to another wallet. The hooks run in the database transaction
+// of the transfer after the ones enforcing parental controls.
+func (s *walletService) Transfer(request models.TransferRequest, userID string, hooks ...storage.TransferHook) (int64, error) {
+	s.logger.Printf("Transferring: walletID=%s, toWalletID=%s, userID=%s, amount=%s", request.WalletID, request.ToWalletID, userID, request.Amount)
+	amount, err := models.ParseAmount(request.Amount)
+	if err != nil {
+		s.logger.Printf("Error parsing amount: %v", err)
+		return 0, err
+	}
+
+	if request.WalletID == request.ToWalletID {
+		return 0, ErrSameWallet
+	}
+
+	details, err := newTransactionDetails(request.Description, request.Category, request.Tags, request.Metadata)
+	if err != nil {
+		return 0, err
+	}
+	details.Reference = request.Reference
+
+	wallet, err := s.storage.GetWallet(request.WalletID, userID)
+	if err != nil {
+		s.logger.Printf("Error getting wallet: %v", err)
+		return 0, errors.Wrap(err, "Error getting wallet")
+	}
+
+	recipient, err := s.storage.GetWalletByID(request.ToWalletID)
+	if err != nil {
+		s.logger.Printf("Error getting recipient wallet: %v", err)
+		return 0, errors.Wrap(err, "Error getting recipient wallet")
+	}
+
+	// Payments to merchants are categorized by the merchant, not by the payer
+	details.MerchantCategory, err = s.storage.GetMerchantCategory(recipient.ID)
+	if err != nil {
+		s.logger.Printf("Error getting merchant category: %v", err)
+		return 0, err
+	}
+	if details.MerchantCategory == "" {
+		details.MerchantCategory = request.MerchantCategory
+	}
+
+	parentID, err := s.parental.GetParentID(userID)
+	if err != nil {
+		s.logger.Printf("Error getting parent: %v", err)
+		return 0, err
+	}
+	if parentID != "" {
+		controlHooks, err := s.checkParentalControls(parentID, userID, request, details, amount)
+		if err != nil {
+			return 0, err
+		}
+		hooks = append(controlHooks, hooks...)
+	}
+
+	if wallet.Balance < amount {
+		return 0, storage.ErrInsufficientFunds
+	}
+
+	result, err := s.storage.Transfer(wallet.ID, recipient.ID, amount, details, hooks...)
+	if errors.Is(err, storage.ErrApprovalNotApproved) {
+		return 0, ErrApprovalRequired
+	}
+	if err != nil {
+		s.logger.Printf("Error transferring funds: %v", err)
+		return 0, err
+	}
+
+	return result.DebitTransactionID, nil
+}
+
+// checkParentalControls applies the rules the parent set for the child. Transfers above
+// the approval threshold are queued for the parent unless they carry an approved request.
+// The returned hooks enforce the rules that depend on other transfers of the child.
+func (s *walletService) checkParentalControls(parentID, childID string, request models.TransferRequest, details models.TransactionDetails, amount int64) ([]storage.TransferHook, error) {
+	controls, err := s.parental.GetControls(childID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		s.logger.Printf("Error getting parental controls: %v", err)
+		return nil, err
+	}
+
+	for _, category := range controls.BlockedCategories {
+		if category == details.MerchantCategory && category != "" {
+			s.logger.Printf("Merchant category %s is blocked for child %s", category, childID)
+			return nil, ErrCategoryBlocked
+		}
+	}
+
+	if controls.PerTransactionLimit > 0 && amount > controls.PerTransactionLimit {
+		s.logger.Printf("Per-transaction limit exceeded: child=%s, amount=%d, limit=%d", childID, amount, controls.PerTransactionLimit)
+		return nil, ErrSpendingLimitExceeded
+	}
+
+	var hooks []storage.TransferHook
+	if controls.MonthlyLimit > 0 {
+		hooks = append(hooks, s.parental.LimitMonthlySpending(childID, controls.MonthlyLimit))
+	}
+
+	if controls.ApprovalThreshold == 0 || amount <= controls.ApprovalThreshold {
+		return hooks, nil
+	}
+
+	if request.ApprovalID != "" {
+		if err := s.checkApproval(childID, request, amount); err != nil {
+			return nil, err
+		}
+		return append(hooks, s.parental.CompleteApproval(request.ApprovalID)), nil
+	}
+
+	approval := &models.ApprovalRequest{
+		ParentID:         parentID,
+		ChildID:          childID,
+		WalletID:         request.WalletID,
+		ToWalletID:       request.ToWalletID,
+		Amount:           amount,
+		MerchantCategory: details.MerchantCategory,
+		Reference:        request.Reference,
+		Status:           models.ApprovalStatusPending,
+	}
+	if err := s.parental.CreateApproval(approval); err != nil {
+		s.logger.Printf("Error creating approval request: %v", err)
+		return nil, err
+	}
+
+	s.notifier.ApprovalRequested(approval)
+	return nil, ErrApprovalRequired
+}
+
+// checkApproval verifies that the approval was granted for exactly this transfer
+func (s *walletService) checkApproval(childID string, request models.TransferRequest, amount int64) error {
+	approval, err := s.parental.GetApproval(request.ApprovalID)
+	if err != nil {
+		s.logger.Printf("Error getting approval request: %v", err)
+		return errors.Wrap(err, "couldn't get approval request")
+	}
+
+	if approval.Status != models.ApprovalStatusApproved || approval.ChildID != childID ||
+		approval.WalletID != request.WalletID || approval.ToWalletID != request.ToWalletID || approval.Amount != amount ||
+		approval.Reference != request.Reference {
+		s.logger.Printf("Approval %s doesn't match transfer", approval.ID)
+		return ErrApprovalRequired
+	}
+
+	return nil
+}
@@ -1,12 +1,16 @@
 package service
 
 import (
+	"database/sql"
+	"encoding/json"
 	"log"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -26,9 +30,13 @@ func (m *MockWalletStorage) GetWallet(walletID, userID string) (*models.Wallet,
 	return args.Get(0).(*models.Wallet), args.Error(1)
 }
 
-func (m *MockWalletStorage) UpdateWalletBalance(walletID, userID string, newBalance, amount int64) error {
-	args := m.Called(walletID, userID, newBalance, amount)
-	return args.Error(0)
+func (m *MockWalletStorage) CreditWallet(walletID string, amount int64, details models.TransactionDetails, hooks ...storage.TransferHook) (int64, error) {
+	args := m.Called(walletID, amount, details)
+	transactionID, err := args.Get(0).(int64), args.Error(1)
+	if err != nil {
+		return 0, err
+	}
+	return transactionID, runHooks(hooks, &models.TransferResult{CreditTransactionID: transactionID})
 }
 
 func (m *MockWalletStorage) GetTransactions(walletID string) (int, int64, error) {
@@ -46,6 +54,77 @@ func (m *MockWalletStorage) IsIdentified(userID string) (bool, error) {
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockWalletStorage) GetWalletByID(walletID string) (*models.Wallet, error) {
+	args := m.Called(walletID)
+	return args.Get(0).(*models.Wallet), args.Error(1)
+}
+
+func (m *MockWalletStorage) ListWalletsByUsers(userIDs []string) ([]models.Wallet, error) {
+	args := m.Called(userIDs)
+	return args.Get(0).([]models.Wallet), args.Error(1)
+}
+
+func (m *MockWalletStorage) ListWalletsByIDs(walletIDs []string) ([]models.Wallet, error) {
+	args := m.Called(walletIDs)
+	return args.Get(0).([]models.Wallet), args.Error(1)
+}
+
+func (m *MockWalletStorage) Transfer(fromWalletID, toWalletID string, amount int64, details models.TransactionDetails, hooks ...storage.TransferHook) (*models.TransferResult, error) {
+	args := m.Called(fromWalletID, toWalletID, amount, details)
+	result, err := args.Get(0).(*models.TransferResult), args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := runHooks(hooks, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (m *MockWalletStorage) GetMerchantCategory(walletID string) (string, error) {
+	args := m.Called(walletID)
+	return args.String(0), args.Error(1)
+}
+
+// runHooks runs transfer hooks the way storage does, there is no database transaction
+// in tests so hooks of the mocks must not use it
+func runHooks(hooks []storage.TransferHook, result *models.TransferResult) error {
+	for _, hook := range hooks {
+		if err := hook(nil, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hookReturning is a transfer hook of a mocked storage
+func hookReturning(err error) storage.TransferHook {
+	return func(*sql.Tx, *models.TransferResult) error {
+		return err
+	}
+}
+
+func (m *MockWalletStorage) HasReference(walletID, reference string) (bool, error) {
+	args := m.Called(walletID, reference)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWalletStorage) NextSnapshotDate() (time.Time, bool, error) {
+	args := m.Called()
+	return args.Get(0).(time.Time), args.Bool(1), args.Error(2)
+}
+
+func (m *MockWalletStorage) CreateSnapshots(day time.Time) (int, error) {
+	args := m.Called(day)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockWalletStorage) GetBalanceAt(walletID string, at time.Time) (*models.HistoricalBalance, error) {
+	args := m.Called(walletID, at)
+	return args.Get(0).(*models.HistoricalBalance), args.Error(1)
+}
+
 func TestCheckWalletExists(t *testing.T) {
 	mockStorage := new(MockWalletStorage)
 	service := &walletService{storage: mockStorage, logger: log.Default()}
@@ -88,24 +167,31 @@ func TestTopUpWallet(t *testing.T) {
 	t.Run("Successful top-up", func(t *testing.T) {
 		wallet := &models.Wallet{ID: walletID1, UserID: userID1, Balance: 5000}
 		mockStorage.On("GetWallet", walletID1, userID1).Return(wallet, nil).Once()
-		mockStorage.On("IsIdentified", userID1).Return(true, nil).Once()
-		mockStorage.On("UpdateWalletBalance", walletID1, userID1, int64(15000), int64(10000)).Return(nil).Once()
-
-		err := service.TopUpWallet(walletID1, userID1, "100.00")
+		details := models.TransactionDetails{Description: "Salary", Category: "income", Tags: []string{"work", "may"}, Metadata: json.RawMessage(`{"source":"payroll"}`)}
+		mockStorage.On("CreditWallet", walletID1, int64(10000), details).Return(int64(77), nil).Once()
+
+		transactionID, err := service.TopUpWallet(models.TopUpRequest{
+			WalletID:    walletID1,
+			Amount:      "100.00",
+			Description: " Salary ",
+			Category:    "Income",
+			Tags:        []string{"Work", "may", "work", ""},
+			Metadata:    json.RawMessage(`{"source":"payroll"}`),
+		}, userID1)
 
 		assert.NoError(t, err)
+		assert.Equal(t, int64(77), transactionID)
 		mockStorage.AssertExpectations(t)
 	})
 
 	t.Run("Top-up exceeds maximum balance", func(t *testing.T) {
 		wallet := &models.Wallet{ID: walletID2, UserID: userID2, Balance: 9000000}
 		mockStorage.On("GetWallet", walletID2, userID2).Return(wallet, nil).Once()
-		mockStorage.On("IsIdentified", userID2).Return(false, nil).Once()
+		mockStorage.On("CreditWallet", walletID2, int64(2000000), models.TransactionDetails{}).Return(int64(0), storage.ErrMaxBalanceExceeded).Once()
 
-		err := service.TopUpWallet(walletID2, userID2, "20000.00")
+		_, err := service.TopUpWallet(models.TopUpRequest{WalletID: walletID2, Amount: "20000.00"}, userID2)
 
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "top-up would exceed maximum balance")
+		assert.ErrorIs(t, err, ErrMaxBalanceExceeded)
 		mockStorage.AssertExpectations(t)
 	})
 }
@@ -162,6 +248,16 @@ func TestGetBalance(t *testing.T) {
 		mockStorage.AssertExpectations(t)
 	})
 
+	t.Run("Balance keeps the cents", func(t *testing.T) {
+		mockStorage.On("GetBalance", walletID1, userID1).Return(int64(1050), nil).Once()
+
+		balance, err := service.GetBalance(walletID1, userID1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "10.50", balance)
+		mockStorage.AssertExpectations(t)
+	})
+
 	t.Run("Error getting balance", func(t *testing.T) {
 		mockStorage.On("GetBalance", walletID2, userID2).Return(int64(0), errors.New("database error")).Once()
 
@@ -171,4 +267,86 @@ func TestGetBalance(t *testing.T) {
 		assert.Contains(t, err.Error(), "database error")
 		mockStorage.AssertExpectations(t)
 	})
-}
\ No newline at end of file
+}
+
+func TestGetWallet(t *testing.T) {
+	mockStorage := new(MockWalletStorage)
+	service := &walletService{storage: mockStorage, logger: log.Default()}
+
+	walletID := uuid.New().String()
+	userID := uuid.New().String()
+
+	t.Run("Successful get wallet", func(t *testing.T) {
+		mockStorage.On("GetWallet", walletID, userID).Return(&models.Wallet{ID: walletID, UserID: userID, Balance: 1050}, nil).Once()
+
+		wallet, err := service.GetWallet(walletID, userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1050), wallet.Balance)
+	})
+
+	t.Run("Wallet of another user", func(t *testing.T) {
+		mockStorage.On("GetWallet", walletID, "other").Return((*models.Wallet)(nil), sql.ErrNoRows).Once()
+
+		_, err := service.GetWallet(walletID, "other")
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestTransfer(t *testing.T) {
+	mockStorage := new(MockWalletStorage)
+	mockParental := new(MockParentalStorage)
+	service := &walletService{storage: mockStorage, parental: mockParental, notifier: NewLogApprovalNotifier(log.Default()), logger: log.Default()}
+
+	userID := uuid.New().String()
+	recipientID := uuid.New().String()
+	walletID := uuid.New().String()
+	toWalletID := uuid.New().String()
+
+	t.Run("Successful transfer", func(t *testing.T) {
+		mockStorage.On("GetWallet", walletID, userID).Return(&models.Wallet{ID: walletID, UserID: userID, Balance: 50000}, nil).Once()
+		mockStorage.On("GetWalletByID", toWalletID).Return(&models.Wallet{ID: toWalletID, UserID: recipientID, Balance: 0}, nil).Once()
+		mockStorage.On("GetMerchantCategory", toWalletID).Return("", nil).Once()
+		mockParental.On("GetParentID", userID).Return("", nil).Once()
+		mockStorage.On("Transfer", walletID, toWalletID, int64(12550), models.TransactionDetails{}).Return(&models.TransferResult{DebitTransactionID: 7, CreditTransactionID: 8, FromBalance: 37450, ToBalance: 12550}, nil).Once()
+
+		transactionID, err := service.Transfer(models.TransferRequest{WalletID: walletID, ToWalletID: toWalletID, Amount: "125.50"}, userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), transactionID)
+		mockStorage.AssertExpectations(t)
+		mockParental.AssertExpectations(t)
+	})
+
+	t.Run("Insufficient funds", func(t *testing.T) {
+		mockStorage.On("GetWallet", walletID, userID).Return(&models.Wallet{ID: walletID, UserID: userID, Balance: 100}, nil).Once()
+		mockStorage.On("GetWalletByID", toWalletID).Return(&models.Wallet{ID: toWalletID, UserID: recipientID, Balance: 0}, nil).Once()
+		mockStorage.On("GetMerchantCategory", toWalletID).Return("", nil).Once()
+		mockParental.On("GetParentID", userID).Return("", nil).Once()
+
+		_, err := service.Transfer(models.TransferRequest{WalletID: walletID, ToWalletID: toWalletID, Amount: "10.00"}, userID)
+
+		assert.ErrorIs(t, err, storage.ErrInsufficientFunds)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Recipient balance limit", func(t *testing.T) {
+		mockStorage.On("GetWallet", walletID, userID).Return(&models.Wallet{ID: walletID, UserID: userID, Balance: 50000}, nil).Once()
+		mockStorage.On("GetWalletByID", toWalletID).Return(&models.Wallet{ID: toWalletID, UserID: recipientID, Balance: models.MaxBalanceUnidentified}, nil).Once()
+		mockStorage.On("GetMerchantCategory", toWalletID).Return("", nil).Once()
+		mockParental.On("GetParentID", userID).Return("", nil).Once()
+		mockStorage.On("Transfer", walletID, toWalletID, int64(100), models.TransactionDetails{}).Return((*models.TransferResult)(nil), storage.ErrMaxBalanceExceeded).Once()
+
+		_, err := service.Transfer(models.TransferRequest{WalletID: walletID, ToWalletID: toWalletID, Amount: "1.00"}, userID)
+
+		assert.ErrorIs(t, err, ErrMaxBalanceExceeded)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Same wallet", func(t *testing.T) {
+		_, err := service.Transfer(models.TransferRequest{WalletID: walletID, ToWalletID: walletID, Amount: "1.00"}, userID)
+
+		assert.ErrorIs(t, err, ErrSameWallet)
+	})
+}
@@ -0,0 +1,395 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/rasul07/alif-task/internal/outbox"
+	"github.com/rasul07/alif-task/internal/storage"
+)
+
+// WebhookService queues deliveries of the wallet events published by the outbox
+// relay and sends them in the background
+type WebhookService interface {
+	outbox.Sink
+	CreateSubscription(request models.CreateWebhookRequest, userID string) (*models.WebhookSubscription, error)
+	ListSubscriptions(userID string) ([]models.WebhookSubscription, error)
+	DeleteSubscription(subscriptionID, userID string) error
+	ListDeliveries(subscriptionID, userID string) ([]models.WebhookDelivery, error)
+	ListDeadLetters() ([]models.WebhookDeadLetter, error)
+	ReplayDeadLetter(deadLetterID string) error
+	DispatchDue() (int, error)
+	Run(ctx context.Context)
+}
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook subscription not found")
+	ErrUnknownEventType        = errors.New("unknown event type")
+	ErrDeadLetterNotReplayable = errors.New("dead letter not found or already replayed")
+	ErrWebhookURLNotAllowed    = errors.New("webhook URL must use https and a public host")
+)
+
+// nonPublicPrefixes are shared and reserved ranges the netip predicates don't cover
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+const (
+	webhookBatchSize    = 50
+	webhookLease        = time.Minute
+	webhookPollInterval = 5 * time.Second
+	webhookListLimit    = 100
+)
+
+type webhookService struct {
+	storage storage.WebhookStorager
+	client  *http.Client
+	now     func() time.Time
+	logger  *log.Logger
+}
+
+func NewWebhookService(db *sql.DB) WebhookService {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: dialPublicOnly}
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 10 * time.Second},
+		// A redirect counts as a failed delivery, receivers answer at the URL they registered
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return &webhookService{
+		storage: storage.NewWebhookStorage(db),
+		client:  client,
+		now:     time.Now,
+		logger:  log.New(log.Writer(), "WebhookService: ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+func (s *webhookService) CreateSubscription(request models.CreateWebhookRequest, userID string) (*models.WebhookSubscription, error) {
+	s.logger.Printf("Creating webhook subscription: userID=%s, url=%s", userID, request.URL)
+	if err := checkWebhookURL(request.URL); err != nil {
+		return nil, err
+	}
+	for _, eventType := range request.EventTypes {
+		if !slices.Contains(models.WalletEventTypes, eventType) {
+			return nil, errors.Wrap(ErrUnknownEventType, eventType)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		s.logger.Printf("Error generating webhook secret: %v", err)
+		return nil, err
+	}
+
+	subscription := &models.WebhookSubscription{
+		UserID:     userID,
+		URL:        request.URL,
+		Secret:     "whsec_" + hex.EncodeToString(secret),
+		EventTypes: request.EventTypes,
+	}
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = []string{}
+	}
+	if err := s.storage.CreateSubscription(subscription); err != nil {
+		s.logger.Printf("Error creating webhook subscription: %v", err)
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (s *webhookService) ListSubscriptions(userID string) ([]models.WebhookSubscription, error) {
+	s.logger.Printf("Listing webhook subscriptions: userID=%s", userID)
+	subscriptions, err := s.storage.ListSubscriptions(userID)
+	if err != nil {
+		s.logger.Printf("Error listing webhook subscriptions: %v", err)
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+func (s *webhookService) DeleteSubscription(subscriptionID, userID string) error {
+	s.logger.Printf("Deleting webhook subscription: subscriptionID=%s, userID=%s", subscriptionID, userID)
+	deleted, err := s.storage.DeleteSubscription(subscriptionID, userID)
+	if err != nil {
+		s.logger.Printf("Error deleting webhook subscription: %v", err)
+		return err
+	}
+	if !deleted {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// ListDeliveries returns the latest deliveries of a subscription owned by the user
+func (s *webhookService) ListDeliveries(subscriptionID, userID string) ([]models.WebhookDelivery, error) {
+	s.logger.Printf("Listing webhook deliveries: subscriptionID=%s, userID=%s", subscriptionID, userID)
+	subscription, err := s.storage.GetSubscription(subscriptionID)
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		s.logger.Printf("Error getting webhook subscription: %v", err)
+		return nil, err
+	}
+	if subscription.UserID != userID {
+		return nil, ErrWebhookNotFound
+	}
+
+	deliveries, err := s.storage.ListDeliveries(subscription.ID, webhookListLimit)
+	if err != nil {
+		s.logger.Printf("Error listing webhook deliveries: %v", err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (s *webhookService) ListDeadLetters() ([]models.WebhookDeadLetter, error) {
+	s.logger.Printf("Listing webhook dead letters")
+	deadLetters, err := s.storage.ListDeadLetters(webhookListLimit)
+	if err != nil {
+		s.logger.Printf("Error listing webhook dead letters: %v", err)
+		return nil, err
+	}
+
+	return deadLetters, nil
+}
+
+// ReplayDeadLetter puts a dead delivery back into the queue with a fresh retry budget
+func (s *webhookService) ReplayDeadLetter(deadLetterID string) error {
+	s.logger.Printf("Replaying webhook dead letter: deadLetterID=%s", deadLetterID)
+	replayed, err := s.storage.ReplayDeadLetter(deadLetterID)
+	if err != nil {
+		s.logger.Printf("Error replaying webhook dead letter: %v", err)
+		return err
+	}
+	if !replayed {
+		return ErrDeadLetterNotReplayable
+	}
+
+	return nil
+}
+
+// Publish queues a delivery of the outbox event for every matching subscription of
+// the wallet owner. Sending happens in the background worker. An error makes the
+// relay hand the event over again, deliveries already queued are not repeated.
+func (s *webhookService) Publish(ctx context.Context, domainEvent models.DomainEvent) error {
+	event, ok, err := walletEvent(domainEvent)
+	if err != nil || !ok {
+		return err
+	}
+
+	subscriptions, err := s.storage.ListSubscriptionsForEvent(event.UserID, event.Type)
+	if err != nil {
+		return errors.Wrapf(err, "unable to list webhook subscriptions for event %d", domainEvent.ID)
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrapf(err, "unable to encode event %d", domainEvent.ID)
+	}
+
+	for _, subscription := range subscriptions {
+		delivery := &models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        domainEvent.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+			Status:         models.WebhookStatusPending,
+		}
+		if err := s.storage.CreateDelivery(delivery); err != nil {
+			return errors.Wrapf(err, "unable to queue webhook delivery: subscriptionID=%s, eventID=%d", subscription.ID, domainEvent.ID)
+		}
+	}
+
+	return nil
+}
+
+// Run dispatches due deliveries until ctx is cancelled
+func (s *webhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DispatchDue(); err != nil {
+				s.logger.Printf("Error dispatching webhooks: %v", err)
+			}
+		}
+	}
+}
+
+// DispatchDue sends one batch of due deliveries and returns how many were sent successfully
+func (s *webhookService) DispatchDue() (int, error) {
+	deliveries, err := s.storage.ClaimDueDeliveries(webhookBatchSize, webhookLease)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to claim webhook deliveries")
+	}
+
+	delivered := 0
+	for _, delivery := range deliveries {
+		if s.deliver(delivery) {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+// deliver makes one attempt and records the outcome: delivered, retried later with
+// exponential backoff, or moved to the dead-letter table after the last attempt
+func (s *webhookService) deliver(delivery models.WebhookDelivery) bool {
+	started := s.now()
+	statusCode, sendErr := s.send(delivery, started)
+
+	attempt := models.WebhookAttempt{
+		DeliveryID:  delivery.ID,
+		StatusCode:  statusCode,
+		DurationMs:  time.Since(started).Milliseconds(),
+		AttemptedAt: started,
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	if err := s.storage.RecordAttempt(attempt); err != nil {
+		s.logger.Printf("Error recording webhook attempt: deliveryID=%s: %v", delivery.ID, err)
+	}
+
+	delivery.Attempts++
+	if sendErr == nil {
+		if err := s.storage.MarkDelivered(delivery.ID, delivery.Attempts); err != nil {
+			s.logger.Printf("Error marking webhook delivered: deliveryID=%s: %v", delivery.ID, err)
+		}
+		return true
+	}
+
+	delivery.LastError = sendErr.Error()
+	if delivery.Attempts >= models.WebhookMaxAttempts {
+		s.logger.Printf("Webhook delivery failed for good: deliveryID=%s, attempts=%d: %v", delivery.ID, delivery.Attempts, sendErr)
+		if err := s.storage.MoveToDeadLetter(delivery); err != nil {
+			s.logger.Printf("Error dead-lettering webhook delivery: deliveryID=%s: %v", delivery.ID, err)
+		}
+		return false
+	}
+
+	nextAttemptAt := started.Add(retryBackoff(models.WebhookBaseBackoff, models.WebhookMaxBackoff, delivery.Attempts))
+	if err := s.storage.ScheduleRetry(delivery.ID, delivery.Attempts, nextAttemptAt, delivery.LastError); err != nil {
+		s.logger.Printf("Error scheduling webhook retry: deliveryID=%s: %v", delivery.ID, err)
+	}
+
+	return false
+}
+
+// send posts the payload signed with the subscription secret. The signature is the
+// HMAC-SHA256 of "<timestamp>.<body>" so receivers can reject replayed requests.
+func (s *webhookService) send(delivery models.WebhookDelivery, now time.Time) (int, error) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Id", delivery.ID)
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhook(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// SignWebhook computes the hex HMAC-SHA256 signature receivers should compare
+// against the X-Webhook-Signature header
+func SignWebhook(secret, timestamp string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// checkWebhookURL accepts https URLs only. Hosts given as an address must be public,
+// names are checked once resolved, when a delivery connects.
+func checkWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return ErrWebhookURLNotAllowed
+	}
+
+	if ip, err := netip.ParseAddr(u.Hostname()); err == nil && !isPublicAddress(ip) {
+		return ErrWebhookURLNotAllowed
+	}
+
+	return nil
+}
+
+// dialPublicOnly refuses to connect to our own network. It runs on the resolved
+// address, so a public name pointing to a private address is refused too.
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !isPublicAddress(addrPort.Addr()) {
+		return errors.Wrap(ErrWebhookURLNotAllowed, address)
+	}
+	return nil
+}
+
+func isPublicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rasul07/alif-task/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock implementation of WebhookStorage
+type MockWebhookStorage struct {
+	mock.Mock
+}
+
+func (m *MockWebhookStorage) CreateSubscription(subscription *models.WebhookSubscription) error {
+	args := m.Called(subscription)
+	return args.Error(0)
+}
+
+func (m *MockWebhookStorage) GetSubscription(subscriptionID string) (*models.WebhookSubscription, error) {
+	args := m.Called(subscriptionID)
+	return args.Get(0).(*models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookStorage) ListSubscriptions(userID string) ([]models.WebhookSubscription, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookStorage) ListSubscriptionsForEvent(userID, eventType string) ([]models.WebhookSubscription, error) {
+	args := m.Called(userID, eventType)
+	return args.Get(0).([]models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookStorage) DeleteSubscription(subscriptionID, userID string) (bool, error) {
+	args := m.Called(subscriptionID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWebhookStorage) CreateDelivery(delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookStorage) ClaimDueDeliveries(limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	args := m.Called(limit, lease)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookStorage) RecordAttempt(attempt models.WebhookAttempt) error {
+	args := m.Called(attempt)
+	return args.Error(0)
+}
+
+func (m *MockWebhookStorage) MarkDelivered(deliveryID string, attempts int) error {
+	args := m.Called(deliveryID, attempts)
+	return args.Error(0)
+}
+
+func (m *MockWebhookStorage) ScheduleRetry(deliveryID string, attempts int, nextAttemptAt time.Time, lastError string) error {
+	args := m.Called(deliveryID, attempts, nextAttemptAt, lastError)
+	return args.Error(0)
+}
+
+func (m *MockWebhookStorage) MoveToDeadLetter(delivery models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookStorage) ListDeliveries(subscriptionID string, limit int) ([]models.WebhookDelivery, error) {
+	args := m.Called(subscriptionID, limit)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookStorage) ListDeadLetters(limit int) ([]models.WebhookDeadLetter, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]models.WebhookDeadLetter), args.Error(1)
+}
+
+func (m *MockWebhookStorage) ReplayDeadLetter(deadLetterID string) (bool, error) {
+	args := m.Called(deadLetterID)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestWebhookCreateSubscription(t *testing.T) {
+	mockStorage := new(MockWebhookStorage)
+	service := &webhookService{storage: mockStorage, logger: log.Default()}
+
+	t.Run("Public https URL", func(t *testing.T) {
+		mockStorage.On("CreateSubscription", mock.AnythingOfType("*models.WebhookSubscription")).Return(nil).Once()
+
+		subscription, err := service.CreateSubscription(models.CreateWebhookRequest{URL: "https://partner.example.com/hooks"}, "user-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "https://partner.example.com/hooks", subscription.URL)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Not allowed URLs", func(t *testing.T) {
+		for _, url := range []string{
+			"http://partner.example.com/hooks",
+			"https://127.0.0.1/hooks",
+			"https://10.0.0.5/hooks",
+			"https://169.254.169.254/latest/meta-data",
+			"https://[::1]:8443/hooks",
+			"https:///hooks",
+		} {
+			_, err := service.CreateSubscription(models.CreateWebhookRequest{URL: url}, "user-1")
+			assert.ErrorIs(t, err, ErrWebhookURLNotAllowed, url)
+		}
+		mockStorage.AssertNumberOfCalls(t, "CreateSubscription", 1)
+	})
+}
+
+func TestWebhookDialPublicOnly(t *testing.T) {
+	assert.NoError(t, dialPublicOnly("tcp4", "93.184.216.34:443", nil))
+	assert.NoError(t, dialPublicOnly("tcp6", "[2606:2800:220:1::1]:443", nil))
+	for _, address := range []string{"127.0.0.1:443", "10.1.2.3:443", "172.16.0.1:443", "192.168.1.1:443",
+		"169.254.169.254:80", "100.64.0.1:443", "0.0.0.0:443", "[::1]:443", "[fe80::1]:443", "[fd00::1]:443", "[::ffff:127.0.0.1]:443"} {
+		assert.ErrorIs(t, dialPublicOnly("tcp", address, nil), ErrWebhookURLNotAllowed, address)
+	}
+}
+
+func TestWebhookPublish(t *testing.T) {
+	credited := models.DomainEvent{
+		ID:          42,
+		AggregateID: "wallet-1",
+		Type:        models.DomainEventWalletCredited,
+		Payload:     json.RawMessage(`{"wallet_id":"wallet-1","user_id":"user-1","amount":500,"balance":1500}`),
+	}
+
+	t.Run("Queued for every subscription", func(t *testing.T) {
+		mockStorage := new(MockWebhookStorage)
+		service := &webhookService{storage: mockStorage, logger: log.Default()}
+
+		mockStorage.On("ListSubscriptionsForEvent", "user-1", models.EventWalletCredited).
+			Return([]models.WebhookSubscription{{ID: "sub-1"}, {ID: "sub-2"}}, nil).Once()
+		mockStorage.On("CreateDelivery", mock.MatchedBy(func(d *models.WebhookDelivery) bool {
+			var payload models.WalletEvent
+			return json.Unmarshal(d.Payload, &payload) == nil && payload.Balance == 1500 && d.EventID == 42 &&
+				d.EventType == models.EventWalletCredited && d.Status == models.WebhookStatusPending
+		})).Return(nil).Twice()
+
+		err := service.Publish(context.Background(), credited)
+
+		assert.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Debit amount is sent positive", func(t *testing.T) {
+		mockStorage := new(MockWebhookStorage)
+		service := &webhookService{storage: mockStorage, logger: log.Default()}
+
+		debited := models.DomainEvent{
+			ID:      43,
+			Type:    models.DomainEventWalletDebited,
+			Payload: json.RawMessage(`{"wallet_id":"wallet-1","user_id":"user-1","amount":-500,"balance":1000}`),
+		}
+		mockStorage.On("ListSubscriptionsForEvent", "user-1", models.EventWalletDebited).
+			Return([]models.WebhookSubscription{{ID: "sub-1"}}, nil).Once()
+		mockStorage.On("CreateDelivery", mock.MatchedBy(func(d *models.WebhookDelivery) bool {
+			var payload models.WalletEvent
+			return json.Unmarshal(d.Payload, &payload) == nil && payload.Amount == 500
+		})).Return(nil).Once()
+
+		err := service.Publish(context.Background(), debited)
+
+		assert.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Storage error is retried by the relay", func(t *testing.T) {
+		mockStorage := new(MockWebhookStorage)
+		service := &webhookService{storage: mockStorage, logger: log.Default()}
+
+		mockStorage.On("ListSubscriptionsForEvent", "user-1", models.EventWalletCredited).
+			Return([]models.WebhookSubscription{{ID: "sub-1"}}, nil).Once()
+		mockStorage.On("CreateDelivery", mock.Anything).Return(assert.AnError).Once()
+
+		err := service.Publish(context.Background(), credited)
+
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("Other events are skipped", func(t *testing.T) {
+		mockStorage := new(MockWebhookStorage)
+		service := &webhookService{storage: mockStorage, logger: log.Default()}
+
+		err := service.Publish(context.Background(), models.DomainEvent{ID: 44, Type: models.DomainEventWalletCreated, Payload: json.RawMessage(`{}`)})
+
+		assert.NoError(t, err)
+		mockStorage.AssertNotCalled(t, "ListSubscriptionsForEvent", mock.Anything, mock.Anything)
+	})
+}
+
+func TestWebhookDispatchDue(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	payload := json.RawMessage(`{"type":"wallet.debited","amount":250}`)
+
+	var received *http.Request
+	var receivedBody []byte
+	status := http.StatusOK
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+
+	newService := func() (*webhookService, *MockWebhookStorage) {
+		mockStorage := new(MockWebhookStorage)
+		mockStorage.On("RecordAttempt", mock.Anything).Return(nil)
+		return &webhookService{
+			storage: mockStorage,
+			client:  receiver.Client(),
+			now:     func() time.Time { return now },
+			logger:  log.Default(),
+		}, mockStorage
+	}
+	delivery := models.WebhookDelivery{
+		ID:        "delivery-1",
+		EventType: models.EventWalletDebited,
+		Payload:   payload,
+		URL:       receiver.URL,
+		Secret:    "whsec_test",
+	}
+
+	t.Run("Signed delivery", func(t *testing.T) {
+		service, mockStorage := newService()
+		status = http.StatusOK
+		mockStorage.On("ClaimDueDeliveries", webhookBatchSize, webhookLease).Return([]models.WebhookDelivery{delivery}, nil).Once()
+		mockStorage.On("MarkDelivered", "delivery-1", 1).Return(nil).Once()
+
+		delivered, err := service.DispatchDue()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+		assert.Equal(t, string(payload), string(receivedBody))
+		assert.Equal(t, models.EventWalletDebited, received.Header.Get("X-Webhook-Event"))
+		assert.Equal(t, "1714564800", received.Header.Get("X-Webhook-Timestamp"))
+		assert.Equal(t, "sha256="+SignWebhook("whsec_test", "1714564800", payload), received.Header.Get("X-Webhook-Signature"))
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Failure schedules retry with backoff", func(t *testing.T) {
+		service, mockStorage := newService()
+		status = http.StatusInternalServerError
+		failed := delivery
+		failed.Attempts = 2
+		mockStorage.On("ClaimDueDeliveries", webhookBatchSize, webhookLease).Return([]models.WebhookDelivery{failed}, nil).Once()
+		mockStorage.On("ScheduleRetry", "delivery-1", 3, now.Add(2*time.Minute), "receiver responded with status 500").Return(nil).Once()
+
+		delivered, err := service.DispatchDue()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Last failure goes to dead letters", func(t *testing.T) {
+		service, mockStorage := newService()
+		status = http.StatusBadGateway
+		failed := delivery
+		failed.Attempts = models.WebhookMaxAttempts - 1
+		mockStorage.On("ClaimDueDeliveries", webhookBatchSize, webhookLease).Return([]models.WebhookDelivery{failed}, nil).Once()
+		mockStorage.On("MoveToDeadLetter", mock.MatchedBy(func(d models.WebhookDelivery) bool {
+			return d.ID == "delivery-1" && d.Attempts == models.WebhookMaxAttempts && d.LastError == "receiver responded with status 502"
+		})).Return(nil).Once()
+
+		_, err := service.DispatchDue()
+
+		assert.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+		mockStorage.AssertNotCalled(t, "ScheduleRetry", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestWebhookReplayDeadLetter(t *testing.T) {
+	mockStorage := new(MockWebhookStorage)
+	service := &webhookService{storage: mockStorage, logger: log.Default()}
+
+	t.Run("Replayed", func(t *testing.T) {
+		mockStorage.On("ReplayDeadLetter", "dl-1").Return(true, nil).Once()
+		assert.NoError(t, service.ReplayDeadLetter("dl-1"))
+	})
+
+	t.Run("Already replayed", func(t *testing.T) {
+		mockStorage.On("ReplayDeadLetter", "dl-2").Return(false, nil).Once()
+		assert.ErrorIs(t, service.ReplayDeadLetter("dl-2"), ErrDeadLetterNotReplayable)
+	})
+}
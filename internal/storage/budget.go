@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type BudgetStorager interface {
+	SetBudget(budget *models.Budget) error
+	GetBudgetByCategory(userID, category string) (*models.Budget, error)
+	ListProgress(userID, period string, from, to time.Time) ([]models.BudgetProgress, error)
+	DeleteBudget(budgetID, userID string) (bool, error)
+	GetSpent(userID, category string, from, to time.Time) (int64, error)
+	RecordAlert(alert *models.BudgetAlert) (bool, error)
+}
+
+type BudgetStorage struct {
+	db *sql.DB
+}
+
+func NewBudgetStorage(db *sql.DB) *BudgetStorage {
+	return &BudgetStorage{db: db}
+}
+
+const budgetColumns = `id, user_id, category, amount, created_at, updated_at`
+
+func scanBudget(row interface{ Scan(...any) error }, b *models.Budget) error {
+	return row.Scan(&b.ID, &b.UserID, &b.Category, &b.Amount, &b.CreatedAt, &b.UpdatedAt)
+}
+
+// SetBudget creates the budget of the category or changes the amount of the existing one
+func (s *BudgetStorage) SetBudget(budget *models.Budget) error {
+	return scanBudget(s.db.QueryRow(`
+		INSERT INTO budgets (user_id, category, amount) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, category) DO UPDATE SET amount=EXCLUDED.amount, updated_at=CURRENT_TIMESTAMP
+		RETURNING `+budgetColumns,
+		budget.UserID, budget.Category, budget.Amount), budget)
+}
+
+func (s *BudgetStorage) GetBudgetByCategory(userID, category string) (*models.Budget, error) {
+	budget := &models.Budget{}
+	err := scanBudget(s.db.QueryRow("SELECT "+budgetColumns+" FROM budgets WHERE user_id=$1 AND category=$2", userID, category), budget)
+	if err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// ListProgress returns every budget of the user with what was spent in the
+// category between from and to and the thresholds alerted for the period
+func (s *BudgetStorage) ListProgress(userID, period string, from, to time.Time) ([]models.BudgetProgress, error) {
+	rows, err := s.db.Query(`
+		SELECT b.id, b.user_id, b.category, b.amount, b.created_at, b.updated_at,
+			COALESCE((
+				SELECT SUM(-t.amount) FROM transactions t JOIN wallets w ON t.wallet_id = w.id
+				WHERE w.user_id = b.user_id AND t.category = b.category AND t.amount < 0 AND t.status = $4
+					AND t.completed_at >= $2 AND t.completed_at < $3
+			), 0),
+			ARRAY(SELECT threshold FROM budget_alerts a WHERE a.budget_id = b.id AND a.period = $5 ORDER BY threshold)
+		FROM budgets b WHERE b.user_id=$1 ORDER BY b.category
+	`, userID, from, to, models.TransactionCompleted, period)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	progress := []models.BudgetProgress{}
+	for rows.Next() {
+		item := models.BudgetProgress{Period: period}
+		var alerts pq.Int64Array
+		b := &item.Budget
+		if err := rows.Scan(&b.ID, &b.UserID, &b.Category, &b.Amount, &b.CreatedAt, &b.UpdatedAt, &item.Spent, &alerts); err != nil {
+			return nil, err
+		}
+		item.Alerts = make([]int, len(alerts))
+		for i, threshold := range alerts {
+			item.Alerts[i] = int(threshold)
+		}
+		progress = append(progress, item)
+	}
+
+	return progress, rows.Err()
+}
+
+func (s *BudgetStorage) DeleteBudget(budgetID, userID string) (bool, error) {
+	res, err := s.db.Exec("DELETE FROM budgets WHERE id=$1 AND user_id=$2", budgetID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
+
+// GetSpent sums the completed debits of the category from all wallets of the user
+// between from and to. Reversed debits don't count.
+func (s *BudgetStorage) GetSpent(userID, category string, from, to time.Time) (int64, error) {
+	var spent int64
+	err := s.db.QueryRow(`
+		SELECT COALESCE(SUM(-t.amount), 0)
+		FROM transactions t
+		JOIN wallets w ON t.wallet_id = w.id
+		WHERE w.user_id=$1 AND t.category=$2 AND t.amount < 0 AND t.status=$3
+			AND t.completed_at >= $4 AND t.completed_at < $5
+	`, userID, category, models.TransactionCompleted, from, to).Scan(&spent)
+	return spent, err
+}
+
+// RecordAlert saves the alert unless the threshold already alerted in the period,
+// it reports whether the alert is new
+func (s *BudgetStorage) RecordAlert(alert *models.BudgetAlert) (bool, error) {
+	err := s.db.QueryRow(`
+		INSERT INTO budget_alerts (budget_id, period, threshold, spent) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (budget_id, period, threshold) DO NOTHING
+		RETURNING id, created_at
+	`, alert.BudgetID, alert.Period, alert.Threshold, alert.Spent).Scan(&alert.ID, &alert.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -2,7 +2,9 @@ package storage
 
 import (
 	"database/sql"
-	_ "github.com/lib/pq"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
 )
 
 func NewPostgresDB(dbURL string) (*sql.DB, error) {
@@ -17,3 +19,9 @@ func NewPostgresDB(dbURL string) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// IsUniqueViolation reports whether err was caused by a unique constraint
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type EscrowStorager interface {
+	GetEscrowWallet() (string, error)
+	CreateEscrow(escrow *models.Escrow) error
+	HoldEscrow(escrowID, buyerWalletID string) TransferHook
+	FailEscrow(escrowID string) (bool, error)
+	GetEscrow(escrowID string) (*models.Escrow, error)
+	ListEscrows(userID string) ([]models.Escrow, error)
+	ListDueEscrows(now time.Time) ([]models.Escrow, error)
+	DisputeEscrow(escrowID, reason string) (bool, error)
+	SettleEscrow(escrowID, fromStatus, toStatus string, sellerAmount int64, resolution string) (bool, error)
+}
+
+type EscrowStorage struct {
+	db *sql.DB
+}
+
+func NewEscrowStorage(db *sql.DB) *EscrowStorage {
+	return &EscrowStorage{db: db}
+}
+
+// EscrowReferencePrefix marks the ledger transactions of an escrow, followed by its id
+const EscrowReferencePrefix = "escrow:"
+
+// ErrEscrowNotPending is returned when the buyer pays for an escrow that was
+// already paid or has failed
+var ErrEscrowNotPending = errors.New("escrow is no longer waiting for payment")
+
+const escrowColumns = `id, buyer_id, buyer_wallet_id, seller_id, seller_wallet_id, amount, description, reference, status,
+	released_amount, refunded_amount, COALESCE(hold_transaction_id, 0), COALESCE(release_transaction_id, 0),
+	COALESCE(refund_transaction_id, 0), dispute_reason, resolution, release_at, created_at, updated_at, settled_at`
+
+func scanEscrow(row interface{ Scan(...any) error }, e *models.Escrow) error {
+	return row.Scan(&e.ID, &e.BuyerID, &e.BuyerWalletID, &e.SellerID, &e.SellerWalletID, &e.Amount, &e.Description,
+		&e.Reference, &e.Status, &e.ReleasedAmount, &e.RefundedAmount, &e.HoldTransactionID, &e.ReleaseTransactionID,
+		&e.RefundTransactionID, &e.DisputeReason, &e.Resolution, &e.ReleaseAt, &e.CreatedAt, &e.UpdatedAt, &e.SettledAt)
+}
+
+// GetEscrowWallet returns the id of the system wallet the buyers pay into
+func (s *EscrowStorage) GetEscrowWallet() (string, error) {
+	return systemWallet(s.db, models.SystemWalletEscrow)
+}
+
+// CreateEscrow saves the escrow before the buyer pays into it
+func (s *EscrowStorage) CreateEscrow(escrow *models.Escrow) error {
+	err := s.db.QueryRow(`
+		INSERT INTO escrows (buyer_id, buyer_wallet_id, seller_id, seller_wallet_id, amount, description, reference, status, release_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at, updated_at
+	`, escrow.BuyerID, escrow.BuyerWalletID, escrow.SellerID, escrow.SellerWalletID, escrow.Amount, escrow.Description,
+		escrow.Reference, escrow.Status, escrow.ReleaseAt).Scan(&escrow.ID, &escrow.CreatedAt, &escrow.UpdatedAt)
+	return errors.Wrap(err, "unable to create escrow")
+}
+
+// HoldEscrow returns a hook that marks the pending escrow as held by the transfer
+// paying for it
+func (s *EscrowStorage) HoldEscrow(escrowID, buyerWalletID string) TransferHook {
+	return func(tx *sql.Tx, result *models.TransferResult) error {
+		res, err := tx.Exec(`
+			UPDATE escrows SET status=$1, hold_transaction_id=$2, updated_at=CURRENT_TIMESTAMP
+			WHERE id=$3 AND buyer_wallet_id=$4 AND status=$5
+		`, models.EscrowHeld, result.DebitTransactionID, escrowID, buyerWalletID, models.EscrowPending)
+		if err != nil {
+			return errors.Wrap(err, "unable to hold escrow")
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrEscrowNotPending
+		}
+		return nil
+	}
+}
+
+// FailEscrow marks a pending escrow whose payment didn't go through, false means
+// it wasn't pending
+func (s *EscrowStorage) FailEscrow(escrowID string) (bool, error) {
+	res, err := s.db.Exec("UPDATE escrows SET status=$1, updated_at=CURRENT_TIMESTAMP WHERE id=$2 AND status=$3",
+		models.EscrowFailed, escrowID, models.EscrowPending)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
+
+func (s *EscrowStorage) GetEscrow(escrowID string) (*models.Escrow, error) {
+	escrow := &models.Escrow{}
+	if err := scanEscrow(s.db.QueryRow("SELECT "+escrowColumns+" FROM escrows WHERE id=$1", escrowID), escrow); err != nil {
+		return nil, err
+	}
+	return escrow, nil
+}
+
+// ListEscrows returns the escrows the user buys or sells in, newest first
+func (s *EscrowStorage) ListEscrows(userID string) ([]models.Escrow, error) {
+	return s.listEscrows("SELECT "+escrowColumns+" FROM escrows WHERE buyer_id=$1 OR seller_id=$1 ORDER BY created_at DESC", userID)
+}
+
+// ListDueEscrows returns the held escrows whose release time has passed
+func (s *EscrowStorage) ListDueEscrows(now time.Time) ([]models.Escrow, error) {
+	return s.listEscrows("SELECT "+escrowColumns+" FROM escrows WHERE status=$1 AND release_at <= $2 ORDER BY release_at",
+		models.EscrowHeld, now)
+}
+
+func (s *EscrowStorage) listEscrows(query string, args ...any) ([]models.Escrow, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	escrows := []models.Escrow{}
+	for rows.Next() {
+		var escrow models.Escrow
+		if err := scanEscrow(rows, &escrow); err != nil {
+			return nil, err
+		}
+		escrows = append(escrows, escrow)
+	}
+
+	return escrows, rows.Err()
+}
+
+// DisputeEscrow stops a held escrow from being settled until an admin resolves it
+func (s *EscrowStorage) DisputeEscrow(escrowID, reason string) (bool, error) {
+	res, err := s.db.Exec(`
+		UPDATE escrows SET status=$1, dispute_reason=$2, updated_at=CURRENT_TIMESTAMP
+		WHERE id=$3 AND status=$4
+	`, models.EscrowDisputed, reason, escrowID, models.EscrowHeld)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
+
+// SettleEscrow moves the escrow from fromStatus to toStatus and pays sellerAmount
+// to the seller and the rest back to the buyer, all in one database transaction.
+// False means the escrow wasn't in fromStatus.
+func (s *EscrowStorage) SettleEscrow(escrowID, fromStatus, toStatus string, sellerAmount int64, resolution string) (bool, error) {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return false, errors.Wrap(err, "unable to begin transaction to settle escrow")
+	}
+
+	settled, err := settleEscrow(tx, escrowID, fromStatus, toStatus, sellerAmount, resolution)
+	if err != nil || !settled {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return false, errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, errors.Wrap(err, "unable to commit transaction")
+	}
+
+	return true, nil
+}
+
+func settleEscrow(tx *sql.Tx, escrowID, fromStatus, toStatus string, sellerAmount int64, resolution string) (bool, error) {
+	escrow := &models.Escrow{}
+	err := scanEscrow(tx.QueryRow(`
+		UPDATE escrows SET status=$1, released_amount=$2, refunded_amount=amount-$2, resolution=$3,
+			settled_at=CURRENT_TIMESTAMP, updated_at=CURRENT_TIMESTAMP
+		WHERE id=$4 AND status=$5 AND amount >= $2
+		RETURNING `+escrowColumns, toStatus, sellerAmount, resolution, escrowID, fromStatus), escrow)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "unable to update escrow")
+	}
+
+	escrowWalletID, err := systemWallet(tx, models.SystemWalletEscrow)
+	if err != nil {
+		return false, err
+	}
+
+	if escrow.ReleasedAmount > 0 {
+		result, err := transfer(tx, escrowWalletID, escrow.SellerWalletID, escrow.ReleasedAmount, models.TransactionDetails{
+			Reference:   EscrowReferencePrefix + escrow.ID,
+			Description: EscrowDescription("Escrow release", escrow.Description),
+		})
+		if err != nil {
+			return false, err
+		}
+		escrow.ReleaseTransactionID = result.CreditTransactionID
+	}
+
+	if escrow.RefundedAmount > 0 {
+		result, err := refund(tx, escrowWalletID, escrow.BuyerWalletID, escrow.RefundedAmount, models.TransactionDetails{
+			Reference:   EscrowReferencePrefix + escrow.ID,
+			Description: EscrowDescription("Escrow refund", escrow.Description),
+		})
+		if err != nil {
+			return false, err
+		}
+		escrow.RefundTransactionID = result.CreditTransactionID
+	}
+
+	_, err = tx.Exec("UPDATE escrows SET release_transaction_id=NULLIF($1, 0), refund_transaction_id=NULLIF($2, 0) WHERE id=$3",
+		escrow.ReleaseTransactionID, escrow.RefundTransactionID, escrow.ID)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to link settlement transactions")
+	}
+
+	return true, nil
+}
+
+// systemWallet returns the id of the named system wallet
+func systemWallet(q interface {
+	QueryRow(query string, args ...any) *sql.Row
+}, name string) (string, error) {
+	var walletID string
+	err := q.QueryRow("SELECT wallet_id FROM system_wallets WHERE name=$1", name).Scan(&walletID)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to find %s system wallet", name)
+	}
+	return walletID, nil
+}
+
+// EscrowDescription is the description of the ledger transactions of an escrow
+func EscrowDescription(prefix, description string) string {
+	if description == "" {
+		return prefix
+	}
+	runes := []rune(prefix + ": " + description)
+	if len(runes) > 255 {
+		runes = runes[:255]
+	}
+	return string(runes)
+}
@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type LoyaltyStorager interface {
+	CreateRule(rule *models.LoyaltyRule) error
+	ListRules() ([]models.LoyaltyRule, error)
+	ListActiveRules(eventType string) ([]models.LoyaltyRule, error)
+	DisableRule(ruleID string) (bool, error)
+	Earn(entry *models.LoyaltyEntry) (bool, error)
+	GetBalance(userID string, now, soon time.Time) (*models.LoyaltyBalance, error)
+	ListEntries(userID string, beforeID int64, limit int) ([]models.LoyaltyEntry, error)
+	RedeemPoints(redemption *models.LoyaltyRedemption, now time.Time) TransferHook
+	ExpirePoints(now time.Time) (int64, error)
+}
+
+// ErrInsufficientPoints is returned when the unexpired points can't cover a redemption
+var ErrInsufficientPoints = errors.New("insufficient loyalty points")
+
+type LoyaltyStorage struct {
+	db *sql.DB
+}
+
+func NewLoyaltyStorage(db *sql.DB) *LoyaltyStorage {
+	return &LoyaltyStorage{db: db}
+}
+
+const loyaltyRuleColumns = `id, event_type, points_per_unit, min_amount, active, created_at`
+
+const loyaltyEntryColumns = `id, user_id, kind, points, remaining, expires_at, COALESCE(lot_id, 0), COALESCE(rule_id::text, ''),
+	COALESCE(wallet_id::text, ''), COALESCE(transaction_id, 0), COALESCE(redemption_id::text, ''), created_at`
+
+func scanLoyaltyRule(row interface{ Scan(...any) error }, r *models.LoyaltyRule) error {
+	return row.Scan(&r.ID, &r.EventType, &r.PointsPerUnit, &r.MinAmount, &r.Active, &r.CreatedAt)
+}
+
+func scanLoyaltyEntry(row interface{ Scan(...any) error }, e *models.LoyaltyEntry) error {
+	return row.Scan(&e.ID, &e.UserID, &e.Kind, &e.Points, &e.Remaining, &e.ExpiresAt, &e.LotID, &e.RuleID,
+		&e.WalletID, &e.TransactionID, &e.RedemptionID, &e.CreatedAt)
+}
+
+func (s *LoyaltyStorage) CreateRule(rule *models.LoyaltyRule) error {
+	return s.db.QueryRow(`
+		INSERT INTO loyalty_rules (event_type, points_per_unit, min_amount, active)
+		VALUES ($1, $2, $3, $4) RETURNING id, created_at
+	`, rule.EventType, rule.PointsPerUnit, rule.MinAmount, rule.Active).Scan(&rule.ID, &rule.CreatedAt)
+}
+
+func (s *LoyaltyStorage) ListRules() ([]models.LoyaltyRule, error) {
+	return s.queryRules("SELECT " + loyaltyRuleColumns + " FROM loyalty_rules ORDER BY created_at")
+}
+
+func (s *LoyaltyStorage) ListActiveRules(eventType string) ([]models.LoyaltyRule, error) {
+	return s.queryRules("SELECT "+loyaltyRuleColumns+" FROM loyalty_rules WHERE event_type=$1 AND active", eventType)
+}
+
+func (s *LoyaltyStorage) queryRules(query string, args ...any) ([]models.LoyaltyRule, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.LoyaltyRule{}
+	for rows.Next() {
+		var rule models.LoyaltyRule
+		if err := scanLoyaltyRule(rows, &rule); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// DisableRule stops the rule from awarding points, points it already awarded are kept
+func (s *LoyaltyStorage) DisableRule(ruleID string) (bool, error) {
+	res, err := s.db.Exec("UPDATE loyalty_rules SET active=FALSE WHERE id=$1 AND active", ruleID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
+
+// Earn adds a lot of points. A transaction earns once per rule, Earn reports false
+// when the points were already awarded.
+func (s *LoyaltyStorage) Earn(entry *models.LoyaltyEntry) (bool, error) {
+	err := s.db.QueryRow(`
+		INSERT INTO loyalty_entries (user_id, kind, points, remaining, expires_at, rule_id, wallet_id, transaction_id)
+		VALUES ($1, $2, $3, $3, $4, $5, $6, NULLIF($7, 0))
+		ON CONFLICT (transaction_id, rule_id) WHERE kind = 'earn' DO NOTHING
+		RETURNING id, created_at
+	`, entry.UserID, models.LoyaltyEntryEarn, entry.Points, entry.ExpiresAt, entry.RuleID, entry.WalletID, entry.TransactionID).Scan(&entry.ID, &entry.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	entry.Kind = models.LoyaltyEntryEarn
+	entry.Remaining = entry.Points
+	return true, nil
+}
+
+// GetBalance sums the unexpired points of the user. ExpiringPoints are those
+// expiring before soon.
+func (s *LoyaltyStorage) GetBalance(userID string, now, soon time.Time) (*models.LoyaltyBalance, error) {
+	balance := &models.LoyaltyBalance{}
+	err := s.db.QueryRow(`
+		SELECT COALESCE(SUM(remaining), 0), COALESCE(SUM(remaining) FILTER (WHERE expires_at <= $3), 0), MIN(expires_at)
+		FROM loyalty_entries WHERE user_id=$1 AND kind=$4 AND remaining > 0 AND expires_at > $2
+	`, userID, now, soon, models.LoyaltyEntryEarn).Scan(&balance.Points, &balance.ExpiringPoints, &balance.NextExpiryAt)
+	if err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+func (s *LoyaltyStorage) ListEntries(userID string, beforeID int64, limit int) ([]models.LoyaltyEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT `+loyaltyEntryColumns+` FROM loyalty_entries
+		WHERE user_id=$1 AND ($2 = 0 OR id < $2) ORDER BY id DESC LIMIT $3
+	`, userID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.LoyaltyEntry{}
+	for rows.Next() {
+		var entry models.LoyaltyEntry
+		if err := scanLoyaltyEntry(rows, &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// RedeemPoints takes the points from the lots expiring first and records the
+// redemption as completed by the credit paying it, in the transaction of that
+// credit. The lots are locked, concurrent redemptions of a user wait for each other
+// and can't spend the same points. The redemption id is set by the caller, it is
+// the reference of the credit.
+func (s *LoyaltyStorage) RedeemPoints(redemption *models.LoyaltyRedemption, now time.Time) TransferHook {
+	return func(tx *sql.Tx, result *models.TransferResult) error {
+		redemption.Status = models.LoyaltyRedemptionCompleted
+		redemption.TransactionID = result.CreditTransactionID
+		redemption.CompletedAt = &now
+		err := redeemPoints(tx, redemption, now)
+		if err == ErrInsufficientPoints {
+			return err
+		}
+		if err != nil {
+			return errors.Wrap(err, "unable to redeem points")
+		}
+		return nil
+	}
+}
+
+type loyaltyAllocation struct {
+	lotID  int64
+	points int64
+}
+
+func redeemPoints(tx *sql.Tx, redemption *models.LoyaltyRedemption, now time.Time) error {
+	rows, err := tx.Query(`
+		SELECT id, remaining FROM loyalty_entries
+		WHERE user_id=$1 AND kind=$2 AND remaining > 0 AND expires_at > $3
+		ORDER BY expires_at, id FOR UPDATE
+	`, redemption.UserID, models.LoyaltyEntryEarn, now)
+	if err != nil {
+		return err
+	}
+
+	var allocations []loyaltyAllocation
+	left := redemption.Points
+	for rows.Next() && left > 0 {
+		var lot loyaltyAllocation
+		if err := rows.Scan(&lot.lotID, &lot.points); err != nil {
+			rows.Close()
+			return err
+		}
+		lot.points = min(lot.points, left)
+		left -= lot.points
+		allocations = append(allocations, lot)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if left > 0 {
+		return ErrInsufficientPoints
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO loyalty_redemptions (id, user_id, wallet_id, points, amount, status, transaction_id, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING created_at
+	`, redemption.ID, redemption.UserID, redemption.WalletID, redemption.Points, redemption.Amount, redemption.Status,
+		redemption.TransactionID, redemption.CompletedAt).Scan(&redemption.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, allocation := range allocations {
+		if _, err := tx.Exec("UPDATE loyalty_entries SET remaining = remaining - $1 WHERE id=$2", allocation.points, allocation.lotID); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			INSERT INTO loyalty_entries (user_id, kind, points, lot_id, wallet_id, redemption_id)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, redemption.UserID, models.LoyaltyEntryRedeem, -allocation.points, allocation.lotID, redemption.WalletID, redemption.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExpirePoints writes off what is left of the lots expired by now and returns the
+// number of points expired
+func (s *LoyaltyStorage) ExpirePoints(now time.Time) (int64, error) {
+	var expired int64
+	err := s.db.QueryRow(`
+		WITH lots AS (
+			UPDATE loyalty_entries e SET remaining = 0
+			FROM (
+				SELECT id, remaining FROM loyalty_entries
+				WHERE kind=$1 AND remaining > 0 AND expires_at <= $2 FOR UPDATE
+			) lot
+			WHERE e.id = lot.id
+			RETURNING e.id, e.user_id, lot.remaining
+		), entries AS (
+			INSERT INTO loyalty_entries (user_id, kind, points, lot_id)
+			SELECT user_id, $3, -remaining, id FROM lots RETURNING points
+		)
+		SELECT COALESCE(-SUM(points), 0) FROM entries
+	`, models.LoyaltyEntryEarn, now, models.LoyaltyEntryExpire).Scan(&expired)
+	return expired, err
+}
@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type MerchantStorager interface {
+	CreateMerchant(merchant *models.Merchant) error
+	GetMerchant(merchantID string) (*models.Merchant, error)
+	GetMerchantByAPIKey(apiKey string) (*models.Merchant, error)
+	UpdateCredentials(merchantID, apiKey, apiSecret string) error
+	CreateOrder(order *models.MerchantOrder) error
+	GetOrder(orderID string) (*models.MerchantOrder, error)
+	UpdateOrderStatus(orderID, fromStatus, toStatus, details string) (bool, error)
+	CompleteOrder(orderID, customerWalletID, details string) TransferHook
+	ListOrderEvents(orderID string) ([]models.MerchantOrderEvent, error)
+}
+
+// ErrOrderNotPayable is returned when the order was paid, cancelled or has expired
+// before the transfer paying it committed
+var ErrOrderNotPayable = errors.New("order can no longer be paid")
+
+type MerchantStorage struct {
+	db *sql.DB
+}
+
+func NewMerchantStorage(db *sql.DB) *MerchantStorage {
+	return &MerchantStorage{db: db}
+}
+
+const merchantColumns = `id, owner_id, legal_name, tax_id, legal_address, settlement_wallet_id, merchant_category, api_key, api_secret, created_at`
+
+func scanMerchant(row *sql.Row) (*models.Merchant, error) {
+	merchant := &models.Merchant{}
+	err := row.Scan(&merchant.ID, &merchant.OwnerID, &merchant.LegalName, &merchant.TaxID, &merchant.LegalAddress,
+		&merchant.SettlementWalletID, &merchant.MerchantCategory, &merchant.APIKey, &merchant.APISecret, &merchant.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return merchant, nil
+}
+
+func (s *MerchantStorage) CreateMerchant(merchant *models.Merchant) error {
+	return s.db.QueryRow(`
+		INSERT INTO merchants (owner_id, legal_name, tax_id, legal_address, settlement_wallet_id, merchant_category, api_key, api_secret)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at
+	`, merchant.OwnerID, merchant.LegalName, merchant.TaxID, merchant.LegalAddress, merchant.SettlementWalletID,
+		merchant.MerchantCategory, merchant.APIKey, merchant.APISecret).Scan(&merchant.ID, &merchant.CreatedAt)
+}
+
+func (s *MerchantStorage) GetMerchant(merchantID string) (*models.Merchant, error) {
+	return scanMerchant(s.db.QueryRow("SELECT "+merchantColumns+" FROM merchants WHERE id=$1", merchantID))
+}
+
+func (s *MerchantStorage) GetMerchantByAPIKey(apiKey string) (*models.Merchant, error) {
+	return scanMerchant(s.db.QueryRow("SELECT "+merchantColumns+" FROM merchants WHERE api_key=$1", apiKey))
+}
+
+func (s *MerchantStorage) UpdateCredentials(merchantID, apiKey, apiSecret string) error {
+	_, err := s.db.Exec("UPDATE merchants SET api_key=$1, api_secret=$2 WHERE id=$3", apiKey, apiSecret, merchantID)
+	return err
+}
+
+const orderColumns = `id, merchant_id, amount, reference, description, status, COALESCE(customer_wallet_id::text, ''),
+	COALESCE(transaction_id, 0), expires_at, created_at, updated_at`
+
+// CreateOrder saves the order together with its first event
+func (s *MerchantStorage) CreateOrder(order *models.MerchantOrder) error {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return errors.Wrap(err, "unable to begin transaction to create order")
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO merchant_orders (merchant_id, amount, reference, description, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at
+	`, order.MerchantID, order.Amount, order.Reference, order.Description, order.Status, order.ExpiresAt).
+		Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
+	if err == nil {
+		err = insertOrderEvent(tx, order.ID, "", order.Status, "")
+	}
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return errors.Wrap(err, "unable to create order")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Wrap(err, "unable to commit transaction")
+	}
+
+	return nil
+}
+
+func (s *MerchantStorage) GetOrder(orderID string) (*models.MerchantOrder, error) {
+	order := &models.MerchantOrder{}
+	err := s.db.QueryRow("SELECT "+orderColumns+" FROM merchant_orders WHERE id=$1", orderID).Scan(
+		&order.ID, &order.MerchantID, &order.Amount, &order.Reference, &order.Description, &order.Status,
+		&order.CustomerWalletID, &order.TransactionID, &order.ExpiresAt, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// UpdateOrderStatus moves the order to toStatus only if it is still in fromStatus
+// and records the change
+func (s *MerchantStorage) UpdateOrderStatus(orderID, fromStatus, toStatus, details string) (bool, error) {
+	return s.transitionOrder(orderID, fromStatus, toStatus, details, `
+		UPDATE merchant_orders SET status=$1, updated_at=CURRENT_TIMESTAMP
+		WHERE id=$2 AND status=$3
+	`, toStatus, orderID, fromStatus)
+}
+
+// CompleteOrder marks an unexpired order waiting for payment as paid and links it to
+// the transfer paying it, in the transaction of the transfer
+func (s *MerchantStorage) CompleteOrder(orderID, customerWalletID, details string) TransferHook {
+	return func(tx *sql.Tx, result *models.TransferResult) error {
+		updated, err := transitionOrder(tx, orderID, models.OrderStatusCreated, models.OrderStatusPaid, details, `
+			UPDATE merchant_orders SET status=$1, customer_wallet_id=$2, transaction_id=$3, updated_at=CURRENT_TIMESTAMP
+			WHERE id=$4 AND status=$5 AND expires_at > $6
+		`, models.OrderStatusPaid, customerWalletID, result.DebitTransactionID, orderID, models.OrderStatusCreated, time.Now())
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return ErrOrderNotPayable
+		}
+		return nil
+	}
+}
+
+func (s *MerchantStorage) transitionOrder(orderID, fromStatus, toStatus, details, query string, args ...any) (bool, error) {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return false, errors.Wrap(err, "unable to begin transaction to update order")
+	}
+
+	updated, err := transitionOrder(tx, orderID, fromStatus, toStatus, details, query, args...)
+	if err != nil || !updated {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return false, errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, errors.Wrap(err, "unable to commit transaction")
+	}
+
+	return true, nil
+}
+
+// transitionOrder runs the update of the order status and records the change. Nothing
+// matches when the order has already left fromStatus.
+func transitionOrder(tx *sql.Tx, orderID, fromStatus, toStatus, details, query string, args ...any) (bool, error) {
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to update order")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, errors.Wrap(err, "unable to update order")
+	}
+
+	err = insertOrderEvent(tx, orderID, fromStatus, toStatus, details)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to record order event")
+	}
+
+	return true, nil
+}
+
+func insertOrderEvent(tx *sql.Tx, orderID, fromStatus, toStatus, details string) error {
+	_, err := tx.Exec(`
+		INSERT INTO merchant_order_events (order_id, from_status, to_status, details)
+		VALUES ($1, $2, $3, $4)
+	`, orderID, fromStatus, toStatus, details)
+	return err
+}
+
+func (s *MerchantStorage) ListOrderEvents(orderID string) ([]models.MerchantOrderEvent, error) {
+	rows, err := s.db.Query(`
+		SELECT id, order_id, from_status, to_status, details, created_at
+		FROM merchant_order_events WHERE order_id=$1 ORDER BY id
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.MerchantOrderEvent{}
+	for rows.Next() {
+		var event models.MerchantOrderEvent
+		if err := rows.Scan(&event.ID, &event.OrderID, &event.FromStatus, &event.ToStatus, &event.Details, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
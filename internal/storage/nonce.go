@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+type NonceStorager interface {
+	UseNonce(userID, nonce string, now time.Time) (bool, error)
+	DeleteNoncesBefore(before time.Time) (int64, error)
+}
+
+type NonceStorage struct {
+	db *sql.DB
+}
+
+func NewNonceStorage(db *sql.DB) *NonceStorage {
+	return &NonceStorage{db: db}
+}
+
+// UseNonce records the nonce of the user, false when it was recorded before
+func (s *NonceStorage) UseNonce(userID, nonce string, now time.Time) (bool, error) {
+	result, err := s.db.Exec(`
+		INSERT INTO signature_nonces (user_id, nonce, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, nonce) DO NOTHING
+	`, userID, nonce, now)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+func (s *NonceStorage) DeleteNoncesBefore(before time.Time) (int64, error) {
+	result, err := s.db.Exec("DELETE FROM signature_nonces WHERE created_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type NotificationStorager interface {
+	SetPreference(preference *models.NotificationPreference) error
+	ListPreferences(userID string) ([]models.NotificationPreference, error)
+	CreateNotification(notification *models.Notification) error
+	ListNotifications(userID string, limit int) ([]models.Notification, error)
+	ClaimDueNotifications(limit int, lease time.Duration) ([]models.Notification, error)
+	MarkSent(notificationID string, attempts int) error
+	ScheduleRetry(notificationID string, attempts int, nextAttemptAt time.Time, lastError string) error
+	MarkFailed(notificationID string, attempts int, lastError string) error
+}
+
+type NotificationStorage struct {
+	db *sql.DB
+}
+
+func NewNotificationStorage(db *sql.DB) *NotificationStorage {
+	return &NotificationStorage{db: db}
+}
+
+const notificationColumns = `id, user_id, kind, channel, address, subject, body, status, attempts, last_error,
+	next_attempt_at, created_at, sent_at`
+
+func scanNotification(row interface{ Scan(...any) error }, n *models.Notification) error {
+	return row.Scan(&n.ID, &n.UserID, &n.Kind, &n.Channel, &n.Address, &n.Subject, &n.Body, &n.Status, &n.Attempts,
+		&n.LastError, &n.NextAttemptAt, &n.CreatedAt, &n.SentAt)
+}
+
+func (s *NotificationStorage) SetPreference(preference *models.NotificationPreference) error {
+	return s.db.QueryRow(`
+		INSERT INTO notification_preferences (user_id, channel, address, enabled) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, channel) DO UPDATE SET address=EXCLUDED.address, enabled=EXCLUDED.enabled, updated_at=CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`, preference.UserID, preference.Channel, preference.Address, preference.Enabled).Scan(&preference.UpdatedAt)
+}
+
+func (s *NotificationStorage) ListPreferences(userID string) ([]models.NotificationPreference, error) {
+	rows, err := s.db.Query(`
+		SELECT user_id, channel, address, enabled, updated_at FROM notification_preferences WHERE user_id=$1 ORDER BY channel
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	preferences := []models.NotificationPreference{}
+	for rows.Next() {
+		var p models.NotificationPreference
+		if err := rows.Scan(&p.UserID, &p.Channel, &p.Address, &p.Enabled, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		preferences = append(preferences, p)
+	}
+
+	return preferences, rows.Err()
+}
+
+// CreateNotification queues the notification. Notifications of an outbox event are
+// queued only once per channel, the relay may hand the same event over again.
+func (s *NotificationStorage) CreateNotification(notification *models.Notification) error {
+	err := s.db.QueryRow(`
+		INSERT INTO notifications (user_id, event_id, kind, channel, address, subject, body, status)
+		VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (event_id, channel) WHERE event_id IS NOT NULL DO NOTHING
+		RETURNING id, next_attempt_at, created_at
+	`, notification.UserID, notification.EventID, notification.Kind, notification.Channel, notification.Address,
+		notification.Subject, notification.Body, notification.Status).Scan(&notification.ID, &notification.NextAttemptAt, &notification.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+func (s *NotificationStorage) ListNotifications(userID string, limit int) ([]models.Notification, error) {
+	return s.queryNotifications("SELECT "+notificationColumns+" FROM notifications WHERE user_id=$1 ORDER BY created_at DESC LIMIT $2", userID, limit)
+}
+
+// ClaimDueNotifications locks up to limit pending notifications that are due for
+// lease, the same way webhook deliveries are claimed
+func (s *NotificationStorage) ClaimDueNotifications(limit int, lease time.Duration) ([]models.Notification, error) {
+	now := time.Now()
+	return s.queryNotifications(`
+		WITH due AS (
+			SELECT id FROM notifications
+			WHERE status=$4 AND next_attempt_at <= $1 AND (locked_until IS NULL OR locked_until < $1)
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE notifications n SET locked_until=$3
+		FROM due WHERE n.id = due.id
+		RETURNING `+notificationColumns,
+		now, limit, now.Add(lease), models.NotificationStatusPending)
+}
+
+func (s *NotificationStorage) queryNotifications(query string, args ...any) ([]models.Notification, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var notification models.Notification
+		if err := scanNotification(rows, &notification); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (s *NotificationStorage) MarkSent(notificationID string, attempts int) error {
+	_, err := s.db.Exec(`
+		UPDATE notifications SET status=$1, attempts=$2, last_error='', locked_until=NULL, sent_at=CURRENT_TIMESTAMP
+		WHERE id=$3
+	`, models.NotificationStatusSent, attempts, notificationID)
+	return err
+}
+
+func (s *NotificationStorage) ScheduleRetry(notificationID string, attempts int, nextAttemptAt time.Time, lastError string) error {
+	_, err := s.db.Exec(`
+		UPDATE notifications SET attempts=$1, next_attempt_at=$2, last_error=$3, locked_until=NULL WHERE id=$4
+	`, attempts, nextAttemptAt, lastError, notificationID)
+	return err
+}
+
+func (s *NotificationStorage) MarkFailed(notificationID string, attempts int, lastError string) error {
+	_, err := s.db.Exec(`
+		UPDATE notifications SET status=$1, attempts=$2, last_error=$3, locked_until=NULL WHERE id=$4
+	`, models.NotificationStatusFailed, attempts, lastError, notificationID)
+	return err
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type OutboxStorager interface {
+	ClaimDueEvents(limit int, lease time.Duration) ([]models.DomainEvent, error)
+	MarkPublished(eventID int64, attempts int) error
+	ScheduleRetry(eventID int64, attempts int, publishedSinks []string, nextAttemptAt time.Time, lastError string) error
+	MarkDead(eventID int64, attempts int, publishedSinks []string, lastError string) error
+	ListDeadEvents(limit int) ([]models.DomainEvent, error)
+	ReplayDeadEvent(eventID int64) (bool, error)
+}
+
+const domainEventColumns = `id, aggregate_id, event_type, payload, attempts, last_error, next_attempt_at, created_at, published_sinks, dead_at`
+
+func scanDomainEvent(row interface{ Scan(...any) error }, e *models.DomainEvent) error {
+	return row.Scan(&e.ID, &e.AggregateID, &e.Type, &e.Payload, &e.Attempts, &e.LastError, &e.NextAttemptAt, &e.CreatedAt,
+		pq.Array(&e.PublishedSinks), &e.DeadAt)
+}
+
+type OutboxStorage struct {
+	db *sql.DB
+}
+
+func NewOutboxStorage(db *sql.DB) *OutboxStorage {
+	return &OutboxStorage{db: db}
+}
+
+// insertDomainEvent writes an event to the outbox inside the transaction making
+// the change. Callers lock the wallet first, so events of a wallet get ids in the
+// order their transactions commit.
+func insertDomainEvent(tx *sql.Tx, eventType, aggregateID string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "unable to encode domain event")
+	}
+
+	_, err = tx.Exec("INSERT INTO domain_events (aggregate_id, event_type, payload) VALUES ($1, $2, $3)", aggregateID, eventType, body)
+	return errors.Wrap(err, "unable to save domain event")
+}
+
+// insertBalanceEvent writes the domain event of a balance change of one wallet.
+// Negative amounts are debits, credits from another wallet are told apart from
+// top-ups by the counterparty.
+func insertBalanceEvent(tx *sql.Tx, payload models.WalletEventPayload) error {
+	eventType := models.DomainEventWalletToppedUp
+	switch {
+	case payload.Amount < 0:
+		eventType = models.DomainEventWalletDebited
+	case payload.CounterpartyWalletID != "":
+		eventType = models.DomainEventWalletCredited
+	}
+	return insertDomainEvent(tx, eventType, payload.WalletID, payload)
+}
+
+// ClaimDueEvents locks up to limit due events for lease. Only the oldest
+// unpublished event of each wallet is claimed, the next one waits until it is
+// published or dead, so a wallet's events are published in order.
+func (s *OutboxStorage) ClaimDueEvents(limit int, lease time.Duration) ([]models.DomainEvent, error) {
+	now := time.Now()
+	rows, err := s.db.Query(`
+		WITH due AS (
+			SELECT id FROM domain_events e
+			WHERE published_at IS NULL AND dead_at IS NULL AND next_attempt_at <= $1 AND (locked_until IS NULL OR locked_until < $1)
+				AND NOT EXISTS (
+					SELECT 1 FROM domain_events p
+					WHERE p.aggregate_id = e.aggregate_id AND p.published_at IS NULL AND p.dead_at IS NULL AND p.id < e.id
+				)
+			ORDER BY id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE domain_events d SET locked_until=$3
+		FROM due WHERE d.id = due.id
+		RETURNING d.id, d.aggregate_id, d.event_type, d.payload, d.attempts, d.last_error, d.next_attempt_at, d.created_at,
+			d.published_sinks, d.dead_at
+	`, now, limit, now.Add(lease))
+	if err != nil {
+		return nil, err
+	}
+	return scanDomainEvents(rows)
+}
+
+func scanDomainEvents(rows *sql.Rows) ([]models.DomainEvent, error) {
+	defer rows.Close()
+
+	events := []models.DomainEvent{}
+	for rows.Next() {
+		var event models.DomainEvent
+		if err := scanDomainEvent(rows, &event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (s *OutboxStorage) MarkPublished(eventID int64, attempts int) error {
+	_, err := s.db.Exec(`
+		UPDATE domain_events SET published_at=CURRENT_TIMESTAMP, attempts=$1, last_error='', locked_until=NULL WHERE id=$2
+	`, attempts, eventID)
+	return err
+}
+
+func (s *OutboxStorage) ScheduleRetry(eventID int64, attempts int, publishedSinks []string, nextAttemptAt time.Time, lastError string) error {
+	_, err := s.db.Exec(`
+		UPDATE domain_events SET attempts=$1, published_sinks=$2, next_attempt_at=$3, last_error=$4, locked_until=NULL WHERE id=$5
+	`, attempts, pq.Array(publishedSinks), nextAttemptAt, lastError, eventID)
+	return err
+}
+
+// MarkDead gives up on an event that failed its last attempt, the later events of
+// its wallet are published without it
+func (s *OutboxStorage) MarkDead(eventID int64, attempts int, publishedSinks []string, lastError string) error {
+	_, err := s.db.Exec(`
+		UPDATE domain_events SET attempts=$1, published_sinks=$2, last_error=$3, dead_at=CURRENT_TIMESTAMP, locked_until=NULL WHERE id=$4
+	`, attempts, pq.Array(publishedSinks), lastError, eventID)
+	return err
+}
+
+// ListDeadEvents returns the latest dead events first
+func (s *OutboxStorage) ListDeadEvents(limit int) ([]models.DomainEvent, error) {
+	rows, err := s.db.Query("SELECT "+domainEventColumns+" FROM domain_events WHERE dead_at IS NOT NULL ORDER BY dead_at DESC LIMIT $1", limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanDomainEvents(rows)
+}
+
+// ReplayDeadEvent makes a dead event due again with a fresh retry budget. It goes
+// only to the sinks that didn't publish it and may arrive after later events of
+// its wallet.
+func (s *OutboxStorage) ReplayDeadEvent(eventID int64) (bool, error) {
+	res, err := s.db.Exec(`
+		UPDATE domain_events SET dead_at=NULL, attempts=0, next_attempt_at=CURRENT_TIMESTAMP WHERE id=$1 AND dead_at IS NOT NULL
+	`, eventID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
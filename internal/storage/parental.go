@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type ParentalStorager interface {
+	CreateChildAccount(parentID string) (string, string, error)
+	GetParentID(userID string) (string, error)
+	SaveControls(controls *models.ParentalControls) error
+	GetControls(childID string) (*models.ParentalControls, error)
+	LimitMonthlySpending(childID string, limit int64) TransferHook
+	CreateApproval(approval *models.ApprovalRequest) error
+	GetApproval(approvalID string) (*models.ApprovalRequest, error)
+	ListApprovals(parentID, status string) ([]models.ApprovalRequest, error)
+	UpdateApprovalStatus(approvalID, fromStatus, toStatus string) (bool, error)
+	CompleteApproval(approvalID string) TransferHook
+}
+
+var (
+	// ErrSpendingLimitExceeded is returned by the monthly limit hook when the transfer
+	// takes the child's spending over the limit
+	ErrSpendingLimitExceeded = errors.New("spending limit set by parent exceeded")
+	// ErrApprovalNotApproved is returned when the approval a transfer executes is
+	// not approved anymore
+	ErrApprovalNotApproved = errors.New("approval request is not approved")
+)
+
+type ParentalStorage struct {
+	db *sql.DB
+}
+
+func NewParentalStorage(db *sql.DB) *ParentalStorage {
+	return &ParentalStorage{db: db}
+}
+
+// CreateChildAccount creates a user linked to the parent together with an empty wallet
+// and returns ids of the new user and wallet
+func (s *ParentalStorage) CreateChildAccount(parentID string) (string, string, error) {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return "", "", errors.Wrap(err, "unable to begin transaction to create child account")
+	}
+
+	var childID, walletID string
+	err = tx.QueryRow("INSERT INTO users (is_identified, parent_id) VALUES (FALSE, $1) RETURNING id", parentID).Scan(&childID)
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return "", "", errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return "", "", errors.Wrap(err, "unable to create child user")
+	}
+
+	err = tx.QueryRow("INSERT INTO wallets (user_id) VALUES ($1) RETURNING id", childID).Scan(&walletID)
+	if err == nil {
+		err = insertDomainEvent(tx, models.DomainEventWalletCreated, walletID, models.WalletEventPayload{WalletID: walletID, UserID: childID})
+	}
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return "", "", errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return "", "", errors.Wrap(err, "unable to create child wallet")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return "", "", errors.Wrap(err, "unable to commit transaction")
+	}
+
+	return childID, walletID, nil
+}
+
+// GetParentID returns the parent of the user or an empty string for adult accounts
+func (s *ParentalStorage) GetParentID(userID string) (string, error) {
+	var parentID sql.NullString
+	err := s.db.QueryRow("SELECT parent_id FROM users WHERE id=$1", userID).Scan(&parentID)
+	return parentID.String, err
+}
+
+func (s *ParentalStorage) SaveControls(controls *models.ParentalControls) error {
+	_, err := s.db.Exec(`
+		INSERT INTO parental_controls (child_id, per_transaction_limit, monthly_limit, approval_threshold, blocked_categories, updated_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		ON CONFLICT (child_id) DO UPDATE SET
+			per_transaction_limit = EXCLUDED.per_transaction_limit,
+			monthly_limit = EXCLUDED.monthly_limit,
+			approval_threshold = EXCLUDED.approval_threshold,
+			blocked_categories = EXCLUDED.blocked_categories,
+			updated_at = EXCLUDED.updated_at
+	`, controls.ChildID, controls.PerTransactionLimit, controls.MonthlyLimit, controls.ApprovalThreshold, pq.Array(controls.BlockedCategories))
+	return err
+}
+
+func (s *ParentalStorage) GetControls(childID string) (*models.ParentalControls, error) {
+	controls := &models.ParentalControls{}
+	err := s.db.QueryRow(`
+		SELECT child_id, per_transaction_limit, monthly_limit, approval_threshold, blocked_categories
+		FROM parental_controls WHERE child_id=$1
+	`, childID).Scan(&controls.ChildID, &controls.PerTransactionLimit, &controls.MonthlyLimit, &controls.ApprovalThreshold, pq.Array(&controls.BlockedCategories))
+	if err != nil {
+		return nil, err
+	}
+	return controls, nil
+}
+
+// LimitMonthlySpending checks the debits from all wallets of the child in the current
+// month, including the one of the transfer, against the limit. The child is locked
+// first, so concurrent transfers of the child are counted one after another.
+func (s *ParentalStorage) LimitMonthlySpending(childID string, limit int64) TransferHook {
+	return func(tx *sql.Tx, _ *models.TransferResult) error {
+		_, err := tx.Exec("SELECT id FROM users WHERE id=$1 FOR UPDATE", childID)
+		if err != nil {
+			return errors.Wrap(err, "unable to lock child")
+		}
+
+		var spent int64
+		err = tx.QueryRow(`
+			SELECT COALESCE(SUM(-t.amount), 0)
+			FROM transactions t
+			JOIN wallets w ON t.wallet_id = w.id
+			WHERE w.user_id=$1 AND t.amount < 0 AND t.completed_at >= DATE_TRUNC('month', CURRENT_DATE)
+		`, childID).Scan(&spent)
+		if err != nil {
+			return errors.Wrap(err, "unable to get monthly spending")
+		}
+
+		if spent > limit {
+			return ErrSpendingLimitExceeded
+		}
+		return nil
+	}
+}
+
+func (s *ParentalStorage) CreateApproval(approval *models.ApprovalRequest) error {
+	return s.db.QueryRow(`
+		INSERT INTO approval_requests (parent_id, child_id, wallet_id, to_wallet_id, amount, merchant_category, reference, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at
+	`, approval.ParentID, approval.ChildID, approval.WalletID, approval.ToWalletID, approval.Amount, approval.MerchantCategory, approval.Reference, approval.Status).Scan(&approval.ID, &approval.CreatedAt)
+}
+
+func (s *ParentalStorage) GetApproval(approvalID string) (*models.ApprovalRequest, error) {
+	approval := &models.ApprovalRequest{}
+	err := s.db.QueryRow(`
+		SELECT id, parent_id, child_id, wallet_id, to_wallet_id, amount, merchant_category, reference, status, created_at, decided_at
+		FROM approval_requests WHERE id=$1
+	`, approvalID).Scan(&approval.ID, &approval.ParentID, &approval.ChildID, &approval.WalletID, &approval.ToWalletID,
+		&approval.Amount, &approval.MerchantCategory, &approval.Reference, &approval.Status, &approval.CreatedAt, &approval.DecidedAt)
+	if err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+func (s *ParentalStorage) ListApprovals(parentID, status string) ([]models.ApprovalRequest, error) {
+	rows, err := s.db.Query(`
+		SELECT id, parent_id, child_id, wallet_id, to_wallet_id, amount, merchant_category, reference, status, created_at, decided_at
+		FROM approval_requests WHERE parent_id=$1 AND status=$2
+		ORDER BY created_at DESC
+	`, parentID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	approvals := []models.ApprovalRequest{}
+	for rows.Next() {
+		var approval models.ApprovalRequest
+		err = rows.Scan(&approval.ID, &approval.ParentID, &approval.ChildID, &approval.WalletID, &approval.ToWalletID,
+			&approval.Amount, &approval.MerchantCategory, &approval.Reference, &approval.Status, &approval.CreatedAt, &approval.DecidedAt)
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, approval)
+	}
+
+	return approvals, rows.Err()
+}
+
+// UpdateApprovalStatus moves the approval to toStatus only if it is still in fromStatus
+func (s *ParentalStorage) UpdateApprovalStatus(approvalID, fromStatus, toStatus string) (bool, error) {
+	res, err := s.db.Exec(`
+		UPDATE approval_requests SET status=$1, decided_at=COALESCE(decided_at, CURRENT_TIMESTAMP)
+		WHERE id=$2 AND status=$3
+	`, toStatus, approvalID, fromStatus)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// CompleteApproval marks the approved request as completed in the transaction of the
+// transfer it allowed, so an approval can't pay twice
+func (s *ParentalStorage) CompleteApproval(approvalID string) TransferHook {
+	return func(tx *sql.Tx, _ *models.TransferResult) error {
+		res, err := tx.Exec("UPDATE approval_requests SET status=$1 WHERE id=$2 AND status=$3",
+			models.ApprovalStatusCompleted, approvalID, models.ApprovalStatusApproved)
+		if err != nil {
+			return errors.Wrap(err, "unable to complete approval request")
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrApprovalNotApproved
+		}
+		return nil
+	}
+}
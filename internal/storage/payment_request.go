@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type PaymentRequestStorager interface {
+	CreatePaymentRequest(request *models.PaymentRequest) error
+	GetPaymentRequest(requestID string) (*models.PaymentRequest, error)
+	ListPaymentRequests(userID, role, status string) ([]models.PaymentRequest, error)
+	UpdatePaymentRequestStatus(requestID, fromStatus, toStatus string) (bool, error)
+	CompletePaymentRequest(requestID, payerWalletID string) TransferHook
+}
+
+// ErrPaymentRequestNotPending is returned when the request was paid, declined,
+// cancelled or has expired before the transfer paying it committed
+var ErrPaymentRequestNotPending = errors.New("payment request is no longer pending")
+
+type PaymentRequestStorage struct {
+	db *sql.DB
+}
+
+func NewPaymentRequestStorage(db *sql.DB) *PaymentRequestStorage {
+	return &PaymentRequestStorage{db: db}
+}
+
+const paymentRequestColumns = `id, requester_id, requester_wallet_id, payer_id, COALESCE(payer_wallet_id::text, ''),
+	amount, message, status, COALESCE(transaction_id, 0), expires_at, created_at, updated_at`
+
+func scanPaymentRequest(row interface{ Scan(...any) error }, request *models.PaymentRequest) error {
+	return row.Scan(&request.ID, &request.RequesterID, &request.RequesterWalletID, &request.PayerID, &request.PayerWalletID,
+		&request.Amount, &request.Message, &request.Status, &request.TransactionID, &request.ExpiresAt, &request.CreatedAt, &request.UpdatedAt)
+}
+
+func (s *PaymentRequestStorage) CreatePaymentRequest(request *models.PaymentRequest) error {
+	return s.db.QueryRow(`
+		INSERT INTO payment_requests (requester_id, requester_wallet_id, payer_id, amount, message, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at, updated_at
+	`, request.RequesterID, request.RequesterWalletID, request.PayerID, request.Amount, request.Message, request.Status, request.ExpiresAt).
+		Scan(&request.ID, &request.CreatedAt, &request.UpdatedAt)
+}
+
+func (s *PaymentRequestStorage) GetPaymentRequest(requestID string) (*models.PaymentRequest, error) {
+	request := &models.PaymentRequest{}
+	err := scanPaymentRequest(s.db.QueryRow("SELECT "+paymentRequestColumns+" FROM payment_requests WHERE id=$1", requestID), request)
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// ListPaymentRequests returns requests addressed to the user ("incoming") or made by the user ("outgoing")
+func (s *PaymentRequestStorage) ListPaymentRequests(userID, role, status string) ([]models.PaymentRequest, error) {
+	column := "payer_id"
+	if role == "outgoing" {
+		column = "requester_id"
+	}
+
+	rows, err := s.db.Query(`
+		SELECT `+paymentRequestColumns+` FROM payment_requests
+		WHERE `+column+`=$1 AND ($2 = '' OR status=$2)
+		ORDER BY created_at DESC
+	`, userID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []models.PaymentRequest{}
+	for rows.Next() {
+		var request models.PaymentRequest
+		if err := scanPaymentRequest(rows, &request); err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
+
+// UpdatePaymentRequestStatus moves the request to toStatus only if it is still in fromStatus.
+// Pending requests past their expiry can't be moved.
+func (s *PaymentRequestStorage) UpdatePaymentRequestStatus(requestID, fromStatus, toStatus string) (bool, error) {
+	res, err := s.db.Exec(`
+		UPDATE payment_requests SET status=$1, updated_at=CURRENT_TIMESTAMP
+		WHERE id=$2 AND status=$3 AND (status <> 'pending' OR expires_at > $4)
+	`, toStatus, requestID, fromStatus, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// CompletePaymentRequest marks a pending request as paid and links it to the transfer
+// paying it, in the transaction of the transfer
+func (s *PaymentRequestStorage) CompletePaymentRequest(requestID, payerWalletID string) TransferHook {
+	return func(tx *sql.Tx, result *models.TransferResult) error {
+		res, err := tx.Exec(`
+			UPDATE payment_requests SET status='paid', payer_wallet_id=$1, transaction_id=$2, updated_at=CURRENT_TIMESTAMP
+			WHERE id=$3 AND status='pending' AND expires_at > $4
+		`, payerWalletID, result.DebitTransactionID, requestID, time.Now())
+		if err != nil {
+			return errors.Wrap(err, "unable to complete payment request")
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrPaymentRequestNotPending
+		}
+		return nil
+	}
+}
@@ -0,0 +1,285 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type PayoutStorager interface {
+	CreateBatch(batch *models.PayoutBatch, rows []models.PayoutRow) error
+	GetBatch(batchID string) (*models.PayoutBatch, error)
+	ListBatches(userID string) ([]models.PayoutBatch, error)
+	ListActiveBatches() ([]models.PayoutBatch, error)
+	UpdateBatchStatus(batchID, fromStatus, toStatus string) (bool, error)
+	FinishBatch(batchID string) (bool, error)
+	ClaimRows(batchID string, limit int) ([]models.PayoutRow, error)
+	ListStuckRows() ([]models.PayoutRow, error)
+	PayRow(rowID string) TransferHook
+	CompleteRow(rowID string, transactionID int64) error
+	FailRow(rowID, reason string) error
+	ReleaseRow(rowID string) error
+	ListRows(batchID string) ([]models.PayoutRow, error)
+	FindWallets(walletIDs []string) (map[string]bool, error)
+}
+
+// ErrPayoutRowNotProcessing is returned when the row paid out is no longer claimed
+var ErrPayoutRowNotProcessing = errors.New("payout row is not being processed")
+
+type PayoutStorage struct {
+	db *sql.DB
+}
+
+func NewPayoutStorage(db *sql.DB) *PayoutStorage {
+	return &PayoutStorage{db: db}
+}
+
+// batchColumns include the row counters so the progress is always up to date
+const batchColumns = `b.id, b.user_id, b.wallet_id, b.file_name, b.format, b.status, b.total_rows, b.total_amount,
+	b.created_at, b.updated_at, b.completed_at,
+	COUNT(r.id) FILTER (WHERE r.status IN ('pending', 'processing')),
+	COUNT(r.id) FILTER (WHERE r.status = 'succeeded'),
+	COUNT(r.id) FILTER (WHERE r.status = 'failed'),
+	COALESCE(SUM(r.amount) FILTER (WHERE r.status = 'succeeded'), 0)`
+
+func scanBatch(row interface{ Scan(...any) error }, batch *models.PayoutBatch) error {
+	return row.Scan(&batch.ID, &batch.UserID, &batch.WalletID, &batch.FileName, &batch.Format, &batch.Status,
+		&batch.TotalRows, &batch.TotalAmount, &batch.CreatedAt, &batch.UpdatedAt, &batch.CompletedAt,
+		&batch.PendingRows, &batch.SucceededRows, &batch.FailedRows, &batch.SucceededAmount)
+}
+
+// CreateBatch saves the batch together with all of its rows
+func (s *PayoutStorage) CreateBatch(batch *models.PayoutBatch, rows []models.PayoutRow) error {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return errors.Wrap(err, "unable to begin transaction to create payout batch")
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO payout_batches (user_id, wallet_id, file_name, format, status, total_rows, total_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at, updated_at
+	`, batch.UserID, batch.WalletID, batch.FileName, batch.Format, batch.Status, batch.TotalRows, batch.TotalAmount).
+		Scan(&batch.ID, &batch.CreatedAt, &batch.UpdatedAt)
+
+	// Rows are copied in one statement, thousands of single inserts are too slow
+	var stmt *sql.Stmt
+	if err == nil {
+		stmt, err = tx.Prepare(pq.CopyIn("payout_rows", "batch_id", "row_number", "wallet_id", "amount", "description", "status"))
+	}
+	for i := 0; err == nil && i < len(rows); i++ {
+		_, err = stmt.Exec(batch.ID, rows[i].RowNumber, rows[i].WalletID, rows[i].Amount, rows[i].Description, models.PayoutRowPending)
+	}
+	if err == nil {
+		_, err = stmt.Exec()
+	}
+	if stmt != nil {
+		if closeErr := stmt.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return errors.Wrap(err, "unable to create payout batch")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Wrap(err, "unable to commit transaction")
+	}
+
+	batch.PendingRows = len(rows)
+	return nil
+}
+
+func (s *PayoutStorage) GetBatch(batchID string) (*models.PayoutBatch, error) {
+	batch := &models.PayoutBatch{}
+	err := scanBatch(s.db.QueryRow(`
+		SELECT `+batchColumns+` FROM payout_batches b LEFT JOIN payout_rows r ON r.batch_id = b.id
+		WHERE b.id=$1 GROUP BY b.id
+	`, batchID), batch)
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func (s *PayoutStorage) ListBatches(userID string) ([]models.PayoutBatch, error) {
+	return s.listBatches(`
+		SELECT `+batchColumns+` FROM payout_batches b LEFT JOIN payout_rows r ON r.batch_id = b.id
+		WHERE b.user_id=$1 GROUP BY b.id ORDER BY b.created_at DESC
+	`, userID)
+}
+
+// ListActiveBatches returns batches the worker should process, oldest first
+func (s *PayoutStorage) ListActiveBatches() ([]models.PayoutBatch, error) {
+	return s.listBatches(`
+		SELECT ` + batchColumns + ` FROM payout_batches b LEFT JOIN payout_rows r ON r.batch_id = b.id
+		WHERE b.status='processing' GROUP BY b.id ORDER BY b.created_at
+	`)
+}
+
+func (s *PayoutStorage) listBatches(query string, args ...any) ([]models.PayoutBatch, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := []models.PayoutBatch{}
+	for rows.Next() {
+		var batch models.PayoutBatch
+		if err := scanBatch(rows, &batch); err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches, rows.Err()
+}
+
+// UpdateBatchStatus moves the batch to toStatus only if it is still in fromStatus
+func (s *PayoutStorage) UpdateBatchStatus(batchID, fromStatus, toStatus string) (bool, error) {
+	res, err := s.db.Exec(`
+		UPDATE payout_batches SET status=$1, updated_at=CURRENT_TIMESTAMP
+		WHERE id=$2 AND status=$3
+	`, toStatus, batchID, fromStatus)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// FinishBatch completes a processing batch once none of its rows are left to pay
+func (s *PayoutStorage) FinishBatch(batchID string) (bool, error) {
+	res, err := s.db.Exec(`
+		UPDATE payout_batches SET status='completed', completed_at=CURRENT_TIMESTAMP, updated_at=CURRENT_TIMESTAMP
+		WHERE id=$1 AND status='processing' AND NOT EXISTS (
+			SELECT 1 FROM payout_rows WHERE batch_id=$1 AND status IN ('pending', 'processing')
+		)
+	`, batchID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// ClaimRows marks up to limit pending rows of a processing batch as processing.
+// Nothing is claimed from a paused batch.
+func (s *PayoutStorage) ClaimRows(batchID string, limit int) ([]models.PayoutRow, error) {
+	return s.listRows(`
+		UPDATE payout_rows SET status='processing'
+		WHERE id IN (
+			SELECT r.id FROM payout_rows r JOIN payout_batches b ON b.id = r.batch_id
+			WHERE r.batch_id=$1 AND r.status='pending' AND b.status='processing'
+			ORDER BY r.row_number
+			LIMIT $2
+			FOR UPDATE OF r SKIP LOCKED
+		)
+		RETURNING `+payoutRowColumns, batchID, limit)
+}
+
+// ListStuckRows returns rows left in processing, e.g. after a restart in the middle of a batch
+func (s *PayoutStorage) ListStuckRows() ([]models.PayoutRow, error) {
+	return s.listRows("SELECT " + payoutRowColumns + " FROM payout_rows WHERE status='processing' ORDER BY batch_id, row_number")
+}
+
+// PayRow marks the claimed row as succeeded in the transaction of the transfer paying it
+func (s *PayoutStorage) PayRow(rowID string) TransferHook {
+	return func(tx *sql.Tx, result *models.TransferResult) error {
+		res, err := tx.Exec(`
+			UPDATE payout_rows SET status='succeeded', transaction_id=$1, processed_at=CURRENT_TIMESTAMP
+			WHERE id=$2 AND status='processing'
+		`, result.DebitTransactionID, rowID)
+		if err != nil {
+			return errors.Wrap(err, "unable to complete payout row")
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrPayoutRowNotProcessing
+		}
+		return nil
+	}
+}
+
+func (s *PayoutStorage) CompleteRow(rowID string, transactionID int64) error {
+	_, err := s.db.Exec(`
+		UPDATE payout_rows SET status='succeeded', transaction_id=NULLIF($1, 0), processed_at=CURRENT_TIMESTAMP
+		WHERE id=$2 AND status='processing'
+	`, transactionID, rowID)
+	return err
+}
+
+func (s *PayoutStorage) FailRow(rowID, reason string) error {
+	_, err := s.db.Exec(`
+		UPDATE payout_rows SET status='failed', error=$1, processed_at=CURRENT_TIMESTAMP
+		WHERE id=$2 AND status='processing'
+	`, reason, rowID)
+	return err
+}
+
+// ReleaseRow puts a processing row back to pending so it is tried again
+func (s *PayoutStorage) ReleaseRow(rowID string) error {
+	_, err := s.db.Exec("UPDATE payout_rows SET status='pending' WHERE id=$1 AND status='processing'", rowID)
+	return err
+}
+
+const payoutRowColumns = `id, batch_id, row_number, wallet_id, amount, description, status, COALESCE(transaction_id, 0), error, processed_at`
+
+func (s *PayoutStorage) ListRows(batchID string) ([]models.PayoutRow, error) {
+	return s.listRows("SELECT "+payoutRowColumns+" FROM payout_rows WHERE batch_id=$1 ORDER BY row_number", batchID)
+}
+
+func (s *PayoutStorage) listRows(query string, args ...any) ([]models.PayoutRow, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payoutRows := []models.PayoutRow{}
+	for rows.Next() {
+		var row models.PayoutRow
+		if err := rows.Scan(&row.ID, &row.BatchID, &row.RowNumber, &row.WalletID, &row.Amount, &row.Description,
+			&row.Status, &row.TransactionID, &row.Error, &row.ProcessedAt); err != nil {
+			return nil, err
+		}
+		payoutRows = append(payoutRows, row)
+	}
+
+	return payoutRows, rows.Err()
+}
+
+// FindWallets reports which of the given wallets exist
+func (s *PayoutStorage) FindWallets(walletIDs []string) (map[string]bool, error) {
+	rows, err := s.db.Query("SELECT id FROM wallets WHERE id = ANY($1::uuid[])", pq.Array(walletIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string]bool, len(walletIDs))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		found[id] = true
+	}
+
+	return found, rows.Err()
+}
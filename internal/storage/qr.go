@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type QRStorager interface {
+	ClaimPayment(walletID, reference string) TransferHook
+}
+
+// ErrQRAlreadyPaid is returned when another transfer has already paid the payload
+var ErrQRAlreadyPaid = errors.New("QR payload has already been paid")
+
+type QRStorage struct {
+	db *sql.DB
+}
+
+func NewQRStorage(db *sql.DB) *QRStorage {
+	return &QRStorage{db: db}
+}
+
+// ClaimPayment records the payment of a single-use payload in the transaction of the
+// transfer paying it. The payload is identified by the merchant wallet and reference,
+// the unique key makes concurrent payments of the same payload fail.
+func (s *QRStorage) ClaimPayment(walletID, reference string) TransferHook {
+	return func(tx *sql.Tx, result *models.TransferResult) error {
+		_, err := tx.Exec("INSERT INTO qr_payments (wallet_id, reference, transaction_id) VALUES ($1, $2, $3)",
+			walletID, reference, result.CreditTransactionID)
+		if IsUniqueViolation(err) {
+			return ErrQRAlreadyPaid
+		}
+		if err != nil {
+			return errors.Wrap(err, "unable to claim QR payment")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type ReconciliationStorager interface {
+	CreateRun(run *models.ReconciliationRun) error
+	FindDiscrepancies() (int, []models.BalanceDiscrepancy, error)
+	OpenCase(discrepancy models.BalanceDiscrepancy) (string, bool, error)
+	FinishRun(run *models.ReconciliationRun, discrepancies []models.BalanceDiscrepancy) error
+	FailRun(runID, reason string) error
+	GetRun(runID string) (*models.ReconciliationRun, error)
+	ListRuns(limit int) ([]models.ReconciliationRun, error)
+	ListDiscrepancies(runID string) ([]models.BalanceDiscrepancy, error)
+	ListCases(status string) ([]models.CorrectionCase, error)
+	ResolveCase(caseID, resolution string) (bool, error)
+}
+
+type ReconciliationStorage struct {
+	db *sql.DB
+}
+
+func NewReconciliationStorage(db *sql.DB) *ReconciliationStorage {
+	return &ReconciliationStorage{db: db}
+}
+
+func (s *ReconciliationStorage) CreateRun(run *models.ReconciliationRun) error {
+	return s.db.QueryRow(`
+		INSERT INTO reconciliation_runs (status) VALUES ($1) RETURNING id, started_at
+	`, run.Status).Scan(&run.ID, &run.StartedAt)
+}
+
+// FindDiscrepancies compares every wallet balance with the sum of its transactions.
+// Both are read from one snapshot, balances and transactions are always written in
+// the same transaction so a running transfer can't show up as a discrepancy.
+func (s *ReconciliationStorage) FindDiscrepancies() (int, []models.BalanceDiscrepancy, error) {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "unable to begin transaction to reconcile balances")
+	}
+	defer tx.Rollback()
+
+	var checked int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM wallets").Scan(&checked); err != nil {
+		return 0, nil, errors.Wrap(err, "unable to count wallets")
+	}
+
+	rows, err := tx.Query(`
+		SELECT w.id, w.user_id, w.balance, COALESCE(SUM(t.amount), 0), COUNT(t.id), MAX(t.created_at)
+		FROM wallets w LEFT JOIN transactions t ON t.wallet_id = w.id AND t.completed_at IS NOT NULL
+		GROUP BY w.id
+		HAVING w.balance <> COALESCE(SUM(t.amount), 0)
+		ORDER BY w.id
+	`)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "unable to compare balances")
+	}
+	defer rows.Close()
+
+	discrepancies := []models.BalanceDiscrepancy{}
+	for rows.Next() {
+		var d models.BalanceDiscrepancy
+		if err := rows.Scan(&d.WalletID, &d.UserID, &d.StoredBalance, &d.LedgerBalance, &d.TransactionCount, &d.LastTransactionAt); err != nil {
+			return 0, nil, err
+		}
+		d.Difference = d.StoredBalance - d.LedgerBalance
+		discrepancies = append(discrepancies, d)
+	}
+
+	return checked, discrepancies, rows.Err()
+}
+
+// OpenCase opens a correction case for the wallet unless one is already open.
+// It returns the id of the open case and whether it was created now.
+func (s *ReconciliationStorage) OpenCase(discrepancy models.BalanceDiscrepancy) (string, bool, error) {
+	var caseID string
+	err := s.db.QueryRow(`
+		INSERT INTO correction_cases (wallet_id, run_id, stored_balance, ledger_balance, difference)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (wallet_id) WHERE status = 'open' DO NOTHING
+		RETURNING id
+	`, discrepancy.WalletID, discrepancy.RunID, discrepancy.StoredBalance, discrepancy.LedgerBalance, discrepancy.Difference).Scan(&caseID)
+	if err == nil {
+		return caseID, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", false, err
+	}
+
+	err = s.db.QueryRow("SELECT id FROM correction_cases WHERE wallet_id=$1 AND status='open'", discrepancy.WalletID).Scan(&caseID)
+	return caseID, false, err
+}
+
+// FinishRun saves the discrepancies found and the totals of the run
+func (s *ReconciliationStorage) FinishRun(run *models.ReconciliationRun, discrepancies []models.BalanceDiscrepancy) error {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return errors.Wrap(err, "unable to begin transaction to finish reconciliation run")
+	}
+
+	for _, d := range discrepancies {
+		_, err = tx.Exec(`
+			INSERT INTO reconciliation_discrepancies (run_id, wallet_id, user_id, stored_balance, ledger_balance, difference,
+				transaction_count, last_transaction_at, case_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, '')::uuid)
+		`, run.ID, d.WalletID, d.UserID, d.StoredBalance, d.LedgerBalance, d.Difference, d.TransactionCount, d.LastTransactionAt, d.CaseID)
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = tx.QueryRow(`
+			UPDATE reconciliation_runs SET status=$1, wallets_checked=$2, discrepancies=$3, total_difference=$4, cases_opened=$5,
+				finished_at=CURRENT_TIMESTAMP
+			WHERE id=$6 RETURNING finished_at
+		`, run.Status, run.WalletsChecked, run.Discrepancies, run.TotalDifference, run.CasesOpened, run.ID).Scan(&run.FinishedAt)
+	}
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return errors.Wrap(err, "unable to finish reconciliation run")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Wrap(err, "unable to commit transaction")
+	}
+
+	return nil
+}
+
+func (s *ReconciliationStorage) FailRun(runID, reason string) error {
+	_, err := s.db.Exec(`
+		UPDATE reconciliation_runs SET status='failed', error=$1, finished_at=CURRENT_TIMESTAMP WHERE id=$2
+	`, reason, runID)
+	return err
+}
+
+const runColumns = `id, status, wallets_checked, discrepancies, total_difference, cases_opened, error, started_at, finished_at`
+
+func scanRun(row interface{ Scan(...any) error }, run *models.ReconciliationRun) error {
+	return row.Scan(&run.ID, &run.Status, &run.WalletsChecked, &run.Discrepancies, &run.TotalDifference,
+		&run.CasesOpened, &run.Error, &run.StartedAt, &run.FinishedAt)
+}
+
+func (s *ReconciliationStorage) GetRun(runID string) (*models.ReconciliationRun, error) {
+	run := &models.ReconciliationRun{}
+	if err := scanRun(s.db.QueryRow("SELECT "+runColumns+" FROM reconciliation_runs WHERE id=$1", runID), run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *ReconciliationStorage) ListRuns(limit int) ([]models.ReconciliationRun, error) {
+	rows, err := s.db.Query("SELECT "+runColumns+" FROM reconciliation_runs ORDER BY started_at DESC LIMIT $1", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []models.ReconciliationRun{}
+	for rows.Next() {
+		var run models.ReconciliationRun
+		if err := scanRun(rows, &run); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+func (s *ReconciliationStorage) ListDiscrepancies(runID string) ([]models.BalanceDiscrepancy, error) {
+	rows, err := s.db.Query(`
+		SELECT run_id, wallet_id, user_id, stored_balance, ledger_balance, difference, transaction_count,
+			last_transaction_at, COALESCE(case_id::text, '')
+		FROM reconciliation_discrepancies WHERE run_id=$1 ORDER BY id
+	`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	discrepancies := []models.BalanceDiscrepancy{}
+	for rows.Next() {
+		var d models.BalanceDiscrepancy
+		if err := rows.Scan(&d.RunID, &d.WalletID, &d.UserID, &d.StoredBalance, &d.LedgerBalance, &d.Difference,
+			&d.TransactionCount, &d.LastTransactionAt, &d.CaseID); err != nil {
+			return nil, err
+		}
+		discrepancies = append(discrepancies, d)
+	}
+
+	return discrepancies, rows.Err()
+}
+
+func (s *ReconciliationStorage) ListCases(status string) ([]models.CorrectionCase, error) {
+	rows, err := s.db.Query(`
+		SELECT id, wallet_id, run_id, stored_balance, ledger_balance, difference, status, resolution, created_at, resolved_at
+		FROM correction_cases WHERE ($1 = '' OR status=$1) ORDER BY created_at DESC
+	`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cases := []models.CorrectionCase{}
+	for rows.Next() {
+		var c models.CorrectionCase
+		if err := rows.Scan(&c.ID, &c.WalletID, &c.RunID, &c.StoredBalance, &c.LedgerBalance, &c.Difference,
+			&c.Status, &c.Resolution, &c.CreatedAt, &c.ResolvedAt); err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+
+	return cases, rows.Err()
+}
+
+func (s *ReconciliationStorage) ResolveCase(caseID, resolution string) (bool, error) {
+	res, err := s.db.Exec(`
+		UPDATE correction_cases SET status='resolved', resolution=$1, resolved_at=CURRENT_TIMESTAMP
+		WHERE id=$2 AND status='open'
+	`, resolution, caseID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
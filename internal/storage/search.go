@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type SearchStorager interface {
+	SearchTransactions(query string, amount int64, filter models.TransactionFilter, offset int) ([]models.TransactionSearchResult, int, error)
+}
+
+type SearchStorage struct {
+	db *sql.DB
+}
+
+func NewSearchStorage(db *sql.DB) *SearchStorage {
+	return &SearchStorage{db: db}
+}
+
+// SearchTransactions finds transactions whose words match the query or whose
+// reference, description or counterparty name contain it. Counterparty names are
+// matched on the trigram indexes of merchants and users first, transactions are then
+// found by the wallets of the matches. A non-zero amount also matches transactions
+// of that amount. Results are ranked by full-text relevance plus the best trigram
+// similarity, and the total number of matches is returned for pagination.
+func (s *SearchStorage) SearchTransactions(query string, amount int64, filter models.TransactionFilter, offset int) ([]models.TransactionSearchResult, int, error) {
+	conditions, args := transactionConditions(filter, []any{query, "%" + escapeLike(query) + "%", amount})
+	conditions = append(conditions, `(search_vector @@ search.query
+		OR reference ILIKE search.pattern
+		OR description ILIKE search.pattern
+		OR counterparty_wallet_id IN (SELECT wallet_id FROM named)
+		OR ABS(amount) = $3)`)
+	args = append(args, filter.Limit, offset)
+
+	rows, err := s.db.Query(fmt.Sprintf(`
+		WITH search AS (
+			SELECT websearch_to_tsquery('simple', $1) AS query, $1::text AS term, $2::text AS pattern
+		), named AS (
+			SELECT settlement_wallet_id AS wallet_id FROM merchants WHERE legal_name ILIKE $2
+			UNION
+			SELECT w.id FROM users u JOIN wallets w ON w.user_id = u.id
+			WHERE u.full_name ILIKE $2
+				AND NOT EXISTS (SELECT 1 FROM merchants m WHERE m.settlement_wallet_id = w.id)
+		)
+		SELECT `+transactionColumns+`, counterparty.name,
+			ts_rank(search_vector, search.query)
+				+ GREATEST(similarity(COALESCE(reference, ''), search.term), similarity(description, search.term), similarity(counterparty.name, search.term))
+				+ CASE WHEN ABS(amount) = $3 THEN 1 ELSE 0 END AS rank,
+			COUNT(*) OVER ()
+		FROM transactions
+		CROSS JOIN search
+		LEFT JOIN LATERAL (
+			SELECT COALESCE(
+				(SELECT legal_name FROM merchants WHERE settlement_wallet_id = transactions.counterparty_wallet_id ORDER BY created_at LIMIT 1),
+				(SELECT NULLIF(u.full_name, '') FROM wallets w JOIN users u ON u.id = w.user_id WHERE w.id = transactions.counterparty_wallet_id),
+				''
+			) AS name
+		) counterparty ON true
+		WHERE %s
+		ORDER BY rank DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, strings.Join(conditions, " AND "), len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	total := 0
+	results := []models.TransactionSearchResult{}
+	for rows.Next() {
+		var r models.TransactionSearchResult
+		err := scanTransaction(scanFunc(func(dest ...any) error {
+			return rows.Scan(append(dest, &r.CounterpartyName, &r.Rank, &total)...)
+		}), &r.Transaction)
+		if err != nil {
+			return nil, 0, err
+		}
+		results = append(results, r)
+	}
+
+	return results, total, rows.Err()
+}
+
+// scanFunc lets a scan helper read extra columns after its own
+type scanFunc func(dest ...any) error
+
+func (f scanFunc) Scan(dest ...any) error {
+	return f(dest...)
+}
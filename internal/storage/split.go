@@ -0,0 +1,304 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type SplitStorager interface {
+	CreateSplit(split *models.SplitBill) error
+	GetSplit(splitID string) (*models.SplitBill, error)
+	ListSplits(userID, role string) ([]models.SplitBill, error)
+	UpdateParticipantStatus(participantID, fromStatus, toStatus string) (bool, error)
+	CompleteParticipant(participantID, walletID string) TransferHook
+	MarkReminded(participantIDs []string) error
+	CancelSplit(splitID string) (bool, error)
+}
+
+var (
+	// ErrSplitNotOpen is returned when the split was settled or cancelled before the
+	// transfer paying a share committed
+	ErrSplitNotOpen = errors.New("split is no longer open")
+	// ErrShareNotPending is returned when the share was paid or declined before the
+	// transfer paying it committed
+	ErrShareNotPending = errors.New("share is no longer pending")
+)
+
+type SplitStorage struct {
+	db *sql.DB
+}
+
+func NewSplitStorage(db *sql.DB) *SplitStorage {
+	return &SplitStorage{db: db}
+}
+
+const splitColumns = `id, originator_id, wallet_id, total_amount, description, status, created_at, updated_at`
+
+const participantColumns = `id, split_id, user_id, amount, status, COALESCE(wallet_id::text, ''), COALESCE(transaction_id, 0),
+	reminder_count, last_reminded_at, paid_at`
+
+func scanSplit(row interface{ Scan(...any) error }, split *models.SplitBill) error {
+	return row.Scan(&split.ID, &split.OriginatorID, &split.WalletID, &split.TotalAmount, &split.Description,
+		&split.Status, &split.CreatedAt, &split.UpdatedAt)
+}
+
+// CreateSplit saves the bill together with its participants
+func (s *SplitStorage) CreateSplit(split *models.SplitBill) error {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return errors.Wrap(err, "unable to begin transaction to create split")
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO split_bills (originator_id, wallet_id, total_amount, description, status)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at
+	`, split.OriginatorID, split.WalletID, split.TotalAmount, split.Description, split.Status).
+		Scan(&split.ID, &split.CreatedAt, &split.UpdatedAt)
+	for i := range split.Participants {
+		if err != nil {
+			break
+		}
+		participant := &split.Participants[i]
+		participant.SplitID = split.ID
+		err = tx.QueryRow(`
+			INSERT INTO split_participants (split_id, user_id, amount, status)
+			VALUES ($1, $2, $3, $4) RETURNING id
+		`, participant.SplitID, participant.UserID, participant.Amount, participant.Status).Scan(&participant.ID)
+	}
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return errors.Wrap(err, "unable to create split")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Wrap(err, "unable to commit transaction")
+	}
+
+	return nil
+}
+
+func (s *SplitStorage) GetSplit(splitID string) (*models.SplitBill, error) {
+	split := &models.SplitBill{}
+	if err := scanSplit(s.db.QueryRow("SELECT "+splitColumns+" FROM split_bills WHERE id=$1", splitID), split); err != nil {
+		return nil, err
+	}
+
+	participants, err := s.listParticipants([]string{split.ID})
+	if err != nil {
+		return nil, err
+	}
+	split.Participants = participants[split.ID]
+
+	return split, nil
+}
+
+// ListSplits returns splits the user owes a share of ("incoming") or created ("outgoing")
+func (s *SplitStorage) ListSplits(userID, role string) ([]models.SplitBill, error) {
+	filter := "originator_id=$1"
+	if role == "incoming" {
+		filter = "id IN (SELECT split_id FROM split_participants WHERE user_id=$1)"
+	}
+
+	rows, err := s.db.Query("SELECT "+splitColumns+" FROM split_bills WHERE "+filter+" ORDER BY created_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	splits := []models.SplitBill{}
+	var ids []string
+	for rows.Next() {
+		var split models.SplitBill
+		if err := scanSplit(rows, &split); err != nil {
+			return nil, err
+		}
+		splits = append(splits, split)
+		ids = append(ids, split.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return splits, nil
+	}
+
+	participants, err := s.listParticipants(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range splits {
+		splits[i].Participants = participants[splits[i].ID]
+	}
+
+	return splits, nil
+}
+
+// listParticipants loads the participants of the given splits grouped by split id
+func (s *SplitStorage) listParticipants(splitIDs []string) (map[string][]models.SplitParticipant, error) {
+	rows, err := s.db.Query(`
+		SELECT `+participantColumns+` FROM split_participants
+		WHERE split_id = ANY($1::uuid[]) ORDER BY created_at, id
+	`, pq.Array(splitIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	participants := make(map[string][]models.SplitParticipant)
+	for rows.Next() {
+		var p models.SplitParticipant
+		if err := rows.Scan(&p.ID, &p.SplitID, &p.UserID, &p.Amount, &p.Status, &p.WalletID, &p.TransactionID,
+			&p.ReminderCount, &p.LastRemindedAt, &p.PaidAt); err != nil {
+			return nil, err
+		}
+		participants[p.SplitID] = append(participants[p.SplitID], p)
+	}
+
+	return participants, rows.Err()
+}
+
+// UpdateParticipantStatus moves the share to toStatus only if it is still in fromStatus
+// and the split is open. The split is settled once no share is outstanding.
+func (s *SplitStorage) UpdateParticipantStatus(participantID, fromStatus, toStatus string) (bool, error) {
+	return s.transitionParticipant(participantID, `
+		UPDATE split_participants p SET status=$1, updated_at=CURRENT_TIMESTAMP
+		FROM split_bills b
+		WHERE p.id=$2 AND p.status=$3 AND b.id=p.split_id AND b.status='open'
+		RETURNING p.split_id
+	`, toStatus, participantID, fromStatus)
+}
+
+// CompleteParticipant marks a pending share of an open split as paid and links it to the
+// transfer paying it, in the transaction of the transfer
+func (s *SplitStorage) CompleteParticipant(participantID, walletID string) TransferHook {
+	return func(tx *sql.Tx, result *models.TransferResult) error {
+		// Lock the split so it can't be cancelled while the share is paid
+		var open bool
+		err := tx.QueryRow(`
+			SELECT b.status='open' FROM split_bills b JOIN split_participants p ON p.split_id=b.id
+			WHERE p.id=$1 FOR UPDATE OF b
+		`, participantID).Scan(&open)
+		if err != nil {
+			return errors.Wrap(err, "unable to lock split")
+		}
+		if !open {
+			return ErrSplitNotOpen
+		}
+
+		updated, err := transitionParticipant(tx, participantID, `
+			UPDATE split_participants SET status='paid', wallet_id=$1, transaction_id=$2, paid_at=CURRENT_TIMESTAMP, updated_at=CURRENT_TIMESTAMP
+			WHERE id=$3 AND status='pending'
+			RETURNING split_id
+		`, walletID, result.DebitTransactionID, participantID)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return ErrShareNotPending
+		}
+		return nil
+	}
+}
+
+func (s *SplitStorage) transitionParticipant(participantID, query string, args ...any) (bool, error) {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return false, errors.Wrap(err, "unable to begin transaction to update share")
+	}
+
+	updated, err := transitionParticipant(tx, participantID, query, args...)
+	if err != nil || !updated {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return false, errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, errors.Wrap(err, "unable to commit transaction")
+	}
+
+	return true, nil
+}
+
+// transitionParticipant runs the update of the share, which returns its split, and
+// settles the split once no share is outstanding
+func transitionParticipant(tx *sql.Tx, participantID, query string, args ...any) (bool, error) {
+	var splitID string
+	err := tx.QueryRow(query, args...).Scan(&splitID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err == nil {
+		_, err = tx.Exec(`
+			UPDATE split_bills SET status='settled', updated_at=CURRENT_TIMESTAMP
+			WHERE id=$1 AND status='open' AND NOT EXISTS (
+				SELECT 1 FROM split_participants WHERE split_id=$1 AND status='pending'
+			)
+		`, splitID)
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to update share %s", participantID)
+	}
+
+	return true, nil
+}
+
+func (s *SplitStorage) MarkReminded(participantIDs []string) error {
+	_, err := s.db.Exec(`
+		UPDATE split_participants SET reminder_count=reminder_count+1, last_reminded_at=CURRENT_TIMESTAMP, updated_at=CURRENT_TIMESTAMP
+		WHERE id = ANY($1::uuid[])
+	`, pq.Array(participantIDs))
+	return err
+}
+
+// CancelSplit cancels an open split and its pending shares. Shares being paid lock the
+// split, so the cancellation waits for their transfers.
+func (s *SplitStorage) CancelSplit(splitID string) (bool, error) {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return false, errors.Wrap(err, "unable to begin transaction to cancel split")
+	}
+
+	res, err := tx.Exec(`
+		UPDATE split_bills SET status='cancelled', updated_at=CURRENT_TIMESTAMP
+		WHERE id=$1 AND status='open'
+	`, splitID)
+	var affected int64
+	if err == nil {
+		affected, err = res.RowsAffected()
+	}
+	if err == nil && affected > 0 {
+		_, err = tx.Exec(`
+			UPDATE split_participants SET status='cancelled', updated_at=CURRENT_TIMESTAMP
+			WHERE split_id=$1 AND status='pending'
+		`, splitID)
+	}
+	if err != nil || affected == 0 {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return false, errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return false, errors.Wrap(err, "unable to cancel split")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, errors.Wrap(err, "unable to commit transaction")
+	}
+
+	return true, nil
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type StreamStorager interface {
+	ListActivity(userID string, afterID int64, limit int) ([]models.DomainEvent, error)
+	GetActivity(eventID int64) (*models.DomainEvent, error)
+}
+
+type StreamStorage struct {
+	db *sql.DB
+}
+
+func NewStreamStorage(db *sql.DB) *StreamStorage {
+	return &StreamStorage{db: db}
+}
+
+// activityTypes are the domain events of balance changes, the ones streams carry
+var activityTypes = []string{models.DomainEventWalletToppedUp, models.DomainEventWalletDebited, models.DomainEventWalletCredited}
+
+// ListActivity returns the balance change events of the user's wallets after the
+// given domain event id, oldest first
+func (s *StreamStorage) ListActivity(userID string, afterID int64, limit int) ([]models.DomainEvent, error) {
+	rows, err := s.db.Query(`SELECT `+domainEventColumns+` FROM domain_events
+		WHERE payload->>'user_id' = $1 AND id > $2 AND event_type = ANY($3)
+		ORDER BY id LIMIT $4`, userID, afterID, pq.Array(activityTypes), limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanDomainEvents(rows)
+}
+
+func (s *StreamStorage) GetActivity(eventID int64) (*models.DomainEvent, error) {
+	event := &models.DomainEvent{}
+	err := scanDomainEvent(s.db.QueryRow(`SELECT `+domainEventColumns+` FROM domain_events
+		WHERE id=$1 AND event_type = ANY($2)`, eventID, pq.Array(activityTypes)), event)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type TopUpStorager interface {
+	CreateTopUp(topUp *models.ProviderTopUp) error
+	GetTopUp(topUpID string) (*models.ProviderTopUp, error)
+	GetTopUpByReference(provider, reference string) (*models.ProviderTopUp, error)
+	ListTopUps(userID string) ([]models.ProviderTopUp, error)
+	ListTopUpsByStatus(status string) ([]models.ProviderTopUp, error)
+	MarkTopUpPending(topUpID, reference string) (bool, error)
+	FailTopUp(topUpID, fromStatus, reason string) (bool, error)
+	CompleteTopUp(topUpID string) (bool, error)
+}
+
+// TopUpReferencePrefix marks the transaction of a provider top-up, followed by its id
+const TopUpReferencePrefix = "topup:"
+
+// topUpTransactionStatus is the status of the ledger transaction of a top-up in each
+// status before it is final
+var topUpTransactionStatus = map[string]string{
+	models.TopUpInitiated: models.TransactionCreated,
+	models.TopUpPending:   models.TransactionPending,
+}
+
+type TopUpStorage struct {
+	db *sql.DB
+}
+
+func NewTopUpStorage(db *sql.DB) *TopUpStorage {
+	return &TopUpStorage{db: db}
+}
+
+const topUpColumns = `id, user_id, wallet_id, provider, source_type, amount, status, COALESCE(provider_reference, ''),
+	failure_reason, COALESCE(transaction_id, 0), created_at, updated_at, completed_at`
+
+func scanTopUp(row interface{ Scan(...any) error }, t *models.ProviderTopUp) error {
+	return row.Scan(&t.ID, &t.UserID, &t.WalletID, &t.Provider, &t.SourceType, &t.Amount, &t.Status, &t.ProviderReference,
+		&t.FailureReason, &t.TransactionID, &t.CreatedAt, &t.UpdatedAt, &t.CompletedAt)
+}
+
+// CreateTopUp saves the top-up together with its ledger transaction, staged as
+// created. The transaction follows the top-up and only moves the balance once the
+// provider confirms the payment.
+func (s *TopUpStorage) CreateTopUp(topUp *models.ProviderTopUp) error {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return errors.Wrap(err, "unable to begin transaction to create top-up")
+	}
+
+	err = createTopUp(tx, topUp)
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Wrap(err, "unable to commit transaction")
+	}
+
+	return nil
+}
+
+func createTopUp(tx *sql.Tx, topUp *models.ProviderTopUp) error {
+	err := tx.QueryRow(`
+		INSERT INTO provider_topups (user_id, wallet_id, provider, source_type, amount, status)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at
+	`, topUp.UserID, topUp.WalletID, topUp.Provider, topUp.SourceType, topUp.Amount, topUp.Status).
+		Scan(&topUp.ID, &topUp.CreatedAt, &topUp.UpdatedAt)
+	if err != nil {
+		return errors.Wrap(err, "unable to create top-up")
+	}
+
+	t := &models.Transaction{
+		WalletID:    topUp.WalletID,
+		Amount:      topUp.Amount,
+		Reference:   TopUpReferencePrefix + topUp.ID,
+		Description: "Top-up via " + topUp.Provider,
+	}
+	if err := insertTransaction(tx, t); err != nil {
+		return err
+	}
+	topUp.TransactionID = t.ID
+
+	_, err = tx.Exec("UPDATE provider_topups SET transaction_id=$1 WHERE id=$2", topUp.TransactionID, topUp.ID)
+	return errors.Wrap(err, "unable to link top-up transaction")
+}
+
+func (s *TopUpStorage) GetTopUp(topUpID string) (*models.ProviderTopUp, error) {
+	topUp := &models.ProviderTopUp{}
+	if err := scanTopUp(s.db.QueryRow("SELECT "+topUpColumns+" FROM provider_topups WHERE id=$1", topUpID), topUp); err != nil {
+		return nil, err
+	}
+	return topUp, nil
+}
+
+func (s *TopUpStorage) GetTopUpByReference(provider, reference string) (*models.ProviderTopUp, error) {
+	topUp := &models.ProviderTopUp{}
+	err := scanTopUp(s.db.QueryRow("SELECT "+topUpColumns+" FROM provider_topups WHERE provider=$1 AND provider_reference=$2",
+		provider, reference), topUp)
+	if err != nil {
+		return nil, err
+	}
+	return topUp, nil
+}
+
+func (s *TopUpStorage) ListTopUps(userID string) ([]models.ProviderTopUp, error) {
+	return s.listTopUps("SELECT "+topUpColumns+" FROM provider_topups WHERE user_id=$1 ORDER BY created_at DESC", userID)
+}
+
+// ListTopUpsByStatus returns the top-ups in the status, oldest first
+func (s *TopUpStorage) ListTopUpsByStatus(status string) ([]models.ProviderTopUp, error) {
+	return s.listTopUps("SELECT "+topUpColumns+" FROM provider_topups WHERE status=$1 ORDER BY created_at", status)
+}
+
+func (s *TopUpStorage) listTopUps(query string, args ...any) ([]models.ProviderTopUp, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	topUps := []models.ProviderTopUp{}
+	for rows.Next() {
+		var topUp models.ProviderTopUp
+		if err := scanTopUp(rows, &topUp); err != nil {
+			return nil, err
+		}
+		topUps = append(topUps, topUp)
+	}
+
+	return topUps, rows.Err()
+}
+
+// MarkTopUpPending saves the provider's reference of an initiated top-up, its
+// transaction moves to pending with it
+func (s *TopUpStorage) MarkTopUpPending(topUpID, reference string) (bool, error) {
+	return s.moveTopUp(models.TopUpInitiated, models.TransactionPending, "", func(tx *sql.Tx) (int64, error) {
+		var transactionID int64
+		err := tx.QueryRow(`
+			UPDATE provider_topups SET status='pending', provider_reference=$1, updated_at=CURRENT_TIMESTAMP
+			WHERE id=$2 AND status='initiated'
+			RETURNING COALESCE(transaction_id, 0)
+		`, reference, topUpID).Scan(&transactionID)
+		return transactionID, err
+	})
+}
+
+// FailTopUp fails the top-up if it is still in fromStatus, its transaction fails
+// with the same reason
+func (s *TopUpStorage) FailTopUp(topUpID, fromStatus, reason string) (bool, error) {
+	return s.moveTopUp(fromStatus, models.TransactionFailed, reason, func(tx *sql.Tx) (int64, error) {
+		var transactionID int64
+		err := tx.QueryRow(`
+			UPDATE provider_topups SET status='failed', failure_reason=$1, updated_at=CURRENT_TIMESTAMP, completed_at=CURRENT_TIMESTAMP
+			WHERE id=$2 AND status=$3
+			RETURNING COALESCE(transaction_id, 0)
+		`, reason, topUpID, fromStatus).Scan(&transactionID)
+		return transactionID, err
+	})
+}
+
+// CompleteTopUp marks a pending top-up succeeded and completes its transaction,
+// which credits the wallet within its maximum balance. When the credit is refused
+// the top-up stays pending.
+func (s *TopUpStorage) CompleteTopUp(topUpID string) (bool, error) {
+	return s.moveTopUp(models.TopUpPending, models.TransactionCompleted, "", func(tx *sql.Tx) (int64, error) {
+		var transactionID int64
+		err := tx.QueryRow(`
+			UPDATE provider_topups SET status='succeeded', updated_at=CURRENT_TIMESTAMP, completed_at=CURRENT_TIMESTAMP
+			WHERE id=$1 AND status='pending'
+			RETURNING COALESCE(transaction_id, 0)
+		`, topUpID).Scan(&transactionID)
+		return transactionID, err
+	})
+}
+
+// moveTopUp changes the status of a top-up with update, which returns the id of
+// its transaction, and moves the transaction to toStatus in the same database
+// transaction. False means the top-up wasn't in fromStatus.
+func (s *TopUpStorage) moveTopUp(fromStatus, toStatus, reason string, update func(tx *sql.Tx) (int64, error)) (bool, error) {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return false, errors.Wrap(err, "unable to begin transaction to update top-up")
+	}
+
+	moved, err := moveTopUp(tx, fromStatus, toStatus, reason, update)
+	if err != nil || !moved {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return false, errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, errors.Wrap(err, "unable to commit transaction")
+	}
+
+	return true, nil
+}
+
+func moveTopUp(tx *sql.Tx, fromStatus, toStatus, reason string, update func(tx *sql.Tx) (int64, error)) (bool, error) {
+	transactionID, err := update(tx)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "unable to update top-up")
+	}
+
+	t, err := moveTransaction(tx, transactionID, topUpTransactionStatus[fromStatus], toStatus, reason, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if t == nil {
+		return false, errors.Errorf("transaction %d of top-up is not %s", transactionID, topUpTransactionStatus[fromStatus])
+	}
+
+	if toStatus == models.TransactionCompleted {
+		return true, completeTransaction(tx, t, true)
+	}
+	return true, nil
+}
@@ -0,0 +1,470 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type TransactionStorager interface {
+	GetTransaction(transactionID int64) (*models.Transaction, error)
+	ListTransactions(filter models.TransactionFilter) ([]models.Transaction, error)
+	UpdateTransactionDetails(request models.UpdateTransactionRequest) (*models.Transaction, error)
+	CreateTransaction(t *models.Transaction) error
+	TransitionTransaction(transactionID int64, fromStatus, toStatus, reason string) (bool, error)
+}
+
+// ErrInvalidTransition is returned for a status change the transaction state machine doesn't allow
+var ErrInvalidTransition = errors.New("transaction can't move to this status")
+
+// ReversalReferencePrefix marks the offsetting transactions of a reversal, followed
+// by the id of the reversed transaction
+const ReversalReferencePrefix = "reversal:"
+
+// transitionTimestamps names the column recording when a transaction reached a status
+var transitionTimestamps = map[string]string{
+	models.TransactionPending:   "pending_at",
+	models.TransactionCompleted: "completed_at",
+	models.TransactionFailed:    "failed_at",
+	models.TransactionReversed:  "reversed_at",
+}
+
+type TransactionStorage struct {
+	db *sql.DB
+}
+
+func NewTransactionStorage(db *sql.DB) *TransactionStorage {
+	return &TransactionStorage{db: db}
+}
+
+const transactionColumns = `id, wallet_id, amount, COALESCE(counterparty_wallet_id::text, ''), COALESCE(merchant_category, ''),
+	COALESCE(reference, ''), description, category, tags, metadata, balance_after, status, failure_reason, reversed_by, pair_id,
+	created_at, updated_at, pending_at, completed_at, failed_at, reversed_at`
+
+func scanTransaction(row interface{ Scan(...any) error }, t *models.Transaction) error {
+	var metadata []byte
+	err := row.Scan(&t.ID, &t.WalletID, &t.Amount, &t.CounterpartyWalletID, &t.MerchantCategory, &t.Reference,
+		&t.Description, &t.Category, pq.Array(&t.Tags), &metadata, &t.BalanceAfter, &t.Status, &t.FailureReason, &t.ReversedBy, &t.PairID,
+		&t.CreatedAt, &t.UpdatedAt, &t.PendingAt, &t.CompletedAt, &t.FailedAt, &t.ReversedAt)
+	t.Metadata = metadata
+	return err
+}
+
+func (s *TransactionStorage) GetTransaction(transactionID int64) (*models.Transaction, error) {
+	t := &models.Transaction{}
+	if err := scanTransaction(s.db.QueryRow("SELECT "+transactionColumns+" FROM transactions WHERE id=$1", transactionID), t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ListTransactions returns the wallet's transactions matching the filter, newest
+// first. Pages are continued with BeforeID set to the last id of the previous page.
+// Like balances at a point in time, the time bounds are compared in server local time.
+func (s *TransactionStorage) ListTransactions(filter models.TransactionFilter) ([]models.Transaction, error) {
+	conditions, args := transactionConditions(filter, nil)
+	if filter.BeforeID > 0 {
+		args = append(args, filter.BeforeID)
+		conditions = append(conditions, fmt.Sprintf("id < $%d", len(args)))
+	}
+	args = append(args, filter.Limit)
+
+	rows, err := s.db.Query(fmt.Sprintf("SELECT "+transactionColumns+" FROM transactions WHERE %s ORDER BY id DESC LIMIT $%d",
+		strings.Join(conditions, " AND "), len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.Transaction{}
+	for rows.Next() {
+		var t models.Transaction
+		if err := scanTransaction(rows, &t); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+
+	return transactions, rows.Err()
+}
+
+// UpdateTransactionDetails changes the notes of a transaction of the wallet. Fields
+// that are nil keep their value.
+func (s *TransactionStorage) UpdateTransactionDetails(request models.UpdateTransactionRequest) (*models.Transaction, error) {
+	// An empty list clears the tags, only a missing one keeps them
+	var tags any
+	if request.Tags != nil {
+		tags = pq.Array(append([]string{}, *request.Tags...))
+	}
+
+	t := &models.Transaction{}
+	err := scanTransaction(s.db.QueryRow(`
+		UPDATE transactions SET
+			description=COALESCE($1, description),
+			category=COALESCE($2, category),
+			tags=COALESCE($3::text[], tags),
+			updated_at=CURRENT_TIMESTAMP
+		WHERE id=$4 AND wallet_id=$5
+		RETURNING `+transactionColumns, request.Description, request.Category, tags, request.TransactionID, request.WalletID), t)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CreateTransaction stages a transaction of a single wallet. The balance is left
+// alone until the transaction is moved to completed.
+func (s *TransactionStorage) CreateTransaction(t *models.Transaction) error {
+	t.Status = models.TransactionCreated
+	return s.db.QueryRow(`
+		INSERT INTO transactions (wallet_id, amount, reference, description, category, tags, metadata, status, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, COALESCE($6::text[], '{}'), $7, $8, $9) RETURNING id, created_at
+	`, t.WalletID, t.Amount, t.Reference, t.Description, t.Category, pq.Array(t.Tags), metadataValue(t.Metadata), t.Status, time.Now()).
+		Scan(&t.ID, &t.CreatedAt)
+}
+
+// TransitionTransaction moves a transaction from fromStatus to toStatus, false
+// means it wasn't in fromStatus. The legs of a transfer move together. Completing
+// applies the amount and reversing posts offsetting transactions, for a transfer
+// on both wallets. Neither may overdraw a wallet. The reason is kept as the
+// failure reason or as the description of the reversal.
+func (s *TransactionStorage) TransitionTransaction(transactionID int64, fromStatus, toStatus, reason string) (bool, error) {
+	if !models.CanTransitionTransaction(fromStatus, toStatus) {
+		return false, ErrInvalidTransition
+	}
+
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return false, errors.Wrap(err, "unable to begin transaction to change transaction status")
+	}
+
+	moved, err := transitionTransaction(tx, transactionID, fromStatus, toStatus, reason)
+	if err != nil || !moved {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return false, errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, errors.Wrap(err, "unable to commit transaction")
+	}
+
+	return true, nil
+}
+
+func transitionTransaction(tx *sql.Tx, transactionID int64, fromStatus, toStatus, reason string) (bool, error) {
+	now := time.Now()
+	t, err := moveTransaction(tx, transactionID, fromStatus, toStatus, reason, now)
+	if err != nil || t == nil {
+		return false, err
+	}
+
+	// Both legs of a transfer change status together
+	var pair *models.Transaction
+	if t.PairID != nil {
+		pair, err = moveTransaction(tx, *t.PairID, fromStatus, toStatus, reason, now)
+		if err != nil {
+			return false, err
+		}
+		if pair == nil {
+			return false, errors.Errorf("other leg of transfer transaction %d is not %s", t.ID, fromStatus)
+		}
+	}
+
+	switch toStatus {
+	case models.TransactionCompleted:
+		if pair == nil {
+			return true, completeTransaction(tx, t, true)
+		}
+		debit, credit := transferLegs(t, pair)
+		_, err = completeTransfer(tx, debit, credit, true)
+		return true, err
+	case models.TransactionReversed:
+		return true, reverseTransaction(tx, t, pair, reason, now)
+	}
+	return true, nil
+}
+
+// insertTransaction stages the transaction as created, the balance is left alone
+// until it completes
+func insertTransaction(tx *sql.Tx, t *models.Transaction) error {
+	t.Status = models.TransactionCreated
+	err := tx.QueryRow(`
+		INSERT INTO transactions (wallet_id, amount, counterparty_wallet_id, merchant_category, reference, description, category, tags, metadata, status, created_at)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, NULLIF($4, ''), NULLIF($5, ''), $6, $7, COALESCE($8::text[], '{}'), $9, $10, $11) RETURNING id, created_at
+	`, t.WalletID, t.Amount, t.CounterpartyWalletID, t.MerchantCategory, t.Reference, t.Description, t.Category, pq.Array(t.Tags),
+		metadataValue(t.Metadata), t.Status, time.Now()).Scan(&t.ID, &t.CreatedAt)
+	return errors.Wrap(err, "unable to save transaction")
+}
+
+// moveTransaction changes the status of the transaction if it is in fromStatus and
+// returns it, nil when it isn't
+func moveTransaction(tx *sql.Tx, transactionID int64, fromStatus, toStatus, reason string, now time.Time) (*models.Transaction, error) {
+	failureReason := ""
+	if toStatus == models.TransactionFailed {
+		failureReason = reason
+		if reason := []rune(reason); len(reason) > models.MaxFailureReasonLength {
+			failureReason = string(reason[:models.MaxFailureReasonLength])
+		}
+	}
+
+	t := &models.Transaction{}
+	err := scanTransaction(tx.QueryRow(`
+		UPDATE transactions SET status=$1, `+transitionTimestamps[toStatus]+`=$2, failure_reason=$3
+		WHERE id=$4 AND status=$5
+		RETURNING `+transactionColumns, toStatus, now, failureReason, transactionID, fromStatus), t)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to update transaction status")
+	}
+	return t, nil
+}
+
+// advanceTransaction moves a staged transaction through pending to completed. Its
+// balance is applied separately by completeTransaction.
+func advanceTransaction(tx *sql.Tx, t *models.Transaction, now time.Time) error {
+	for _, status := range []string{models.TransactionPending, models.TransactionCompleted} {
+		if t.Status == status {
+			continue
+		}
+		moved, err := moveTransaction(tx, t.ID, t.Status, status, "", now)
+		if err != nil {
+			return err
+		}
+		if moved == nil {
+			return errors.Errorf("transaction %d is no longer %s", t.ID, t.Status)
+		}
+		*t = *moved
+	}
+	return nil
+}
+
+// completeTransaction applies a transaction that moved to completed to the balance
+// of its wallet. A debit may not overdraw the wallet, a limited credit must keep it
+// within the maximum balance of its owner.
+func completeTransaction(tx *sql.Tx, t *models.Transaction, limited bool) error {
+	var balance int64
+	var userID string
+	var err error
+	if t.Amount < 0 {
+		err = tx.QueryRow("UPDATE wallets SET balance=balance+$1 WHERE id=$2 AND balance+$1 >= 0 RETURNING balance, user_id", t.Amount, t.WalletID).Scan(&balance, &userID)
+		if err == sql.ErrNoRows {
+			return ErrInsufficientFunds
+		}
+		if err != nil {
+			return errors.Wrap(err, "unable to debit wallet")
+		}
+	} else if balance, userID, err = creditWallet(tx, t.WalletID, t.Amount, limited); err != nil {
+		return err
+	}
+
+	t.BalanceAfter = &balance
+	_, err = tx.Exec("UPDATE transactions SET balance_after=$1 WHERE id=$2", balance, t.ID)
+	if err != nil {
+		return errors.Wrap(err, "unable to save balance after transaction")
+	}
+
+	return insertBalanceEvent(tx, models.WalletEventPayload{
+		WalletID:             t.WalletID,
+		UserID:               userID,
+		Amount:               t.Amount,
+		Balance:              balance,
+		TransactionID:        t.ID,
+		CounterpartyWalletID: t.CounterpartyWalletID,
+		Reference:            t.Reference,
+		Category:             t.Category,
+	})
+}
+
+// stageTransfer writes both legs of a transfer as created, each pointing at the
+// other. The credit only carries the notes both sides share.
+func stageTransfer(tx *sql.Tx, fromWalletID, toWalletID string, amount int64, details models.TransactionDetails) (*models.Transaction, *models.Transaction, error) {
+	debit := &models.Transaction{
+		WalletID:             fromWalletID,
+		Amount:               -amount,
+		CounterpartyWalletID: toWalletID,
+		MerchantCategory:     details.MerchantCategory,
+		Reference:            details.Reference,
+		Description:          details.Description,
+		Category:             details.Category,
+		Tags:                 details.Tags,
+		Metadata:             details.Metadata,
+	}
+	credit := &models.Transaction{
+		WalletID:             toWalletID,
+		Amount:               amount,
+		CounterpartyWalletID: fromWalletID,
+		MerchantCategory:     details.MerchantCategory,
+		Reference:            details.Reference,
+		Description:          details.Description,
+		Metadata:             details.Metadata,
+	}
+	for _, t := range []*models.Transaction{debit, credit} {
+		if err := insertTransaction(tx, t); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	_, err := tx.Exec("UPDATE transactions SET pair_id = CASE id WHEN $1 THEN $2::integer ELSE $1::integer END WHERE id IN ($1, $2)", debit.ID, credit.ID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to pair transfer transactions")
+	}
+	debit.PairID, credit.PairID = &credit.ID, &debit.ID
+
+	return debit, credit, nil
+}
+
+// settleTransfer moves both legs of a staged transfer through pending to completed
+// and applies them
+func settleTransfer(tx *sql.Tx, debit, credit *models.Transaction, limited bool) (*models.TransferResult, error) {
+	now := time.Now()
+	for _, t := range []*models.Transaction{debit, credit} {
+		if err := advanceTransaction(tx, t, now); err != nil {
+			return nil, err
+		}
+	}
+	return completeTransfer(tx, debit, credit, limited)
+}
+
+// completeTransfer applies both legs of a transfer that moved to completed
+func completeTransfer(tx *sql.Tx, debit, credit *models.Transaction, limited bool) (*models.TransferResult, error) {
+	// Lock both wallets in a stable order so opposite transfers don't deadlock
+	_, err := tx.Exec("SELECT id FROM wallets WHERE id IN ($1, $2) ORDER BY id FOR UPDATE", debit.WalletID, credit.WalletID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to lock wallets")
+	}
+
+	if err := completeTransaction(tx, debit, limited); err != nil {
+		return nil, err
+	}
+	if err := completeTransaction(tx, credit, limited); err != nil {
+		return nil, err
+	}
+
+	return &models.TransferResult{
+		DebitTransactionID:  debit.ID,
+		CreditTransactionID: credit.ID,
+		FromBalance:         *debit.BalanceAfter,
+		ToBalance:           *credit.BalanceAfter,
+	}, nil
+}
+
+// transferLegs tells the debit and the credit of a transfer apart
+func transferLegs(t, pair *models.Transaction) (*models.Transaction, *models.Transaction) {
+	if t.Amount < 0 {
+		return t, pair
+	}
+	return pair, t
+}
+
+// reverseTransaction posts the offsetting transactions of a completed one. A
+// transfer is reversed as a whole by transferring the amount back, its other leg
+// was moved to reversed along with it.
+func reverseTransaction(tx *sql.Tx, t, pair *models.Transaction, reason string, now time.Time) error {
+	if strings.HasPrefix(t.Reference, ReversalReferencePrefix) {
+		return ErrInvalidTransition
+	}
+	details := models.TransactionDetails{
+		Reference:   ReversalReferencePrefix + strconv.FormatInt(t.ID, 10),
+		Description: reason,
+	}
+
+	if pair == nil {
+		offset := &models.Transaction{
+			WalletID:    t.WalletID,
+			Amount:      -t.Amount,
+			Reference:   details.Reference,
+			Description: details.Description,
+			Category:    t.Category,
+		}
+		if err := insertTransaction(tx, offset); err != nil {
+			return err
+		}
+		if err := advanceTransaction(tx, offset, now); err != nil {
+			return err
+		}
+		if err := completeTransaction(tx, offset, false); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec("UPDATE transactions SET reversed_by=$1 WHERE id=$2", offset.ID, t.ID)
+		return errors.Wrap(err, "unable to link reversal transaction")
+	}
+
+	debit, credit := transferLegs(t, pair)
+	result, err := refund(tx, credit.WalletID, debit.WalletID, credit.Amount, details)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE transactions SET reversed_by = CASE id WHEN $1 THEN $2::integer ELSE $3::integer END WHERE id IN ($1, $4)",
+		debit.ID, result.CreditTransactionID, result.DebitTransactionID, credit.ID)
+	return errors.Wrap(err, "unable to link reversal transactions")
+}
+
+// transactionConditions turns the filter into WHERE conditions on the transactions
+// table. Their placeholders are numbered after args, which are returned extended.
+func transactionConditions(filter models.TransactionFilter, args []any) ([]string, []any) {
+	var conditions []string
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.WalletID != "" {
+		where("wallet_id=$%d", filter.WalletID)
+	}
+	if filter.Status != "" {
+		where("status=$%d", filter.Status)
+	}
+	switch filter.Type {
+	case models.TransactionTypeCredit:
+		conditions = append(conditions, "amount > 0")
+	case models.TransactionTypeDebit:
+		conditions = append(conditions, "amount < 0")
+	}
+	if filter.Category != "" {
+		where("category=$%d", filter.Category)
+	}
+	if len(filter.Tags) > 0 {
+		where("tags @> $%d", pq.Array(filter.Tags))
+	}
+	if filter.Description != "" {
+		where("description ILIKE '%%' || $%d || '%%'", escapeLike(filter.Description))
+	}
+	if len(filter.Metadata) > 0 {
+		where("metadata @> $%d::jsonb", string(filter.Metadata))
+	}
+	if filter.From != nil {
+		where("created_at >= $%d", filter.From.In(time.Local))
+	}
+	if filter.To != nil {
+		where("created_at < $%d", filter.To.In(time.Local))
+	}
+	if filter.MinAmountUnits > 0 {
+		where("ABS(amount) >= $%d", filter.MinAmountUnits)
+	}
+	if filter.MaxAmountUnits > 0 {
+		where("ABS(amount) <= $%d", filter.MaxAmountUnits)
+	}
+
+	return conditions, args
+}
+
+// escapeLike makes the wildcards of a LIKE pattern match literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type VoucherStorager interface {
+	CreateBatch(batch *models.VoucherBatch, hashes, hints []string) error
+	GetBatch(batchID string) (*models.VoucherBatch, error)
+	ListBatches() ([]models.VoucherBatch, error)
+	RevokeBatch(batchID, reason string) (bool, error)
+	GetBatchReport(batchID string) (*models.VoucherUsageReport, error)
+	GetVoucherByHash(hash string) (*models.Voucher, error)
+	RedeemVoucher(voucherID, userID, walletID string, now time.Time) TransferHook
+}
+
+// ErrVoucherNotAvailable is returned when the voucher was redeemed, revoked or has
+// expired before the credit redeeming it completed
+var ErrVoucherNotAvailable = errors.New("voucher is no longer available")
+
+type VoucherStorage struct {
+	db *sql.DB
+}
+
+func NewVoucherStorage(db *sql.DB) *VoucherStorage {
+	return &VoucherStorage{db: db}
+}
+
+const voucherBatchColumns = `id, name, amount, voucher_count, status, expires_at, revoke_reason, created_at, revoked_at`
+
+const voucherColumns = `v.id, v.batch_id, v.code_hint, v.status, b.amount, COALESCE(v.redeemed_by::text, ''),
+	COALESCE(v.wallet_id::text, ''), COALESCE(v.transaction_id, 0), v.redeemed_at, b.expires_at, b.status`
+
+func scanVoucherBatch(row interface{ Scan(...any) error }, b *models.VoucherBatch) error {
+	return row.Scan(&b.ID, &b.Name, &b.Amount, &b.Count, &b.Status, &b.ExpiresAt, &b.RevokeReason, &b.CreatedAt, &b.RevokedAt)
+}
+
+func scanVoucher(row interface{ Scan(...any) error }, v *models.Voucher) error {
+	return row.Scan(&v.ID, &v.BatchID, &v.CodeHint, &v.Status, &v.Amount, &v.RedeemedBy, &v.WalletID, &v.TransactionID,
+		&v.RedeemedAt, &v.ExpiresAt, &v.BatchStatus)
+}
+
+// CreateBatch saves the batch together with the hashes of its codes
+func (s *VoucherStorage) CreateBatch(batch *models.VoucherBatch, hashes, hints []string) error {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return errors.Wrap(err, "unable to begin transaction to create voucher batch")
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO voucher_batches (name, amount, voucher_count, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at
+	`, batch.Name, batch.Amount, batch.Count, batch.Status, batch.ExpiresAt).Scan(&batch.ID, &batch.CreatedAt)
+	if err == nil {
+		_, err = tx.Exec(`
+			INSERT INTO vouchers (batch_id, code_hash, code_hint, status)
+			SELECT $1, hash, hint, $4 FROM unnest($2::text[], $3::text[]) AS codes(hash, hint)
+		`, batch.ID, pq.Array(hashes), pq.Array(hints), models.VoucherAvailable)
+	}
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return errors.Wrap(err, "unable to create voucher batch")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Wrap(err, "unable to commit transaction")
+	}
+
+	return nil
+}
+
+func (s *VoucherStorage) GetBatch(batchID string) (*models.VoucherBatch, error) {
+	batch := &models.VoucherBatch{}
+	if err := scanVoucherBatch(s.db.QueryRow("SELECT "+voucherBatchColumns+" FROM voucher_batches WHERE id=$1", batchID), batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func (s *VoucherStorage) ListBatches() ([]models.VoucherBatch, error) {
+	rows, err := s.db.Query("SELECT " + voucherBatchColumns + " FROM voucher_batches ORDER BY created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := []models.VoucherBatch{}
+	for rows.Next() {
+		var batch models.VoucherBatch
+		if err := scanVoucherBatch(rows, &batch); err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches, rows.Err()
+}
+
+// RevokeBatch stops the vouchers of the batch that aren't redeemed yet from being redeemed
+func (s *VoucherStorage) RevokeBatch(batchID, reason string) (bool, error) {
+	res, err := s.db.Exec(`
+		UPDATE voucher_batches SET status=$1, revoke_reason=$2, revoked_at=CURRENT_TIMESTAMP
+		WHERE id=$3 AND status=$4
+	`, models.VoucherBatchRevoked, reason, batchID, models.VoucherBatchActive)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
+
+// GetBatchReport counts the vouchers of the batch
+func (s *VoucherStorage) GetBatchReport(batchID string) (*models.VoucherUsageReport, error) {
+	batch, err := s.GetBatch(batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.VoucherUsageReport{Batch: *batch}
+	err = s.db.QueryRow(`
+		SELECT COUNT(*) FILTER (WHERE status <> $2), COUNT(*) FILTER (WHERE status = $2),
+			MIN(redeemed_at), MAX(redeemed_at)
+		FROM vouchers WHERE batch_id=$1
+	`, batchID, models.VoucherRedeemed).Scan(&report.Available, &report.Redeemed, &report.FirstRedeemedAt, &report.LastRedeemedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	report.RedeemedAmount = int64(report.Redeemed) * batch.Amount
+	report.OutstandingAmount = int64(report.Available) * batch.Amount
+	return report, nil
+}
+
+func (s *VoucherStorage) GetVoucherByHash(hash string) (*models.Voucher, error) {
+	voucher := &models.Voucher{}
+	err := scanVoucher(s.db.QueryRow(`
+		SELECT `+voucherColumns+` FROM vouchers v JOIN voucher_batches b ON b.id = v.batch_id WHERE v.code_hash=$1
+	`, hash), voucher)
+	if err != nil {
+		return nil, err
+	}
+	return voucher, nil
+}
+
+// RedeemVoucher marks an available voucher of an active, unexpired batch redeemed by
+// the credit paying it, in the transaction of that credit. Concurrent redemptions
+// of the same voucher wait for each other and only the first completes, the others
+// fail with ErrVoucherNotAvailable and roll their credit back.
+func (s *VoucherStorage) RedeemVoucher(voucherID, userID, walletID string, now time.Time) TransferHook {
+	return func(tx *sql.Tx, result *models.TransferResult) error {
+		res, err := tx.Exec(`
+			UPDATE vouchers v SET status=$1, redeemed_by=$2, wallet_id=$3, transaction_id=$4, redeemed_at=$5
+			FROM voucher_batches b
+			WHERE b.id = v.batch_id AND v.id=$6 AND v.status=$7
+				AND b.status=$8 AND (b.expires_at IS NULL OR b.expires_at > $5)
+		`, models.VoucherRedeemed, userID, walletID, result.CreditTransactionID, now, voucherID, models.VoucherAvailable, models.VoucherBatchActive)
+		if err != nil {
+			return errors.Wrap(err, "unable to redeem voucher")
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "unable to redeem voucher")
+		}
+		if affected == 0 {
+			return ErrVoucherNotAvailable
+		}
+		return nil
+	}
+}
@@ -3,8 +3,10 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"github.com/rasul07/alif-task/internal/models"
 )
@@ -12,12 +14,33 @@ import (
 type WalletStorager interface {
 	CheckWalletExists(walletID, userID string) (bool, error)
 	GetWallet(walletID, userID string) (*models.Wallet, error)
-	UpdateWalletBalance(walletID, userID string, newBalance, amount int64) error
+	CreditWallet(walletID string, amount int64, details models.TransactionDetails, hooks ...TransferHook) (int64, error)
 	GetTransactions(walletID string) (int, int64, error)
 	GetBalance(walletID, userID string) (int64, error)
 	IsIdentified(userID string) (bool, error)
+	GetWalletByID(walletID string) (*models.Wallet, error)
+	ListWalletsByUsers(userIDs []string) ([]models.Wallet, error)
+	ListWalletsByIDs(walletIDs []string) ([]models.Wallet, error)
+	Transfer(fromWalletID, toWalletID string, amount int64, details models.TransactionDetails, hooks ...TransferHook) (*models.TransferResult, error)
+	GetMerchantCategory(walletID string) (string, error)
+	HasReference(walletID, reference string) (bool, error)
+	NextSnapshotDate() (time.Time, bool, error)
+	CreateSnapshots(day time.Time) (int, error)
+	GetBalanceAt(walletID string, at time.Time) (*models.HistoricalBalance, error)
 }
 
+// ErrInsufficientFunds is returned when the source wallet can't cover a debit
+var (
+	ErrInsufficientFunds  = errors.New("insufficient funds")
+	ErrMaxBalanceExceeded = errors.New("operation would exceed maximum balance")
+)
+
+// TransferHook runs in the database transaction of a transfer or a credit once the
+// balances moved. Returning an error rolls the whole transfer back, so hooks are used
+// to change the state of whatever the transfer pays for together with the ledger.
+// A credit has no debit leg, its result carries only the credit transaction.
+type TransferHook func(tx *sql.Tx, result *models.TransferResult) error
+
 type WalletStorage struct {
 	db *sql.DB
 }
@@ -41,38 +64,71 @@ func (s *WalletStorage) GetWallet(walletID, userID string) (*models.Wallet, erro
 	return wallet, nil
 }
 
-func (s *WalletStorage) UpdateWalletBalance(walletID, userID string, newBalance, amount int64) error {
+// CreditWallet tops the wallet up within its maximum balance and returns the id of
+// the transaction. The transaction is staged before the balance changes, then
+// completed in a database transaction that also runs the hooks. A refused credit
+// leaves it failed with the reason.
+func (s *WalletStorage) CreditWallet(walletID string, amount int64, details models.TransactionDetails, hooks ...TransferHook) (int64, error) {
+	t := &models.Transaction{
+		WalletID:    walletID,
+		Amount:      amount,
+		Reference:   details.Reference,
+		Description: details.Description,
+		Category:    details.Category,
+		Tags:        details.Tags,
+		Metadata:    details.Metadata,
+	}
+
 	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
-		return errors.Wrap(err, "unable to begin transaction to save card relation")
+		return 0, errors.Wrap(err, "unable to begin transaction to stage top-up")
 	}
 
-	_, err = tx.Exec("UPDATE wallets SET balance=$1 WHERE id=$2 and user_id=$3", newBalance, walletID, userID)
+	err = insertTransaction(tx, t)
 	if err != nil {
 		rollbackErr := tx.Rollback()
 		if rollbackErr != nil {
-			return errors.Wrap(rollbackErr, "unable to rollback transaction")
+			return 0, errors.Wrap(rollbackErr, "unable to rollback transaction")
 		}
 
-		return errors.Wrap(err, "unable to execute transaction")
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to commit transaction")
+	}
+
+	tx, err = s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return 0, s.failStaged(errors.Wrap(err, "unable to begin transaction to credit wallet"), t)
 	}
 
-	_, err = tx.Exec("INSERT INTO transactions (wallet_id, amount, created_at) VALUES ($1, $2, $3)", walletID, amount, time.Now())
+	err = advanceTransaction(tx, t, time.Now())
+	if err == nil {
+		err = completeTransaction(tx, t, true)
+	}
+	if err == nil && len(hooks) > 0 {
+		result := &models.TransferResult{CreditTransactionID: t.ID, ToBalance: *t.BalanceAfter}
+		for i := 0; err == nil && i < len(hooks); i++ {
+			err = hooks[i](tx, result)
+		}
+	}
 	if err != nil {
 		rollbackErr := tx.Rollback()
 		if rollbackErr != nil {
-			return errors.Wrap(rollbackErr, "unable to rollback transaction")
+			return 0, errors.Wrap(rollbackErr, "unable to rollback transaction")
 		}
 
-		return errors.Wrap(err, "unable to execute transaction")
+		return 0, s.failStaged(err, t)
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		return errors.Wrap(err, "unable to commit transaction")
+		return 0, s.failStaged(errors.Wrap(err, "unable to commit transaction"), t)
 	}
 
-	return err
+	return t.ID, nil
 }
 
 func (s *WalletStorage) GetTransactions(walletID string) (int, int64, error) {
@@ -83,7 +139,7 @@ func (s *WalletStorage) GetTransactions(walletID string) (int, int64, error) {
 		SELECT COUNT(*), COALESCE(SUM(t.amount), 0)
 		FROM transactions t
 		JOIN wallets w ON t.wallet_id = w.id
-		WHERE w.id=$1 AND t.created_at >= DATE_TRUNC('month', CURRENT_DATE)
+		WHERE w.id=$1 AND t.completed_at >= DATE_TRUNC('month', CURRENT_DATE)
 	`, walletID).Scan(&count, &total)
 
 	return count, total, err
@@ -100,3 +156,262 @@ func (s *WalletStorage) IsIdentified(userID string) (bool, error) {
 	err := s.db.QueryRow("SELECT is_identified FROM users WHERE id=$1", userID).Scan(&identified)
 	return identified, err
 }
+
+func (s *WalletStorage) GetWalletByID(walletID string) (*models.Wallet, error) {
+	wallet := &models.Wallet{}
+	err := s.db.QueryRow("SELECT id, user_id, balance FROM wallets WHERE id=$1", walletID).Scan(&wallet.ID, &wallet.UserID, &wallet.Balance)
+	if err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// ListWalletsByUsers returns the wallets of all given users in one query
+func (s *WalletStorage) ListWalletsByUsers(userIDs []string) ([]models.Wallet, error) {
+	return s.listWallets("SELECT id, user_id, balance FROM wallets WHERE user_id = ANY($1::uuid[]) ORDER BY id", userIDs)
+}
+
+// ListWalletsByIDs returns the given wallets that exist, in one query
+func (s *WalletStorage) ListWalletsByIDs(walletIDs []string) ([]models.Wallet, error) {
+	return s.listWallets("SELECT id, user_id, balance FROM wallets WHERE id = ANY($1::uuid[]) ORDER BY id", walletIDs)
+}
+
+func (s *WalletStorage) listWallets(query string, ids []string) ([]models.Wallet, error) {
+	rows, err := s.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wallets []models.Wallet
+	for rows.Next() {
+		var wallet models.Wallet
+		if err := rows.Scan(&wallet.ID, &wallet.UserID, &wallet.Balance); err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, wallet)
+	}
+
+	return wallets, rows.Err()
+}
+
+// Transfer moves amount between two wallets. Both legs are staged first, then
+// completed in a database transaction that also runs the hooks in the given order.
+// A transfer that can't complete leaves its legs failed with the reason.
+func (s *WalletStorage) Transfer(fromWalletID, toWalletID string, amount int64, details models.TransactionDetails, hooks ...TransferHook) (*models.TransferResult, error) {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to begin transaction to stage transfer")
+	}
+
+	debit, credit, err := stageTransfer(tx, fromWalletID, toWalletID, amount, details)
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return nil, errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to commit transaction")
+	}
+
+	tx, err = s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, s.failStaged(errors.Wrap(err, "unable to begin transaction to transfer funds"), debit, credit)
+	}
+
+	result, err := settleTransfer(tx, debit, credit, true)
+	for i := 0; err == nil && i < len(hooks); i++ {
+		err = hooks[i](tx, result)
+	}
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return nil, errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return nil, s.failStaged(err, debit, credit)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, s.failStaged(errors.Wrap(err, "unable to commit transaction"), debit, credit)
+	}
+
+	return result, nil
+}
+
+// failStaged marks staged transactions that couldn't complete failed with cause as
+// the reason and returns cause. Transactions that can't be marked stay created,
+// which doesn't move a balance either.
+func (s *WalletStorage) failStaged(cause error, transactions ...*models.Transaction) error {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return cause
+	}
+
+	now := time.Now()
+	for _, t := range transactions {
+		if _, err := moveTransaction(tx, t.ID, models.TransactionCreated, models.TransactionFailed, cause.Error(), now); err != nil {
+			_ = tx.Rollback()
+			return cause
+		}
+	}
+
+	_ = tx.Commit()
+	return cause
+}
+
+// creditWallet adds amount to the wallet balance and returns the new balance and
+// the wallet owner. A limited credit must keep the balance within the maximum for
+// the owner, system wallets have none. The limit is checked by the update itself,
+// so concurrent credits can't both pass it.
+func creditWallet(tx *sql.Tx, walletID string, amount int64, limited bool) (int64, string, error) {
+	var balance int64
+	var userID string
+	err := tx.QueryRow(`
+		UPDATE wallets w SET balance = w.balance + $1
+		FROM users u
+		WHERE w.id = $2 AND u.id = w.user_id AND (
+			NOT $3
+			OR w.balance + $1 <= CASE WHEN u.is_identified THEN $4 ELSE $5 END
+			OR EXISTS (SELECT 1 FROM system_wallets s WHERE s.wallet_id = w.id)
+		)
+		RETURNING w.balance, w.user_id
+	`, amount, walletID, limited, models.MaxBalanceIdentified, models.MaxBalanceUnidentified).Scan(&balance, &userID)
+	if err == sql.ErrNoRows {
+		exists := false
+		if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM wallets WHERE id=$1)", walletID).Scan(&exists); err != nil {
+			return 0, "", errors.Wrap(err, "unable to credit wallet")
+		}
+		if exists {
+			return 0, "", ErrMaxBalanceExceeded
+		}
+		return 0, "", sql.ErrNoRows
+	}
+	if err != nil {
+		return 0, "", errors.Wrap(err, "unable to credit wallet")
+	}
+
+	return balance, userID, nil
+}
+
+// transfer moves amount between the wallets, the recipient must stay within its
+// maximum balance
+func transfer(tx *sql.Tx, fromWalletID, toWalletID string, amount int64, details models.TransactionDetails) (*models.TransferResult, error) {
+	return moveFunds(tx, fromWalletID, toWalletID, amount, details, true)
+}
+
+// refund moves amount back to a wallet it was taken from, which may take the wallet
+// over its maximum balance
+func refund(tx *sql.Tx, fromWalletID, toWalletID string, amount int64, details models.TransactionDetails) (*models.TransferResult, error) {
+	return moveFunds(tx, fromWalletID, toWalletID, amount, details, false)
+}
+
+func moveFunds(tx *sql.Tx, fromWalletID, toWalletID string, amount int64, details models.TransactionDetails, limited bool) (*models.TransferResult, error) {
+	debit, credit, err := stageTransfer(tx, fromWalletID, toWalletID, amount, details)
+	if err != nil {
+		return nil, err
+	}
+	return settleTransfer(tx, debit, credit, limited)
+}
+
+// GetMerchantCategory returns the category code of the merchant settling into the
+// wallet, an empty string when the wallet doesn't belong to a merchant
+func (s *WalletStorage) GetMerchantCategory(walletID string) (string, error) {
+	var category string
+	err := s.db.QueryRow("SELECT merchant_category FROM merchants WHERE settlement_wallet_id=$1 ORDER BY created_at LIMIT 1", walletID).Scan(&category)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return category, err
+}
+
+// metadataValue stores missing metadata as an empty object
+func metadataValue(metadata json.RawMessage) string {
+	if len(metadata) == 0 || string(metadata) == "null" {
+		return "{}"
+	}
+	return string(metadata)
+}
+
+// HasReference checks whether the wallet already has a transaction with the reference
+// that moved its balance
+func (s *WalletStorage) HasReference(walletID, reference string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM transactions WHERE wallet_id=$1 AND reference=$2 AND completed_at IS NOT NULL)", walletID, reference).Scan(&exists)
+	return exists, err
+}
+
+// NextSnapshotDate returns the first day without balance snapshots. Before the first
+// snapshot it is the day of the first transaction, false means there is nothing to
+// snapshot yet.
+func (s *WalletStorage) NextSnapshotDate() (time.Time, bool, error) {
+	var day sql.NullTime
+	err := s.db.QueryRow(`
+		SELECT COALESCE(
+			(SELECT MAX(snapshot_date) + 1 FROM balance_snapshots),
+			(SELECT MIN(completed_at)::date FROM transactions)
+		)
+	`).Scan(&day)
+	return day.Time, day.Valid, err
+}
+
+// CreateSnapshots saves the end-of-day balance of every wallet for the day. The
+// balance is the previous snapshot plus the transactions completed since, wallets
+// without a snapshot replay their whole ledger. Existing snapshots are kept.
+func (s *WalletStorage) CreateSnapshots(day time.Time) (int, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO balance_snapshots (wallet_id, snapshot_date, balance, transaction_count)
+		SELECT w.id, $1::date, COALESCE(prev.balance, 0) + COALESCE(replay.amount, 0), COALESCE(replay.count, 0)
+		FROM wallets w
+		LEFT JOIN LATERAL (
+			SELECT snapshot_date, balance FROM balance_snapshots
+			WHERE wallet_id = w.id AND snapshot_date < $1::date
+			ORDER BY snapshot_date DESC LIMIT 1
+		) prev ON true
+		LEFT JOIN LATERAL (
+			SELECT SUM(t.amount) AS amount, COUNT(*) AS count FROM transactions t
+			WHERE t.wallet_id = w.id AND t.completed_at < $1::date + 1
+				AND t.completed_at >= COALESCE(prev.snapshot_date + 1, '-infinity'::date)
+		) replay ON true
+		ON CONFLICT (wallet_id, snapshot_date) DO NOTHING
+	`, day.Format(time.DateOnly))
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to create balance snapshots")
+	}
+
+	affected, err := res.RowsAffected()
+	return int(affected), err
+}
+
+// GetBalanceAt replays the transactions completed after the nearest snapshot up to at.
+// Transaction times are stored in server local time, so at is converted to it.
+func (s *WalletStorage) GetBalanceAt(walletID string, at time.Time) (*models.HistoricalBalance, error) {
+	balance := &models.HistoricalBalance{WalletID: walletID, At: at}
+	var snapshotDate sql.NullTime
+	err := s.db.QueryRow(`
+		WITH snapshot AS (
+			SELECT snapshot_date, balance FROM balance_snapshots
+			WHERE wallet_id=$1 AND snapshot_date + 1 <= $2::timestamp
+			ORDER BY snapshot_date DESC LIMIT 1
+		)
+		SELECT COALESCE((SELECT balance FROM snapshot), 0) + COALESCE(SUM(t.amount), 0), COUNT(t.id),
+			(SELECT snapshot_date FROM snapshot)
+		FROM transactions t
+		WHERE t.wallet_id=$1 AND t.completed_at <= $2::timestamp
+			AND t.completed_at >= COALESCE((SELECT snapshot_date + 1 FROM snapshot), '-infinity'::date)
+	`, walletID, at.In(time.Local)).Scan(&balance.Balance, &balance.TransactionsReplayed, &snapshotDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshotDate.Valid {
+		balance.SnapshotDate = &snapshotDate.Time
+	}
+	return balance, nil
+}
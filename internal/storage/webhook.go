@@ -0,0 +1,309 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/rasul07/alif-task/internal/models"
+)
+
+type WebhookStorager interface {
+	CreateSubscription(subscription *models.WebhookSubscription) error
+	GetSubscription(subscriptionID string) (*models.WebhookSubscription, error)
+	ListSubscriptions(userID string) ([]models.WebhookSubscription, error)
+	ListSubscriptionsForEvent(userID, eventType string) ([]models.WebhookSubscription, error)
+	DeleteSubscription(subscriptionID, userID string) (bool, error)
+	CreateDelivery(delivery *models.WebhookDelivery) error
+	ClaimDueDeliveries(limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	RecordAttempt(attempt models.WebhookAttempt) error
+	MarkDelivered(deliveryID string, attempts int) error
+	ScheduleRetry(deliveryID string, attempts int, nextAttemptAt time.Time, lastError string) error
+	MoveToDeadLetter(delivery models.WebhookDelivery) error
+	ListDeliveries(subscriptionID string, limit int) ([]models.WebhookDelivery, error)
+	ListDeadLetters(limit int) ([]models.WebhookDeadLetter, error)
+	ReplayDeadLetter(deadLetterID string) (bool, error)
+}
+
+type WebhookStorage struct {
+	db *sql.DB
+}
+
+func NewWebhookStorage(db *sql.DB) *WebhookStorage {
+	return &WebhookStorage{db: db}
+}
+
+const subscriptionColumns = `id, user_id, url, secret, event_types, created_at`
+
+func scanSubscription(row interface{ Scan(...any) error }, subscription *models.WebhookSubscription) error {
+	return row.Scan(&subscription.ID, &subscription.UserID, &subscription.URL, &subscription.Secret,
+		pq.Array(&subscription.EventTypes), &subscription.CreatedAt)
+}
+
+func (s *WebhookStorage) CreateSubscription(subscription *models.WebhookSubscription) error {
+	return s.db.QueryRow(`
+		INSERT INTO webhook_subscriptions (user_id, url, secret, event_types)
+		VALUES ($1, $2, $3, $4) RETURNING id, created_at
+	`, subscription.UserID, subscription.URL, subscription.Secret, pq.Array(subscription.EventTypes)).
+		Scan(&subscription.ID, &subscription.CreatedAt)
+}
+
+func (s *WebhookStorage) GetSubscription(subscriptionID string) (*models.WebhookSubscription, error) {
+	subscription := &models.WebhookSubscription{}
+	err := scanSubscription(s.db.QueryRow("SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE id=$1", subscriptionID), subscription)
+	if err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *WebhookStorage) ListSubscriptions(userID string) ([]models.WebhookSubscription, error) {
+	return s.listSubscriptions(`
+		SELECT `+subscriptionColumns+` FROM webhook_subscriptions
+		WHERE user_id=$1 ORDER BY created_at
+	`, userID)
+}
+
+// ListSubscriptionsForEvent returns the user's subscriptions that accept eventType.
+// A subscription without event types accepts every event.
+func (s *WebhookStorage) ListSubscriptionsForEvent(userID, eventType string) ([]models.WebhookSubscription, error) {
+	return s.listSubscriptions(`
+		SELECT `+subscriptionColumns+` FROM webhook_subscriptions
+		WHERE user_id=$1 AND (cardinality(event_types) = 0 OR $2 = ANY(event_types))
+		ORDER BY created_at
+	`, userID, eventType)
+}
+
+func (s *WebhookStorage) listSubscriptions(query string, args ...any) ([]models.WebhookSubscription, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []models.WebhookSubscription{}
+	for rows.Next() {
+		var subscription models.WebhookSubscription
+		if err := scanSubscription(rows, &subscription); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func (s *WebhookStorage) DeleteSubscription(subscriptionID, userID string) (bool, error) {
+	res, err := s.db.Exec("DELETE FROM webhook_subscriptions WHERE id=$1 AND user_id=$2", subscriptionID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// CreateDelivery queues the delivery. An event is queued only once per subscription,
+// the outbox relay may hand the same event over again.
+func (s *WebhookStorage) CreateDelivery(delivery *models.WebhookDelivery) error {
+	err := s.db.QueryRow(`
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+		RETURNING id, next_attempt_at, created_at
+	`, delivery.SubscriptionID, delivery.EventID, delivery.EventType, []byte(delivery.Payload), delivery.Status).
+		Scan(&delivery.ID, &delivery.NextAttemptAt, &delivery.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+// ClaimDueDeliveries locks up to limit pending deliveries that are due for lease so that
+// several workers don't send the same delivery at once. A worker that dies while
+// holding a lease gives the delivery back once the lease runs out.
+func (s *WebhookStorage) ClaimDueDeliveries(limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	now := time.Now()
+	rows, err := s.db.Query(`
+		WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status='pending' AND next_attempt_at <= $1 AND (locked_until IS NULL OR locked_until < $1)
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d SET locked_until=$3, updated_at=CURRENT_TIMESTAMP
+		FROM due, webhook_subscriptions s
+		WHERE d.id = due.id AND s.id = d.subscription_id
+		RETURNING d.id, d.subscription_id, d.event_type, d.payload, d.status, d.attempts, d.last_error,
+			d.next_attempt_at, d.created_at, s.url, s.secret
+	`, now, limit, now.Add(lease))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		if err := rows.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.EventType, &delivery.Payload, &delivery.Status,
+			&delivery.Attempts, &delivery.LastError, &delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.URL, &delivery.Secret); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (s *WebhookStorage) RecordAttempt(attempt models.WebhookAttempt) error {
+	_, err := s.db.Exec(`
+		INSERT INTO webhook_delivery_attempts (delivery_id, status_code, error, duration_ms, attempted_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, attempt.DeliveryID, attempt.StatusCode, attempt.Error, attempt.DurationMs, attempt.AttemptedAt)
+	return err
+}
+
+func (s *WebhookStorage) MarkDelivered(deliveryID string, attempts int) error {
+	_, err := s.db.Exec(`
+		UPDATE webhook_deliveries SET status='delivered', attempts=$1, last_error='', locked_until=NULL, updated_at=CURRENT_TIMESTAMP
+		WHERE id=$2
+	`, attempts, deliveryID)
+	return err
+}
+
+func (s *WebhookStorage) ScheduleRetry(deliveryID string, attempts int, nextAttemptAt time.Time, lastError string) error {
+	_, err := s.db.Exec(`
+		UPDATE webhook_deliveries SET attempts=$1, next_attempt_at=$2, last_error=$3, locked_until=NULL, updated_at=CURRENT_TIMESTAMP
+		WHERE id=$4
+	`, attempts, nextAttemptAt, lastError, deliveryID)
+	return err
+}
+
+// MoveToDeadLetter marks the delivery as dead and copies it to the dead-letter table
+func (s *WebhookStorage) MoveToDeadLetter(delivery models.WebhookDelivery) error {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return errors.Wrap(err, "unable to begin transaction to dead-letter delivery")
+	}
+
+	_, err = tx.Exec(`
+		UPDATE webhook_deliveries SET status='dead', attempts=$1, last_error=$2, locked_until=NULL, updated_at=CURRENT_TIMESTAMP
+		WHERE id=$3
+	`, delivery.Attempts, delivery.LastError, delivery.ID)
+	if err == nil {
+		_, err = tx.Exec(`
+			INSERT INTO webhook_dead_letters (delivery_id, subscription_id, event_type, payload, attempts, last_error)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, delivery.ID, delivery.SubscriptionID, delivery.EventType, []byte(delivery.Payload), delivery.Attempts, delivery.LastError)
+	}
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return errors.Wrap(err, "unable to dead-letter delivery")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Wrap(err, "unable to commit transaction")
+	}
+
+	return nil
+}
+
+const deliveryColumns = `id, subscription_id, event_type, payload, status, attempts, last_error, next_attempt_at, created_at`
+
+func (s *WebhookStorage) ListDeliveries(subscriptionID string, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := s.db.Query(`
+		SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE subscription_id=$1 ORDER BY created_at DESC LIMIT $2
+	`, subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		if err := rows.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.EventType, &delivery.Payload, &delivery.Status,
+			&delivery.Attempts, &delivery.LastError, &delivery.NextAttemptAt, &delivery.CreatedAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (s *WebhookStorage) ListDeadLetters(limit int) ([]models.WebhookDeadLetter, error) {
+	rows, err := s.db.Query(`
+		SELECT id, delivery_id, subscription_id, event_type, payload, attempts, last_error, replayed_at, created_at
+		FROM webhook_dead_letters ORDER BY created_at DESC LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deadLetters := []models.WebhookDeadLetter{}
+	for rows.Next() {
+		var deadLetter models.WebhookDeadLetter
+		if err := rows.Scan(&deadLetter.ID, &deadLetter.DeliveryID, &deadLetter.SubscriptionID, &deadLetter.EventType,
+			&deadLetter.Payload, &deadLetter.Attempts, &deadLetter.LastError, &deadLetter.ReplayedAt, &deadLetter.CreatedAt); err != nil {
+			return nil, err
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+
+	return deadLetters, rows.Err()
+}
+
+// ReplayDeadLetter queues the dead delivery again with a fresh retry budget.
+// It returns false if the dead letter doesn't exist or was already replayed.
+func (s *WebhookStorage) ReplayDeadLetter(deadLetterID string) (bool, error) {
+	tx, err := s.db.BeginTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return false, errors.Wrap(err, "unable to begin transaction to replay dead letter")
+	}
+
+	var deliveryID string
+	err = tx.QueryRow(`
+		UPDATE webhook_dead_letters SET replayed_at=CURRENT_TIMESTAMP
+		WHERE id=$1 AND replayed_at IS NULL RETURNING delivery_id
+	`, deadLetterID).Scan(&deliveryID)
+	if err == sql.ErrNoRows {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return false, errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return false, nil
+	}
+	if err == nil {
+		_, err = tx.Exec(`
+			UPDATE webhook_deliveries SET status='pending', attempts=0, next_attempt_at=CURRENT_TIMESTAMP, locked_until=NULL, updated_at=CURRENT_TIMESTAMP
+			WHERE id=$1
+		`, deliveryID)
+	}
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return false, errors.Wrap(rollbackErr, "unable to rollback transaction")
+		}
+
+		return false, errors.Wrap(err, "unable to replay dead letter")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, errors.Wrap(err, "unable to commit transaction")
+	}
+
+	return true, nil
+}
@@ -0,0 +1,62 @@
+// Package topup connects wallet top-ups to the providers that collect the money:
+// card acquirers, bank transfers and cash terminals.
+//
+// A top-up is initiated with the provider, which answers with its own reference
+// and usually a pending status. The final status arrives later, either in a
+// callback the provider sends or by polling Status.
+package topup
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Funding sources a provider can collect from
+const (
+	SourceCard         = "card"
+	SourceBankTransfer = "bank_transfer"
+	SourceCash         = "cash"
+)
+
+// Statuses reported by providers
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+var (
+	ErrUnknownReference  = errors.New("unknown provider reference")
+	ErrInvalidCallback   = errors.New("provider callback is malformed")
+	ErrInvalidSignature  = errors.New("provider callback signature is invalid")
+	ErrUnsupportedSource = errors.New("funding source is not supported by the provider")
+)
+
+// Payment is what the provider is asked to collect. Source identifies the card
+// token, bank account or terminal, its meaning depends on SourceType.
+type Payment struct {
+	TopUpID    string
+	Amount     int64
+	SourceType string
+	Source     string
+}
+
+// Result is the state of a payment at the provider
+type Result struct {
+	Reference     string
+	Status        string
+	FailureReason string
+}
+
+// Provider collects money for top-ups. Implementations must be safe for
+// concurrent use.
+type Provider interface {
+	Name() string
+	Supports(sourceType string) bool
+	Initiate(ctx context.Context, payment Payment) (Result, error)
+	Status(ctx context.Context, reference string) (Result, error)
+	// ParseCallback authenticates a callback request and returns the result it reports
+	ParseCallback(header http.Header, body []byte) (Result, error)
+}
@@ -0,0 +1,184 @@
+package topup
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// SimulatorName is the name the simulator is registered under
+	SimulatorName = "simulator"
+	// SimulatorDeclinedSource makes the simulated payment fail
+	SimulatorDeclinedSource = "declined"
+	// SimulatorSignatureHeader carries the HMAC of a simulator callback body
+	SimulatorSignatureHeader = "X-Simulator-Signature"
+)
+
+// Simulator is a provider that runs in memory. Every payment stays pending for
+// Delay and then succeeds, unless its source is SimulatorDeclinedSource. With a
+// CallbackURL the result is also posted there, signed like a real provider would.
+type Simulator struct {
+	Delay       time.Duration
+	CallbackURL string
+
+	secret   []byte
+	client   *http.Client
+	now      func() time.Time
+	mu       sync.Mutex
+	payments map[string]*simulatedPayment
+}
+
+type simulatedPayment struct {
+	result  Result
+	readyAt time.Time
+}
+
+type simulatorCallback struct {
+	Reference     string `json:"reference"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+func NewSimulator(secret string, delay time.Duration, callbackURL string) *Simulator {
+	return &Simulator{
+		Delay:       delay,
+		CallbackURL: callbackURL,
+		secret:      []byte(secret),
+		client:      &http.Client{Timeout: 10 * time.Second},
+		now:         time.Now,
+		payments:    make(map[string]*simulatedPayment),
+	}
+}
+
+func (s *Simulator) Name() string {
+	return SimulatorName
+}
+
+func (s *Simulator) Supports(sourceType string) bool {
+	return sourceType == SourceCard || sourceType == SourceBankTransfer || sourceType == SourceCash
+}
+
+func (s *Simulator) Initiate(ctx context.Context, payment Payment) (Result, error) {
+	if !s.Supports(payment.SourceType) {
+		return Result{}, ErrUnsupportedSource
+	}
+
+	reference, err := newReference()
+	if err != nil {
+		return Result{}, err
+	}
+
+	final := Result{Reference: reference, Status: StatusSucceeded}
+	if payment.Source == SimulatorDeclinedSource {
+		final.Status = StatusFailed
+		final.FailureReason = "declined by issuer"
+	}
+
+	s.mu.Lock()
+	s.payments[reference] = &simulatedPayment{result: final, readyAt: s.now().Add(s.Delay)}
+	s.mu.Unlock()
+
+	if s.CallbackURL != "" {
+		go s.sendCallback(final)
+	}
+
+	return Result{Reference: reference, Status: StatusPending}, nil
+}
+
+func (s *Simulator) Status(ctx context.Context, reference string) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payment, ok := s.payments[reference]
+	if !ok {
+		return Result{}, ErrUnknownReference
+	}
+	if s.now().Before(payment.readyAt) {
+		return Result{Reference: reference, Status: StatusPending}, nil
+	}
+	return payment.result, nil
+}
+
+func (s *Simulator) ParseCallback(header http.Header, body []byte) (Result, error) {
+	if !hmac.Equal([]byte(header.Get(SimulatorSignatureHeader)), []byte(s.sign(body))) {
+		return Result{}, ErrInvalidSignature
+	}
+
+	var callback simulatorCallback
+	if err := json.Unmarshal(body, &callback); err != nil || callback.Reference == "" {
+		return Result{}, ErrInvalidCallback
+	}
+	if callback.Status != StatusSucceeded && callback.Status != StatusFailed {
+		return Result{}, ErrInvalidCallback
+	}
+
+	return Result(callback), nil
+}
+
+// Callback returns the signed callback the simulator sends once the payment is
+// final, so tests can feed it to the callback handler
+func (s *Simulator) Callback(reference string) (http.Header, []byte, error) {
+	s.mu.Lock()
+	payment, ok := s.payments[reference]
+	s.mu.Unlock()
+	if !ok {
+		return nil, nil, ErrUnknownReference
+	}
+
+	body, err := json.Marshal(simulatorCallback(payment.result))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(SimulatorSignatureHeader, s.sign(body))
+	return header, body, nil
+}
+
+func (s *Simulator) sendCallback(result Result) {
+	time.Sleep(s.Delay)
+
+	header, body, err := s.Callback(result.Reference)
+	if err != nil {
+		log.Printf("Simulator: error building callback for %s: %v", result.Reference, err)
+		return
+	}
+
+	request, err := http.NewRequest(http.MethodPost, s.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Simulator: error building callback for %s: %v", result.Reference, err)
+		return
+	}
+	request.Header = header
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		log.Printf("Simulator: error sending callback for %s: %v", result.Reference, err)
+		return
+	}
+	response.Body.Close()
+}
+
+func (s *Simulator) sign(body []byte) string {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func newReference() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "sim_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,114 @@
+package topup
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulator(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := NewSimulator("secret", time.Minute, "")
+	s.now = func() time.Time { return now }
+
+	t.Run("Payment succeeds after the delay", func(t *testing.T) {
+		result, err := s.Initiate(context.Background(), Payment{TopUpID: "topup1", Amount: 2500, SourceType: SourceCard})
+		require.NoError(t, err)
+		assert.Equal(t, StatusPending, result.Status)
+
+		status, err := s.Status(context.Background(), result.Reference)
+		require.NoError(t, err)
+		assert.Equal(t, StatusPending, status.Status)
+
+		now = now.Add(time.Minute)
+		status, err = s.Status(context.Background(), result.Reference)
+		require.NoError(t, err)
+		assert.Equal(t, StatusSucceeded, status.Status)
+	})
+
+	t.Run("Declined source fails", func(t *testing.T) {
+		result, err := s.Initiate(context.Background(), Payment{TopUpID: "topup2", Amount: 2500, SourceType: SourceBankTransfer, Source: SimulatorDeclinedSource})
+		require.NoError(t, err)
+
+		now = now.Add(time.Minute)
+		status, err := s.Status(context.Background(), result.Reference)
+		require.NoError(t, err)
+		assert.Equal(t, StatusFailed, status.Status)
+		assert.Equal(t, "declined by issuer", status.FailureReason)
+	})
+
+	t.Run("Unknown reference", func(t *testing.T) {
+		_, err := s.Status(context.Background(), "sim_unknown")
+		assert.ErrorIs(t, err, ErrUnknownReference)
+	})
+
+	t.Run("Unsupported source", func(t *testing.T) {
+		_, err := s.Initiate(context.Background(), Payment{TopUpID: "topup3", Amount: 2500, SourceType: "crypto"})
+		assert.ErrorIs(t, err, ErrUnsupportedSource)
+	})
+}
+
+func TestSimulator_ParseCallback(t *testing.T) {
+	s := NewSimulator("secret", 0, "")
+	result, err := s.Initiate(context.Background(), Payment{TopUpID: "topup1", Amount: 2500, SourceType: SourceCash})
+	require.NoError(t, err)
+
+	header, body, err := s.Callback(result.Reference)
+	require.NoError(t, err)
+
+	t.Run("Signed callback", func(t *testing.T) {
+		parsed, err := s.ParseCallback(header, body)
+		require.NoError(t, err)
+		assert.Equal(t, Result{Reference: result.Reference, Status: StatusSucceeded}, parsed)
+	})
+
+	t.Run("Tampered body", func(t *testing.T) {
+		_, err := s.ParseCallback(header, []byte(`{"reference":"`+result.Reference+`","status":"failed"}`))
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("Other secret", func(t *testing.T) {
+		_, err := NewSimulator("other", 0, "").ParseCallback(header, body)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("Malformed body", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(SimulatorSignatureHeader, s.sign([]byte("{}")))
+		_, err := s.ParseCallback(header, []byte("{}"))
+		assert.ErrorIs(t, err, ErrInvalidCallback)
+	})
+}
+
+func TestSimulator_SendsCallback(t *testing.T) {
+	received := make(chan Result, 1)
+	s := NewSimulator("secret", 0, "")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		result, err := s.ParseCallback(r.Header, body)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received <- result
+	}))
+	defer server.Close()
+	s.CallbackURL = server.URL
+
+	result, err := s.Initiate(context.Background(), Payment{TopUpID: "topup1", Amount: 2500, SourceType: SourceCard})
+	require.NoError(t, err)
+
+	select {
+	case callback := <-received:
+		assert.Equal(t, result.Reference, callback.Reference)
+		assert.Equal(t, StatusSucceeded, callback.Status)
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback was not sent")
+	}
+}
@@ -0,0 +1,108 @@
+// Package voucher generates and checks prepaid voucher codes.
+//
+// A code is 16 characters of Crockford's base32, printed in groups of four. The
+// first 15 are random and the last is a Luhn mod 32 check character, so most
+// typos are caught before the code is looked up. Codes are stored only as an
+// HMAC, a leaked table can't be redeemed.
+package voucher
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// alphabet is Crockford's base32, it has no I, L, O or U so codes survive being
+// read aloud and typed
+const alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+const (
+	// CodeLength is the number of characters in a code, without separators
+	CodeLength = 16
+	groupSize  = 4
+	hintLength = 4
+)
+
+var (
+	ErrMalformed       = errors.New("malformed voucher code")
+	ErrInvalidChecksum = errors.New("voucher code checksum mismatch")
+)
+
+// Generate returns a new random code formatted for printing
+func Generate() (string, error) {
+	payload := make([]byte, CodeLength-1)
+	max := big.NewInt(int64(len(alphabet)))
+	for i := range payload {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", errors.Wrap(err, "unable to generate voucher code")
+		}
+		payload[i] = alphabet[n.Int64()]
+	}
+
+	return Format(string(payload) + string(checkCharacter(string(payload)))), nil
+}
+
+// Normalize turns a code as typed by a user into its canonical form: upper case,
+// without separators, with the characters Crockford's base32 treats as
+// look-alikes mapped. The checksum is verified.
+func Normalize(code string) (string, error) {
+	code = strings.NewReplacer("-", "", " ", "", "O", "0", "I", "1", "L", "1").Replace(strings.ToUpper(code))
+	if len(code) != CodeLength {
+		return "", ErrMalformed
+	}
+	for i := 0; i < len(code); i++ {
+		if strings.IndexByte(alphabet, code[i]) < 0 {
+			return "", ErrMalformed
+		}
+	}
+
+	if checkCharacter(code[:CodeLength-1]) != code[CodeLength-1] {
+		return "", ErrInvalidChecksum
+	}
+	return code, nil
+}
+
+// Format groups a canonical code for printing, e.g. ABCD-EFGH-JKMN-PQRS
+func Format(code string) string {
+	groups := make([]string, 0, len(code)/groupSize+1)
+	for len(code) > groupSize {
+		groups = append(groups, code[:groupSize])
+		code = code[groupSize:]
+	}
+	return strings.Join(append(groups, code), "-")
+}
+
+// Hash returns the keyed hash a canonical code is stored and looked up by
+func Hash(code string, key []byte) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(code))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Hint returns the last characters of a canonical code, enough for support to
+// talk about a voucher without seeing the code
+func Hint(code string) string {
+	if len(code) < hintLength {
+		return code
+	}
+	return code[len(code)-hintLength:]
+}
+
+// checkCharacter computes the Luhn mod N check character of the payload
+func checkCharacter(payload string) byte {
+	n := len(alphabet)
+	factor := 2
+	sum := 0
+	for i := len(payload) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(alphabet, payload[i])
+		sum += addend/n + addend%n
+		factor = 3 - factor
+	}
+	return alphabet[(n-sum%n)%n]
+}
@@ -0,0 +1,78 @@
+package voucher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		code, err := Generate()
+		require.NoError(t, err)
+		assert.Len(t, code, CodeLength+3)
+		assert.Equal(t, 4, len(strings.Split(code, "-")))
+
+		canonical, err := Normalize(code)
+		require.NoError(t, err)
+		assert.False(t, seen[canonical])
+		seen[canonical] = true
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	code, err := Generate()
+	require.NoError(t, err)
+	canonical := strings.ReplaceAll(code, "-", "")
+
+	t.Run("Typed loosely", func(t *testing.T) {
+		normalized, err := Normalize(" " + strings.ToLower(strings.ReplaceAll(code, "-", " ")) + " ")
+		require.NoError(t, err)
+		assert.Equal(t, canonical, normalized)
+	})
+
+	t.Run("Look-alike characters", func(t *testing.T) {
+		payload := "0000111100001111"[:CodeLength-1]
+		valid := payload + string(checkCharacter(payload))
+		normalized, err := Normalize(strings.NewReplacer("0", "O", "1", "l").Replace(valid))
+		require.NoError(t, err)
+		assert.Equal(t, valid, normalized)
+	})
+
+	t.Run("Single typo", func(t *testing.T) {
+		typo := []byte(canonical)
+		typo[3] = alphabet[(strings.IndexByte(alphabet, typo[3])+1)%len(alphabet)]
+		_, err := Normalize(string(typo))
+		assert.ErrorIs(t, err, ErrInvalidChecksum)
+	})
+
+	t.Run("Swapped neighbours", func(t *testing.T) {
+		payload := "ABCDEFGHJKMNPQR"
+		swapped := "ACBDEFGHJKMNPQR" + string(checkCharacter(payload))
+		_, err := Normalize(swapped)
+		assert.ErrorIs(t, err, ErrInvalidChecksum)
+	})
+
+	t.Run("Wrong length", func(t *testing.T) {
+		_, err := Normalize(canonical[:10])
+		assert.ErrorIs(t, err, ErrMalformed)
+	})
+
+	t.Run("Invalid character", func(t *testing.T) {
+		_, err := Normalize("U" + canonical[1:])
+		assert.ErrorIs(t, err, ErrMalformed)
+	})
+}
+
+func TestHash(t *testing.T) {
+	assert.Equal(t, Hash("ABCD", []byte("key")), Hash("ABCD", []byte("key")))
+	assert.NotEqual(t, Hash("ABCD", []byte("key")), Hash("ABCD", []byte("other")))
+	assert.Len(t, Hash("ABCD", []byte("key")), 64)
+}
+
+func TestHint(t *testing.T) {
+	assert.Equal(t, "QRST", Hint("ABCDEFGHJKMNPQRST"))
+}
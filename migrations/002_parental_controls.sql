@@ -0,0 +1,47 @@
+-- +goose Up
+
+-- Link child accounts to their parent
+ALTER TABLE users ADD COLUMN IF NOT EXISTS parent_id uuid REFERENCES users(id);
+
+-- Keep the other side and the merchant category of transfers
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS counterparty_wallet_id uuid REFERENCES wallets(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS merchant_category VARCHAR(4);
+
+-- Create parental controls table
+CREATE TABLE IF NOT EXISTS parental_controls (
+    child_id uuid PRIMARY KEY,
+    per_transaction_limit BIGINT NOT NULL DEFAULT 0,
+    monthly_limit BIGINT NOT NULL DEFAULT 0,
+    approval_threshold BIGINT NOT NULL DEFAULT 0,
+    blocked_categories TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_child_id FOREIGN KEY(child_id) REFERENCES users(id)
+);
+
+-- Create approval requests table
+CREATE TABLE IF NOT EXISTS approval_requests (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    parent_id uuid NOT NULL,
+    child_id uuid NOT NULL,
+    wallet_id uuid NOT NULL,
+    to_wallet_id uuid NOT NULL,
+    amount BIGINT NOT NULL,
+    merchant_category VARCHAR(4) NOT NULL DEFAULT '',
+    reference VARCHAR(64) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    decided_at TIMESTAMP,
+    CONSTRAINT fk_parent_id FOREIGN KEY(parent_id) REFERENCES users(id),
+    CONSTRAINT fk_child_id FOREIGN KEY(child_id) REFERENCES users(id),
+    CONSTRAINT fk_wallet_id FOREIGN KEY(wallet_id) REFERENCES wallets(id),
+    CONSTRAINT fk_to_wallet_id FOREIGN KEY(to_wallet_id) REFERENCES wallets(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_approval_requests_parent_status ON approval_requests(parent_id, status);
+
+-- +goose Down
+drop table approval_requests;
+drop table parental_controls;
+alter table transactions drop column merchant_category;
+alter table transactions drop column counterparty_wallet_id;
+alter table users drop column parent_id;
@@ -0,0 +1,34 @@
+-- +goose Up
+
+-- Free-form reference linking a transaction to the operation that created it
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reference VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_reference ON transactions(reference);
+
+-- Create payment requests table
+CREATE TABLE IF NOT EXISTS payment_requests (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    requester_id uuid NOT NULL,
+    requester_wallet_id uuid NOT NULL,
+    payer_id uuid NOT NULL,
+    payer_wallet_id uuid,
+    amount BIGINT NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    transaction_id INT,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_requester_id FOREIGN KEY(requester_id) REFERENCES users(id),
+    CONSTRAINT fk_requester_wallet_id FOREIGN KEY(requester_wallet_id) REFERENCES wallets(id),
+    CONSTRAINT fk_payer_id FOREIGN KEY(payer_id) REFERENCES users(id),
+    CONSTRAINT fk_payer_wallet_id FOREIGN KEY(payer_wallet_id) REFERENCES wallets(id),
+    CONSTRAINT fk_transaction_id FOREIGN KEY(transaction_id) REFERENCES transactions(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_requests_requester ON payment_requests(requester_id, status);
+CREATE INDEX IF NOT EXISTS idx_payment_requests_payer ON payment_requests(payer_id, status);
+
+-- +goose Down
+drop table payment_requests;
+alter table transactions drop column reference;
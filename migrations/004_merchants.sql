@@ -0,0 +1,55 @@
+-- +goose Up
+
+-- Create merchants table
+CREATE TABLE IF NOT EXISTS merchants (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    owner_id uuid NOT NULL,
+    legal_name VARCHAR(255) NOT NULL,
+    tax_id VARCHAR(32) NOT NULL,
+    legal_address TEXT NOT NULL DEFAULT '',
+    settlement_wallet_id uuid NOT NULL,
+    merchant_category VARCHAR(4) NOT NULL,
+    api_key VARCHAR(64) NOT NULL UNIQUE,
+    api_secret VARCHAR(128) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_owner_id FOREIGN KEY(owner_id) REFERENCES users(id),
+    CONSTRAINT fk_settlement_wallet_id FOREIGN KEY(settlement_wallet_id) REFERENCES wallets(id)
+);
+
+-- Create merchant orders table
+CREATE TABLE IF NOT EXISTS merchant_orders (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    merchant_id uuid NOT NULL,
+    amount BIGINT NOT NULL,
+    reference VARCHAR(64) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'created',
+    customer_wallet_id uuid,
+    transaction_id INT,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_merchant_id FOREIGN KEY(merchant_id) REFERENCES merchants(id),
+    CONSTRAINT fk_customer_wallet_id FOREIGN KEY(customer_wallet_id) REFERENCES wallets(id),
+    CONSTRAINT fk_transaction_id FOREIGN KEY(transaction_id) REFERENCES transactions(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_merchant_orders_reference ON merchant_orders(merchant_id, reference) WHERE reference <> '';
+
+-- Every status change of an order
+CREATE TABLE IF NOT EXISTS merchant_order_events (
+    id SERIAL PRIMARY KEY,
+    order_id uuid NOT NULL,
+    from_status VARCHAR(16) NOT NULL DEFAULT '',
+    to_status VARCHAR(16) NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_order_id FOREIGN KEY(order_id) REFERENCES merchant_orders(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchant_order_events_order ON merchant_order_events(order_id);
+
+-- +goose Down
+drop table merchant_order_events;
+drop table merchant_orders;
+drop table merchants;
@@ -0,0 +1,44 @@
+-- +goose Up
+
+-- Create split bills table
+CREATE TABLE IF NOT EXISTS split_bills (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    originator_id uuid NOT NULL,
+    wallet_id uuid NOT NULL,
+    total_amount BIGINT NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_originator_id FOREIGN KEY(originator_id) REFERENCES users(id),
+    CONSTRAINT fk_wallet_id FOREIGN KEY(wallet_id) REFERENCES wallets(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_split_bills_originator ON split_bills(originator_id, created_at);
+
+-- Create split participants table, one row per user owing a share
+CREATE TABLE IF NOT EXISTS split_participants (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    split_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    wallet_id uuid,
+    transaction_id INT,
+    reminder_count INT NOT NULL DEFAULT 0,
+    last_reminded_at TIMESTAMP,
+    paid_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_split_id FOREIGN KEY(split_id) REFERENCES split_bills(id) ON DELETE CASCADE,
+    CONSTRAINT fk_user_id FOREIGN KEY(user_id) REFERENCES users(id),
+    CONSTRAINT fk_wallet_id FOREIGN KEY(wallet_id) REFERENCES wallets(id),
+    CONSTRAINT fk_transaction_id FOREIGN KEY(transaction_id) REFERENCES transactions(id),
+    CONSTRAINT uq_split_participant UNIQUE (split_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_split_participants_user ON split_participants(user_id, status);
+
+-- +goose Down
+drop table split_participants;
+drop table split_bills;
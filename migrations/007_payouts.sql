@@ -0,0 +1,44 @@
+-- +goose Up
+
+-- Create payout batches table
+CREATE TABLE IF NOT EXISTS payout_batches (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id uuid NOT NULL,
+    wallet_id uuid NOT NULL,
+    file_name VARCHAR(255) NOT NULL DEFAULT '',
+    format VARCHAR(8) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'processing',
+    total_rows INT NOT NULL,
+    total_amount BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    CONSTRAINT fk_user_id FOREIGN KEY(user_id) REFERENCES users(id),
+    CONSTRAINT fk_wallet_id FOREIGN KEY(wallet_id) REFERENCES wallets(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payout_batches_user ON payout_batches(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_payout_batches_active ON payout_batches(status) WHERE status = 'processing';
+
+-- Create payout rows table
+CREATE TABLE IF NOT EXISTS payout_rows (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    batch_id uuid NOT NULL,
+    row_number INT NOT NULL,
+    wallet_id uuid NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    transaction_id INT,
+    error TEXT NOT NULL DEFAULT '',
+    processed_at TIMESTAMP,
+    CONSTRAINT fk_batch_id FOREIGN KEY(batch_id) REFERENCES payout_batches(id) ON DELETE CASCADE,
+    CONSTRAINT fk_transaction_id FOREIGN KEY(transaction_id) REFERENCES transactions(id),
+    CONSTRAINT uq_payout_row UNIQUE (batch_id, row_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payout_rows_status ON payout_rows(batch_id, status, row_number);
+
+-- +goose Down
+drop table payout_rows;
+drop table payout_batches;
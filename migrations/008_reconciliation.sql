@@ -0,0 +1,57 @@
+-- +goose Up
+
+-- Every run of the balance reconciliation job
+CREATE TABLE IF NOT EXISTS reconciliation_runs (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    status VARCHAR(16) NOT NULL DEFAULT 'running',
+    wallets_checked INT NOT NULL DEFAULT 0,
+    discrepancies INT NOT NULL DEFAULT 0,
+    total_difference BIGINT NOT NULL DEFAULT 0,
+    cases_opened INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+-- Wallets whose stored balance didn't match the sum of their transactions
+CREATE TABLE IF NOT EXISTS reconciliation_discrepancies (
+    id SERIAL PRIMARY KEY,
+    run_id uuid NOT NULL,
+    wallet_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    stored_balance BIGINT NOT NULL,
+    ledger_balance BIGINT NOT NULL,
+    difference BIGINT NOT NULL,
+    transaction_count INT NOT NULL,
+    last_transaction_at TIMESTAMP,
+    case_id uuid,
+    CONSTRAINT fk_run_id FOREIGN KEY(run_id) REFERENCES reconciliation_runs(id) ON DELETE CASCADE,
+    CONSTRAINT fk_wallet_id FOREIGN KEY(wallet_id) REFERENCES wallets(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_discrepancies_run ON reconciliation_discrepancies(run_id);
+
+-- Correction cases for operators to investigate, one open case per wallet
+CREATE TABLE IF NOT EXISTS correction_cases (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    wallet_id uuid NOT NULL,
+    run_id uuid NOT NULL,
+    stored_balance BIGINT NOT NULL,
+    ledger_balance BIGINT NOT NULL,
+    difference BIGINT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    resolution TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP,
+    CONSTRAINT fk_wallet_id FOREIGN KEY(wallet_id) REFERENCES wallets(id),
+    CONSTRAINT fk_run_id FOREIGN KEY(run_id) REFERENCES reconciliation_runs(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_correction_cases_open ON correction_cases(wallet_id) WHERE status = 'open';
+
+ALTER TABLE reconciliation_discrepancies ADD CONSTRAINT fk_case_id FOREIGN KEY(case_id) REFERENCES correction_cases(id);
+
+-- +goose Down
+drop table reconciliation_discrepancies;
+drop table correction_cases;
+drop table reconciliation_runs;
@@ -0,0 +1,19 @@
+-- +goose Up
+
+-- End-of-day balance of every wallet, computed from its transactions.
+-- A snapshot covers every transaction created before the start of the next day.
+CREATE TABLE IF NOT EXISTS balance_snapshots (
+    wallet_id uuid NOT NULL,
+    snapshot_date DATE NOT NULL,
+    balance BIGINT NOT NULL,
+    transaction_count INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (wallet_id, snapshot_date),
+    CONSTRAINT fk_wallet_id FOREIGN KEY(wallet_id) REFERENCES wallets(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_wallet_created ON transactions(wallet_id, created_at);
+
+-- +goose Down
+drop index idx_transactions_wallet_created;
+drop table balance_snapshots;
@@ -0,0 +1,22 @@
+-- +goose Up
+
+-- Context users attach to their operations
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS description VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS category VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_wallet_category ON transactions(wallet_id, category);
+CREATE INDEX IF NOT EXISTS idx_transactions_tags ON transactions USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_transactions_metadata ON transactions USING GIN (metadata jsonb_path_ops);
+
+-- +goose Down
+drop index idx_transactions_metadata;
+drop index idx_transactions_tags;
+drop index idx_transactions_wallet_category;
+alter table transactions drop column updated_at;
+alter table transactions drop column metadata;
+alter table transactions drop column tags;
+alter table transactions drop column category;
+alter table transactions drop column description;
@@ -0,0 +1,37 @@
+-- +goose Up
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Name of the account holder, filled in during identification
+ALTER TABLE users ADD COLUMN IF NOT EXISTS full_name VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Words of the searchable text of a transaction
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('simple', COALESCE(reference, '') || ' ' || description || ' ' || category)
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_search ON transactions USING GIN (search_vector);
+
+-- Trigram indexes find partial references, descriptions and names
+CREATE INDEX IF NOT EXISTS idx_transactions_reference_trgm ON transactions USING GIN (reference gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_transactions_description_trgm ON transactions USING GIN (description gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_transactions_counterparty ON transactions(counterparty_wallet_id);
+CREATE INDEX IF NOT EXISTS idx_users_full_name_trgm ON users USING GIN (full_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_merchants_legal_name_trgm ON merchants USING GIN (legal_name gin_trgm_ops);
+
+-- Matched names lead back to the wallets of their transactions
+CREATE INDEX IF NOT EXISTS idx_wallets_user ON wallets(user_id);
+CREATE INDEX IF NOT EXISTS idx_merchants_settlement_wallet ON merchants(settlement_wallet_id);
+
+-- +goose Down
+drop index idx_merchants_settlement_wallet;
+drop index idx_wallets_user;
+drop index idx_merchants_legal_name_trgm;
+drop index idx_users_full_name_trgm;
+drop index idx_transactions_counterparty;
+drop index idx_transactions_description_trgm;
+drop index idx_transactions_reference_trgm;
+drop index idx_transactions_search;
+alter table transactions drop column search_vector;
+alter table users drop column full_name;
+drop extension pg_trgm;
@@ -0,0 +1,8 @@
+-- +goose Up
+
+-- Balance of the wallet right after the transaction, printed on receipts.
+-- Older transactions don't have it and get no receipt.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS balance_after BIGINT;
+
+-- +goose Down
+alter table transactions drop column balance_after;
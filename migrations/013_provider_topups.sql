@@ -0,0 +1,28 @@
+-- +goose Up
+
+-- Top-ups funded by card acquirers, bank transfers and cash terminals
+CREATE TABLE IF NOT EXISTS provider_topups (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id uuid NOT NULL,
+    wallet_id uuid NOT NULL,
+    provider VARCHAR(32) NOT NULL,
+    source_type VARCHAR(16) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    status VARCHAR(16) NOT NULL DEFAULT 'initiated',
+    provider_reference VARCHAR(128),
+    failure_reason TEXT NOT NULL DEFAULT '',
+    transaction_id INT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    CONSTRAINT fk_user_id FOREIGN KEY(user_id) REFERENCES users(id),
+    CONSTRAINT fk_wallet_id FOREIGN KEY(wallet_id) REFERENCES wallets(id),
+    CONSTRAINT fk_transaction_id FOREIGN KEY(transaction_id) REFERENCES transactions(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_provider_topups_reference ON provider_topups(provider, provider_reference);
+CREATE INDEX IF NOT EXISTS idx_provider_topups_user ON provider_topups(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_provider_topups_status ON provider_topups(status) WHERE status IN ('pending', 'processing');
+
+-- +goose Down
+drop table provider_topups;
@@ -0,0 +1,48 @@
+-- +goose Up
+
+-- Transactions used to be written only once they succeeded. Existing rows are
+-- completed, new staged rows start as created and move the balance only when
+-- they complete or are reversed.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'completed';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS pending_at TIMESTAMP;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS failed_at TIMESTAMP;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reversed_at TIMESTAMP;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS failure_reason VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reversed_by INTEGER REFERENCES transactions(id);
+-- The two legs of a transfer point at each other
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS pair_id INTEGER REFERENCES transactions(id);
+
+UPDATE transactions SET completed_at = created_at WHERE completed_at IS NULL;
+-- Legs of earlier transfers were written in one go with the same time and opposite amounts
+UPDATE transactions t SET pair_id = (
+    SELECT o.id FROM transactions o
+    WHERE o.wallet_id = t.counterparty_wallet_id AND o.counterparty_wallet_id = t.wallet_id
+        AND o.amount = -t.amount AND o.created_at = t.created_at
+    ORDER BY ABS(o.id - t.id) LIMIT 1
+)
+WHERE t.counterparty_wallet_id IS NOT NULL;
+ALTER TABLE transactions ALTER COLUMN status SET DEFAULT 'created';
+
+ALTER TABLE transactions ADD CONSTRAINT chk_transactions_status
+    CHECK (status IN ('created', 'pending', 'completed', 'failed', 'reversed'));
+-- Exactly the transactions that moved the balance have completed_at
+ALTER TABLE transactions ADD CONSTRAINT chk_transactions_completed_at
+    CHECK ((status IN ('completed', 'reversed')) = (completed_at IS NOT NULL));
+
+CREATE INDEX IF NOT EXISTS idx_transactions_wallet_completed ON transactions(wallet_id, completed_at) WHERE completed_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_status ON transactions(status) WHERE status IN ('created', 'pending');
+
+-- +goose Down
+drop index idx_transactions_status;
+drop index idx_transactions_wallet_completed;
+alter table transactions drop constraint chk_transactions_completed_at;
+alter table transactions drop constraint chk_transactions_status;
+alter table transactions drop column pair_id;
+alter table transactions drop column reversed_by;
+alter table transactions drop column failure_reason;
+alter table transactions drop column reversed_at;
+alter table transactions drop column failed_at;
+alter table transactions drop column completed_at;
+alter table transactions drop column pending_at;
+alter table transactions drop column status;
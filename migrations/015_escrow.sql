@@ -0,0 +1,56 @@
+-- +goose Up
+
+-- Wallets the service itself holds money in, looked up by name
+CREATE TABLE IF NOT EXISTS system_wallets (
+    name VARCHAR(32) PRIMARY KEY,
+    wallet_id uuid NOT NULL,
+    CONSTRAINT fk_wallet_id FOREIGN KEY(wallet_id) REFERENCES wallets(id)
+);
+
+-- Buyer funds of open escrows sit in this wallet
+WITH escrow_user AS (
+    INSERT INTO users (is_identified) VALUES (TRUE) RETURNING id
+), escrow_wallet AS (
+    INSERT INTO wallets (user_id) SELECT id FROM escrow_user RETURNING id
+)
+INSERT INTO system_wallets (name, wallet_id) SELECT 'escrow', id FROM escrow_wallet;
+
+-- Create escrows table
+CREATE TABLE IF NOT EXISTS escrows (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    buyer_id uuid NOT NULL,
+    buyer_wallet_id uuid NOT NULL,
+    seller_id uuid NOT NULL,
+    seller_wallet_id uuid NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    reference VARCHAR(64) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'held',
+    released_amount BIGINT NOT NULL DEFAULT 0,
+    refunded_amount BIGINT NOT NULL DEFAULT 0,
+    hold_transaction_id INT,
+    release_transaction_id INT,
+    refund_transaction_id INT,
+    dispute_reason VARCHAR(255) NOT NULL DEFAULT '',
+    resolution VARCHAR(255) NOT NULL DEFAULT '',
+    release_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    settled_at TIMESTAMP,
+    CONSTRAINT fk_buyer_id FOREIGN KEY(buyer_id) REFERENCES users(id),
+    CONSTRAINT fk_buyer_wallet_id FOREIGN KEY(buyer_wallet_id) REFERENCES wallets(id),
+    CONSTRAINT fk_seller_id FOREIGN KEY(seller_id) REFERENCES users(id),
+    CONSTRAINT fk_seller_wallet_id FOREIGN KEY(seller_wallet_id) REFERENCES wallets(id),
+    CONSTRAINT fk_hold_transaction_id FOREIGN KEY(hold_transaction_id) REFERENCES transactions(id),
+    CONSTRAINT fk_release_transaction_id FOREIGN KEY(release_transaction_id) REFERENCES transactions(id),
+    CONSTRAINT fk_refund_transaction_id FOREIGN KEY(refund_transaction_id) REFERENCES transactions(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_escrows_buyer ON escrows(buyer_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_escrows_seller ON escrows(seller_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_escrows_release_at ON escrows(release_at) WHERE status = 'held';
+
+-- +goose Down
+drop table escrows;
+delete from system_wallets where name = 'escrow';
+drop table system_wallets;
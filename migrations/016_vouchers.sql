@@ -0,0 +1,38 @@
+-- +goose Up
+
+-- Create voucher batches table, every voucher of a batch has the same amount
+CREATE TABLE IF NOT EXISTS voucher_batches (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    voucher_count INT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    expires_at TIMESTAMP,
+    revoke_reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+-- Create vouchers table. Codes are kept only as a keyed hash, the hint is the
+-- last characters for support.
+CREATE TABLE IF NOT EXISTS vouchers (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    batch_id uuid NOT NULL,
+    code_hash CHAR(64) NOT NULL UNIQUE,
+    code_hint VARCHAR(8) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'available',
+    redeemed_by uuid,
+    wallet_id uuid,
+    transaction_id INT,
+    redeemed_at TIMESTAMP,
+    CONSTRAINT fk_batch_id FOREIGN KEY(batch_id) REFERENCES voucher_batches(id) ON DELETE CASCADE,
+    CONSTRAINT fk_redeemed_by FOREIGN KEY(redeemed_by) REFERENCES users(id),
+    CONSTRAINT fk_wallet_id FOREIGN KEY(wallet_id) REFERENCES wallets(id),
+    CONSTRAINT fk_transaction_id FOREIGN KEY(transaction_id) REFERENCES transactions(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_vouchers_batch ON vouchers(batch_id, status);
+
+-- +goose Down
+drop table vouchers;
+drop table voucher_batches;
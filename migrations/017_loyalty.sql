@@ -0,0 +1,66 @@
+-- +goose Up
+
+-- Rules that award points for wallet events, points are per whole unit of the amount
+CREATE TABLE IF NOT EXISTS loyalty_rules (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    event_type VARCHAR(32) NOT NULL,
+    points_per_unit BIGINT NOT NULL CHECK (points_per_unit > 0),
+    min_amount BIGINT NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_rules_event ON loyalty_rules(event_type) WHERE active;
+
+-- Spending earns a point per unit unless the rule is disabled
+INSERT INTO loyalty_rules (event_type, points_per_unit) VALUES ('wallet.debited', 1);
+
+-- Create loyalty redemptions table, points converted to wallet credit
+CREATE TABLE IF NOT EXISTS loyalty_redemptions (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id uuid NOT NULL,
+    wallet_id uuid NOT NULL,
+    points BIGINT NOT NULL CHECK (points > 0),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    status VARCHAR(16) NOT NULL DEFAULT 'processing',
+    transaction_id INT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    CONSTRAINT fk_user_id FOREIGN KEY(user_id) REFERENCES users(id),
+    CONSTRAINT fk_wallet_id FOREIGN KEY(wallet_id) REFERENCES wallets(id),
+    CONSTRAINT fk_transaction_id FOREIGN KEY(transaction_id) REFERENCES transactions(id)
+);
+
+-- Points ledger, kept apart from wallet balances. Earned entries are lots that
+-- expire, remaining is what is left of the lot. Redemptions, refunds and expiry
+-- point to the lot they took points from.
+CREATE TABLE IF NOT EXISTS loyalty_entries (
+    id BIGSERIAL PRIMARY KEY,
+    user_id uuid NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    points BIGINT NOT NULL,
+    remaining BIGINT NOT NULL DEFAULT 0 CHECK (remaining >= 0),
+    expires_at TIMESTAMP,
+    lot_id BIGINT,
+    rule_id uuid,
+    wallet_id uuid,
+    transaction_id INT,
+    redemption_id uuid,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user_id FOREIGN KEY(user_id) REFERENCES users(id),
+    CONSTRAINT fk_lot_id FOREIGN KEY(lot_id) REFERENCES loyalty_entries(id),
+    CONSTRAINT fk_rule_id FOREIGN KEY(rule_id) REFERENCES loyalty_rules(id),
+    CONSTRAINT fk_redemption_id FOREIGN KEY(redemption_id) REFERENCES loyalty_redemptions(id),
+    CHECK (remaining <= points)
+);
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_entries_user ON loyalty_entries(user_id, id);
+CREATE INDEX IF NOT EXISTS idx_loyalty_entries_lots ON loyalty_entries(user_id, expires_at) WHERE kind = 'earn' AND remaining > 0;
+CREATE INDEX IF NOT EXISTS idx_loyalty_entries_redemption ON loyalty_entries(redemption_id) WHERE redemption_id IS NOT NULL;
+-- A transaction earns points once per rule
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loyalty_entries_earned ON loyalty_entries(transaction_id, rule_id) WHERE kind = 'earn';
+
+-- +goose Down
+drop table loyalty_entries;
+drop table loyalty_redemptions;
+drop table loyalty_rules;
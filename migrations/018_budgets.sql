@@ -0,0 +1,29 @@
+-- +goose Up
+
+-- Create budgets table, a monthly spending limit of a user for a transaction category
+CREATE TABLE IF NOT EXISTS budgets (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id uuid NOT NULL,
+    category VARCHAR(32) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user_id FOREIGN KEY(user_id) REFERENCES users(id),
+    UNIQUE (user_id, category)
+);
+
+-- Alerts sent for a budget, each threshold alerts once a month
+CREATE TABLE IF NOT EXISTS budget_alerts (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    budget_id uuid NOT NULL,
+    period CHAR(7) NOT NULL,
+    threshold INT NOT NULL,
+    spent BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_budget_id FOREIGN KEY(budget_id) REFERENCES budgets(id) ON DELETE CASCADE,
+    UNIQUE (budget_id, period, threshold)
+);
+
+-- +goose Down
+drop table budget_alerts;
+drop table budgets;
@@ -0,0 +1,21 @@
+-- +goose Up
+
+-- Transactional outbox. Events are written in the same transaction as the change
+-- they describe and published by the relay, in id order per aggregate.
+CREATE TABLE IF NOT EXISTS domain_events (
+    id BIGSERIAL PRIMARY KEY,
+    aggregate_id uuid NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_domain_events_unpublished ON domain_events(aggregate_id, id) WHERE published_at IS NULL;
+
+-- +goose Down
+drop table domain_events;
@@ -0,0 +1,22 @@
+-- +goose Up
+
+-- Notify listeners of every transaction that moved a balance. NOTIFY is delivered
+-- when the transaction commits, so listeners never see rolled back changes.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_wallet_activity() RETURNS trigger AS $$
+BEGIN
+    IF NEW.completed_at IS NOT NULL AND (TG_OP = 'INSERT' OR OLD.completed_at IS NULL) THEN
+        PERFORM pg_notify('wallet_activity', NEW.id::text);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER transactions_wallet_activity
+    AFTER INSERT OR UPDATE OF completed_at ON transactions
+    FOR EACH ROW EXECUTE FUNCTION notify_wallet_activity();
+
+-- +goose Down
+drop trigger transactions_wallet_activity on transactions;
+drop function notify_wallet_activity();
@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- Dynamic QR payloads paid so far, one row per payload
+CREATE TABLE IF NOT EXISTS qr_payments (
+    wallet_id uuid NOT NULL,
+    reference VARCHAR(64) NOT NULL,
+    transaction_id INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (wallet_id, reference),
+    CONSTRAINT fk_wallet_id FOREIGN KEY(wallet_id) REFERENCES wallets(id),
+    CONSTRAINT fk_transaction_id FOREIGN KEY(transaction_id) REFERENCES transactions(id)
+);
+
+-- +goose Down
+drop table qr_payments;
@@ -0,0 +1,10 @@
+-- +goose Up
+
+-- Webhook deliveries are queued from the outbox, an event is queued once per subscription
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS event_id BIGINT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries(subscription_id, event_id);
+
+-- +goose Down
+drop index idx_webhook_deliveries_event;
+alter table webhook_deliveries drop column event_id;
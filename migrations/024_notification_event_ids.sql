@@ -0,0 +1,10 @@
+-- +goose Up
+
+-- Notifications of balance changes are queued from the outbox, an event is queued once per channel
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS event_id BIGINT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_event ON notifications(event_id, channel) WHERE event_id IS NOT NULL;
+
+-- +goose Down
+drop index idx_notifications_event;
+alter table notifications drop column event_id;
@@ -0,0 +1,12 @@
+-- +goose Up
+
+-- Vouchers are redeemed in the transaction of their credit, settle those a
+-- redemption left in processing: credited ones are redeemed, the others available
+UPDATE vouchers v SET status = 'redeemed', transaction_id = t.id, redeemed_at = t.completed_at
+FROM transactions t
+WHERE v.status = 'processing' AND t.wallet_id = v.wallet_id
+    AND t.reference = 'voucher:' || v.id AND t.completed_at IS NOT NULL;
+
+UPDATE vouchers SET status = 'available', redeemed_by = NULL, wallet_id = NULL WHERE status = 'processing';
+
+-- +goose Down
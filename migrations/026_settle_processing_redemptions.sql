@@ -0,0 +1,26 @@
+-- +goose Up
+
+-- Points redemptions complete in the transaction of their credit, settle those left
+-- in processing: credited ones are completed, the others give their points back
+UPDATE loyalty_redemptions r SET status = 'completed', transaction_id = t.id, completed_at = t.completed_at
+FROM transactions t
+WHERE r.status = 'processing' AND t.wallet_id = r.wallet_id
+    AND t.reference = 'loyalty:' || r.id AND t.completed_at IS NOT NULL;
+
+UPDATE loyalty_entries lot SET remaining = lot.remaining - e.points
+FROM (
+    SELECT e.lot_id, SUM(e.points) AS points FROM loyalty_entries e
+    JOIN loyalty_redemptions r ON r.id = e.redemption_id
+    WHERE r.status = 'processing' AND e.kind = 'redeem'
+    GROUP BY e.lot_id
+) e
+WHERE lot.id = e.lot_id;
+
+INSERT INTO loyalty_entries (user_id, kind, points, lot_id, wallet_id, redemption_id)
+SELECT e.user_id, 'refund', -e.points, e.lot_id, e.wallet_id, e.redemption_id FROM loyalty_entries e
+JOIN loyalty_redemptions r ON r.id = e.redemption_id
+WHERE r.status = 'processing' AND e.kind = 'redeem';
+
+UPDATE loyalty_redemptions SET status = 'released' WHERE status = 'processing';
+
+-- +goose Down
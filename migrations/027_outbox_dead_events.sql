@@ -0,0 +1,17 @@
+-- +goose Up
+
+-- Sinks an event was published to, a retry hands it only to the others. Events
+-- that failed every attempt are dead and no longer hold back their wallet.
+ALTER TABLE domain_events ADD COLUMN IF NOT EXISTS published_sinks TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE domain_events ADD COLUMN IF NOT EXISTS dead_at TIMESTAMP;
+
+DROP INDEX IF EXISTS idx_domain_events_unpublished;
+CREATE INDEX IF NOT EXISTS idx_domain_events_unpublished ON domain_events(aggregate_id, id) WHERE published_at IS NULL AND dead_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_domain_events_dead ON domain_events(dead_at) WHERE dead_at IS NOT NULL;
+
+-- +goose Down
+drop index idx_domain_events_dead;
+drop index idx_domain_events_unpublished;
+CREATE INDEX IF NOT EXISTS idx_domain_events_unpublished ON domain_events(aggregate_id, id) WHERE published_at IS NULL;
+alter table domain_events drop column dead_at;
+alter table domain_events drop column published_sinks;
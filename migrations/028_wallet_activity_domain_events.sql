@@ -0,0 +1,44 @@
+-- +goose Up
+
+-- Streams resume from the id of the domain event, so they replay the outbox and
+-- listeners are notified of the events rather than of the transactions.
+CREATE INDEX IF NOT EXISTS idx_domain_events_user ON domain_events((payload->>'user_id'), id);
+
+drop trigger transactions_wallet_activity on transactions;
+drop function notify_wallet_activity();
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_wallet_activity() RETURNS trigger AS $$
+BEGIN
+    IF NEW.event_type IN ('WalletToppedUp', 'WalletDebited', 'WalletCredited') THEN
+        PERFORM pg_notify('wallet_activity', NEW.id::text);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER domain_events_wallet_activity
+    AFTER INSERT ON domain_events
+    FOR EACH ROW EXECUTE FUNCTION notify_wallet_activity();
+
+-- +goose Down
+drop trigger domain_events_wallet_activity on domain_events;
+drop function notify_wallet_activity();
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_wallet_activity() RETURNS trigger AS $$
+BEGIN
+    IF NEW.completed_at IS NOT NULL AND (TG_OP = 'INSERT' OR OLD.completed_at IS NULL) THEN
+        PERFORM pg_notify('wallet_activity', NEW.id::text);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER transactions_wallet_activity
+    AFTER INSERT OR UPDATE OF completed_at ON transactions
+    FOR EACH ROW EXECUTE FUNCTION notify_wallet_activity();
+
+drop index idx_domain_events_user;
@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- Nonces of signed /v2 requests. A nonce is accepted once per user, rows are
+-- kept for as long as a request carrying it could pass the timestamp check.
+CREATE TABLE IF NOT EXISTS signature_nonces (
+    user_id VARCHAR(255) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, nonce)
+);
+
+CREATE INDEX IF NOT EXISTS idx_signature_nonces_created_at ON signature_nonces(created_at);
+
+-- +goose Down
+drop table signature_nonces;